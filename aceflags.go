@@ -0,0 +1,131 @@
+package sddl
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// ACEFlags holds the flags of an ACE, as found in the AceFlags field of its header.
+type ACEFlags byte
+
+// ACE flags, named after the Windows constants.
+const (
+	// ACEFlagObjectInherit - Inherited by objects, "OI" in SDDL (OBJECT_INHERIT_ACE)
+	ACEFlagObjectInherit ACEFlags = objectInheritACE
+	// ACEFlagContainerInherit - Inherited by containers, "CI" in SDDL (CONTAINER_INHERIT_ACE)
+	ACEFlagContainerInherit ACEFlags = containerInheritACE
+	// ACEFlagNoPropagateInherit - Inherited by direct children only, "NP" in SDDL (NO_PROPAGATE_INHERIT_ACE)
+	ACEFlagNoPropagateInherit ACEFlags = noPropagateInheritACE
+	// ACEFlagInheritOnly - Only inherited, doesn't apply to the object itself, "IO" in SDDL (INHERIT_ONLY_ACE)
+	ACEFlagInheritOnly ACEFlags = inheritOnlyACE
+	// ACEFlagInherited - Inherited from the parent, "ID" in SDDL (INHERITED_ACE)
+	ACEFlagInherited ACEFlags = inheritedACE
+	// ACEFlagSuccessfulAccess - Audit successful accesses, "SA" in SDDL (SUCCESSFUL_ACCESS_ACE)
+	ACEFlagSuccessfulAccess ACEFlags = successfulAccessACE
+	// ACEFlagFailedAccess - Audit failed accesses, "FA" in SDDL (FAILED_ACCESS_ACE)
+	ACEFlagFailedAccess ACEFlags = failedAccessACE
+)
+
+// sddlACEFlags maps each ACE flag bit to its SDDL mnemonic, in the order ACE.String writes them
+var sddlACEFlags = []struct {
+	flag ACEFlags
+	name string
+}{
+	{ACEFlagSuccessfulAccess, "SA"},
+	{ACEFlagFailedAccess, "FA"},
+	{ACEFlagObjectInherit, "OI"},
+	{ACEFlagContainerInherit, "CI"},
+	{ACEFlagNoPropagateInherit, "NP"},
+	{ACEFlagInheritOnly, "IO"},
+	{ACEFlagInherited, "ID"},
+}
+
+// String returns the SDDL mnemonics of the flags, e.g. "OICI" for ACEFlagObjectInherit|ACEFlagContainerInherit.
+// Bits without a mnemonic can't be written in SDDL and are left out, see ACEFlagNames to show them.
+func (f ACEFlags) String() string {
+	var sb strings.Builder
+	for _, named := range sddlACEFlags {
+		if f&named.flag != 0 {
+			sb.WriteString(named.name)
+		}
+	}
+	return sb.String()
+}
+
+// Flags returns the flags of the ACE.
+func (e *ACE) Flags() ACEFlags {
+	if e.header == nil {
+		return 0
+	}
+	return ACEFlags(e.header.aceFlags)
+}
+
+// namedACEFlags maps each ACE flag bit to its Windows constant name, by increasing bit value
+var namedACEFlags = []struct {
+	flag byte
+	name string
+}{
+	{objectInheritACE, "OBJECT_INHERIT_ACE"},
+	{containerInheritACE, "CONTAINER_INHERIT_ACE"},
+	{noPropagateInheritACE, "NO_PROPAGATE_INHERIT_ACE"},
+	{inheritOnlyACE, "INHERIT_ONLY_ACE"},
+	{inheritedACE, "INHERITED_ACE"},
+	{successfulAccessACE, "SUCCESSFUL_ACCESS_ACE"},
+	{failedAccessACE, "FAILED_ACCESS_ACE"},
+}
+
+// ACEFlagNames returns the Windows constant names of the ACE flags set in flags, by increasing bit value.
+// Bits without a name are returned as hexadecimal values (e.g. "0x20").
+//
+// For example, ACEFlagNames(0x03) returns:
+//
+//	[OBJECT_INHERIT_ACE CONTAINER_INHERIT_ACE]
+func ACEFlagNames(flags byte) []string {
+	names := make([]string, 0, bits.OnesCount8(flags))
+	for _, f := range namedACEFlags {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+			flags &^= f.flag
+		}
+	}
+
+	// Unnamed bits, one by one
+	for flags != 0 {
+		bit := byte(1) << bits.TrailingZeros8(flags)
+		names = append(names, fmt.Sprintf("0x%02X", bit))
+		flags &^= bit
+	}
+
+	return names
+}
+
+// ParseACEFlagNames returns the ACE flags with the given Windows constant names or hexadecimal values,
+// as returned by ACEFlagNames. It returns an error if a name is unknown.
+func ParseACEFlagNames(names []string) (byte, error) {
+	var flags byte
+	for _, name := range names {
+		if strings.HasPrefix(name, "0x") {
+			value, err := strconv.ParseUint(name[2:], 16, 8)
+			if err != nil {
+				return 0, fmt.Errorf("invalid hexadecimal ACE flag: %s", name)
+			}
+			flags |= byte(value)
+			continue
+		}
+
+		found := false
+		for _, f := range namedACEFlags {
+			if f.name == name {
+				flags |= f.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown ACE flag: %s", name)
+		}
+	}
+	return flags, nil
+}
@@ -0,0 +1,143 @@
+package sddl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACEFlagNames(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		flags byte
+		want  []string
+	}{
+		{
+			name:  "Container and object inherit",
+			flags: objectInheritACE | containerInheritACE,
+			want:  []string{"OBJECT_INHERIT_ACE", "CONTAINER_INHERIT_ACE"},
+		},
+		{
+			name:  "Inheritance flags",
+			flags: noPropagateInheritACE | inheritOnlyACE | inheritedACE,
+			want:  []string{"NO_PROPAGATE_INHERIT_ACE", "INHERIT_ONLY_ACE", "INHERITED_ACE"},
+		},
+		{
+			name:  "Audit flags",
+			flags: successfulAccessACE | failedAccessACE,
+			want:  []string{"SUCCESSFUL_ACCESS_ACE", "FAILED_ACCESS_ACE"},
+		},
+		{
+			name:  "Unnamed bit",
+			flags: containerInheritACE | 0x20,
+			want:  []string{"CONTAINER_INHERIT_ACE", "0x20"},
+		},
+		{
+			name:  "No flags",
+			flags: 0,
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ACEFlagNames(tt.flags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ACEFlagNames(0x%02X) = %v, want %v", tt.flags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseACEFlagNames(t *testing.T) {
+	t.Parallel()
+
+	// Every combination of flags must survive a round trip through the names
+	for flags := 0; flags <= 0xFF; flags++ {
+		names := ACEFlagNames(byte(flags))
+		got, err := ParseACEFlagNames(names)
+		if err != nil {
+			t.Fatalf("ParseACEFlagNames(%v) unexpected error = %v", names, err)
+		}
+		if got != byte(flags) {
+			t.Errorf("ParseACEFlagNames(%v) = 0x%02X, want 0x%02X", names, got, flags)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    byte
+		wantErr bool
+	}{
+		{name: "Any order", names: []string{"INHERIT_ONLY_ACE", "OBJECT_INHERIT_ACE"}, want: objectInheritACE | inheritOnlyACE},
+		{name: "Repeated name", names: []string{"INHERITED_ACE", "INHERITED_ACE"}, want: inheritedACE},
+		{name: "Empty", names: nil, want: 0},
+		{name: "Unknown name", names: []string{"OBJECT_INHERIT_ACE", "CI"}, wantErr: true},
+		{name: "Invalid hexadecimal", names: []string{"0x100"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseACEFlagNames(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseACEFlagNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseACEFlagNames() = 0x%02X, want 0x%02X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestACE_StringIndent_FlagNames(t *testing.T) {
+	t.Parallel()
+
+	sd, err := FromString("D:(A;OICIID;FA;;;SY)(A;;FR;;;BU)S:(AU;SAFA;FA;;;WD)")
+	if err != nil {
+		t.Fatalf("FromString() unexpected error = %v", err)
+	}
+
+	want := []string{
+		"    (A;OICIID [OBJECT_INHERIT_ACE|CONTAINER_INHERIT_ACE|INHERITED_ACE];FA;;;SY [S-1-5-18])",
+		"    (A;;FR;;;BU [S-1-5-32-545])",
+		"    (AU;SAFA [SUCCESSFUL_ACCESS_ACE|FAILED_ACCESS_ACE];FA [audited];;;WD [S-1-1-0])",
+	}
+	aces := append(sd.DACL().ACEs(), sd.SACL().ACEs()...)
+	for i, ace := range aces {
+		if got := ace.StringIndent(4); got != want[i] {
+			t.Errorf("ACE %d StringIndent() = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestACEFlags_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		flags ACEFlags
+		want  string
+	}{
+		{name: "No flags", flags: 0, want: ""},
+		{name: "Object and container inherit", flags: ACEFlagObjectInherit | ACEFlagContainerInherit, want: "OICI"},
+		{name: "No propagate inherit", flags: ACEFlagContainerInherit | ACEFlagNoPropagateInherit | ACEFlagInheritOnly, want: "CINPIO"},
+		{name: "Inherited", flags: ACEFlagInherited, want: "ID"},
+		{name: "Audit flags come first", flags: ACEFlagFailedAccess | ACEFlagSuccessfulAccess | ACEFlagObjectInherit, want: "SAFAOI"},
+		{name: "Bits without mnemonic are left out", flags: ACEFlagInherited | 0x20, want: "ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.flags.String(); got != tt.want {
+				t.Errorf("ACEFlags.String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
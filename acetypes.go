@@ -0,0 +1,102 @@
+package sddl
+
+import "fmt"
+
+// ACEType is the type of an ACE, as found in the AceType field of its header.
+type ACEType byte
+
+// ACE types, named after the Windows constants.
+// See https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/628ebb1d-c509-4ea0-a10f-77ef97ca4586
+const (
+	// ACETypeAccessAllowed - Access allowed, "A" in SDDL (ACCESS_ALLOWED_ACE_TYPE)
+	ACETypeAccessAllowed ACEType = accessAllowedACEType
+	// ACETypeAccessDenied - Access denied, "D" in SDDL (ACCESS_DENIED_ACE_TYPE)
+	ACETypeAccessDenied ACEType = accessDeniedACEType
+	// ACETypeSystemAudit - System audit, "AU" in SDDL (SYSTEM_AUDIT_ACE_TYPE)
+	ACETypeSystemAudit ACEType = systemAuditACEType
+	// ACETypeSystemAlarm - System alarm, "AL" in SDDL (SYSTEM_ALARM_ACE_TYPE)
+	ACETypeSystemAlarm ACEType = systemAlarmACEType
+	// ACETypeAccessAllowedCompound - Access allowed compound (ACCESS_ALLOWED_COMPOUND_ACE_TYPE)
+	ACETypeAccessAllowedCompound ACEType = accessAllowedCompoundACEType
+	// ACETypeAccessAllowedObject - Access allowed object, "OA" in SDDL (ACCESS_ALLOWED_OBJECT_ACE_TYPE)
+	ACETypeAccessAllowedObject ACEType = accessAllowedObjectACEType
+	// ACETypeAccessDeniedObject - Access denied object, "OD" in SDDL (ACCESS_DENIED_OBJECT_ACE_TYPE)
+	ACETypeAccessDeniedObject ACEType = accessDeniedObjectACEType
+	// ACETypeSystemAuditObject - System audit object, "OU" in SDDL (SYSTEM_AUDIT_OBJECT_ACE_TYPE)
+	ACETypeSystemAuditObject ACEType = systemAuditObjectACEType
+	// ACETypeSystemAlarmObject - System alarm object, "OL" in SDDL (SYSTEM_ALARM_OBJECT_ACE_TYPE)
+	ACETypeSystemAlarmObject ACEType = systemAlarmObjectACEType
+	// ACETypeAccessAllowedCallback - Access allowed callback, "XA" in SDDL (ACCESS_ALLOWED_CALLBACK_ACE_TYPE)
+	ACETypeAccessAllowedCallback ACEType = accessAllowedCallbackACEType
+	// ACETypeAccessDeniedCallback - Access denied callback, "XD" in SDDL (ACCESS_DENIED_CALLBACK_ACE_TYPE)
+	ACETypeAccessDeniedCallback ACEType = accessDeniedCallbackACEType
+	// ACETypeAccessAllowedCallbackObject - Access allowed callback object, "ZA" in SDDL (ACCESS_ALLOWED_CALLBACK_OBJECT_ACE_TYPE)
+	ACETypeAccessAllowedCallbackObject ACEType = accessAllowedCallbackObjectACEType
+	// ACETypeAccessDeniedCallbackObject - Access denied callback object (ACCESS_DENIED_CALLBACK_OBJECT_ACE_TYPE)
+	ACETypeAccessDeniedCallbackObject ACEType = accessDeniedCallbackObjectACEType
+	// ACETypeSystemAuditCallback - System audit callback, "XU" in SDDL (SYSTEM_AUDIT_CALLBACK_ACE_TYPE)
+	ACETypeSystemAuditCallback ACEType = systemAuditCallbackACEType
+	// ACETypeSystemAlarmCallback - System alarm callback (SYSTEM_ALARM_CALLBACK_ACE_TYPE)
+	ACETypeSystemAlarmCallback ACEType = systemAlarmCallbackACEType
+	// ACETypeSystemAuditCallbackObject - System audit callback object (SYSTEM_AUDIT_CALLBACK_OBJECT_ACE_TYPE)
+	ACETypeSystemAuditCallbackObject ACEType = systemAuditCallbackObjectACEType
+	// ACETypeSystemAlarmCallbackObject - System alarm callback object (SYSTEM_ALARM_CALLBACK_OBJECT_ACE_TYPE)
+	ACETypeSystemAlarmCallbackObject ACEType = systemAlarmCallbackObjectACEType
+	// ACETypeSystemMandatoryLabel - System mandatory label, "ML" in SDDL (SYSTEM_MANDATORY_LABEL_ACE_TYPE)
+	ACETypeSystemMandatoryLabel ACEType = systemMandatoryLabelACEType
+	// ACETypeSystemResourceAttribute - System resource attribute, "RA" in SDDL (SYSTEM_RESOURCE_ATTRIBUTE_ACE_TYPE)
+	ACETypeSystemResourceAttribute ACEType = systemResourceAttributeACEType
+	// ACETypeSystemScopedPolicyID - System scoped policy ID, "SP" in SDDL (SYSTEM_SCOPED_POLICY_ID_ACE_TYPE)
+	ACETypeSystemScopedPolicyID ACEType = systemScopedPolicyIDACEType
+	// ACETypeSystemProcessTrustLabel - System process trust label, "TL" in SDDL (SYSTEM_PROCESS_TRUST_LABEL_ACE_TYPE)
+	ACETypeSystemProcessTrustLabel ACEType = systemProcessTrustLabelACEType
+	// ACETypeSystemAccessFilter - System access filter (SYSTEM_ACCESS_FILTER_ACE_TYPE)
+	ACETypeSystemAccessFilter ACEType = systemAccessFilterACEType
+)
+
+// aceTypeMnemonics maps the ACE types that have an SDDL mnemonic to it. It is the only place where
+// mnemonics are defined: both ACEType.String and the SDDL parser use it, through aceTypesByMnemonic.
+var aceTypeMnemonics = map[ACEType]string{
+	ACETypeAccessAllowed:               "A",
+	ACETypeAccessDenied:                "D",
+	ACETypeSystemAudit:                 "AU",
+	ACETypeSystemAlarm:                 "AL",
+	ACETypeAccessAllowedObject:         "OA",
+	ACETypeAccessDeniedObject:          "OD",
+	ACETypeSystemAuditObject:           "OU",
+	ACETypeSystemAlarmObject:           "OL",
+	ACETypeAccessAllowedCallback:       "XA",
+	ACETypeAccessDeniedCallback:        "XD",
+	ACETypeAccessAllowedCallbackObject: "ZA",
+	ACETypeSystemAuditCallback:         "XU",
+	ACETypeSystemMandatoryLabel:        "ML",
+	ACETypeSystemResourceAttribute:     "RA",
+	ACETypeSystemScopedPolicyID:        "SP",
+	ACETypeSystemProcessTrustLabel:     "TL",
+}
+
+// aceTypesByMnemonic maps SDDL mnemonics to their ACE type, it is the reverse of aceTypeMnemonics
+var aceTypesByMnemonic = make(map[string]ACEType, len(aceTypeMnemonics))
+
+func init() {
+	for t, mnemonic := range aceTypeMnemonics {
+		aceTypesByMnemonic[mnemonic] = t
+	}
+}
+
+// String returns the SDDL mnemonic of the ACE type (e.g. "A" or "OA"), or its hexadecimal value
+// (e.g. "0x0E") for the types this package has no mnemonic for, which is how ACE.String writes them.
+func (t ACEType) String() string {
+	if mnemonic, ok := aceTypeMnemonics[t]; ok {
+		return mnemonic
+	}
+	return fmt.Sprintf("0x%02X", byte(t))
+}
+
+// Type returns the type of the ACE.
+func (e *ACE) Type() ACEType {
+	if e.header == nil {
+		return 0
+	}
+	return ACEType(e.header.aceType)
+}
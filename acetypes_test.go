@@ -0,0 +1,155 @@
+package sddl
+
+import "testing"
+
+func TestACEType_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		aceType ACEType
+		want    string
+	}{
+		{name: "Access allowed", aceType: ACETypeAccessAllowed, want: "A"},
+		{name: "Access denied", aceType: ACETypeAccessDenied, want: "D"},
+		{name: "System audit", aceType: ACETypeSystemAudit, want: "AU"},
+		{name: "System alarm", aceType: ACETypeSystemAlarm, want: "AL"},
+		{name: "Access allowed object", aceType: ACETypeAccessAllowedObject, want: "OA"},
+		{name: "Access denied object", aceType: ACETypeAccessDeniedObject, want: "OD"},
+		{name: "System audit object", aceType: ACETypeSystemAuditObject, want: "OU"},
+		{name: "System alarm object", aceType: ACETypeSystemAlarmObject, want: "OL"},
+		{name: "System mandatory label", aceType: ACETypeSystemMandatoryLabel, want: "ML"},
+		{name: "System scoped policy ID", aceType: ACETypeSystemScopedPolicyID, want: "SP"},
+		{name: "System process trust label", aceType: ACETypeSystemProcessTrustLabel, want: "TL"},
+		{name: "Access allowed callback", aceType: ACETypeAccessAllowedCallback, want: "XA"},
+		{name: "Access denied callback", aceType: ACETypeAccessDeniedCallback, want: "XD"},
+		{name: "Access allowed callback object", aceType: ACETypeAccessAllowedCallbackObject, want: "ZA"},
+		{name: "System audit callback", aceType: ACETypeSystemAuditCallback, want: "XU"},
+		{name: "System resource attribute", aceType: ACETypeSystemResourceAttribute, want: "RA"},
+		{name: "Callback without mnemonic", aceType: ACETypeSystemAlarmCallback, want: "0x0E"},
+		{name: "Unknown type", aceType: ACEType(0xFE), want: "0xFE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.aceType.String(); got != tt.want {
+				t.Errorf("ACEType.String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestACETypeMnemonics(t *testing.T) {
+	t.Parallel()
+
+	if len(aceTypesByMnemonic) != len(aceTypeMnemonics) {
+		t.Fatalf("aceTypesByMnemonic has %d entries, want %d: mnemonics must be unique", len(aceTypesByMnemonic), len(aceTypeMnemonics))
+	}
+
+	// Parsing and writing are inverses for every ACE type, with or without mnemonic
+	for i := 0; i <= 0xFF; i++ {
+		aceType := ACEType(i)
+		s := aceType.String()
+		if mnemonic, ok := aceTypeMnemonics[aceType]; ok && s != mnemonic {
+			t.Errorf("ACEType(0x%02X).String() = %s, want %s", i, s, mnemonic)
+		}
+
+		parsed, err := parseACEType(s)
+		if err != nil {
+			t.Errorf("parseACEType(%q) unexpected error = %v", s, err)
+			continue
+		}
+		if parsed != byte(aceType) {
+			t.Errorf("parseACEType(%q) = 0x%02X, want 0x%02X", s, parsed, i)
+		}
+	}
+
+	// A descriptor using every mnemonic round-trips through SDDL and binary
+	for aceType, mnemonic := range aceTypeMnemonics {
+		ace := "(" + mnemonic + ";;FA;;;WD)"
+		if isObjectACEType(byte(aceType)) {
+			ace = "(" + mnemonic + ";;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)"
+		}
+		s := "S:" + ace
+		sd := mustFromString(t, s)
+		back, err := FromBinary(sd.Binary())
+		if err != nil {
+			t.Fatalf("FromBinary() unexpected error = %v", err)
+		}
+		if got := back.String(); got != s {
+			t.Errorf("FromString(%s) -> Binary() -> FromBinary().String() = %s", s, got)
+		}
+	}
+}
+
+func TestACE_TypeAndFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		wantType  ACEType
+		wantFlags ACEFlags
+	}{
+		{
+			name:      "Inheritable allow",
+			input:     "D:(A;OICI;FA;;;SY)",
+			wantType:  ACETypeAccessAllowed,
+			wantFlags: ACEFlagObjectInherit | ACEFlagContainerInherit,
+		},
+		{
+			name:      "Inherited deny",
+			input:     "D:(D;ID;FW;;;WD)",
+			wantType:  ACETypeAccessDenied,
+			wantFlags: ACEFlagInherited,
+		},
+		{
+			name:      "Audit",
+			input:     "S:(AU;SAFA;FA;;;WD)",
+			wantType:  ACETypeSystemAudit,
+			wantFlags: ACEFlagSuccessfulAccess | ACEFlagFailedAccess,
+		},
+		{
+			name:      "Object ACE",
+			input:     "D:(OA;CINP;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)",
+			wantType:  ACETypeAccessAllowedObject,
+			wantFlags: ACEFlagContainerInherit | ACEFlagNoPropagateInherit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			acl := sd.DACL()
+			if acl == nil {
+				acl = sd.SACL()
+			}
+			ace := acl.ACEs()[0]
+
+			if got := ace.Type(); got != tt.wantType {
+				t.Errorf("ACE.Type() = %s, want %s", got, tt.wantType)
+			}
+			if got := ace.Flags(); got != tt.wantFlags {
+				t.Errorf("ACE.Flags() = %s, want %s", got, tt.wantFlags)
+			}
+
+			// Types and flags are written the way the enums render them
+			if got := sd.String(); got != tt.input {
+				t.Errorf("String() = %s, want %s", got, tt.input)
+			}
+		})
+	}
+
+	// Unknown mnemonics are reported once
+	_, err := parseACEString("(XX;;FA;;;WD)", ParseOptions{})
+	if want := "invalid ACE type: XX (must be a known type or hexadecimal value)"; err == nil || err.Error() != want {
+		t.Errorf("parseACEString() error = %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,275 @@
+package sddl
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Resource attributes are the claims carried by resource attribute ACEs (RA), e.g. ("Project",TS,0x0,"SQL")
+// in "(RA;;;;;WD;("Project",TS,0x0,"SQL"))". In binary form, they are the application data of the ACE: a
+// CLAIM_SECURITY_ATTRIBUTE_RELATIVE_V1 structure, as defined in [MS-DTYP] section 2.4.10.1.
+
+// Value types of resource attributes (CLAIM_SECURITY_ATTRIBUTE_TYPE_*)
+const (
+	claimTypeInt64       = 0x0001
+	claimTypeUint64      = 0x0002
+	claimTypeString      = 0x0003
+	claimTypeSID         = 0x0005
+	claimTypeBoolean     = 0x0006
+	claimTypeOctetString = 0x0010
+)
+
+// claimTypeMnemonics maps the value types of resource attributes to their SDDL mnemonic.
+var claimTypeMnemonics = map[uint16]string{
+	claimTypeInt64:       "TI",
+	claimTypeUint64:      "TU",
+	claimTypeString:      "TS",
+	claimTypeSID:         "TD",
+	claimTypeBoolean:     "TB",
+	claimTypeOctetString: "TX",
+}
+
+// encodeResourceAttribute encodes the SDDL resource attribute s, such as `("Project",TS,0x0,"SQL")`, into
+// the binary form of the application data of resource attribute ACEs, padded to a multiple of 4 bytes.
+// The values are written as in SDDL: TI and TU values are integers, TS values quoted strings, TD values
+// SIDs, TX values octet strings prefixed with "#" and TB values 0 or 1.
+func encodeResourceAttribute(s string) ([]byte, error) {
+	fields, err := splitResourceAttribute(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected a name, a type and flags, got %d fields", len(fields))
+	}
+
+	name, ok := unquoteAttributeString(fields[0])
+	if !ok || name == "" {
+		return nil, fmt.Errorf("invalid name %s: must be a quoted string", fields[0])
+	}
+	var valueType uint16
+	for t, mnemonic := range claimTypeMnemonics {
+		if strings.EqualFold(fields[1], mnemonic) {
+			valueType = t
+		}
+	}
+	if valueType == 0 {
+		return nil, fmt.Errorf("invalid type %s", fields[1])
+	}
+	flags, err := strconv.ParseUint(fields[2], 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flags %s", fields[2])
+	}
+
+	values := make([][]byte, 0, len(fields)-3)
+	for _, field := range fields[3:] {
+		value, err := encodeClaimValue(valueType, field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %s: %w", claimTypeMnemonics[valueType], field, err)
+		}
+		values = append(values, value)
+	}
+
+	// The fixed part is followed by the value offsets, the name and the values
+	headerSize := 16 + 4*len(values)
+	b := make([]byte, headerSize, headerSize+2*len(name)+2+8*len(values))
+	binary.LittleEndian.PutUint32(b[0:], uint32(headerSize))
+	binary.LittleEndian.PutUint16(b[4:], valueType)
+	binary.LittleEndian.PutUint32(b[8:], uint32(flags))
+	binary.LittleEndian.PutUint32(b[12:], uint32(len(values)))
+	b = appendClaimString(b, name)
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(b[16+4*i:], uint32(len(b)))
+		b = append(b, value...)
+	}
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b, nil
+}
+
+// splitResourceAttribute splits the resource attribute s, enclosed in parentheses, into its fields
+// separated by commas. Commas within quoted strings don't separate fields.
+func splitResourceAttribute(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return nil, errors.New("must be enclosed in parentheses")
+	}
+	s = s[1 : len(s)-1]
+
+	var fields []string
+	start, inLiteral := 0, false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inLiteral = !inLiteral
+		case ',':
+			if !inLiteral {
+				fields = append(fields, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if inLiteral {
+		return nil, errors.New("unterminated string")
+	}
+	return append(fields, strings.TrimSpace(s[start:])), nil
+}
+
+// unquoteAttributeString returns the content of the quoted string s.
+func unquoteAttributeString(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// encodeClaimValue encodes a value of a resource attribute of the given type.
+func encodeClaimValue(valueType uint16, s string) ([]byte, error) {
+	switch valueType {
+	case claimTypeInt64:
+		v, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return nil, errors.New("not a signed integer")
+		}
+		return binary.LittleEndian.AppendUint64(nil, uint64(v)), nil
+	case claimTypeUint64:
+		v, err := strconv.ParseUint(s, 0, 64)
+		if err != nil {
+			return nil, errors.New("not an unsigned integer")
+		}
+		return binary.LittleEndian.AppendUint64(nil, v), nil
+	case claimTypeBoolean:
+		if s != "0" && s != "1" {
+			return nil, errors.New("must be 0 or 1")
+		}
+		return binary.LittleEndian.AppendUint64(nil, uint64(s[0]-'0')), nil
+	case claimTypeString:
+		str, ok := unquoteAttributeString(s)
+		if !ok {
+			return nil, errors.New("must be a quoted string")
+		}
+		return appendClaimString(nil, str), nil
+	case claimTypeSID:
+		r, err := parseSIDString(s)
+		if err != nil {
+			return nil, err
+		}
+		sid, err := r.toSID(nil)
+		if err != nil {
+			return nil, err
+		}
+		b := binary.LittleEndian.AppendUint32(nil, uint32(sid.BinarySize()))
+		return sid.appendBinary(b), nil
+	default: // claimTypeOctetString
+		octets, err := hex.DecodeString(strings.TrimPrefix(s, "#"))
+		if err != nil {
+			return nil, errors.New("not an octet string")
+		}
+		b := binary.LittleEndian.AppendUint32(nil, uint32(len(octets)))
+		return append(b, octets...), nil
+	}
+}
+
+// appendClaimString appends the null-terminated UTF-16 code units of s.
+func appendClaimString(b []byte, s string) []byte {
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return append(b, 0, 0)
+}
+
+// resourceAttributeString decodes the binary resource attribute data, the application data of a resource
+// attribute ACE, into its SDDL form, e.g. `("Project",TS,0x0,"SQL")`.
+func resourceAttributeString(data []byte) (string, error) {
+	if len(data) < 16 {
+		return "", errors.New("truncated resource attribute")
+	}
+	valueType := binary.LittleEndian.Uint16(data[4:])
+	mnemonic, ok := claimTypeMnemonics[valueType]
+	if !ok {
+		return "", fmt.Errorf("unknown value type 0x%04X", valueType)
+	}
+	count := binary.LittleEndian.Uint32(data[12:])
+	if uint64(count) > uint64(len(data)-16)/4 {
+		return "", fmt.Errorf("value count %d exceeds the data", count)
+	}
+
+	name, err := claimStringAt(data, binary.LittleEndian.Uint32(data[0:]))
+	if err != nil {
+		return "", fmt.Errorf("invalid name: %w", err)
+	}
+	fields := []string{`"` + name + `"`, mnemonic, fmt.Sprintf("0x%X", binary.LittleEndian.Uint32(data[8:]))}
+	for i := range int(count) {
+		offset := binary.LittleEndian.Uint32(data[16+4*i:])
+		value, err := claimValueString(data, valueType, offset)
+		if err != nil {
+			return "", fmt.Errorf("invalid value %d: %w", i, err)
+		}
+		fields = append(fields, value)
+	}
+	return "(" + strings.Join(fields, ",") + ")", nil
+}
+
+// claimValueString decodes the value of the given type at offset in the resource attribute data.
+func claimValueString(data []byte, valueType uint16, offset uint32) (string, error) {
+	if uint64(offset) >= uint64(len(data)) {
+		return "", fmt.Errorf("offset %d exceeds the data", offset)
+	}
+	value := data[offset:]
+
+	switch valueType {
+	case claimTypeInt64, claimTypeUint64, claimTypeBoolean:
+		if len(value) < 8 {
+			return "", errors.New("truncated integer")
+		}
+		v := binary.LittleEndian.Uint64(value)
+		if valueType == claimTypeInt64 {
+			return strconv.FormatInt(int64(v), 10), nil
+		}
+		return strconv.FormatUint(v, 10), nil
+	case claimTypeString:
+		s, err := claimStringAt(data, offset)
+		return `"` + s + `"`, err
+	}
+
+	// SIDs and octet strings are prefixed with their length
+	if len(value) < 4 {
+		return "", errors.New("truncated length")
+	}
+	length := binary.LittleEndian.Uint32(value)
+	if uint64(length) > uint64(len(value)-4) {
+		return "", fmt.Errorf("length %d exceeds the data", length)
+	}
+	value = value[4 : 4+length]
+	if valueType == claimTypeSID {
+		sid, err := decodeSIDBinary(value, ParseOptions{})
+		if err != nil {
+			return "", err
+		}
+		return sid.String(), nil
+	}
+	return "#" + hex.EncodeToString(value), nil
+}
+
+// claimStringAt decodes the null-terminated UTF-16 string at offset in the resource attribute data.
+func claimStringAt(data []byte, offset uint32) (string, error) {
+	if uint64(offset) > uint64(len(data)) {
+		return "", fmt.Errorf("offset %d exceeds the data", offset)
+	}
+	var units []uint16
+	for i := int(offset); ; i += 2 {
+		if i+2 > len(data) {
+			return "", errors.New("unterminated string")
+		}
+		u := binary.LittleEndian.Uint16(data[i:])
+		if u == 0 {
+			return string(utf16.Decode(units)), nil
+		}
+		units = append(units, u)
+	}
+}
@@ -0,0 +1,106 @@
+package sddl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestResourceAttributeRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Strings", input: `("Project",TS,0x0,"Windows","SQL")`, want: `("Project",TS,0x0,"Windows","SQL")`},
+		{name: "Unsigned integers", input: `("Secrecy",TU,0x0,3)`, want: `("Secrecy",TU,0x0,3)`},
+		{name: "Signed integers", input: `("n",TI,0x1,-5,7)`, want: `("n",TI,0x1,-5,7)`},
+		{name: "SIDs", input: `("Owners",TD,0x0,BA,S-1-5-32-545)`, want: `("Owners",TD,0x0,BA,BU)`},
+		{name: "Booleans", input: `("b",TB,0x0,1,0)`, want: `("b",TB,0x0,1,0)`},
+		{name: "Octet strings", input: `("x",TX,0x0,#0aff)`, want: `("x",TX,0x0,#0aff)`},
+		{name: "Commas in strings", input: `("a,b",TS,0x0,"c,d")`, want: `("a,b",TS,0x0,"c,d")`},
+		{name: "No values", input: `("Empty",TS,0x0)`, want: `("Empty",TS,0x0)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := encodeResourceAttribute(tt.input)
+			if err != nil {
+				t.Fatalf("encodeResourceAttribute() unexpected error = %v", err)
+			}
+			if len(data)%4 != 0 {
+				t.Errorf("encodeResourceAttribute() = %x, want a multiple of 4 bytes", data)
+			}
+
+			got, err := resourceAttributeString(data)
+			if err != nil {
+				t.Fatalf("resourceAttributeString() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resourceAttributeString() = %s, want %s", got, tt.want)
+			}
+
+			again, err := encodeResourceAttribute(got)
+			if err != nil {
+				t.Fatalf("encodeResourceAttribute() of the written form unexpected error = %v", err)
+			}
+			if !bytes.Equal(again, data) {
+				t.Errorf("encodeResourceAttribute(%s) = %x, want %x", got, again, data)
+			}
+		})
+	}
+}
+
+func TestEncodeResourceAttribute_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Missing parentheses", input: `"x",TS,0x0`},
+		{name: "Missing flags", input: `("x",TS)`},
+		{name: "Unquoted name", input: `(x,TS,0x0)`},
+		{name: "Unknown type", input: `("x",TZ,0x0)`},
+		{name: "Invalid boolean", input: `("x",TB,0x0,2)`},
+		{name: "Invalid SID", input: `("x",TD,0x0,XX)`},
+		{name: "Unterminated string", input: `("x",TS,0x0,"y)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, err := encodeResourceAttribute(tt.input); err == nil {
+				t.Errorf("encodeResourceAttribute() = %x, want error", got)
+			}
+		})
+	}
+}
+
+func TestResourceAttributeString_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "Truncated header", data: []byte{0x10, 0x00, 0x00, 0x00}},
+		{name: "Unknown type", data: []byte{0x10, 0, 0, 0, 0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 'x', 0, 0, 0}},
+		{name: "Value count exceeds the data", data: []byte{0x10, 0, 0, 0, 0x03, 0, 0, 0, 0, 0, 0, 0, 0x10, 0, 0, 0, 'x', 0, 0, 0}},
+		{name: "Unterminated name", data: []byte{0x10, 0, 0, 0, 0x03, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 'x', 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, err := resourceAttributeString(tt.data); err == nil {
+				t.Errorf("resourceAttributeString() = %s, want error", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package sddl
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+)
+
+// CanonicalString returns the canonical SDDL representation of the security descriptor, a deterministic
+// and minimal string that is the same for all the equivalent ways of writing it, for storage or comparison.
+// It follows these rules:
+//   - The components are written in the order O, G, D, S, and the ACL flags in the order P, AR, AI, R.
+//   - SIDs are written with their well-known alias when they have one (e.g. "SY" for S-1-5-18).
+//   - Access masks are written with their well-known mnemonic (e.g. "FA"), else as a group of mnemonics
+//     (e.g. "RPWP"), and in hexadecimal only if some bits have no mnemonic, even for ACEs parsed with
+//     ParseOptions.RawMasks.
+//   - Within each run of consecutive allow ACEs, or of consecutive deny ACEs, of the same type and
+//     either all explicit or all inherited, the ACEs are sorted by their SDDL representation and the
+//     duplicates are removed. This doesn't change the access granted, as the ACEs of such a run grant
+//     or deny the union of their rights whatever their order, and it keeps canonically ordered DACLs so.
+//
+// The other ACEs, such as audit ACEs or mandatory labels, keep their place, as do the runs: reordering
+// ACEs across runs could change the access granted. Callback ACEs, written with their conditional
+// expression like String does, are never reordered either.
+func (sd *SecurityDescriptor) CanonicalString() string {
+	var b strings.Builder
+	if sd.ownerSID != nil {
+		b.WriteString("O:" + sd.ownerSID.String())
+	}
+	if sd.groupSID != nil {
+		b.WriteString("G:" + sd.groupSID.String())
+	}
+	if sd.dacl != nil {
+		b.WriteString("D:" + sd.dacl.canonicalString())
+	} else if sd.control&seDACLPresent != 0 {
+		b.WriteString("D:" + sd.nullACLString("D"))
+	}
+	if sd.sacl != nil {
+		b.WriteString("S:" + sd.sacl.canonicalString())
+	} else if sd.control&seSACLPresent != 0 {
+		b.WriteString("S:" + sd.nullACLString("S"))
+	}
+	return b.String()
+}
+
+// canonicalString returns the canonical SDDL representation of the ACL, see SecurityDescriptor.CanonicalString.
+func (a *ACL) canonicalString() string {
+	var b strings.Builder
+	b.WriteString(a.FlagsString())
+	for _, ace := range a.canonicalACEs() {
+		b.WriteString(ace.String())
+	}
+	return b.String()
+}
+
+// canonicalACEs returns a copy of the ACEs of the ACL where each run of interchangeable ACEs is sorted by
+// SDDL representation and stripped of its duplicates, see SecurityDescriptor.CanonicalString. The returned
+// ACEs never have rawMask set nor padding after their SID, and their size is updated accordingly.
+func (a *ACL) canonicalACEs() []ACE {
+	aces := make([]ACE, 0, len(a.aces))
+	for i := 0; i < len(a.aces); {
+		// Find the run of interchangeable ACEs starting at i
+		j := i + 1
+		if isCanonicalRunACE(&a.aces[i]) {
+			for j < len(a.aces) && sameCanonicalRun(&a.aces[i], &a.aces[j]) {
+				j++
+			}
+		}
+
+		run := make([]ACE, 0, j-i)
+		for k := i; k < j; k++ {
+			ace := a.aces[k]
+			ace.rawMask = false
+			if len(ace.padding) > 0 {
+				header := *ace.header
+				ace.header = &header
+				ace.padding = nil
+				ace.updateSize()
+			}
+			run = append(run, ace)
+		}
+		slices.SortStableFunc(run, func(x, y ACE) int { return strings.Compare(x.String(), y.String()) })
+		run = slices.CompactFunc(run, func(x, y ACE) bool { return x.String() == y.String() })
+		aces = append(aces, run...)
+		i = j
+	}
+	return aces
+}
+
+// Hash returns a 64-bit FNV-1a hash of the binary representation of the canonical form of the security
+// descriptor, as described by CanonicalString: equivalent descriptors hash equal however they were built,
+// so it can be used as a key for caching or to detect changes. Unlike CanonicalString, it covers the
+// application data of callback ACEs that can't be written in SDDL. Like Equal, it ignores the padding
+// found after the SID of decoded ACEs.
+//
+// The hash is only stable across versions of this package as long as the canonicalization rules don't
+// change, so it shouldn't be persisted beyond a cache. Like Binary, it panics if the security descriptor
+// cannot be converted to its binary representation; use Validate to check it first.
+func (sd *SecurityDescriptor) Hash() uint64 {
+	c := *sd
+	c.dacl = sd.dacl.canonical()
+	c.sacl = sd.sacl.canonical()
+
+	h := fnv.New64a()
+	h.Write(c.Binary())
+	return h.Sum64()
+}
+
+// canonical returns a shallow copy of the ACL holding its canonical ACEs, or nil for a nil ACL.
+func (a *ACL) canonical() *ACL {
+	if a == nil {
+		return nil
+	}
+	c := *a
+	c.aces = a.canonicalACEs()
+	c.updateSize()
+	return &c
+}
+
+// isCanonicalRunACE reports whether the ACE can be reordered within a run of similar ACEs: it must be a
+// non-callback allow or deny ACE.
+func isCanonicalRunACE(ace *ACE) bool {
+	if ace.header == nil || ace.sid == nil || isCallbackACEType(ace.header.aceType) {
+		return false
+	}
+	return isAllowACEType(ace.header.aceType) || isDenyACEType(ace.header.aceType)
+}
+
+// sameCanonicalRun reports whether other belongs to the same run of interchangeable ACEs as ace, that is
+// it has the same type and is inherited if and only if ace is.
+func sameCanonicalRun(ace, other *ACE) bool {
+	return isCanonicalRunACE(other) && other.header.aceType == ace.header.aceType &&
+		other.header.aceFlags&inheritedACE == ace.header.aceFlags&inheritedACE
+}
+
+// CanonicalViolation describes an ACE that breaks the Windows canonical order of ACEs, see ACL.CanonicalViolations.
+type CanonicalViolation struct {
+	// Index is the index of the ACE that is out of order.
+	Index int
+	// Preceding is the index of the first ACE preceding it that should come after it.
+	Preceding int
+	// Rule is the canonical order rule that the two ACEs break.
+	Rule CanonicalRule
+}
+
+// CanonicalRule is a rule of the Windows canonical order of ACEs.
+type CanonicalRule int
+
+const (
+	// CanonicalRuleDenyFirst - Explicit deny ACEs come before the other explicit ACEs
+	CanonicalRuleDenyFirst CanonicalRule = iota + 1
+	// CanonicalRuleExplicitFirst - Explicit ACEs come before inherited ACEs
+	CanonicalRuleExplicitFirst
+)
+
+// String returns the description of the rule, e.g. "explicit ACEs come before inherited ACEs".
+func (r CanonicalRule) String() string {
+	switch r {
+	case CanonicalRuleDenyFirst:
+		return "explicit deny ACEs come before the other explicit ACEs"
+	case CanonicalRuleExplicitFirst:
+		return "explicit ACEs come before inherited ACEs"
+	default:
+		return fmt.Sprintf("CanonicalRule(%d)", int(r))
+	}
+}
+
+// String returns a description of the violation, e.g. "allow ACE at index 1 precedes deny ACE at index 2".
+func (v CanonicalViolation) String() string {
+	if v.Rule == CanonicalRuleExplicitFirst {
+		return fmt.Sprintf("inherited ACE at index %d precedes explicit ACE at index %d", v.Preceding, v.Index)
+	}
+	return fmt.Sprintf("allow ACE at index %d precedes deny ACE at index %d", v.Preceding, v.Index)
+}
+
+// CanonicalViolations returns the ACEs that break the Windows canonical order, which is explicit deny ACEs
+// first, then the other explicit ACEs, then the inherited ACEs. Each ACE out of order is reported once,
+// along with the first ACE preceding it that should come after it, in the order of the ACL. For example,
+// "D:(A;;FA;;;SY)(D;;FA;;;AN)(A;ID;FR;;;BU)(A;;FR;;;WD)" has two violations: "allow ACE at index 0
+// precedes deny ACE at index 1" and "inherited ACE at index 2 precedes explicit ACE at index 3".
+//
+// The order of the inherited ACEs, which depends on the generation they were inherited from, is not checked.
+func (a *ACL) CanonicalViolations() []CanonicalViolation {
+	var violations []CanonicalViolation
+
+	// Index of the first ACE of each rank, see canonicalRank
+	firstOfRank := [3]int{-1, -1, -1}
+	for i := range a.aces {
+		rank := canonicalRank(&a.aces[i])
+
+		preceding, precedingRank := -1, 0
+		for r := rank + 1; r < len(firstOfRank); r++ {
+			if j := firstOfRank[r]; j >= 0 && (preceding < 0 || j < preceding) {
+				preceding, precedingRank = j, r
+			}
+		}
+		if preceding >= 0 {
+			rule := CanonicalRuleDenyFirst
+			if precedingRank == 2 {
+				rule = CanonicalRuleExplicitFirst
+			}
+			violations = append(violations, CanonicalViolation{Index: i, Preceding: preceding, Rule: rule})
+		}
+
+		if firstOfRank[rank] < 0 {
+			firstOfRank[rank] = i
+		}
+	}
+
+	return violations
+}
+
+// IsCanonical reports whether the ACEs of the ACL are in the Windows canonical order, see CanonicalViolations.
+func (a *ACL) IsCanonical() bool {
+	return len(a.CanonicalViolations()) == 0
+}
@@ -0,0 +1,288 @@
+package sddl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSecurityDescriptor_CanonicalString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		inputs []string
+		want   string
+	}{
+		{
+			name: "Aliases and mnemonics",
+			inputs: []string{
+				"O:SYG:BAD:(A;;FA;;;WD)",
+				"G:S-1-5-32-544O:S-1-5-18D:(A;;0x001F01FF;;;S-1-1-0)",
+			},
+			want: "O:SYG:BAD:(A;;FA;;;WD)",
+		},
+		{
+			name: "Mnemonic groups",
+			inputs: []string{
+				"D:(OA;;RPWP;;;AU)",
+				"D:(OA;;0x30;;;S-1-5-11)",
+				"D:(OA;;WPRP;;;AU)",
+			},
+			want: "D:(OA;;RPWP;;;AU)",
+		},
+		{
+			name: "ACL flags",
+			inputs: []string{
+				"D:PAIAR(A;;FA;;;SY)",
+				"D:ARPAI(A;;FA;;;SY)",
+			},
+			want: "D:PARAI(A;;FA;;;SY)",
+		},
+		{
+			name: "Allow ACEs sorted and deduplicated",
+			inputs: []string{
+				"D:(D;;FA;;;AN)(A;;FR;;;BU)(A;;FA;;;SY)(A;;FR;;;BU)",
+				"D:(D;;FA;;;AN)(A;;FA;;;SY)(A;;FR;;;BU)",
+			},
+			want: "D:(D;;FA;;;AN)(A;;FA;;;SY)(A;;FR;;;BU)",
+		},
+		{
+			name: "Explicit and inherited runs kept apart",
+			inputs: []string{
+				"D:(A;;FR;;;BU)(A;;FA;;;SY)(A;ID;FR;;;WD)(A;ID;FA;;;BA)",
+				"D:(A;;FA;;;SY)(A;;FR;;;BU)(A;ID;FA;;;BA)(A;ID;FR;;;WD)",
+			},
+			want: "D:(A;;FA;;;SY)(A;;FR;;;BU)(A;ID;FA;;;BA)(A;ID;FR;;;WD)",
+		},
+		{
+			name: "Deny after allow keeps its place",
+			inputs: []string{
+				"D:(A;;FR;;;BU)(D;;FA;;;BU)",
+			},
+			want: "D:(A;;FR;;;BU)(D;;FA;;;BU)",
+		},
+		{
+			name: "SACL order kept",
+			inputs: []string{
+				"S:(ML;;NW;;;LW)(AU;SA;FA;;;WD)(AU;FA;FA;;;BA)",
+			},
+			want: "S:(ML;;NW;;;LW)(AU;SA;FA;;;WD)(AU;FA;FA;;;BA)",
+		},
+		{
+			name: "NULL DACL",
+			inputs: []string{
+				"D:NO_ACCESS_CONTROL",
+			},
+			want: "D:NO_ACCESS_CONTROL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, input := range tt.inputs {
+				sd := mustFromString(t, input)
+				if got := sd.CanonicalString(); got != tt.want {
+					t.Errorf("CanonicalString() of %s = %s, want %s", input, got, tt.want)
+				}
+			}
+		})
+	}
+
+	// Masks parsed as raw hexadecimal values are written with their mnemonics as well
+	sd, err := FromStringWithOptions("D:(A;;0x1F01FF;;;SY)", ParseOptions{RawMasks: true})
+	if err != nil {
+		t.Fatalf("FromStringWithOptions() unexpected error = %v", err)
+	}
+	if got, want := sd.CanonicalString(), "D:(A;;FA;;;SY)"; got != want {
+		t.Errorf("CanonicalString() = %s, want %s", got, want)
+	}
+}
+
+func TestSecurityDescriptor_Hash(t *testing.T) {
+	t.Parallel()
+
+	// Each group holds equivalent descriptors, which must hash equal, and differ from the other groups
+	groups := [][]string{
+		{
+			"O:SYG:BAD:(A;;FA;;;WD)",
+			"G:S-1-5-32-544O:S-1-5-18D:(A;;0x001F01FF;;;S-1-1-0)",
+		},
+		{
+			"D:(A;;FR;;;BU)(A;;FA;;;SY)(A;;FR;;;BU)",
+			"D:(A;;FA;;;SY)(A;;FR;;;BU)",
+		},
+		{
+			"D:(D;;FA;;;BG)(A;;FA;;;SY)",
+		},
+		{
+			"D:(A;;FA;;;SY)(D;;FA;;;BG)",
+		},
+		{
+			"D:P(A;;FA;;;SY)",
+		},
+		{
+			"D:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)",
+			"S:(AU;SA;FA;;;WD)D:(A;;FA;;;SY)",
+		},
+		{
+			"D:NO_ACCESS_CONTROL",
+		},
+		{
+			"D:",
+		},
+		{
+			"",
+		},
+	}
+
+	seen := make(map[uint64]int)
+	for i, group := range groups {
+		for _, input := range group {
+			got := mustFromString(t, input).Hash()
+			if j, ok := seen[got]; ok && j != i {
+				t.Errorf("Hash(%q) = %#x, same as the descriptors of group %d", input, got, j)
+			}
+			seen[got] = i
+			if want := mustFromString(t, group[0]).Hash(); got != want {
+				t.Errorf("Hash(%q) = %#x, want %#x as for %q", input, got, want, group[0])
+			}
+		}
+	}
+
+	// Hashing doesn't change the descriptor
+	sd := mustFromString(t, "D:(A;;FR;;;BU)(A;;FA;;;SY)(A;;FR;;;BU)")
+	sd.Hash()
+	if got, want := sd.String(), "D:(A;;FR;;;BU)(A;;FA;;;SY)(A;;FR;;;BU)"; got != want {
+		t.Errorf("String() after Hash() = %s, want %s", got, want)
+	}
+
+	// Masks parsed as raw hexadecimal values hash as the same masks written with mnemonics
+	raw, err := FromStringWithOptions("D:(A;;0x1F01FF;;;SY)", ParseOptions{RawMasks: true})
+	if err != nil {
+		t.Fatalf("FromStringWithOptions() unexpected error = %v", err)
+	}
+	if got, want := raw.Hash(), mustFromString(t, "D:(A;;FA;;;SY)").Hash(); got != want {
+		t.Errorf("Hash() with RawMasks = %#x, want %#x", got, want)
+	}
+
+	// Padding after the SID of decoded ACEs carries no meaning, so padded ACEs hash as unpadded ones
+	padded, err := FromBinary([]byte{
+		0x01, 0x00, 0x27, 0x80, // Revision, Sbz1, Control as set by FromString
+		0x00, 0x00, 0x00, 0x00, // Owner offset
+		0x00, 0x00, 0x00, 0x00, // Group offset
+		0x00, 0x00, 0x00, 0x00, // SACL offset
+		0x14, 0x00, 0x00, 0x00, // DACL offset
+		0x02, 0x00, 0x20, 0x00, // ACL revision, Sbz1, AclSize
+		0x01, 0x00, 0x00, 0x00, // AceCount, Sbz2
+		0x00, 0x00, 0x18, 0x00, // ACE type, flags, size (4 bytes of padding)
+		0xFF, 0x01, 0x1F, 0x00, // Full Access
+		0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00, // SY
+		0x00, 0x00, 0x00, 0x00, // Padding
+	})
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	unpadded := mustFromString(t, "D:(A;;FA;;;SY)")
+	if !padded.Equal(unpadded) || padded.CanonicalString() != unpadded.CanonicalString() {
+		t.Fatalf("padded descriptor %s is not equivalent to %s", padded, unpadded)
+	}
+	if got, want := padded.Hash(), unpadded.Hash(); got != want {
+		t.Errorf("Hash() with padding = %#x, want %#x", got, want)
+	}
+	if got := len(padded.dacl.aces[0].padding); got != 4 {
+		t.Errorf("Hash() changed the padding of the descriptor to %d bytes, want 4", got)
+	}
+}
+
+func TestACL_CanonicalViolations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sddl string
+		want []string
+	}{
+		{
+			name: "Canonical",
+			sddl: "D:(D;;FA;;;AN)(A;;FA;;;SY)(A;;FR;;;BU)(D;ID;FW;;;WD)(A;ID;FA;;;BA)",
+		},
+		{
+			name: "Empty",
+			sddl: "D:",
+		},
+		{
+			name: "Deny after allow",
+			sddl: "D:(A;;FR;;;BU)(D;;FA;;;AN)",
+			want: []string{"allow ACE at index 0 precedes deny ACE at index 1"},
+		},
+		{
+			name: "Several denies after allows",
+			sddl: "D:(D;;FA;;;AN)(A;;FA;;;SY)(A;;FR;;;BU)(D;;FW;;;WD)(D;;FX;;;BG)",
+			want: []string{
+				"allow ACE at index 1 precedes deny ACE at index 3",
+				"allow ACE at index 1 precedes deny ACE at index 4",
+			},
+		},
+		{
+			name: "Explicit after inherited",
+			sddl: "D:(A;;FA;;;SY)(A;ID;FA;;;BA)(A;;FR;;;BU)",
+			want: []string{"inherited ACE at index 1 precedes explicit ACE at index 2"},
+		},
+		{
+			// Only the first ACE that should come after the deny ACE is reported
+			name: "Explicit deny after allow and inherited ACEs",
+			sddl: "D:(A;;FA;;;SY)(A;ID;FA;;;BA)(D;;FA;;;AN)",
+			want: []string{
+				"allow ACE at index 0 precedes deny ACE at index 2",
+			},
+		},
+		{
+			name: "Reversed groups",
+			sddl: "D:(A;ID;FA;;;BA)(A;;FA;;;SY)(D;;FA;;;AN)",
+			want: []string{
+				"inherited ACE at index 0 precedes explicit ACE at index 1",
+				"inherited ACE at index 0 precedes explicit ACE at index 2",
+			},
+		},
+		{
+			name: "Inherited deny after inherited allow",
+			sddl: "D:(A;ID;FA;;;BA)(D;ID;FW;;;WD)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			acl := mustFromString(t, tt.sddl).DACL()
+			violations := acl.CanonicalViolations()
+
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("CanonicalViolations() = %q, want %q", got, tt.want)
+			}
+			if acl.IsCanonical() != (len(tt.want) == 0) {
+				t.Errorf("IsCanonical() = %v, want %v", acl.IsCanonical(), len(tt.want) == 0)
+			}
+		})
+	}
+
+	// The rule tells which group each ACE belongs to
+	violations := mustFromString(t, "D:(A;ID;FA;;;BA)(A;;FA;;;SY)(D;;FA;;;AN)(A;;FR;;;BU)").DACL().CanonicalViolations()
+	want := []CanonicalViolation{
+		{Index: 1, Preceding: 0, Rule: CanonicalRuleExplicitFirst},
+		{Index: 2, Preceding: 0, Rule: CanonicalRuleExplicitFirst},
+		{Index: 3, Preceding: 0, Rule: CanonicalRuleExplicitFirst},
+	}
+	if !slices.Equal(violations, want) {
+		t.Errorf("CanonicalViolations() = %+v, want %+v", violations, want)
+	}
+	if got := CanonicalRuleDenyFirst.String(); got != "explicit deny ACEs come before the other explicit ACEs" {
+		t.Errorf("CanonicalRuleDenyFirst.String() = %s", got)
+	}
+}
@@ -0,0 +1,148 @@
+package sddl
+
+import "bytes"
+
+// Capabilities is a set of features used by a security descriptor, as reported by
+// SecurityDescriptor.Capabilities. It allows callers to find out whether a descriptor relies on
+// features that this package does not fully support before relying on its conversions.
+//
+// The data of CapUnknownACEs and CapACEPadding can't be represented in SDDL, and neither can the
+// application data of CapCallbackACEs and CapResourceAttributes that this package can't decode, see
+// SecurityDescriptor.LosslessRoundTrip.
+type Capabilities uint32
+
+const (
+	// CapObjectACEs - The descriptor has object ACEs (OA, OD, OU, OL), carrying object type GUIDs.
+	CapObjectACEs Capabilities = 1 << iota
+	// CapCallbackACEs - The descriptor has callback ACEs (XA, XD, ZA, XU...), which carry a conditional
+	// expression as application data.
+	CapCallbackACEs
+	// CapResourceAttributes - The descriptor has resource attribute ACEs (RA), which carry
+	// claim data.
+	CapResourceAttributes
+	// CapMandatoryLabel - The descriptor has mandatory integrity label ACEs (ML).
+	CapMandatoryLabel
+	// CapUnknownACEs - The descriptor has ACEs of unknown type, which are only kept as raw data.
+	CapUnknownACEs
+	// CapACEPadding - The descriptor has ACEs with trailing bytes after their content.
+	CapACEPadding
+)
+
+// Has reports whether all the capabilities in c2 are present in c.
+func (c Capabilities) Has(c2 Capabilities) bool {
+	return c&c2 == c2
+}
+
+// Capabilities returns the set of features used by the ACEs of the security descriptor.
+func (sd *SecurityDescriptor) Capabilities() Capabilities {
+	var c Capabilities
+	for _, acl := range []*ACL{sd.dacl, sd.sacl} {
+		if acl == nil {
+			continue
+		}
+		for i := range acl.aces {
+			c |= acl.aces[i].capabilities()
+		}
+	}
+	return c
+}
+
+// LosslessRoundTrip reports whether the security descriptor can be converted to its SDDL string
+// representation and parsed back without losing data. It returns false when the descriptor holds data
+// that SDDL can't represent:
+//   - ACEs whose content is only preserved as raw bytes: unknown and padded ACEs, and callback and
+//     resource attribute ACEs whose application data can't be decoded, or wouldn't be encoded back to the
+//     same bytes
+//   - Resource manager control bits, see ResourceManagerControl
+//   - Control flags without SDDL form: the defaulted flags of a present owner or group, SE_DACL_TRUSTED
+//     and SE_SERVER_SECURITY. Defaulted ACLs are kept, they have the R flag
+//   - ACLs whose revision is not the one FromString picks, 4 with object ACEs and 2 otherwise, or whose
+//     reserved Sbz1 and Sbz2 fields are not zero
+//
+// In that case, callers should keep the original binary representation: binary round-trips (FromBinary
+// followed by Binary) preserve all of the above.
+func (sd *SecurityDescriptor) LosslessRoundTrip() bool {
+	if sd.sbzl != 0 || sd.control&(seResourceManagerControlValid|seDACLTrusted|seServerSecurity) != 0 {
+		return false
+	}
+	if (sd.ownerSID != nil && sd.control&seOwnerDefaulted != 0) ||
+		(sd.groupSID != nil && sd.control&seGroupDefaulted != 0) {
+		return false
+	}
+
+	for _, acl := range []*ACL{sd.dacl, sd.sacl} {
+		if acl != nil && !acl.losslessInSDDL() {
+			return false
+		}
+	}
+	return true
+}
+
+// losslessInSDDL reports whether the ACL is parsed back from its SDDL string as it is, see
+// SecurityDescriptor.LosslessRoundTrip.
+func (a *ACL) losslessInSDDL() bool {
+	if a.sbzl != 0 || a.sbz2 != 0 {
+		return false
+	}
+
+	revision := byte(aclRevision)
+	for i := range a.aces {
+		ace := &a.aces[i]
+		if !ace.losslessInSDDL() {
+			return false
+		}
+		if isObjectACEType(ace.header.aceType) {
+			revision = aclRevisionDS
+		}
+	}
+	return a.aclRevision == revision
+}
+
+// losslessInSDDL reports whether the ACE is parsed back from its SDDL string as it is, see
+// SecurityDescriptor.LosslessRoundTrip.
+func (e *ACE) losslessInSDDL() bool {
+	if e.isRaw() || len(e.padding) > 0 {
+		return false
+	}
+	if len(e.applicationData) == 0 {
+		return true
+	}
+
+	s := e.applicationDataString()
+	if s == "" {
+		return false
+	}
+	var encoded []byte
+	var err error
+	if isCallbackACEType(e.header.aceType) {
+		encoded, err = encodeCondition(s[1:])
+	} else {
+		encoded, err = encodeResourceAttribute(s[1:])
+	}
+	return err == nil && bytes.Equal(encoded, e.applicationData)
+}
+
+// capabilities returns the set of features used by the ACE.
+func (e *ACE) capabilities() Capabilities {
+	if e.isRaw() {
+		return CapUnknownACEs
+	}
+
+	var c Capabilities
+	if isObjectACEType(e.header.aceType) {
+		c |= CapObjectACEs
+	}
+	if isCallbackACEType(e.header.aceType) {
+		c |= CapCallbackACEs
+	}
+	switch e.header.aceType {
+	case systemResourceAttributeACEType:
+		c |= CapResourceAttributes
+	case systemMandatoryLabelACEType:
+		c |= CapMandatoryLabel
+	}
+	if len(e.padding) > 0 {
+		c |= CapACEPadding
+	}
+	return c
+}
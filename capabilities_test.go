@@ -0,0 +1,309 @@
+package sddl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecurityDescriptor_Capabilities(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		sddl         string
+		want         Capabilities
+		wantLossless bool
+	}{
+		{
+			name:         "No ACL",
+			sddl:         "O:SYG:SY",
+			want:         0,
+			wantLossless: true,
+		},
+		{
+			name:         "Basic ACEs",
+			sddl:         "O:SYD:(A;;FA;;;SY)(D;OICI;FR;;;WD)S:(AU;SA;FA;;;WD)",
+			want:         0,
+			wantLossless: true,
+		},
+		{
+			name:         "Object ACEs",
+			sddl:         "D:(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)(A;;FA;;;SY)",
+			want:         CapObjectACEs,
+			wantLossless: true,
+		},
+		{
+			name:         "Mandatory label",
+			sddl:         "S:(0x11;;0x1;;;S-1-16-4096)",
+			want:         CapMandatoryLabel,
+			wantLossless: true,
+		},
+		{
+			name:         "Callback ACE without condition",
+			sddl:         "D:(0x9;;FA;;;WD)",
+			want:         CapCallbackACEs,
+			wantLossless: true,
+		},
+		{
+			name:         "Callback ACE",
+			sddl:         `D:(XA;;FX;;;WD;(@User.Title == "PM"))`,
+			want:         CapCallbackACEs,
+			wantLossless: true,
+		},
+		{
+			name:         "Callback object ACE",
+			sddl:         "D:(ZA;;CR;00299570-246d-11d0-a768-00aa006e0529;;AU;(Member_of {SID(BA)}))",
+			want:         CapCallbackACEs | CapObjectACEs,
+			wantLossless: true,
+		},
+		{
+			name:         "Resource attribute",
+			sddl:         `S:(RA;;;;;WD;("Project",TS,0x0,"SQL"))`,
+			want:         CapResourceAttributes,
+			wantLossless: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.sddl)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+
+			if got := sd.Capabilities(); got != tt.want {
+				t.Errorf("Capabilities() = %#x, want %#x", got, tt.want)
+			}
+			if got := sd.LosslessRoundTrip(); got != tt.wantLossless {
+				t.Errorf("LosslessRoundTrip() = %v, want %v", got, tt.wantLossless)
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_LosslessRoundTrip_DescriptorState(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		sddl         string
+		modify       func(sd *SecurityDescriptor)
+		wantLossless bool
+	}{
+		{
+			name:         "Unmodified",
+			sddl:         "O:BAG:SYD:PAI(A;;FA;;;SY)S:(AU;SA;FA;;;WD)",
+			modify:       func(sd *SecurityDescriptor) {},
+			wantLossless: true,
+		},
+		{
+			name:   "Resource manager control",
+			sddl:   "O:BAG:SYD:(A;;FA;;;SY)",
+			modify: func(sd *SecurityDescriptor) { sd.SetResourceManagerControl(0x5A) },
+		},
+		{
+			name:   "Defaulted owner",
+			sddl:   "O:BAG:SYD:(A;;FA;;;SY)",
+			modify: func(sd *SecurityDescriptor) { sd.control |= seOwnerDefaulted },
+		},
+		{
+			name:   "Defaulted group",
+			sddl:   "O:BAG:SYD:(A;;FA;;;SY)",
+			modify: func(sd *SecurityDescriptor) { sd.control |= seGroupDefaulted },
+		},
+		{
+			name:         "Defaulted DACL",
+			sddl:         "O:BAG:SYD:(A;;FA;;;SY)",
+			modify:       func(sd *SecurityDescriptor) { sd.SetDACLDefaulted(true) },
+			wantLossless: true,
+		},
+		{
+			name:         "Defaulted DACL in SDDL",
+			sddl:         "O:SYD:R(A;;FA;;;SY)",
+			modify:       func(sd *SecurityDescriptor) {},
+			wantLossless: true,
+		},
+		{
+			name:         "Defaulted SACL",
+			sddl:         "O:BAG:SYS:(AU;SA;FA;;;WD)",
+			modify:       func(sd *SecurityDescriptor) { sd.SetSACLDefaulted(true) },
+			wantLossless: true,
+		},
+		{
+			name:   "Trusted DACL",
+			sddl:   "O:BAG:SYD:(A;;FA;;;SY)",
+			modify: func(sd *SecurityDescriptor) { sd.control |= seDACLTrusted },
+		},
+		{
+			name:   "Revision 4 without object ACEs",
+			sddl:   "O:BAG:SYD:(A;;FA;;;SY)",
+			modify: func(sd *SecurityDescriptor) { sd.dacl.aclRevision = aclRevisionDS },
+		},
+		{
+			name:   "ACL Sbz1",
+			sddl:   "O:BAG:SYD:(A;;FA;;;SY)",
+			modify: func(sd *SecurityDescriptor) { sd.dacl.sbzl = 1 },
+		},
+		{
+			name:   "ACL Sbz2",
+			sddl:   "O:BAG:SYS:(AU;SA;FA;;;WD)",
+			modify: func(sd *SecurityDescriptor) { sd.sacl.sbz2 = 1 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			tt.modify(sd)
+
+			if got := sd.LosslessRoundTrip(); got != tt.wantLossless {
+				t.Errorf("LosslessRoundTrip() = %v, want %v", got, tt.wantLossless)
+			}
+
+			// The answer matches what actually happens going through SDDL
+			parsed := mustFromString(t, sd.String())
+			if lossless := bytes.Equal(parsed.Binary(), sd.Binary()); lossless != tt.wantLossless {
+				t.Errorf("FromString(String()).Binary() equal to Binary() = %v, want %v", lossless, tt.wantLossless)
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_Capabilities_Binary(t *testing.T) {
+	t.Parallel()
+
+	aceSY := []byte{
+		0x00,       // Type (ACCESS_ALLOWED_ACE_TYPE)
+		0x00,       // Flags
+		0x14, 0x00, // Size (20 bytes)
+		0xFF, 0x01, 0x1F, 0x00, // Full Access
+		0x01, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+		0x12, 0x00, 0x00, 0x00,
+	}
+	acePadded := []byte{
+		0x00,       // Type (ACCESS_ALLOWED_ACE_TYPE)
+		0x00,       // Flags
+		0x18, 0x00, // Size (24 bytes)
+		0xFF, 0x01, 0x1F, 0x00, // Full Access
+		0x01, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+		0x12, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, // Padding
+	}
+	// callbackACE returns a callback ACE for SY with the given application data
+	callbackACE := func(data ...byte) []byte {
+		ace := []byte{
+			0x09,                       // Type (ACCESS_ALLOWED_CALLBACK_ACE_TYPE)
+			0x00,                       // Flags
+			byte(20 + len(data)), 0x00, // Size
+			0xFF, 0x01, 0x1F, 0x00, // Full Access
+			0x01, 0x01,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+			0x12, 0x00, 0x00, 0x00,
+		}
+		return append(ace, data...)
+	}
+	aceUnknown := []byte{
+		0x42,       // Type (unknown)
+		0x00,       // Flags
+		0x0C, 0x00, // Size (12 bytes)
+		0x01, 0x00, 0x00, 0x00, // Access mask
+		0xDE, 0xAD, 0xBE, 0xEF, // Opaque payload
+	}
+
+	// withDACL returns a security descriptor with a DACL holding the given ACE
+	withDACL := func(ace []byte) []byte {
+		aclSize := 8 + len(ace)
+		data := []byte{
+			0x01,       // Revision
+			0x00,       // Sbz1
+			0x04, 0x80, // Control (SE_SELF_RELATIVE | SE_DACL_PRESENT)
+			0x00, 0x00, 0x00, 0x00, // Owner offset
+			0x00, 0x00, 0x00, 0x00, // Group offset
+			0x00, 0x00, 0x00, 0x00, // Sacl offset
+			0x14, 0x00, 0x00, 0x00, // Dacl offset
+			0x02,                // Revision
+			0x00,                // Sbz1
+			byte(aclSize), 0x00, // Size
+			0x01, 0x00, // AceCount
+			0x00, 0x00, // Sbz2
+		}
+		return append(data, ace...)
+	}
+
+	tests := []struct {
+		name         string
+		data         []byte
+		want         Capabilities
+		wantLossless bool
+	}{
+		{
+			name:         "Basic ACE",
+			data:         withDACL(aceSY),
+			want:         0,
+			wantLossless: true,
+		},
+		{
+			name:         "Padded ACE",
+			data:         withDACL(acePadded),
+			want:         CapACEPadding,
+			wantLossless: false,
+		},
+		{
+			name: "Callback ACE with opaque data",
+			data: withDACL(callbackACE('a', 'r', 't', 'x', 0x01, 0x00, 0x00, 0x00)),
+			want: CapCallbackACEs,
+		},
+		{
+			name: "Callback ACE with a condition not encoded as this package does",
+			// (@USER.x == 5), with an 8-bit integer token where this package writes a 64-bit one
+			data: withDACL(callbackACE(
+				'a', 'r', 't', 'x', 0xF9, 0x02, 0x00, 0x00, 0x00, 'x', 0x00,
+				0x01, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x02, 0x80, 0x00,
+			)),
+			want: CapCallbackACEs,
+		},
+		{
+			name: "Callback ACE with a condition",
+			// (@USER.x == 5)
+			data: withDACL(callbackACE(
+				'a', 'r', 't', 'x', 0xF9, 0x02, 0x00, 0x00, 0x00, 'x', 0x00,
+				0x04, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x02, 0x80, 0x00,
+			)),
+			want:         CapCallbackACEs,
+			wantLossless: true,
+		},
+		{
+			name:         "Unknown ACE",
+			data:         withDACL(aceUnknown),
+			want:         CapUnknownACEs,
+			wantLossless: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromBinary(tt.data)
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+
+			if got := sd.Capabilities(); got != tt.want {
+				t.Errorf("Capabilities() = %#x, want %#x", got, tt.want)
+			}
+			if !sd.Capabilities().Has(tt.want) {
+				t.Errorf("Capabilities().Has(%#x) = false, want true", tt.want)
+			}
+			if got := sd.LosslessRoundTrip(); got != tt.wantLossless {
+				t.Errorf("LosslessRoundTrip() = %v, want %v", got, tt.wantLossless)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"bufio"
 	"encoding/base64"
+	"encoding/csv"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -11,13 +13,32 @@ import (
 	"github.com/cloudsoda/sddl"
 )
 
+// maxLineSize bounds the length of an input line. SDDL strings are the most verbose input format,
+// and take at most about four times the size of the binary security descriptor they describe.
+const maxLineSize = 4 * sddl.DefaultMaxDescriptorSize
+
 type config struct {
 	inputFormat  string
 	outputFormat string
 	fileMode     bool
 	debug        bool
+	validate     bool
+	verify       bool
+	apply        bool
+	yes          bool
+	comments     bool
+	parts        string
+	secInfo      uint32
 }
 
+// Parts of a security descriptor to read in file mode or to set in apply mode, as defined by the Windows SECURITY_INFORMATION flags
+const (
+	ownerSecurityInformation = 0x00000001
+	groupSecurityInformation = 0x00000002
+	daclSecurityInformation  = 0x00000004
+	saclSecurityInformation  = 0x00000008
+)
+
 func main() {
 	cfg := parseFlags()
 
@@ -31,9 +52,15 @@ func parseFlags() config {
 	cfg := config{}
 
 	flag.StringVar(&cfg.inputFormat, "i", "binary", "Input format: 'binary' (base64 encoded) or 'string'")
-	flag.StringVar(&cfg.outputFormat, "o", "string", "Output format: 'binary' (base64 encoded) or 'string'")
-	flag.BoolVar(&cfg.fileMode, "file", false, "Process input as filenames and read their security descriptors using native Windows API calls")
+	flag.StringVar(&cfg.outputFormat, "o", "string", "Output format: 'binary' (base64 encoded), 'string', or 'csv' and 'tsv' (one row per ACE)")
+	flag.BoolVar(&cfg.fileMode, "file", false, "Process input as filenames and read their security descriptors using native Windows API calls, or their NFSv4 ACL on Linux")
 	flag.BoolVar(&cfg.debug, "debug", false, "Enable debugging output (applies only if -o string is set)")
+	flag.BoolVar(&cfg.validate, "validate", false, "Validate each input security descriptor and report OK or the validation error instead of converting it")
+	flag.BoolVar(&cfg.verify, "verify", false, "Check the library against Windows in file mode: compare the native SDDL of each file with the library's parsing and output of its binary security descriptor")
+	flag.BoolVar(&cfg.apply, "apply", false, "Set file security descriptors (Windows only): each input line is a filename and a security descriptor separated by a tab")
+	flag.BoolVar(&cfg.yes, "yes", false, "Confirm that files must be modified in apply mode")
+	flag.BoolVar(&cfg.comments, "comments", false, "Strip comments starting with '#' from SDDL strings (applies only if -i string is set)")
+	flag.StringVar(&cfg.parts, "parts", "ogds", "Parts of the security descriptors to read in file mode: any of 'o' (owner), 'g' (group), 'd' (DACL) and 's' (SACL, requires SeSecurityPrivilege)")
 	flag.Parse()
 
 	// Validate input format
@@ -46,8 +73,8 @@ func parseFlags() config {
 
 	// Validate output format
 	cfg.outputFormat = strings.ToLower(cfg.outputFormat)
-	if cfg.outputFormat != "binary" && cfg.outputFormat != "string" {
-		fmt.Fprintf(os.Stderr, "invalid output format: %s (must be 'binary' or 'string')\n", cfg.outputFormat)
+	if cfg.outputFormat != "binary" && cfg.outputFormat != "string" && cfg.outputFormat != "csv" && cfg.outputFormat != "tsv" {
+		fmt.Fprintf(os.Stderr, "invalid output format: %s (must be 'binary', 'string', 'csv' or 'tsv')\n", cfg.outputFormat)
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -57,12 +84,65 @@ func parseFlags() config {
 		fmt.Fprintln(os.Stderr, "warning: input format is ignored in file mode")
 	}
 
+	// Parts to read from files
+	secInfo, err := parseParts(cfg.parts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid parts: %v\n", err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	cfg.secInfo = secInfo
+	if !cfg.fileMode && secInfo != ownerSecurityInformation|groupSecurityInformation|daclSecurityInformation|saclSecurityInformation {
+		fmt.Fprintln(os.Stderr, "warning: parts are ignored outside of file mode")
+	}
+
+	// Validation only applies to security descriptors given as input
+	if cfg.fileMode && cfg.validate {
+		fmt.Fprintln(os.Stderr, "invalid flags: -validate cannot be used in file mode")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Verification compares the native representations of files' security descriptors
+	if cfg.verify && !cfg.fileMode {
+		fmt.Fprintln(os.Stderr, "invalid flags: -verify requires -file")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Applying descriptors modifies files, it can't be mixed with other modes and must be confirmed
+	if cfg.apply && (cfg.fileMode || cfg.validate) {
+		fmt.Fprintln(os.Stderr, "invalid flags: -apply cannot be used with -file or -validate")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if cfg.apply && !cfg.yes {
+		fmt.Fprintln(os.Stderr, "refusing to modify files: -apply requires -yes to confirm")
+		os.Exit(1)
+	}
+
 	return cfg
 }
 
 func processInput(cfg config) error {
 	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
 	lineNum := 0
+	failures := 0
+
+	// Tabular output starts with a header row, each descriptor adding the rows of its ACEs
+	var table *csv.Writer
+	if cfg.outputFormat == "csv" || cfg.outputFormat == "tsv" {
+		table = csv.NewWriter(os.Stdout)
+		if cfg.outputFormat == "tsv" {
+			table.Comma = '\t'
+		}
+		table.Write(aceColumns)
+		table.Flush()
+		if err := table.Error(); err != nil {
+			return fmt.Errorf("error writing output: %w", err)
+		}
+	}
 
 	for scanner.Scan() {
 		lineNum++
@@ -73,48 +153,114 @@ func processInput(cfg config) error {
 			continue
 		}
 
+		if cfg.fileMode && cfg.verify {
+			if err := verifyFile(input, cfg.secInfo); err != nil {
+				fmt.Fprintf(os.Stderr, "line %d: %q: %v\n", lineNum, input, err)
+				failures++
+				continue
+			}
+			fmt.Println("OK")
+			continue
+		}
+
 		if cfg.fileMode {
 			// Process input as filename
 			var output string
 			var err error
-			if cfg.outputFormat == "binary" {
-				output, err = GetFileSecurityBase64(input)
+			if cfg.outputFormat != "string" {
+				output, err = GetFileSecurityBase64Info(input, cfg.secInfo)
 			} else {
-				output, err = GetFileSDString(input)
+				output, err = GetFileSDStringInfo(input, cfg.secInfo)
 			}
 
+			if err == nil && table != nil {
+				err = writeFileACERows(table, output)
+			}
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "line %d: error processing file %q: %v\n", lineNum, input, err)
 				continue
 			}
-			fmt.Println(output)
+			if table == nil {
+				fmt.Println(output)
+			}
 			continue
 		}
 
+		// In apply mode, the line starts with the name of the file to modify
+		var filename string
+		if cfg.apply {
+			var found bool
+			filename, input, found = strings.Cut(input, "\t")
+			if !found {
+				fmt.Fprintf(os.Stderr, "line %d: expected a filename and a security descriptor separated by a tab\n", lineNum)
+				failures++
+				continue
+			}
+		}
+
 		// Process security descriptor input
 		var sd *sddl.SecurityDescriptor
+		var data []byte
 		var err error
 
 		// Parse input based on format
 		switch cfg.inputFormat {
 		case "binary":
-			data, err := base64.StdEncoding.DecodeString(input)
+			data, err = base64.StdEncoding.DecodeString(input)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "line %d: error decoding base64: %v\n", lineNum, err)
+				failures++
 				continue
 			}
 			sd, err = sddl.FromBinary(data)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "line %d: error parsing security descriptor: %v\n", lineNum, err)
+				failures++
 				continue
 			}
 
 		case "string":
+			if cfg.comments {
+				input = sddl.StripComments(input, "#")
+				if strings.TrimSpace(input) == "" {
+					// Comment-only line
+					continue
+				}
+			}
 			sd, err = sddl.FromString(input)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "line %d: error parsing security descriptor string: %v\n", lineNum, err)
+				failures++
+				continue
+			}
+		}
+
+		// In apply mode, set the parts of the file's security descriptor present in the input
+		if cfg.apply {
+			secInfo := securityInformation(sd)
+			if cfg.inputFormat == "binary" {
+				err = SetFileSDBinary(filename, data, secInfo)
+			} else {
+				err = SetFileSDString(filename, input, secInfo)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "line %d: error setting security descriptor of %q: %v\n", lineNum, filename, err)
+				failures++
+				continue
+			}
+			fmt.Println("OK")
+			continue
+		}
+
+		// In validation mode, report the result instead of converting
+		if cfg.validate {
+			if err := sd.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "line %d: invalid security descriptor: %v\n", lineNum, err)
+				failures++
 				continue
 			}
+			fmt.Println("OK")
+			continue
 		}
 
 		// Generate output based on format
@@ -127,12 +273,179 @@ func processInput(cfg config) error {
 			} else {
 				fmt.Println(sd.String())
 			}
+		case "csv", "tsv":
+			if err := writeACERows(table, sd); err != nil {
+				return fmt.Errorf("error writing output: %w", err)
+			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("error reading input: line %d exceeds the maximum size of %d bytes", lineNum+1, maxLineSize)
+		}
 		return fmt.Errorf("error reading input: %w", err)
 	}
 
+	// Only validation, verification and apply modes turn per-line failures into a non-zero exit status
+	if cfg.validate && failures > 0 {
+		return fmt.Errorf("validation failed for %d line(s)", failures)
+	}
+	if cfg.verify && failures > 0 {
+		return fmt.Errorf("verification failed for %d line(s)", failures)
+	}
+	if cfg.apply && failures > 0 {
+		return fmt.Errorf("failed to apply %d line(s)", failures)
+	}
+
+	return nil
+}
+
+// aceColumns is the header row of the csv and tsv output formats
+var aceColumns = []string{"component", "type", "flags", "mask", "mask_mnemonic", "trustee_sid", "trustee_alias"}
+
+// writeACERows writes a row per ACE of the DACL and then of the SACL of sd, and flushes them so that
+// the output is streamed as the input is read. Security descriptors without ACE write no row.
+func writeACERows(table *csv.Writer, sd *sddl.SecurityDescriptor) error {
+	for _, component := range []struct {
+		name string
+		acl  *sddl.ACL
+	}{
+		{"DACL", sd.DACL()},
+		{"SACL", sd.SACL()},
+	} {
+		if component.acl == nil {
+			continue
+		}
+		for _, ace := range component.acl.ACEs() {
+			// ACEs of unknown type have no trustee
+			var trusteeSID, trusteeAlias string
+			if sid := ace.SID(); sid != nil {
+				text, err := sid.MarshalText()
+				if err != nil {
+					return err
+				}
+				trusteeSID = string(text)
+				if alias := sid.String(); alias != trusteeSID {
+					trusteeAlias = alias
+				}
+			}
+
+			row := []string{
+				component.name,
+				ace.Type().String(),
+				ace.Flags().String(),
+				fmt.Sprintf("0x%08X", ace.AccessMask()),
+				ace.AccessMaskString(),
+				trusteeSID,
+				trusteeAlias,
+			}
+			if err := table.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	table.Flush()
+	return table.Error()
+}
+
+// writeFileACERows writes the ACE rows of the security descriptor of a file, given in base64-encoded format.
+func writeFileACERows(table *csv.Writer, encoded string) error {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	sd, err := sddl.FromBinary(data)
+	if err != nil {
+		return err
+	}
+	return writeACERows(table, sd)
+}
+
+// parseParts returns the SECURITY_INFORMATION flags selecting the parts of a security descriptor
+// named by the letters of parts: 'o' (owner), 'g' (group), 'd' (DACL) and 's' (SACL), in any case and order.
+func parseParts(parts string) (uint32, error) {
+	if parts == "" {
+		return 0, errors.New("no part selected")
+	}
+
+	var secInfo uint32
+	for _, c := range strings.ToLower(parts) {
+		switch c {
+		case 'o':
+			secInfo |= ownerSecurityInformation
+		case 'g':
+			secInfo |= groupSecurityInformation
+		case 'd':
+			secInfo |= daclSecurityInformation
+		case 's':
+			secInfo |= saclSecurityInformation
+		default:
+			return 0, fmt.Errorf("unknown part %q in %q (must be 'o', 'g', 'd' or 's')", c, parts)
+		}
+	}
+	return secInfo, nil
+}
+
+// securityInformation returns the SECURITY_INFORMATION flags selecting the parts present in the
+// security descriptor, so that applying it leaves the other parts of the file's descriptor untouched.
+func securityInformation(sd *sddl.SecurityDescriptor) uint32 {
+	var secInfo uint32
+	if sd.Owner() != nil {
+		secInfo |= ownerSecurityInformation
+	}
+	if sd.Group() != nil {
+		secInfo |= groupSecurityInformation
+	}
+	if sd.DACL() != nil || sd.HasNullDACL() {
+		secInfo |= daclSecurityInformation
+	}
+	if sd.SACL() != nil {
+		secInfo |= saclSecurityInformation
+	}
+	return secInfo
+}
+
+// verifyFile checks the library against Windows on the security descriptor of a file, restricted to the
+// parts selected by secInfo. It decodes the binary descriptor returned by Windows, and returns an error
+// describing the discrepancies with the SDDL string Windows writes for it:
+//   - the library rejects the Windows string
+//   - the library parses the Windows string into a different descriptor
+//   - the library writes the descriptor as a different string
+func verifyFile(filename string, secInfo uint32) error {
+	encoded, err := GetFileSecurityBase64Info(filename, secInfo)
+	if err != nil {
+		return fmt.Errorf("error reading security descriptor: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("error decoding security descriptor: %w", err)
+	}
+	native, err := GetFileSDStringInfo(filename, secInfo)
+	if err != nil {
+		return fmt.Errorf("error reading security descriptor string: %w", err)
+	}
+
+	sd, err := sddl.FromBinary(data)
+	if err != nil {
+		return fmt.Errorf("error parsing security descriptor: %w", err)
+	}
+
+	var discrepancies []string
+	parsed, err := sddl.FromString(native)
+	switch {
+	case err != nil:
+		discrepancies = append(discrepancies, fmt.Sprintf("Windows string %q rejected: %v", native, err))
+	case !parsed.Equal(sd):
+		discrepancies = append(discrepancies, fmt.Sprintf("Windows string %q parsed as %q", native, parsed.String()))
+	}
+	if s := sd.String(); s != native {
+		discrepancies = append(discrepancies, fmt.Sprintf("written as %q, Windows writes %q", s, native))
+	}
+
+	if len(discrepancies) > 0 {
+		return errors.New(strings.Join(discrepancies, "; "))
+	}
 	return nil
 }
@@ -3,15 +3,49 @@
 package main
 
 import (
+	"encoding/base64"
 	"errors"
 )
 
+// allSecurityInformation selects all the parts of a security descriptor
+const allSecurityInformation = ownerSecurityInformation | groupSecurityInformation | daclSecurityInformation | saclSecurityInformation
+
 // GetFileSecurityBase64 retrieves a file's security descriptor in base64-encoded format.
+// On Linux, it is converted from the NFSv4 ACL of the file.
 func GetFileSecurityBase64(filename string) (string, error) {
-	return "", errors.New("not implemented on this platform")
+	return GetFileSecurityBase64Info(filename, allSecurityInformation)
 }
 
 // GetFileSDString retrieves a file's security descriptor as a SDDL string.
+// On Linux, it is converted from the NFSv4 ACL of the file.
 func GetFileSDString(filename string) (string, error) {
-	return "", errors.New("not implemented on this platform")
+	return GetFileSDStringInfo(filename, allSecurityInformation)
+}
+
+// GetFileSecurityBase64Info retrieves the selected parts of a file's security descriptor in base64-encoded format.
+func GetFileSecurityBase64Info(filename string, secInfo uint32) (string, error) {
+	sd, err := getFileSD(filename, secInfo)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sd.Binary()), nil
+}
+
+// GetFileSDStringInfo retrieves the selected parts of a file's security descriptor as a SDDL string.
+func GetFileSDStringInfo(filename string, secInfo uint32) (string, error) {
+	sd, err := getFileSD(filename, secInfo)
+	if err != nil {
+		return "", err
+	}
+	return sd.String(), nil
+}
+
+// SetFileSDString sets a file's security descriptor from a SDDL string.
+func SetFileSDString(filename, sddl string, secInfo uint32) error {
+	return errors.New("not implemented on this platform")
+}
+
+// SetFileSDBinary sets a file's security descriptor from its binary self-relative form.
+func SetFileSDBinary(filename string, sd []byte, secInfo uint32) error {
+	return errors.New("not implemented on this platform")
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runProcessInput runs processInput with the given standard input, and returns what it wrote to the standard output.
+// It replaces os.Stdin and os.Stdout, so the tests calling it can't run in parallel.
+func runProcessInput(t *testing.T, cfg config, input string) (string, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	stdin, err := os.Create(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	if _, err := stdin.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+
+	savedStdin, savedStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdin, stdout
+	defer func() { os.Stdin, os.Stdout = savedStdin, savedStdout }()
+
+	processErr := processInput(cfg)
+
+	output, err := os.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(output), processErr
+}
+
+func TestProcessInput_Table(t *testing.T) {
+	const descriptor = "O:SYG:BAD:P(A;OICI;FA;;;SY)(D;;0x800000;;;S-1-5-21-1-2-3-1001)(A;;RPWP;;;S-1-5-21-1-2-3-500)S:(AU;SAFA;FA;;;WD)"
+
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   []string
+	}{
+		{
+			name:   "CSV",
+			format: "csv",
+			input:  descriptor + "\n",
+			want: []string{
+				"component,type,flags,mask,mask_mnemonic,trustee_sid,trustee_alias",
+				"DACL,A,OICI,0x001F01FF,FA,S-1-5-18,SY",
+				"DACL,D,,0x00800000,0x00800000,S-1-5-21-1-2-3-1001,",
+				"DACL,A,,0x00000030,RPWP,S-1-5-21-1-2-3-500,LA",
+				"SACL,AU,SAFA,0x001F01FF,FA,S-1-1-0,WD",
+			},
+		},
+		{
+			name:   "TSV",
+			format: "tsv",
+			input:  descriptor + "\n",
+			want: []string{
+				"component\ttype\tflags\tmask\tmask_mnemonic\ttrustee_sid\ttrustee_alias",
+				"DACL\tA\tOICI\t0x001F01FF\tFA\tS-1-5-18\tSY",
+				"DACL\tD\t\t0x00800000\t0x00800000\tS-1-5-21-1-2-3-1001\t",
+				"DACL\tA\t\t0x00000030\tRPWP\tS-1-5-21-1-2-3-500\tLA",
+				"SACL\tAU\tSAFA\t0x001F01FF\tFA\tS-1-1-0\tWD",
+			},
+		},
+		{
+			name:   "Several descriptors",
+			format: "csv",
+			input:  "D:(A;;FA;;;SY)\nO:SY\n\nD:(A;;FR;;;BU)\n",
+			want: []string{
+				"component,type,flags,mask,mask_mnemonic,trustee_sid,trustee_alias",
+				"DACL,A,,0x001F01FF,FA,S-1-5-18,SY",
+				"DACL,A,,0x00120089,FR,S-1-5-32-545,BU",
+			},
+		},
+		{
+			name:   "No input",
+			format: "csv",
+			want: []string{
+				"component,type,flags,mask,mask_mnemonic,trustee_sid,trustee_alias",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runProcessInput(t, config{inputFormat: "string", outputFormat: tt.format}, tt.input)
+			if err != nil {
+				t.Fatalf("processInput() unexpected error = %v", err)
+			}
+			want := strings.Join(tt.want, "\n") + "\n"
+			if output != want {
+				t.Errorf("processInput() output =\n%s\nwant\n%s", output, want)
+			}
+		})
+	}
+}
+
+func TestParseParts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		parts   string
+		want    uint32
+		wantErr bool
+	}{
+		{name: "Owner", parts: "o", want: ownerSecurityInformation},
+		{name: "All parts", parts: "ogds", want: ownerSecurityInformation | groupSecurityInformation | daclSecurityInformation | saclSecurityInformation},
+		{name: "Any order", parts: "sdgo", want: ownerSecurityInformation | groupSecurityInformation | daclSecurityInformation | saclSecurityInformation},
+		{name: "Upper case", parts: "DS", want: daclSecurityInformation | saclSecurityInformation},
+		{name: "Mixed case", parts: "oG", want: ownerSecurityInformation | groupSecurityInformation},
+		{name: "Duplicates", parts: "ddD", want: daclSecurityInformation},
+		{name: "Empty", parts: "", wantErr: true},
+		{name: "Invalid letter", parts: "ox", wantErr: true},
+		{name: "Separator", parts: "o,d", wantErr: true},
+		{name: "Whitespace", parts: " d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseParts(tt.parts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseParts(%q) error = %v, wantErr %v", tt.parts, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseParts(%q) = %#x, want %#x", tt.parts, got, tt.want)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ var (
 	openProcessToken                                     = advapi32.NewProc("OpenProcessToken")
 	lookupPrivilegeValueW                                = advapi32.NewProc("LookupPrivilegeValueW")
 	adjustTokenPrivileges                                = advapi32.NewProc("AdjustTokenPrivileges")
+	setFileSecurityW                                     = advapi32.NewProc("SetFileSecurityW")
 )
 
 const (
@@ -33,6 +34,7 @@ const (
 	SACL_SECURITY_INFORMATION  = 0x00000008
 
 	SE_SECURITY_NAME        = "SeSecurityPrivilege"
+	SE_RESTORE_NAME         = "SeRestorePrivilege"
 	TOKEN_ADJUST_PRIVILEGES = 0x0020
 	TOKEN_QUERY             = 0x0008
 
@@ -42,6 +44,9 @@ const (
 
 	// Security descriptor control flags
 	SE_SELF_RELATIVE = 0x8000
+
+	// SDDL revision expected by ConvertStringSecurityDescriptorToSecurityDescriptorW
+	SDDL_REVISION_1 = 1
 )
 
 type LUID struct {
@@ -60,6 +65,17 @@ type TOKEN_PRIVILEGES struct {
 }
 
 func enableSecurityPrivilege() error {
+	return enablePrivilege(SE_SECURITY_NAME)
+}
+
+// enableRestorePrivilege enables SeRestorePrivilege, which allows setting any owner on a file
+// and writing security descriptors regardless of the file's DACL.
+func enableRestorePrivilege() error {
+	return enablePrivilege(SE_RESTORE_NAME)
+}
+
+// enablePrivilege enables the given privilege in the token of the current process.
+func enablePrivilege(name string) error {
 
 	var token windows.Token
 	currentProcess := windows.CurrentProcess()
@@ -77,7 +93,7 @@ func enableSecurityPrivilege() error {
 
 	// Lookup the privilege value
 	var luid LUID
-	privName, err := syscall.UTF16PtrFromString(SE_SECURITY_NAME)
+	privName, err := syscall.UTF16PtrFromString(name)
 	if err != nil {
 		return fmt.Errorf("UTF16PtrFromString failed: %v", err)
 	}
@@ -113,7 +129,10 @@ func enableSecurityPrivilege() error {
 	return nil
 }
 
-func getSecurityDescriptorPointerAndInfo(filename string) (uintptr, int, error) {
+// getSecurityDescriptorPointerAndInfo reads the parts of a file's security descriptor selected by secInfo.
+// If the SACL is requested but can't be read, it falls back to the other parts.
+// It returns the security descriptor and the parts it actually holds.
+func getSecurityDescriptorPointerAndInfo(filename string, secInfo uint32) (uintptr, uint32, error) {
 
 	// Open the file to get a handle
 	pathPtr, err := syscall.UTF16PtrFromString(filename)
@@ -132,9 +151,15 @@ func getSecurityDescriptorPointerAndInfo(filename string) (uintptr, int, error)
 		fileFlags = syscall.FILE_FLAG_BACKUP_SEMANTICS
 	}
 
+	// Reading the SACL requires ACCESS_SYSTEM_SECURITY, and therefore SeSecurityPrivilege
+	var access uint32 = READ_CONTROL
+	if secInfo&SACL_SECURITY_INFORMATION != 0 {
+		access |= ACCESS_SYSTEM_SECURITY
+	}
+
 	handle, err := syscall.CreateFile(
 		pathPtr,
-		READ_CONTROL|ACCESS_SYSTEM_SECURITY,
+		access,
 		syscall.FILE_SHARE_READ,
 		nil,
 		syscall.OPEN_EXISTING,
@@ -148,7 +173,6 @@ func getSecurityDescriptorPointerAndInfo(filename string) (uintptr, int, error)
 
 	// Get the security descriptor
 	var pSD, pOwner, pGroup, pDacl, pSacl uintptr
-	secInfo := OWNER_SECURITY_INFORMATION | GROUP_SECURITY_INFORMATION | DACL_SECURITY_INFORMATION | SACL_SECURITY_INFORMATION
 
 	ret, _, err := getSecurityInfo.Call(
 		uintptr(handle),
@@ -162,9 +186,9 @@ func getSecurityDescriptorPointerAndInfo(filename string) (uintptr, int, error)
 	)
 
 	// If failed, try without SACL
-	if ret != 0 {
+	if ret != 0 && secInfo&SACL_SECURITY_INFORMATION != 0 && secInfo != SACL_SECURITY_INFORMATION {
 		fmt.Fprintf(os.Stderr, "Warning: Could not get full security info, trying without SACL...\n")
-		secInfo = OWNER_SECURITY_INFORMATION | GROUP_SECURITY_INFORMATION | DACL_SECURITY_INFORMATION
+		secInfo &^= SACL_SECURITY_INFORMATION
 		ret, _, err = getSecurityInfo.Call(
 			uintptr(handle),
 			uintptr(1), // SE_FILE_OBJECT
@@ -175,25 +199,43 @@ func getSecurityDescriptorPointerAndInfo(filename string) (uintptr, int, error)
 			0,
 			uintptr(unsafe.Pointer(&pSD)),
 		)
-		if ret != 0 {
-			return 0, 0, fmt.Errorf("GetSecurityInfo failed: %w", err)
-		}
+	}
+	if ret != 0 {
+		return 0, 0, fmt.Errorf("GetSecurityInfo failed: %w", err)
 	}
 
 	return pSD, secInfo, nil
 }
 
-// GetFileSDBytes retrieves a file's security descriptor in binary form.
-// It uses direct Windows API calls to get the raw SD bytes.
-func GetFileSDBytes(filename string) ([]byte, error) {
-	// Try to enable security privilege
-	err := enableSecurityPrivilege()
-	if err != nil {
+// allSecurityInformation selects all the parts of a security descriptor
+const allSecurityInformation = OWNER_SECURITY_INFORMATION | GROUP_SECURITY_INFORMATION | DACL_SECURITY_INFORMATION | SACL_SECURITY_INFORMATION
+
+// enableGetPrivileges enables the privilege needed to read the SACL if secInfo requests it,
+// warning if it can't be enabled.
+func enableGetPrivileges(secInfo uint32) {
+	if secInfo&SACL_SECURITY_INFORMATION == 0 {
+		return
+	}
+	if err := enableSecurityPrivilege(); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Could not enable security privilege: %v\n", err)
 		fmt.Fprintf(os.Stderr, "Will try to continue with reduced privileges...\n")
 	}
+}
 
-	pSD, _, err := getSecurityDescriptorPointerAndInfo(filename)
+// GetFileSDBytes retrieves a file's security descriptor in binary form.
+// It uses direct Windows API calls to get the raw SD bytes.
+func GetFileSDBytes(filename string) ([]byte, error) {
+	return GetFileSDBytesInfo(filename, allSecurityInformation)
+}
+
+// GetFileSDBytesInfo is like GetFileSDBytes, but only retrieves the parts of the security descriptor
+// selected by secInfo (a combination of OWNER_SECURITY_INFORMATION, GROUP_SECURITY_INFORMATION,
+// DACL_SECURITY_INFORMATION and SACL_SECURITY_INFORMATION). Without SACL_SECURITY_INFORMATION,
+// SeSecurityPrivilege is not needed.
+func GetFileSDBytesInfo(filename string, secInfo uint32) ([]byte, error) {
+	enableGetPrivileges(secInfo)
+
+	pSD, _, err := getSecurityDescriptorPointerAndInfo(filename, secInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -259,15 +301,15 @@ func GetFileSDBytes(filename string) ([]byte, error) {
 // It tries to use the ConvertSecurityDescriptorToStringSecurityDescriptor API
 // first for accuracy, but falls back to our SDDL package if that fails.
 func GetFileSDString(filename string) (string, error) {
+	return GetFileSDStringInfo(filename, allSecurityInformation)
+}
 
-	// Try to enable security privilege
-	err := enableSecurityPrivilege()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Could not enable security privilege: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Will try to continue with reduced privileges...\n")
-	}
+// GetFileSDStringInfo is like GetFileSDString, but only retrieves the parts of the security descriptor
+// selected by secInfo, see GetFileSDBytesInfo.
+func GetFileSDStringInfo(filename string, secInfo uint32) (string, error) {
+	enableGetPrivileges(secInfo)
 
-	pSD, secInfo, err := getSecurityDescriptorPointerAndInfo(filename)
+	pSD, secInfo, err := getSecurityDescriptorPointerAndInfo(filename, secInfo)
 	if err != nil {
 		return "", err
 	}
@@ -294,9 +336,88 @@ func GetFileSDString(filename string) (string, error) {
 
 // GetFileSecurityBase64 retrieves a file's security descriptor in base64-encoded format.
 func GetFileSecurityBase64(filename string) (string, error) {
-	sd, err := GetFileSDBytes(filename)
+	return GetFileSecurityBase64Info(filename, allSecurityInformation)
+}
+
+// GetFileSecurityBase64Info is like GetFileSecurityBase64, but only retrieves the parts of the security
+// descriptor selected by secInfo, see GetFileSDBytesInfo.
+func GetFileSecurityBase64Info(filename string, secInfo uint32) (string, error) {
+	sd, err := GetFileSDBytesInfo(filename, secInfo)
 	if err != nil {
 		return "", err
 	}
 	return base64.StdEncoding.EncodeToString(sd), nil
 }
+
+// enableSetPrivileges enables the privileges needed to write any part of a file's security descriptor,
+// warning if they can't be enabled: the call may still succeed depending on the file's DACL.
+func enableSetPrivileges(secInfo uint32) {
+	if secInfo&SACL_SECURITY_INFORMATION != 0 {
+		if err := enableSecurityPrivilege(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not enable security privilege: %v\n", err)
+		}
+	}
+	if err := enableRestorePrivilege(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not enable restore privilege: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Will try to continue with reduced privileges...\n")
+	}
+}
+
+// setFileSecurity sets the parts of a file's security descriptor selected by secInfo from the
+// security descriptor pointed to by pSD.
+func setFileSecurity(filename string, secInfo uint32, pSD uintptr) error {
+	pathPtr, err := syscall.UTF16PtrFromString(filename)
+	if err != nil {
+		return fmt.Errorf("Error converting filename: %w", err)
+	}
+
+	ret, _, err := setFileSecurityW.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(secInfo),
+		pSD,
+	)
+	if ret == 0 {
+		return fmt.Errorf("SetFileSecurity failed: %v", err)
+	}
+
+	return nil
+}
+
+// SetFileSDString sets a file's security descriptor from a SDDL string.
+// Only the parts selected by secInfo (a combination of OWNER_SECURITY_INFORMATION,
+// GROUP_SECURITY_INFORMATION, DACL_SECURITY_INFORMATION and SACL_SECURITY_INFORMATION) are set,
+// so callers can set e.g. just the DACL.
+func SetFileSDString(filename, sddl string, secInfo uint32) error {
+	strPtr, err := syscall.UTF16PtrFromString(sddl)
+	if err != nil {
+		return fmt.Errorf("Error converting SDDL string: %w", err)
+	}
+
+	var pSD uintptr
+	ret, _, err := convertStringSecurityDescriptorToSecurityDescriptorW.Call(
+		uintptr(unsafe.Pointer(strPtr)),
+		uintptr(SDDL_REVISION_1),
+		uintptr(unsafe.Pointer(&pSD)),
+		0,
+	)
+	if ret == 0 {
+		return fmt.Errorf("ConvertStringSecurityDescriptorToSecurityDescriptor failed: %v", err)
+	}
+	defer windows.LocalFree(windows.Handle(pSD))
+
+	enableSetPrivileges(secInfo)
+
+	return setFileSecurity(filename, secInfo, pSD)
+}
+
+// SetFileSDBinary sets a file's security descriptor from its binary self-relative form.
+// Only the parts selected by secInfo are set, see SetFileSDString.
+func SetFileSDBinary(filename string, sd []byte, secInfo uint32) error {
+	if len(sd) < 20 {
+		return fmt.Errorf("invalid security descriptor: it must be 20 bytes length at minimum")
+	}
+
+	enableSetPrivileges(secInfo)
+
+	return setFileSecurity(filename, secInfo, uintptr(unsafe.Pointer(&sd[0])))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudsoda/sddl"
+	"golang.org/x/sys/unix"
+)
+
+// nfs4ACLAttribute is the extended attribute holding the NFSv4 ACL of files on NFSv4-backed filesystems
+const nfs4ACLAttribute = "system.nfs4_acl"
+
+// Identifier authority and sub-authorities of the SIDs Samba uses for Unix users (S-1-22-1-<uid>)
+// and groups (S-1-22-2-<gid>) that have no Windows counterpart
+const (
+	unixIDAuthority = 22
+	unixUserRID     = 1
+	unixGroupRID    = 2
+)
+
+// getFileSD returns the selected parts of the security descriptor converted from the NFSv4 ACL of the file.
+// The owner and the group are the Unix owner and group of the file, as S-1-22-1-<uid> and S-1-22-2-<gid>.
+func getFileSD(filename string, secInfo uint32) (*sddl.SecurityDescriptor, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(filename, &st); err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	owner := sddl.NewSID(unixIDAuthority, unixUserRID, st.Uid)
+	group := sddl.NewSID(unixIDAuthority, unixGroupRID, st.Gid)
+
+	data, err := getxattr(filename, nfs4ACLAttribute)
+	if err != nil {
+		if errors.Is(err, unix.ENODATA) || errors.Is(err, unix.EOPNOTSUPP) {
+			return nil, fmt.Errorf("no NFSv4 ACL: %w", err)
+		}
+		return nil, fmt.Errorf("reading %s: %w", nfs4ACLAttribute, err)
+	}
+
+	resolve := func(principal string, isGroup bool) (*sddl.SID, error) {
+		switch principal {
+		case "OWNER@":
+			return owner, nil
+		case "GROUP@":
+			return group, nil
+		}
+		// Without ID mapping, principals are numeric user and group IDs
+		if id, err := strconv.ParseUint(principal, 10, 32); err == nil {
+			if isGroup {
+				return sddl.NewSID(unixIDAuthority, unixGroupRID, uint32(id)), nil
+			}
+			return sddl.NewSID(unixIDAuthority, unixUserRID, uint32(id)), nil
+		}
+		return sddl.DefaultNFS4PrincipalResolver(principal, isGroup)
+	}
+	acl, err := sddl.FromNFS4ACL(data, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assemble the selected parts, the owner and group are not part of the ACL
+	var b strings.Builder
+	if secInfo&ownerSecurityInformation != 0 {
+		b.WriteString("O:" + owner.String())
+	}
+	if secInfo&groupSecurityInformation != 0 {
+		b.WriteString("G:" + group.String())
+	}
+	if secInfo&daclSecurityInformation != 0 {
+		b.WriteString("D:" + acl.DACL().String())
+	}
+	if secInfo&saclSecurityInformation != 0 && acl.SACL() != nil {
+		b.WriteString("S:" + acl.SACL().String())
+	}
+
+	return sddl.FromString(b.String())
+}
+
+// getxattr returns the value of the extended attribute of the file.
+func getxattr(filename, attr string) ([]byte, error) {
+	for {
+		size, err := unix.Getxattr(filename, attr, nil)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		n, err := unix.Getxattr(filename, attr, buf)
+		if errors.Is(err, unix.ERANGE) {
+			// The attribute grew in between, try again
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
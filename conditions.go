@@ -0,0 +1,606 @@
+package sddl
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Conditional expressions are the conditions of callback ACEs, e.g. (@User.Title == "PM") in
+// "(XA;;FX;;;WD;(@User.Title == "PM"))". In binary form, they are the application data of the ACE: the
+// "artx" signature followed by the tokens of the expression in postfix notation, as defined in [MS-DTYP]
+// section 2.4.4.17 Conditional ACEs. Their SDDL syntax is defined in [MS-DTYP] section 2.5.1.1.
+
+// conditionSignature starts the application data of callback ACEs holding a conditional expression
+const conditionSignature = "artx"
+
+// Tokens of binary conditional expressions
+const (
+	// condPadding - Padding after the last token
+	condPadding = 0x00
+	// condInt8, condInt16, condInt32, condInt64 - Integer literals, all encoded on 8 bytes
+	condInt8  = 0x01
+	condInt16 = 0x02
+	condInt32 = 0x03
+	condInt64 = 0x04
+	// condUnicodeString - UTF-16 string literal
+	condUnicodeString = 0x10
+	// condOctetString - Octet string literal
+	condOctetString = 0x18
+	// condComposite - List of literals
+	condComposite = 0x50
+	// condSID - SID literal
+	condSID = 0x51
+
+	// condExists, condNotExists - Tests the existence of an attribute
+	condExists    = 0x87
+	condNotExists = 0x8D
+	// condNot - Logical negation
+	condNot = 0xA2
+	// condAnd, condOr - Logical operators
+	condAnd = 0xA0
+	condOr  = 0xA1
+
+	// condLocalAttribute, condUserAttribute, condResourceAttribute, condDeviceAttribute - Attribute names
+	condLocalAttribute    = 0xF8
+	condUserAttribute     = 0xF9
+	condResourceAttribute = 0xFA
+	condDeviceAttribute   = 0xFB
+)
+
+// Sign and base of integer literals
+const (
+	condSignPlus  = 0x01
+	condSignMinus = 0x02
+	condSignNone  = 0x03
+
+	condBaseOctal   = 0x01
+	condBaseDecimal = 0x02
+	condBaseHex     = 0x03
+)
+
+// conditionOperator is an operator of conditional expressions, with its token and SDDL spelling
+type conditionOperator struct {
+	token byte
+	name  string
+}
+
+// conditionRelationalOperators are the binary operators comparing an attribute to a value or another
+// attribute. The symbols of two characters come first so that they are matched before their prefixes.
+var conditionRelationalOperators = []conditionOperator{
+	{0x80, "=="}, {0x81, "!="}, {0x83, "<="}, {0x85, ">="}, {0x82, "<"}, {0x84, ">"},
+	{0x86, "Contains"}, {0x88, "Any_of"}, {0x8E, "Not_Contains"}, {0x8F, "Not_Any_of"},
+}
+
+// conditionUnaryOperators are the operators written before their operand: the existence tests, whose
+// operand is an attribute, and the membership tests, whose operand is a SID or a list of SIDs.
+var conditionUnaryOperators = []conditionOperator{
+	{condExists, "Exists"}, {condNotExists, "Not_Exists"},
+	{0x89, "Member_of"}, {0x8A, "Device_Member_of"}, {0x8B, "Member_of_Any"}, {0x8C, "Device_Member_of_Any"},
+	{0x90, "Not_Member_of"}, {0x91, "Not_Device_Member_of"}, {0x92, "Not_Member_of_Any"}, {0x93, "Not_Device_Member_of_Any"},
+}
+
+// conditionAttributePrefixes maps the tokens of attribute names to the prefix of the name in SDDL.
+// Local attributes have no prefix. Prefixes are matched case-insensitively when parsing.
+var conditionAttributePrefixes = map[byte]string{
+	condUserAttribute:     "@USER.",
+	condResourceAttribute: "@RESOURCE.",
+	condDeviceAttribute:   "@DEVICE.",
+}
+
+// encodeCondition encodes the SDDL conditional expression s, such as `(@User.Title == "PM")`, into the
+// binary form of the application data of callback ACEs, padded to a multiple of 4 bytes.
+func encodeCondition(s string) ([]byte, error) {
+	p := &conditionParser{s: s, out: []byte(conditionSignature)}
+	if err := p.parseOr(); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(s) {
+		return nil, p.errorf("unexpected %q", s[p.pos:])
+	}
+	for len(p.out)%4 != 0 {
+		p.out = append(p.out, condPadding)
+	}
+	return p.out, nil
+}
+
+// conditionParser parses SDDL conditional expressions, writing their tokens in postfix notation to out
+// as it goes, which is the order of the recursive descent.
+type conditionParser struct {
+	s   string
+	pos int
+	out []byte
+}
+
+func (p *conditionParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *conditionParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// consume skips the symbol if the input continues with it.
+func (p *conditionParser) consume(symbol string) bool {
+	if strings.HasPrefix(p.s[p.pos:], symbol) {
+		p.pos += len(symbol)
+		return true
+	}
+	return false
+}
+
+// consumeOperator skips the first operator of ops the input continues with, and returns its token.
+// Operators spelled with letters are matched case-insensitively, and only as whole words.
+func (p *conditionParser) consumeOperator(ops []conditionOperator) (byte, bool) {
+	for _, op := range ops {
+		end := p.pos + len(op.name)
+		if end > len(p.s) || !strings.EqualFold(p.s[p.pos:end], op.name) {
+			continue
+		}
+		if isAttributeNameChar(op.name[0]) && end < len(p.s) && isAttributeNameChar(p.s[end]) {
+			continue
+		}
+		p.pos = end
+		return op.token, true
+	}
+	return 0, false
+}
+
+// parseOr parses expressions joined with "||", the operator with the lowest precedence.
+func (p *conditionParser) parseOr() error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("||") {
+			return nil
+		}
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+		p.out = append(p.out, condOr)
+	}
+}
+
+// parseAnd parses expressions joined with "&&".
+func (p *conditionParser) parseAnd() error {
+	if err := p.parseTerm(); err != nil {
+		return err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("&&") {
+			return nil
+		}
+		if err := p.parseTerm(); err != nil {
+			return err
+		}
+		p.out = append(p.out, condAnd)
+	}
+}
+
+// parseTerm parses a parenthesized expression, a negation, an existence or membership test, a comparison
+// or an attribute alone, which tests its truth value.
+func (p *conditionParser) parseTerm() error {
+	p.skipSpace()
+	if p.consume("(") {
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return p.errorf("missing closing parenthesis")
+		}
+		return nil
+	}
+	if !strings.HasPrefix(p.s[p.pos:], "!=") && p.consume("!") {
+		if err := p.parseTerm(); err != nil {
+			return err
+		}
+		p.out = append(p.out, condNot)
+		return nil
+	}
+
+	if op, ok := p.consumeOperator(conditionUnaryOperators); ok {
+		p.skipSpace()
+		var err error
+		if op == condExists || op == condNotExists {
+			err = p.parseAttribute()
+		} else {
+			err = p.parseSIDs()
+		}
+		if err != nil {
+			return err
+		}
+		p.out = append(p.out, op)
+		return nil
+	}
+
+	if err := p.parseAttribute(); err != nil {
+		return err
+	}
+	p.skipSpace()
+	op, ok := p.consumeOperator(conditionRelationalOperators)
+	if !ok {
+		return nil
+	}
+	p.skipSpace()
+	var err error
+	if strings.HasPrefix(p.s[p.pos:], "@") {
+		err = p.parseAttribute()
+	} else {
+		err = p.parseValue()
+	}
+	if err != nil {
+		return err
+	}
+	p.out = append(p.out, op)
+	return nil
+}
+
+// parseAttribute parses an attribute name, either prefixed with "@User.", "@Resource." or "@Device.",
+// or a local attribute without prefix.
+func (p *conditionParser) parseAttribute() error {
+	token := byte(condLocalAttribute)
+	if strings.HasPrefix(p.s[p.pos:], "@") {
+		token = 0
+		for t, prefix := range conditionAttributePrefixes {
+			if end := p.pos + len(prefix); end <= len(p.s) && strings.EqualFold(p.s[p.pos:end], prefix) {
+				token = t
+				p.pos = end
+				break
+			}
+		}
+		if token == 0 {
+			return p.errorf("invalid attribute prefix, must be @User., @Resource. or @Device.")
+		}
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && isAttributeNameChar(p.s[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return p.errorf("missing attribute name")
+	}
+	p.out = append(p.out, token)
+	p.out = appendConditionUTF16(p.out, p.s[start:p.pos])
+	return nil
+}
+
+// isAttributeNameChar reports whether c can be part of an attribute name: letters, digits, ":", ".",
+// "/", "_" and "%", which escapes the other characters.
+func isAttributeNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == ':' || c == '.' || c == '/' || c == '_' || c == '%'
+}
+
+// parseSIDs parses the operand of a membership test: a SID literal or a list of SID literals.
+func (p *conditionParser) parseSIDs() error {
+	if !strings.HasPrefix(p.s[p.pos:], "{") && !hasPrefixFold(p.s[p.pos:], "SID(") {
+		return p.errorf("expected a SID or a list of SIDs")
+	}
+	return p.parseValue()
+}
+
+// parseValue parses a literal or a list of literals between braces.
+func (p *conditionParser) parseValue() error {
+	switch {
+	case p.consume("{"):
+		// The length of the composite is known once its elements are written
+		p.out = append(p.out, condComposite, 0, 0, 0, 0)
+		start := len(p.out)
+		for {
+			p.skipSpace()
+			if err := p.parseValue(); err != nil {
+				return err
+			}
+			p.skipSpace()
+			if p.consume("}") {
+				break
+			}
+			if !p.consume(",") {
+				return p.errorf("expected \",\" or \"}\" in list")
+			}
+		}
+		binary.LittleEndian.PutUint32(p.out[start-4:], uint32(len(p.out)-start))
+		return nil
+
+	case p.consume(`"`):
+		end := strings.IndexByte(p.s[p.pos:], '"')
+		if end == -1 {
+			return p.errorf("unterminated string")
+		}
+		p.out = append(p.out, condUnicodeString)
+		p.out = appendConditionUTF16(p.out, p.s[p.pos:p.pos+end])
+		p.pos += end + 1
+		return nil
+
+	case p.consume("#"):
+		start := p.pos
+		for p.pos < len(p.s) && isHexDigit(p.s[p.pos]) {
+			p.pos++
+		}
+		octets, err := hex.DecodeString(p.s[start:p.pos])
+		if err != nil {
+			return p.errorf("invalid octet string %q", p.s[start:p.pos])
+		}
+		p.out = append(p.out, condOctetString)
+		p.out = binary.LittleEndian.AppendUint32(p.out, uint32(len(octets)))
+		p.out = append(p.out, octets...)
+		return nil
+
+	case hasPrefixFold(p.s[p.pos:], "SID("):
+		p.pos += len("SID(")
+		end := strings.IndexByte(p.s[p.pos:], ')')
+		if end == -1 {
+			return p.errorf("unterminated SID literal")
+		}
+		r, err := parseSIDString(strings.TrimSpace(p.s[p.pos : p.pos+end]))
+		if err != nil {
+			return p.errorf("invalid SID literal: %v", err)
+		}
+		sid, err := r.toSID(nil)
+		if err != nil {
+			return p.errorf("invalid SID literal: %v", err)
+		}
+		p.pos += end + 1
+		p.out = append(p.out, condSID)
+		p.out = binary.LittleEndian.AppendUint32(p.out, uint32(sid.BinarySize()))
+		p.out = sid.appendBinary(p.out)
+		return nil
+	}
+
+	return p.parseInteger()
+}
+
+// parseInteger parses a signed decimal, hexadecimal ("0x" prefix) or octal ("0" prefix) integer literal,
+// keeping its sign and base so that it is written back as it was.
+func (p *conditionParser) parseInteger() error {
+	sign := byte(condSignNone)
+	switch {
+	case p.consume("+"):
+		sign = condSignPlus
+	case p.consume("-"):
+		sign = condSignMinus
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && isAttributeNameChar(p.s[p.pos]) {
+		p.pos++
+	}
+	digits := p.s[start:p.pos]
+	base, baseToken := 10, byte(condBaseDecimal)
+	switch {
+	case len(digits) > 2 && (digits[:2] == "0x" || digits[:2] == "0X"):
+		base, baseToken, digits = 16, condBaseHex, digits[2:]
+	case len(digits) > 1 && digits[0] == '0':
+		base, baseToken, digits = 8, condBaseOctal, digits[1:]
+	}
+	u, err := strconv.ParseUint(digits, base, 64)
+	if err != nil || u > math.MaxInt64+1 || u > math.MaxInt64 && sign != condSignMinus {
+		p.pos = start
+		return p.errorf("invalid value %q", p.s[start:])
+	}
+	value := int64(u)
+	if sign == condSignMinus {
+		value = -value
+	}
+
+	p.out = append(p.out, condInt64)
+	p.out = binary.LittleEndian.AppendUint64(p.out, uint64(value))
+	p.out = append(p.out, sign, baseToken)
+	return nil
+}
+
+// appendConditionUTF16 appends the length in bytes and the UTF-16 code units of s, as found in the
+// string literals and attribute names of conditional expressions.
+func appendConditionUTF16(b []byte, s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b = binary.LittleEndian.AppendUint32(b, uint32(2*len(units)))
+	for _, u := range units {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// conditionNode is an operand of the stack used to decode conditional expressions: its SDDL form,
+// which is parenthesized if it is the result of an operator.
+type conditionNode struct {
+	s           string
+	parenthesis bool
+}
+
+// conditionString decodes the binary conditional expression data, the application data of a callback
+// ACE, into its SDDL form, e.g. `(@USER.Title == "PM")`. Every operation is parenthesized, like Windows
+// writes them, and the attribute prefixes are written in upper case.
+func conditionString(data []byte) (string, error) {
+	if !strings.HasPrefix(string(data), conditionSignature) {
+		return "", errors.New("missing artx signature")
+	}
+
+	var stack []conditionNode
+	pop := func(n int) ([]conditionNode, error) {
+		if len(stack) < n {
+			return nil, errors.New("operator without operand")
+		}
+		operands := stack[len(stack)-n:]
+		stack = stack[:len(stack)-n]
+		return operands, nil
+	}
+
+	for offset := len(conditionSignature); offset < len(data); {
+		token := data[offset]
+		if token == condPadding {
+			offset++
+			continue
+		}
+
+		if s, size, err := conditionLiteralString(data[offset:]); err != nil {
+			return "", fmt.Errorf("at offset %d: %w", offset, err)
+		} else if size > 0 {
+			stack = append(stack, conditionNode{s: s})
+			offset += size
+			continue
+		}
+
+		offset++
+		switch {
+		case token == condAnd || token == condOr:
+			operands, err := pop(2)
+			if err != nil {
+				return "", err
+			}
+			op := map[byte]string{condAnd: "&&", condOr: "||"}[token]
+			stack = append(stack, conditionNode{s: "(" + operands[0].s + " " + op + " " + operands[1].s + ")", parenthesis: true})
+		case token == condNot:
+			operands, err := pop(1)
+			if err != nil {
+				return "", err
+			}
+			stack = append(stack, conditionNode{s: "(!" + operands[0].s + ")", parenthesis: true})
+		case conditionOperatorName(conditionRelationalOperators, token) != "":
+			operands, err := pop(2)
+			if err != nil {
+				return "", err
+			}
+			op := conditionOperatorName(conditionRelationalOperators, token)
+			stack = append(stack, conditionNode{s: "(" + operands[0].s + " " + op + " " + operands[1].s + ")", parenthesis: true})
+		case conditionOperatorName(conditionUnaryOperators, token) != "":
+			operands, err := pop(1)
+			if err != nil {
+				return "", err
+			}
+			op := conditionOperatorName(conditionUnaryOperators, token)
+			stack = append(stack, conditionNode{s: "(" + op + " " + operands[0].s + ")", parenthesis: true})
+		default:
+			return "", fmt.Errorf("at offset %d: unknown token 0x%02X", offset-1, token)
+		}
+	}
+
+	if len(stack) != 1 {
+		return "", fmt.Errorf("%d expressions instead of one", len(stack))
+	}
+	if !stack[0].parenthesis {
+		return "(" + stack[0].s + ")", nil
+	}
+	return stack[0].s, nil
+}
+
+// conditionLiteralString decodes the literal or attribute name at the start of data, and returns its
+// SDDL form and its size in bytes. The size is 0 if data doesn't start with a literal or an attribute.
+func conditionLiteralString(data []byte) (string, int, error) {
+	switch token := data[0]; token {
+	case condInt8, condInt16, condInt32, condInt64:
+		if len(data) < 11 {
+			return "", 0, errors.New("truncated integer")
+		}
+		value := int64(binary.LittleEndian.Uint64(data[1:9]))
+		abs := uint64(value)
+		if value < 0 {
+			abs = -abs
+		}
+		var s string
+		switch data[10] {
+		case condBaseOctal:
+			s = "0" + strconv.FormatUint(abs, 8)
+		case condBaseHex:
+			s = "0x" + strings.ToUpper(strconv.FormatUint(abs, 16))
+		default:
+			s = strconv.FormatUint(abs, 10)
+		}
+		switch {
+		case value < 0:
+			s = "-" + s
+		case data[9] == condSignPlus:
+			s = "+" + s
+		}
+		return s, 11, nil
+
+	case condUnicodeString, condOctetString, condComposite, condSID,
+		condLocalAttribute, condUserAttribute, condResourceAttribute, condDeviceAttribute:
+		if len(data) < 5 {
+			return "", 0, errors.New("truncated token")
+		}
+		length := binary.LittleEndian.Uint32(data[1:5])
+		if uint64(length) > uint64(len(data)-5) {
+			return "", 0, fmt.Errorf("token length %d exceeds the data", length)
+		}
+		value := data[5 : 5+length]
+		size := 5 + int(length)
+
+		switch token {
+		case condUnicodeString:
+			s, err := decodeConditionUTF16(value)
+			return `"` + s + `"`, size, err
+		case condOctetString:
+			return "#" + hex.EncodeToString(value), size, nil
+		case condSID:
+			sid, err := decodeSIDBinary(value, ParseOptions{})
+			if err != nil {
+				return "", 0, err
+			}
+			if err := sid.Validate(); err != nil {
+				return "", 0, err
+			}
+			return "SID(" + sid.String() + ")", size, nil
+		case condComposite:
+			var elements []string
+			for offset := 0; offset < len(value); {
+				s, n, err := conditionLiteralString(value[offset:])
+				if err != nil {
+					return "", 0, err
+				}
+				if n == 0 {
+					return "", 0, fmt.Errorf("unexpected token 0x%02X in list", value[offset])
+				}
+				elements = append(elements, s)
+				offset += n
+			}
+			return "{" + strings.Join(elements, ", ") + "}", size, nil
+		default:
+			name, err := decodeConditionUTF16(value)
+			return conditionAttributePrefixes[token] + name, size, err
+		}
+	}
+	return "", 0, nil
+}
+
+// decodeConditionUTF16 decodes the UTF-16 code units of a string literal or attribute name.
+func decodeConditionUTF16(b []byte) (string, error) {
+	if len(b)%2 != 0 {
+		return "", errors.New("odd length for a UTF-16 string")
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// conditionOperatorName returns the SDDL spelling of the operator token among ops, or "" if it isn't one.
+func conditionOperatorName(ops []conditionOperator, token byte) string {
+	for _, op := range ops {
+		if op.token == token {
+			return op.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package sddl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestConditionRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Attribute comparison",
+			input: `(@User.Title=="PM")`,
+			want:  `(@USER.Title == "PM")`,
+		},
+		{
+			name:  "Member_of with SID literals",
+			input: `(!(Member_of {SID(BA), SID(SY)}))`,
+			want:  `(!(Member_of {SID(BA), SID(SY)}))`,
+		},
+		{
+			name:  "Logical operators",
+			input: `(@Resource.Secrecy >= 3 && Exists @Device.Managed)`,
+			want:  `((@RESOURCE.Secrecy >= 3) && (Exists @DEVICE.Managed))`,
+		},
+		{
+			name:  "Nested expressions",
+			input: `(Title == "a" && (b < +5 || Not_Exists c))`,
+			want:  `((Title == "a") && ((b < +5) || (Not_Exists c)))`,
+		},
+		{
+			name:  "Composite value",
+			input: `(@User.Department Any_of {"Sales", "HR"})`,
+			want:  `(@USER.Department Any_of {"Sales", "HR"})`,
+		},
+		{
+			name:  "Integer bases and signs",
+			input: `(x == -0x10 || y != 010)`,
+			want:  `((x == -0x10) || (y != 010))`,
+		},
+		{
+			name:  "Octet string",
+			input: `(@User.x == #0aff)`,
+			want:  `(@USER.x == #0aff)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := encodeCondition(tt.input)
+			if err != nil {
+				t.Fatalf("encodeCondition() unexpected error = %v", err)
+			}
+			if !bytes.HasPrefix(data, []byte("artx")) || len(data)%4 != 0 {
+				t.Errorf("encodeCondition() = %x, want the artx signature and a multiple of 4 bytes", data)
+			}
+
+			got, err := conditionString(data)
+			if err != nil {
+				t.Fatalf("conditionString() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("conditionString() = %s, want %s", got, tt.want)
+			}
+
+			// The written form encodes to the same bytes
+			again, err := encodeCondition(got)
+			if err != nil {
+				t.Fatalf("encodeCondition() of the written form unexpected error = %v", err)
+			}
+			if !bytes.Equal(again, data) {
+				t.Errorf("encodeCondition(%s) = %x, want %x", got, again, data)
+			}
+		})
+	}
+}
+
+func TestEncodeCondition_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Empty expression", input: "()"},
+		{name: "Missing operand", input: "(@User.Title =="},
+		{name: "Trailing input", input: `(@User.Title == "PM") x`},
+		{name: "Unterminated string", input: `(@User.Title == "PM`},
+		{name: "Invalid SID literal", input: "(Member_of {SID(XX)})"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, err := encodeCondition(tt.input); err == nil {
+				t.Errorf("encodeCondition() = %x, want error", got)
+			}
+		})
+	}
+}
+
+func TestConditionString_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "Missing signature", data: []byte{0x00, 0x00, 0x00, 0x00}},
+		{name: "Unknown token", data: []byte{'a', 'r', 't', 'x', 0x7F, 0x00, 0x00, 0x00}},
+		{name: "Truncated integer", data: []byte{'a', 'r', 't', 'x', 0x04, 0x05, 0x00, 0x00}},
+		{name: "Operator without operands", data: []byte{'a', 'r', 't', 'x', 0xA0, 0x00, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, err := conditionString(tt.data); err == nil {
+				t.Errorf("conditionString() = %s, want error", got)
+			}
+		})
+	}
+}
+
+func TestFromBinary_ConditionWithInvalidSID(t *testing.T) {
+	t.Parallel()
+
+	// An XA ACE whose conditional expression holds a SID() of revision 0xC6, which can't be written in
+	// SDDL: the condition is kept in binary form only
+	data, err := hex.DecodeString("0100278000000000000000000000000014000000020064000100000009005c00ff011f00" +
+		"01010000000000010000000061727478f9060000005400610067001004000000230031008050260000005110000000" +
+		"c6020000000000052000000020020000510c00000001010000000000051200000089a00000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := FromBinary(data)
+	if err != nil {
+		t.Fatalf("FromBinary() error = %v", err)
+	}
+	if err := sd.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	want := "D:(XA;;FA;;;WD)"
+	if got := sd.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got := sd.StringIndent(0); got == "" {
+		t.Error("StringIndent() is empty")
+	}
+	if got := sd.CanonicalString(); got != want {
+		t.Errorf("CanonicalString() = %s, want %s", got, want)
+	}
+	if got, want := sd.Hash(), mustFromString(t, want).Hash(); got == want {
+		t.Errorf("Hash() = %d, the same as without the condition", got)
+	}
+}
@@ -2,97 +2,217 @@ package sddl
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"slices"
 )
 
 // FromBinary takes a binary security descriptor in relative format (contiguous memory with offsets)
+// Inputs larger than DefaultMaxDescriptorSize are rejected with ErrDescriptorTooLarge.
+//
+// Like Windows, the owner and the group are decoded whenever their offset is not zero, whatever the
+// SE_OWNER_DEFAULTED and SE_GROUP_DEFAULTED control flags: these flags only tell how the SIDs were chosen,
+// and are kept as they are. A zero offset means that there is no owner or group. The DACL and the SACL,
+// on the other hand, are only decoded if their SE_DACL_PRESENT or SE_SACL_PRESENT flag is set: Windows
+// ignores their offset otherwise.
 func FromBinary(data []byte) (*SecurityDescriptor, error) {
+	return fromBinary(data, nil, ParseOptions{})
+}
+
+// FromBinaryWithOptions is like FromBinary but decodes the security descriptor according to opts.
+func FromBinaryWithOptions(data []byte, opts ParseOptions) (*SecurityDescriptor, error) {
+	return fromBinary(data, nil, opts)
+}
+
+// DACLFromBinary decodes only the DACL of a binary security descriptor in relative format, for callers that
+// don't need the rest: the owner, the group and the SACL are neither decoded nor checked. It returns the
+// same DACL as FromBinary(data).DACL(), which is nil if the security descriptor has no DACL or a NULL DACL,
+// including when SE_DACL_PRESENT is not set whatever the DACL offset.
+func DACLFromBinary(data []byte) (*ACL, error) {
+	if len(data) > DefaultMaxDescriptorSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes", ErrDescriptorTooLarge, len(data), DefaultMaxDescriptorSize)
+	}
+
+	sd, err := DebugParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if sd.daclOffset == 0 || sd.control&seDACLPresent == 0 {
+		return nil, nil
+	}
+	if dataLen := uint32(len(data)); sd.daclOffset >= dataLen {
+		return nil, fmt.Errorf("invalid security descriptor: DACL offset 0x%x exceeds data length 0x%x", sd.daclOffset, dataLen)
+	}
+
+	dacl, err := parseACLBinary(data[sd.daclOffset:], "D", sd.control, nil, ParseOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DACL: %w", err)
+	}
+	return dacl, nil
+}
+
+// fromBinary implements FromBinary, using buf as scratch space if it is not nil.
+func fromBinary(data []byte, buf *decodeBuffers, opts ParseOptions) (*SecurityDescriptor, error) {
+	if maxSize := opts.maxDescriptorSize(); len(data) > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes", ErrDescriptorTooLarge, len(data), maxSize)
+	}
+
+	sd, err := DebugParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
 	dataLen := uint32(len(data))
-	if dataLen < 20 {
-		return nil, fmt.Errorf("invalid security descriptor: it must be 20 bytes length at minimum")
+	control := sd.control
+	ownerOffset, groupOffset, saclOffset, daclOffset := sd.ownerOffset, sd.groupOffset, sd.saclOffset, sd.daclOffset
+
+	// The offset of an ACL whose present flag is not set is ignored
+	if control&seDACLPresent == 0 {
+		daclOffset = 0
+	}
+	if control&seSACLPresent == 0 {
+		saclOffset = 0
 	}
 
-	revision := data[0]
-	sbzl := data[1]
-	control := binary.LittleEndian.Uint16(data[2:4])
-	ownerOffset := binary.LittleEndian.Uint32(data[4:8])
-	groupOffset := binary.LittleEndian.Uint32(data[8:12])
-	saclOffset := binary.LittleEndian.Uint32(data[12:16])
-	daclOffset := binary.LittleEndian.Uint32(data[16:20])
+	// errs collects the errors of the components that failed to decode with ParseOptions.Partial,
+	// otherwise the first error is returned
+	var errs []error
 
+	// A component starts strictly before the end of the data, and may end exactly at it
 	if ownerOffset > 0 && ownerOffset >= dataLen {
-		return nil, fmt.Errorf("invalid security descriptor: Owner offset 0x%x exceeds data length 0x%x", ownerOffset, dataLen)
+		err := fmt.Errorf("invalid security descriptor: Owner offset 0x%x exceeds data length 0x%x", ownerOffset, dataLen)
+		if !opts.Partial {
+			return nil, err
+		}
+		errs = append(errs, err)
+		ownerOffset = 0
 	}
 	if groupOffset > 0 && groupOffset >= dataLen {
-		return nil, fmt.Errorf("invalid security descriptor: Group offset 0x%x exceeds data length 0x%x", groupOffset, dataLen)
+		err := fmt.Errorf("invalid security descriptor: Group offset 0x%x exceeds data length 0x%x", groupOffset, dataLen)
+		if !opts.Partial {
+			return nil, err
+		}
+		errs = append(errs, err)
+		groupOffset = 0
 	}
 	if saclOffset > 0 && saclOffset >= dataLen {
-		return nil, fmt.Errorf("invalid security descriptor: SACL offset 0x%x exceeds data length 0x%x", saclOffset, dataLen)
+		err := fmt.Errorf("invalid security descriptor: SACL offset 0x%x exceeds data length 0x%x", saclOffset, dataLen)
+		if !opts.Partial {
+			return nil, err
+		}
+		errs = append(errs, err)
+		saclOffset = 0
+		control &^= seSACLPresent
 	}
 	if daclOffset > 0 && daclOffset >= dataLen {
-		return nil, fmt.Errorf("invalid security descriptor: DACL offset 0x%x exceeds data length 0x%x", daclOffset, dataLen)
+		err := fmt.Errorf("invalid security descriptor: DACL offset 0x%x exceeds data length 0x%x", daclOffset, dataLen)
+		if !opts.Partial {
+			return nil, err
+		}
+		errs = append(errs, err)
+		daclOffset = 0
+		control &^= seDACLPresent
 	}
 
 	// Parse Owner SID if present
-	var ownerSID *sid
+	var ownerSID *SID
 	if ownerOffset > 0 {
-		sid, err := parseSIDBinary(data[ownerOffset:])
+		sid, err := buf.decodeSID(data[ownerOffset:], opts)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing owner SID: %w", err)
+			err = fmt.Errorf("error parsing owner SID: %w", err)
+			if !opts.Partial {
+				return nil, err
+			}
+			errs = append(errs, err)
 		}
 		ownerSID = sid
 	}
 
 	// Parse Group SID if present
-	var groupSID *sid
+	var groupSID *SID
 	if groupOffset > 0 {
-		sid, err := parseSIDBinary(data[groupOffset:])
+		sid, err := buf.decodeSID(data[groupOffset:], opts)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing group SID: %w", err)
+			err = fmt.Errorf("error parsing group SID: %w", err)
+			if !opts.Partial {
+				return nil, err
+			}
+			errs = append(errs, err)
 		}
 		groupSID = sid
 	}
 
 	// Parse DACL if present
-	var dacl *acl
+	// An ACL that failed to decode is left out with its present flag cleared, so that it is not
+	// mistaken for a NULL ACL
+	var dacl *ACL
 	if daclOffset > 0 {
-		acl, err := parseACLBinary(data[daclOffset:], "D", control)
+		acl, err := parseACLBinary(data[daclOffset:], "D", control, buf, opts)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing DACL: %w", err)
+			err = fmt.Errorf("error parsing DACL: %w", err)
+			if !opts.Partial {
+				return nil, err
+			}
+			errs = append(errs, err)
+			control &^= seDACLPresent
 		}
 		dacl = acl
 	}
 
 	// Parse SACL if present
-	var sacl *acl
+	var sacl *ACL
 	if saclOffset > 0 {
-		acl, err := parseACLBinary(data[saclOffset:], "S", control)
+		acl, err := parseACLBinary(data[saclOffset:], "S", control, buf, opts)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing SACL: %w", err)
+			err = fmt.Errorf("error parsing SACL: %w", err)
+			if !opts.Partial {
+				return nil, err
+			}
+			errs = append(errs, err)
+			control &^= seSACLPresent
 		}
 		sacl = acl
 	}
 
+	sd.control = control
+	sd.ownerSID = ownerSID
+	sd.groupSID = groupSID
+	sd.dacl = dacl
+	sd.sacl = sacl
+	return sd, errors.Join(errs...)
+}
+
+// DebugParseHeader decodes only the 20-byte fixed header of a binary security descriptor: its revision,
+// control flags and the offsets of its components, which are not followed. It is meant for inspecting
+// corrupt descriptors whose variable part can't be decoded, see SecurityDescriptor.HeaderString.
+//
+// The returned security descriptor has no owner, group or ACLs, whatever its control flags and offsets
+// say, so it is generally not valid and must not be used for anything but inspection.
+func DebugParseHeader(data []byte) (*SecurityDescriptor, error) {
+	if len(data) < 20 {
+		return nil, fmt.Errorf("invalid security descriptor: it must be 20 bytes length at minimum")
+	}
+
 	return &SecurityDescriptor{
-		revision:    revision,
-		sbzl:        sbzl,
-		control:     control,
-		ownerOffset: ownerOffset,
-		groupOffset: groupOffset,
-		saclOffset:  saclOffset,
-		daclOffset:  daclOffset,
-		ownerSID:    ownerSID,
-		groupSID:    groupSID,
-		dacl:        dacl,
-		sacl:        sacl,
+		revision:    data[0],
+		sbzl:        data[1],
+		control:     binary.LittleEndian.Uint16(data[2:4]),
+		ownerOffset: binary.LittleEndian.Uint32(data[4:8]),
+		groupOffset: binary.LittleEndian.Uint32(data[8:12]),
+		saclOffset:  binary.LittleEndian.Uint32(data[12:16]),
+		daclOffset:  binary.LittleEndian.Uint32(data[16:20]),
 	}, nil
 }
 
-// parseACEBinary takes a binary ACE and returns an ACE struct
-func parseACEBinary(data []byte) (*ace, error) {
-	dataLen := uint16(len(data))
-	if dataLen < 16 {
-		return nil, fmt.Errorf("invalid ACE: too short, got %d bytes but need at least 16 (4 for header + 4 for access mask + 8 for SID)", dataLen)
+// parseACEBinary takes a binary ACE and returns an ACE struct.
+// Bytes left between the end of the SID and AceSize are kept as padding, unless opts.StrictACESize is set,
+// except for callback ACEs, whose bytes after the SID are their application data.
+// If buf is not nil, the sub-authorities of the SID are stored in its scratch space, see decodeBuffers.decodeSID.
+func parseACEBinary(data []byte, buf *decodeBuffers, opts ParseOptions) (*ACE, error) {
+	// data may extend past the ACE, beyond 65535 bytes: its length must not be truncated to 16 bits
+	dataLen := len(data)
+	if dataLen < 8 {
+		return nil, fmt.Errorf("invalid ACE: too short, got %d bytes but need at least 8 (4 for header + 4 for access mask)", dataLen)
 	}
 
 	aceType := data[0]
@@ -100,32 +220,103 @@ func parseACEBinary(data []byte) (*ace, error) {
 	aceSize := binary.LittleEndian.Uint16(data[2:4])
 
 	// Validate full ACE size fits in data provided
-	if dataLen < aceSize {
+	if dataLen < int(aceSize) {
 		return nil, fmt.Errorf("invalid ACE: data length %d doesn't match ACE size %d", dataLen, aceSize)
 	}
+	if aceSize < 8 {
+		return nil, fmt.Errorf("invalid ACE: ACE size %d is too small, need at least 8", aceSize)
+	}
+	// Never read beyond the ACE itself
+	data = data[:aceSize]
 
 	accessMask := binary.LittleEndian.Uint32(data[4:8])
+	offset := 8
 
-	sid, err := parseSIDBinary(data[8:])
+	// The layout of unknown ACE types can't be decoded, keep their content as is
+	if !isKnownACEType(aceType) {
+		return &ACE{
+			header: &aceHeader{
+				aceType:  aceType,
+				aceFlags: aceFlags,
+				aceSize:  aceSize,
+			},
+			accessMask: accessMask,
+			rawData:    append([]byte{}, data[offset:]...), // never nil, even if empty
+			rawMask:    opts.RawMasks,
+		}, nil
+	}
+
+	// Object ACEs carry the object flags and the object type GUIDs before the SID
+	var objectType, inheritedObjectType *GUID
+	if isObjectACEType(aceType) {
+		if len(data) < offset+4 {
+			return nil, fmt.Errorf("invalid object ACE: too short to contain object flags")
+		}
+		objectFlags := binary.LittleEndian.Uint32(data[offset : offset+4])
+		offset += 4
+
+		if objectFlags&aceObjectTypePresent != 0 {
+			guid, err := parseGUIDBinary(data[offset:])
+			if err != nil {
+				return nil, fmt.Errorf("error parsing ACE object type: %w", err)
+			}
+			objectType = &guid
+			offset += 16
+		}
+		if objectFlags&aceInheritedObjectTypePresent != 0 {
+			guid, err := parseGUIDBinary(data[offset:])
+			if err != nil {
+				return nil, fmt.Errorf("error parsing ACE inherited object type: %w", err)
+			}
+			inheritedObjectType = &guid
+			offset += 16
+		}
+	}
+
+	sid, err := buf.decodeSID(data[offset:], opts)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing ACE SID: %w", err)
 	}
+	offset += 8 + 4*len(sid.subAuthority)
+
+	// The bytes following the SID of callback and resource attribute ACEs are their application data, kept verbatim
+	var applicationData []byte
+	if hasApplicationData(aceType) && offset < len(data) {
+		applicationData = slices.Clone(data[offset:])
+		offset = len(data)
+	}
 
-	return &ace{
+	// Keep any trailing bytes so the ACE keeps its original size when encoded again
+	var padding []byte
+	if offset < len(data) {
+		if opts.StrictACESize {
+			return nil, fmt.Errorf("invalid ACE: ACE size %d is larger than its content size %d", aceSize, offset)
+		}
+		padding = slices.Clone(data[offset:])
+	}
+
+	return &ACE{
 		header: &aceHeader{
 			aceType:  aceType,
 			aceFlags: aceFlags,
 			aceSize:  aceSize,
 		},
-		accessMask: accessMask,
-		sid:        sid,
+		accessMask:          accessMask,
+		sid:                 sid,
+		objectType:          objectType,
+		inheritedObjectType: inheritedObjectType,
+		applicationData:     applicationData,
+		padding:             padding,
+		rawMask:             opts.RawMasks,
 	}, nil
 }
 
-// parseACLBinary takes a binary ACL and returns an ACL struct
-func parseACLBinary(data []byte, aclType string, control uint16) (*acl, error) {
-	dataLength := uint16(len(data))
-	if dataLength < 8 {
+// parseACLBinary takes a binary ACL and returns an ACL struct.
+// If buf is not nil, its ACE buffer is used to accumulate the parsed ACEs before copying them into the result.
+func parseACLBinary(data []byte, aclType string, control uint16, buf *decodeBuffers, opts ParseOptions) (*ACL, error) {
+	// data holds everything from the ACL to the end of the descriptor, which may be longer than 65535 bytes:
+	// its length must not be truncated to 16 bits
+	if len(data) < 8 {
 		return nil, fmt.Errorf("invalid ACL: too short")
 	}
 
@@ -135,59 +326,110 @@ func parseACLBinary(data []byte, aclType string, control uint16) (*acl, error) {
 	aceCount := binary.LittleEndian.Uint16(data[4:6])
 	sbz2 := binary.LittleEndian.Uint16(data[6:8])
 
-	var aces []ace
-	offset := uint16(8)
+	if int(aclSize) < 8 || int(aclSize) > len(data) {
+		return nil, fmt.Errorf("invalid ACL: AclSize %d is out of range, %d bytes available", aclSize, len(data))
+	}
+	// The ACEs must lie within the ACL
+	data = data[:aclSize]
+
+	var aces []ACE
+	if buf != nil {
+		aces = buf.aces[:0]
+	}
+	offset := 8
 
 	// Parse each ACE
 	for i := uint16(0); i < aceCount; i++ {
-		if offset >= aclSize {
+		if offset >= int(aclSize) {
 			return nil, fmt.Errorf("invalid ACL: offset is bigger than AclSize: offset 0x%x (ACL Size: 0x%x)", offset, aclSize)
 		}
 
-		ace, err := parseACEBinary(data[offset:])
+		ace, err := parseACEBinary(data[offset:], buf, opts)
 		if err != nil {
 			return nil, fmt.Errorf("error parsing ACE: %w", err)
 		}
 
 		aces = append(aces, *ace)
-		offset += uint16(ace.header.aceSize)
+		offset += int(ace.header.aceSize)
 	}
 
-	return &acl{
+	// The ACL may have been allocated larger than its ACEs, leaving zeroed capacity after them, which is
+	// not kept: the ACL is decoded with the size of its ACEs. Non-zero bytes are ACEs left unread by a
+	// corrupt AceCount.
+	if offset != int(aclSize) {
+		if slices.ContainsFunc(data[offset:], func(b byte) bool { return b != 0 }) {
+			return nil, fmt.Errorf("invalid ACL: %d ACEs take %d bytes, but AclSize is %d and the remaining bytes are not zero",
+				aceCount, offset, aclSize)
+		}
+		aclSize = uint16(offset)
+	}
+
+	if buf != nil {
+		// Keep the grown buffer for the next call and hand out an exact-size copy
+		buf.aces = aces
+		aces = slices.Clone(aces)
+		clear(buf.aces)
+	}
+
+	return &ACL{
 		aclRevision: aclRevision,
 		sbzl:        sbzl,
 		aclSize:     aclSize,
 		aceCount:    aceCount,
 		sbz2:        sbz2,
 		aclType:     aclType,
-		control:     control,
+		control:     aclControl(aclType, control),
 		aces:        aces,
 	}, nil
 }
 
+// parseGUIDBinary takes a binary GUID and returns a GUID
+func parseGUIDBinary(data []byte) (GUID, error) {
+	var guid GUID
+	if len(data) < len(guid) {
+		return guid, fmt.Errorf("invalid GUID: it must be 16 bytes long, got %d bytes", len(data))
+	}
+	copy(guid[:], data)
+	return guid, nil
+}
+
 // parseSIDBinary takes a binary SID and returns a SID struct
-func parseSIDBinary(data []byte) (*sid, error) {
+func parseSIDBinary(data []byte) (*SID, error) {
+	subAuthorityCount, err := checkSIDBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCheckedSIDBinary(data, make([]uint32, subAuthorityCount)), nil
+}
+
+// checkSIDBinary checks that data starts with a complete binary SID and returns its number of sub-authorities.
+func checkSIDBinary(data []byte) (int, error) {
 	if len(data) < 8 {
-		return nil, fmt.Errorf("invalid SID: it must be at least 8 bytes long")
+		return 0, fmt.Errorf("invalid SID: it must be at least 8 bytes long")
+	}
+
+	if data[0] != 1 {
+		return 0, fmt.Errorf("invalid SID: revision must be 1, was %d", data[0])
 	}
 
-	revision := data[0]
 	subAuthorityCount := int(data[1])
 
 	neededLen := 8 + (4 * subAuthorityCount)
 	if len(data) < neededLen {
-		return nil, fmt.Errorf("invalid SID: truncated data, got %d bytes but need %d bytes for %d sub-authorities",
+		return 0, fmt.Errorf("invalid SID: truncated data, got %d bytes but need %d bytes for %d sub-authorities",
 			len(data), neededLen, subAuthorityCount)
 	}
 
 	if subAuthorityCount > 15 { // Maximum sub-authorities in a valid SID
-		return nil, fmt.Errorf("invalid SID: too many sub-authorities (%d), maximum is 15", subAuthorityCount)
+		return 0, fmt.Errorf("invalid SID: too many sub-authorities (%d), maximum is 15", subAuthorityCount)
 	}
 
-	if len(data) < 8+4*subAuthorityCount {
-		return nil, fmt.Errorf("invalid SID: data too short for sub-authority count")
-	}
+	return subAuthorityCount, nil
+}
 
+// decodeCheckedSIDBinary decodes the binary SID at the start of data, checked by checkSIDBinary, storing its
+// sub-authorities in subAuthorities, whose length must be their number.
+func decodeCheckedSIDBinary(data []byte, subAuthorities []uint32) *SID {
 	// Parse authority (48 bits)
 	authority := uint64(0)
 	for i := 2; i < 8; i++ {
@@ -195,15 +437,14 @@ func parseSIDBinary(data []byte) (*sid, error) {
 	}
 
 	// Parse sub-authorities
-	subAuthorities := make([]uint32, subAuthorityCount)
-	for i := 0; i < subAuthorityCount; i++ {
+	for i := range subAuthorities {
 		offset := 8 + 4*i
 		subAuthorities[i] = binary.LittleEndian.Uint32(data[offset : offset+4])
 	}
 
-	return &sid{
-		revision:            revision,
+	return &SID{
+		revision:            data[0],
 		identifierAuthority: authority,
 		subAuthority:        subAuthorities,
-	}, nil
+	}
 }
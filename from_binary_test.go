@@ -1,6 +1,13 @@
 package sddl
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -43,7 +50,7 @@ func TestParseSIDBinary(t *testing.T) {
 				0x00, 0x00, 0x00, 0x00, 0x00, 0x05, // IdentifierAuthority (NT Authority)
 				0x01, 0x00, 0x00, 0x00, // SubAuthority[0] = 1 (DIALUP)
 			},
-			want:    "DU", // Well-known SID for DIALUP
+			want:    "S-1-5-1", // DIALUP has no SDDL alias
 			wantErr: false,
 		},
 		{
@@ -120,6 +127,17 @@ func TestParseSIDBinary(t *testing.T) {
 			want:    "S-1-5-1-2-3-4-5-6-7-8-9-10-11-12-13-14-15",
 			wantErr: false,
 		},
+		{
+			name: "Invalid revision",
+			data: []byte{
+				0x00,                               // Revision (must be 1)
+				0x01,                               // SubAuthorityCount
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x05, // IdentifierAuthority (NT Authority)
+				0x12, 0x00, 0x00, 0x00, // SubAuthority[0] = 18
+			},
+			want:    "",
+			wantErr: true,
+		},
 		{
 			name: "Too many sub-authorities",
 			data: []byte{
@@ -291,7 +309,7 @@ func TestParseACEBinary(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			ace, err := parseACEBinary(tt.data)
+			ace, err := parseACEBinary(tt.data, nil, ParseOptions{})
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("parseACEBinary() expected error, got nil")
@@ -319,6 +337,91 @@ func TestParseACEBinary(t *testing.T) {
 	}
 }
 
+func TestParseACEBinary_Padding(t *testing.T) {
+	t.Parallel()
+
+	// SYSTEM - Full Access, with AceSize rounded up to 24 bytes and 4 padding bytes after the SID
+	padded := []byte{
+		0x00,       // Type (ACCESS_ALLOWED_ACE_TYPE)
+		0x00,       // Flags
+		0x18, 0x00, // Size (24 bytes)
+		0xFF, 0x01, 0x1F, 0x00, // Full Access
+		// SID (SYSTEM)
+		0x01, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+		0x12, 0x00, 0x00, 0x00,
+		// Padding
+		0xAA, 0xBB, 0x00, 0x00,
+	}
+
+	// Same ACE without padding
+	exact := append([]byte{0x00, 0x00, 0x14, 0x00}, padded[4:20]...)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		opts    ParseOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Padding preserved by default",
+			data: padded,
+			want: "(A;;FA;;;SY)",
+		},
+		{
+			name:    "Padding rejected in strict mode",
+			data:    padded,
+			opts:    ParseOptions{StrictACESize: true},
+			wantErr: true,
+		},
+		{
+			name: "Exact size accepted in strict mode",
+			data: exact,
+			opts: ParseOptions{StrictACESize: true},
+			want: "(A;;FA;;;SY)",
+		},
+		{
+			name: "SID extending beyond AceSize",
+			data: []byte{
+				0x00,       // Type (ACCESS_ALLOWED_ACE_TYPE)
+				0x00,       // Flags
+				0x10, 0x00, // Size (16 bytes, too small for the SID below)
+				0xFF, 0x01, 0x1F, 0x00, // Full Access
+				// SID (SYSTEM)
+				0x01, 0x01,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+				0x12, 0x00, 0x00, 0x00,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ace, err := parseACEBinary(tt.data, nil, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseACEBinary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := ace.String(); got != tt.want {
+				t.Errorf("parseACEBinary() = %v, want %v", got, tt.want)
+			}
+			if err := ace.validate(); err != nil {
+				t.Errorf("validate() unexpected error = %v", err)
+			}
+			if got := ace.Binary(); !bytes.Equal(got, tt.data) {
+				t.Errorf("Binary() = % x, want % x", got, tt.data)
+			}
+		})
+	}
+}
+
 func TestParseACLBinary(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -326,7 +429,7 @@ func TestParseACLBinary(t *testing.T) {
 		data    []byte
 		aclType string
 		control uint16
-		want    *acl
+		want    *ACL
 		wantStr string
 		wantErr bool
 	}{
@@ -352,6 +455,95 @@ func TestParseACLBinary(t *testing.T) {
 			wantStr: "",
 			wantErr: true,
 		},
+		{
+			name: "AceCount larger than the ACEs",
+			data: []byte{
+				0x02,       // Revision
+				0x00,       // Sbz1
+				0x1C, 0x00, // Size (28 bytes = 8 header + 20 ACE)
+				0xE8, 0x03, // AceCount (1000, only one ACE present)
+				0x00, 0x00, // Sbz2
+				0x00, 0x00, 0x14, 0x00, // ACE header (ACCESS_ALLOWED_ACE_TYPE, 20 bytes)
+				0xFF, 0x01, 0x1F, 0x00, // Access mask (Full Access)
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00, // SYSTEM
+				// Bytes following the ACL in the descriptor, e.g. the owner SID
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00,
+			},
+			aclType: "D",
+			wantErr: true,
+		},
+		{
+			name: "AceCount smaller than the ACEs",
+			data: []byte{
+				0x02,       // Revision
+				0x00,       // Sbz1
+				0x30, 0x00, // Size (48 bytes = 8 header + 2 ACEs of 20 bytes each)
+				0x01, 0x00, // AceCount (1, two ACEs present)
+				0x00, 0x00, // Sbz2
+				0x00, 0x00, 0x14, 0x00, // ACE header (ACCESS_ALLOWED_ACE_TYPE, 20 bytes)
+				0xFF, 0x01, 0x1F, 0x00, // Access mask (Full Access)
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00, // SYSTEM
+				0x01, 0x00, 0x14, 0x00, // ACE header (ACCESS_DENIED_ACE_TYPE, 20 bytes)
+				0xFF, 0x01, 0x1F, 0x00, // Access mask (Full Access)
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // Everyone
+			},
+			aclType: "D",
+			wantErr: true,
+		},
+		{
+			name: "Over-allocated ACL",
+			data: []byte{
+				0x02,       // Revision
+				0x00,       // Sbz1
+				0x28, 0x00, // Size (40 bytes = 8 header + 20 ACE + 12 reserved)
+				0x01, 0x00, // AceCount
+				0x00, 0x00, // Sbz2
+				0x00, 0x00, 0x14, 0x00, // ACE header (ACCESS_ALLOWED_ACE_TYPE, 20 bytes)
+				0xFF, 0x01, 0x1F, 0x00, // Access mask (Full Access)
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00, // SYSTEM
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Reserved capacity
+			},
+			aclType: "D",
+			want: &ACL{
+				aclRevision: 0x02,
+				aclSize:     0x1C, // The size of the ACE, without the reserved capacity
+				aceCount:    1,
+				aclType:     "D",
+				aces:        []ACE{*newACE(accessAllowedACEType, 0, 0x001f01ff, NewSID(5, 18))},
+			},
+			wantStr: "(A;;FA;;;SY)",
+		},
+		{
+			name: "Over-allocated ACL with non-zero bytes after the ACEs",
+			data: []byte{
+				0x02,       // Revision
+				0x00,       // Sbz1
+				0x28, 0x00, // Size (40 bytes = 8 header + 20 ACE + 12 reserved)
+				0x01, 0x00, // AceCount
+				0x00, 0x00, // Sbz2
+				0x00, 0x00, 0x14, 0x00, // ACE header (ACCESS_ALLOWED_ACE_TYPE, 20 bytes)
+				0xFF, 0x01, 0x1F, 0x00, // Access mask (Full Access)
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00, // SYSTEM
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, // Not zeroed
+			},
+			aclType: "D",
+			wantErr: true,
+		},
+		{
+			name: "ACE extending beyond AclSize",
+			data: []byte{
+				0x02,       // Revision
+				0x00,       // Sbz1
+				0x18, 0x00, // Size (24 bytes, 4 bytes short of the ACE)
+				0x01, 0x00, // AceCount
+				0x00, 0x00, // Sbz2
+				0x00, 0x00, 0x14, 0x00, // ACE header (ACCESS_ALLOWED_ACE_TYPE, 20 bytes)
+				0xFF, 0x01, 0x1F, 0x00, // Access mask (Full Access)
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00, // SYSTEM
+			},
+			aclType: "D",
+			wantErr: true,
+		},
 		{
 			name: "Empty ACL",
 			data: []byte{
@@ -363,7 +555,7 @@ func TestParseACLBinary(t *testing.T) {
 			},
 			aclType: "D",
 			control: 0,
-			want: &acl{
+			want: &ACL{
 				aclRevision: 0x02,
 				sbzl:        0,
 				aclSize:     0x8,
@@ -386,7 +578,7 @@ func TestParseACLBinary(t *testing.T) {
 			},
 			aclType: "D",
 			control: seDACLProtected,
-			want: &acl{
+			want: &ACL{
 				aclRevision: 0x02,
 				sbzl:        0,
 				aclSize:     0x8,
@@ -408,7 +600,7 @@ func TestParseACLBinary(t *testing.T) {
 			},
 			aclType: "D",
 			control: seDACLAutoInherited,
-			want: &acl{
+			want: &ACL{
 				aclRevision: 0x02,
 				sbzl:        0,
 				aclSize:     0x8,
@@ -430,7 +622,7 @@ func TestParseACLBinary(t *testing.T) {
 			},
 			aclType: "D",
 			control: seDACLProtected | seDACLAutoInherited,
-			want: &acl{
+			want: &ACL{
 				aclRevision: 0x02,
 				sbzl:        0,
 				aclSize:     0x8,
@@ -462,7 +654,7 @@ func TestParseACLBinary(t *testing.T) {
 			},
 			aclType: "D",
 			control: 0,
-			want: &acl{
+			want: &ACL{
 				aclRevision: 0x02,
 				sbzl:        0,
 				aclSize:     0x1C, // 28 bytes = 8 header + 20 ACE
@@ -470,7 +662,7 @@ func TestParseACLBinary(t *testing.T) {
 				sbz2:        0,
 				aclType:     "D",
 				control:     0,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  0,
@@ -478,7 +670,7 @@ func TestParseACLBinary(t *testing.T) {
 							aceSize:  0x14, // 20 Bytes
 						},
 						accessMask: 0x001F01FF, // Full Access
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,              // NT Authority
 							subAuthority:        []uint32{0x12}, // SYSTEM
@@ -495,7 +687,7 @@ func TestParseACLBinary(t *testing.T) {
 				// ACL Header
 				0x02,       // Revision
 				0x00,       // Sbz1
-				0x38, 0x00, // Size (56 bytes = 8 header + 20 first ACE + 28 second ACE)
+				0x34, 0x00, // Size (52 bytes = 8 header + 20 first ACE + 24 second ACE)
 				0x02, 0x00, // AceCount
 				0x00, 0x00, // Sbz2
 				// First ACE - Allow System Full Access
@@ -518,15 +710,15 @@ func TestParseACLBinary(t *testing.T) {
 			},
 			aclType: "D",
 			control: 0,
-			want: &acl{
+			want: &ACL{
 				aclRevision: 0x02,
 				sbzl:        0,
-				aclSize:     0x38, // 56 bytes = 8 header + 20 first ACE + 28 second ACE
+				aclSize:     0x34, // 52 bytes = 8 header + 20 first ACE + 24 second ACE
 				aceCount:    2,
 				sbz2:        0,
 				aclType:     "D",
 				control:     0,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  0,
@@ -534,7 +726,7 @@ func TestParseACLBinary(t *testing.T) {
 							aceSize:  0x14, // 20 Bytes
 						},
 						accessMask: 0x001F01FF, // Full Access
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,
 							subAuthority:        []uint32{0x12},
@@ -547,7 +739,7 @@ func TestParseACLBinary(t *testing.T) {
 							aceSize:  0x18, // 24 Bytes
 						},
 						accessMask: 0x00120089, // File Read
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,                      // NT Authority
 							subAuthority:        []uint32{0x20, 0x0220}, // BUILTIN, Administrators
@@ -564,7 +756,7 @@ func TestParseACLBinary(t *testing.T) {
 				// ACL Header
 				0x02,       // Revision
 				0x00,       // Sbz1
-				0x28, 0x00, // Size (40 bytes = 8 header + 2 ACEs of 16 bytes each)
+				0x30, 0x00, // Size (48 bytes = 8 header + 2 ACEs of 20 bytes each)
 				0x02, 0x00, // AceCount
 				0x00, 0x00, // Sbz2
 				// First ACE - Audit System Success
@@ -586,15 +778,15 @@ func TestParseACLBinary(t *testing.T) {
 			},
 			aclType: "S",
 			control: seSACLPresent,
-			want: &acl{
+			want: &ACL{
 				aclRevision: 0x02,
 				sbzl:        0,
-				aclSize:     0x28, // 40 bytes = 8 header + 2 ACEs of 16 bytes each
+				aclSize:     0x30, // 48 bytes = 8 header + 2 ACEs of 20 bytes each
 				aceCount:    2,
 				sbz2:        0,
 				aclType:     "S",
 				control:     seSACLPresent,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  2,    // SYSTEM_AUDIT_ACE_TYPE
@@ -602,7 +794,7 @@ func TestParseACLBinary(t *testing.T) {
 							aceSize:  0x14, // 20 Bytes
 						},
 						accessMask: 0x001F01FF, // Full Access
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,              // NT Authority
 							subAuthority:        []uint32{0x12}, // SYSTEM
@@ -615,7 +807,7 @@ func TestParseACLBinary(t *testing.T) {
 							aceSize:  0x14, // 20 Bytes
 						},
 						accessMask: 0x001F01FF, // Full Access
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,              // NT Authority
 							subAuthority:        []uint32{0x12}, // SYSTEM
@@ -630,7 +822,7 @@ func TestParseACLBinary(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			acl, err := parseACLBinary(tt.data, tt.aclType, tt.control)
+			acl, err := parseACLBinary(tt.data, tt.aclType, tt.control, nil, ParseOptions{})
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("parseACLBinary() = %v, wantErr %v", acl, tt.wantErr)
@@ -940,3 +1132,926 @@ func TestFromBinary(t *testing.T) {
 		})
 	}
 }
+
+func TestFromBinary_UnknownACEType(t *testing.T) {
+	t.Parallel()
+
+	data := []byte{
+		// Security descriptor header
+		0x01,       // Revision
+		0x00,       // Sbz1
+		0x04, 0x80, // Control (SE_SELF_RELATIVE | SE_DACL_PRESENT)
+		0x00, 0x00, 0x00, 0x00, // Owner offset
+		0x00, 0x00, 0x00, 0x00, // Group offset
+		0x00, 0x00, 0x00, 0x00, // Sacl offset
+		0x14, 0x00, 0x00, 0x00, // Dacl offset
+		// DACL
+		0x02,       // Revision
+		0x00,       // Sbz1
+		0x2C, 0x00, // Size (44 bytes)
+		0x02, 0x00, // AceCount
+		0x00, 0x00, // Sbz2
+		// ACE of unknown type
+		0x42,       // Type (unknown)
+		0x02,       // Flags (CONTAINER_INHERIT_ACE)
+		0x10, 0x00, // Size (16 bytes)
+		0x01, 0x00, 0x00, 0x00, // Access mask
+		0xDE, 0xAD, 0xBE, 0xEF, 0x01, 0x02, 0x03, 0x04, // Opaque payload
+		// ACE (A;;FA;;;SY)
+		0x00,       // Type (ACCESS_ALLOWED_ACE_TYPE)
+		0x00,       // Flags
+		0x14, 0x00, // Size (20 bytes)
+		0xFF, 0x01, 0x1F, 0x00, // Full Access
+		0x01, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+		0x12, 0x00, 0x00, 0x00,
+	}
+
+	sd, err := FromBinary(data)
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+
+	aces := sd.DACL().ACEs()
+	if len(aces) != 2 {
+		t.Fatalf("FromBinary() got %d ACEs, want 2", len(aces))
+	}
+	if got, want := aces[0].RawData(), data[36:44]; !bytes.Equal(got, want) {
+		t.Errorf("RawData() = % x, want % x", got, want)
+	}
+	if aces[0].SID() != nil {
+		t.Errorf("SID() = %v, want nil", aces[0].SID())
+	}
+	if aces[1].RawData() != nil {
+		t.Errorf("RawData() of known ACE = % x, want nil", aces[1].RawData())
+	}
+
+	if got, want := sd.String(), "D:(0x42;CI;CC;;;)(A;;FA;;;SY)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if err := sd.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+	if got := sd.Binary(); !bytes.Equal(got, data) {
+		t.Errorf("Binary() = % x, want % x", got, data)
+	}
+}
+
+func TestFromBinaryWithOptions_MaxDescriptorSize(t *testing.T) {
+	t.Parallel()
+
+	// O:SY
+	valid := []byte{
+		0x01,       // Revision
+		0x00,       // Sbz1
+		0x00, 0x80, // Control (SE_SELF_RELATIVE)
+		0x14, 0x00, 0x00, 0x00, // Owner offset
+		0x00, 0x00, 0x00, 0x00, // Group offset
+		0x00, 0x00, 0x00, 0x00, // Sacl offset
+		0x00, 0x00, 0x00, 0x00, // Dacl offset
+		// Owner SID (SYSTEM)
+		0x01, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+		0x12, 0x00, 0x00, 0x00,
+	}
+
+	// Larger than any legitimate descriptor, padding after a valid descriptor
+	oversized := make([]byte, DefaultMaxDescriptorSize+1)
+	copy(oversized, valid)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		opts    ParseOptions
+		wantErr error
+	}{
+		{
+			name: "Valid descriptor with default limit",
+			data: valid,
+		},
+		{
+			name: "Largest accepted size with default limit",
+			data: oversized[:DefaultMaxDescriptorSize],
+		},
+		{
+			name:    "Oversized input with default limit",
+			data:    oversized,
+			wantErr: ErrDescriptorTooLarge,
+		},
+		{
+			name:    "Custom limit below descriptor size",
+			data:    valid,
+			opts:    ParseOptions{MaxDescriptorSize: 31},
+			wantErr: ErrDescriptorTooLarge,
+		},
+		{
+			name: "Custom limit equal to descriptor size",
+			data: valid,
+			opts: ParseOptions{MaxDescriptorSize: 32},
+		},
+		{
+			name:    "Negative limit means default",
+			data:    oversized,
+			opts:    ParseOptions{MaxDescriptorSize: -1},
+			wantErr: ErrDescriptorTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromBinaryWithOptions(tt.data, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("FromBinaryWithOptions() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromBinaryWithOptions() unexpected error = %v", err)
+			}
+			if got := sd.String(); got != "O:SY" {
+				t.Errorf("String() = %s, want O:SY", got)
+			}
+		})
+	}
+}
+
+func TestFromBinary_ACLRevision(t *testing.T) {
+	t.Parallel()
+
+	// withRevision returns a security descriptor with a DACL (A;;FA;;;SY) of the given revision
+	withRevision := func(revision byte) []byte {
+		return []byte{
+			0x01,       // Revision
+			0x00,       // Sbz1
+			0x04, 0x80, // Control (SE_SELF_RELATIVE | SE_DACL_PRESENT)
+			0x00, 0x00, 0x00, 0x00, // Owner offset
+			0x00, 0x00, 0x00, 0x00, // Group offset
+			0x00, 0x00, 0x00, 0x00, // Sacl offset
+			0x14, 0x00, 0x00, 0x00, // Dacl offset
+			// DACL
+			revision,   // Revision
+			0x00,       // Sbz1
+			0x1C, 0x00, // Size (28 bytes)
+			0x01, 0x00, // AceCount
+			0x00, 0x00, // Sbz2
+			// ACE (A;;FA;;;SY)
+			0x00,       // Type (ACCESS_ALLOWED_ACE_TYPE)
+			0x00,       // Flags
+			0x14, 0x00, // Size (20 bytes)
+			0xFF, 0x01, 0x1F, 0x00, // Full Access
+			0x01, 0x01,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+			0x12, 0x00, 0x00, 0x00,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		revision byte
+	}{
+		{name: "ACL_REVISION", revision: 2},
+		{name: "ACL_REVISION3", revision: 3},
+		{name: "ACL_REVISION_DS", revision: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := withRevision(tt.revision)
+			sd, err := FromBinary(data)
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			if got := sd.DACL().aclRevision; got != tt.revision {
+				t.Errorf("FromBinary() ACL revision = %d, want %d", got, tt.revision)
+			}
+			if got := sd.Binary(); !bytes.Equal(got, data) {
+				t.Errorf("Binary() = % x, want % x", got, data)
+			}
+		})
+	}
+}
+
+func TestFromBinary_CompoundACE(t *testing.T) {
+	t.Parallel()
+
+	data := []byte{
+		// Security descriptor header
+		0x01,       // Revision
+		0x00,       // Sbz1
+		0x04, 0x80, // Control (SE_SELF_RELATIVE | SE_DACL_PRESENT)
+		0x00, 0x00, 0x00, 0x00, // Owner offset
+		0x00, 0x00, 0x00, 0x00, // Group offset
+		0x00, 0x00, 0x00, 0x00, // Sacl offset
+		0x14, 0x00, 0x00, 0x00, // Dacl offset
+		// DACL
+		0x03,       // Revision (ACL_REVISION3)
+		0x00,       // Sbz1
+		0x44, 0x00, // Size (68 bytes)
+		0x02, 0x00, // AceCount
+		0x00, 0x00, // Sbz2
+		// ACE (ACCESS_ALLOWED_COMPOUND_ACE_TYPE)
+		0x04,       // Type
+		0x00,       // Flags
+		0x28, 0x00, // Size (40 bytes)
+		0x89, 0x00, 0x12, 0x00, // File Read
+		0x01, 0x00, // CompoundAceType (COMPOUND_ACE_IMPERSONATION)
+		0x00, 0x00, // Reserved
+		0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00, // Server SID (S-1-5-18)
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00, 0x20, 0x02, 0x00, 0x00, // Client SID (S-1-5-32-544)
+		// ACE (A;;FA;;;SY)
+		0x00,       // Type (ACCESS_ALLOWED_ACE_TYPE)
+		0x00,       // Flags
+		0x14, 0x00, // Size (20 bytes)
+		0xFF, 0x01, 0x1F, 0x00, // Full Access
+		0x01, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+		0x12, 0x00, 0x00, 0x00,
+	}
+
+	sd, err := FromBinary(data)
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	if err := sd.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+	if got := sd.Binary(); !bytes.Equal(got, data) {
+		t.Errorf("Binary() = % x, want % x", got, data)
+	}
+	if got, want := sd.String(), "D:(0x04;;FR;;;)(A;;FA;;;SY)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+
+	aces := sd.DACL().ACEs()
+	server, client, ok := aces[0].CompoundSIDs()
+	if !ok || !server.Equal(NewSID(5, 18)) || !client.Equal(NewSID(5, 32, 544)) {
+		t.Errorf("CompoundSIDs() = (%v, %v, %v), want (SY, BA, true)", server, client, ok)
+	}
+	if _, _, ok := aces[1].CompoundSIDs(); ok {
+		t.Errorf("CompoundSIDs() of an access allowed ACE = true, want false")
+	}
+
+	// Truncated client SID, still kept as raw data
+	truncated := append([]byte{}, data...)
+	truncated[53] = 0x03 // Client SubAuthorityCount beyond the ACE
+	sd, err = FromBinary(truncated)
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	if _, _, ok := sd.DACL().ACEs()[0].CompoundSIDs(); ok {
+		t.Errorf("CompoundSIDs() of a truncated ACE = true, want false")
+	}
+}
+
+func TestFromBinary_OverAllocatedACL(t *testing.T) {
+	t.Parallel()
+
+	data := []byte{
+		// Security descriptor header
+		0x01,       // Revision
+		0x00,       // Sbz1
+		0x04, 0x80, // Control (SE_SELF_RELATIVE | SE_DACL_PRESENT)
+		0x38, 0x00, 0x00, 0x00, // Owner offset
+		0x00, 0x00, 0x00, 0x00, // Group offset
+		0x00, 0x00, 0x00, 0x00, // Sacl offset
+		0x14, 0x00, 0x00, 0x00, // Dacl offset
+		// DACL, allocated with room for more ACEs
+		0x02,       // Revision
+		0x00,       // Sbz1
+		0x24, 0x00, // Size (36 bytes = 8 header + 20 ACE + 8 reserved)
+		0x01, 0x00, // AceCount
+		0x00, 0x00, // Sbz2
+		// ACE (A;;FA;;;SY)
+		0x00,       // Type (ACCESS_ALLOWED_ACE_TYPE)
+		0x00,       // Flags
+		0x14, 0x00, // Size (20 bytes)
+		0xFF, 0x01, 0x1F, 0x00, // Full Access
+		0x01, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+		0x12, 0x00, 0x00, 0x00,
+		// Reserved capacity
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		// Owner SID (S-1-5-32-544)
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00, 0x20, 0x02, 0x00, 0x00,
+	}
+
+	sd, err := FromBinary(data)
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	if got, want := sd.String(), "O:BAD:(A;;FA;;;SY)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if err := sd.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+
+	// The reserved capacity is not kept: the descriptor is written back compacted
+	compareACLs(t, "DACL", sd.DACL(), mustFromString(t, "D:(A;;FA;;;SY)").DACL())
+	back, err := FromBinary(sd.Binary())
+	if err != nil {
+		t.Fatalf("Binary() -> FromBinary() unexpected error = %v", err)
+	}
+	if got := len(sd.Binary()); got != len(data)-8 {
+		t.Errorf("len(Binary()) = %d, want %d", got, len(data)-8)
+	}
+	compareSecurityDescriptors(t, back, sd)
+}
+
+func TestFromBinary_DefaultedWithSID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		defaulted uint16
+		wantStr   string
+		wantLost  uint16
+	}{
+		{
+			name:      "Owner defaulted",
+			input:     "O:BAG:SYD:(A;;FA;;;SY)",
+			defaulted: seOwnerDefaulted,
+			wantStr:   "O:BAG:SYD:(A;;FA;;;SY)",
+			wantLost:  seOwnerDefaulted,
+		},
+		{
+			name:      "Owner and group defaulted",
+			input:     "O:BAG:SYD:(A;;FA;;;SY)",
+			defaulted: seOwnerDefaulted | seGroupDefaulted,
+			wantStr:   "O:BAG:SYD:(A;;FA;;;SY)",
+			wantLost:  seOwnerDefaulted | seGroupDefaulted,
+		},
+		{
+			name:      "Everything defaulted",
+			input:     "O:BAG:SYD:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)",
+			defaulted: seOwnerDefaulted | seGroupDefaulted | seDACLDefaulted | seSACLDefaulted,
+			wantStr:   "O:BAG:SYD:R(A;;FA;;;SY)S:R(AU;SA;FA;;;WD)",
+			wantLost:  seOwnerDefaulted | seGroupDefaulted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			sd.control |= tt.defaulted
+			want := sd.control
+
+			// The binary form keeps the control word as is
+			decoded, err := FromBinary(sd.Binary())
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			if decoded.control != want {
+				t.Errorf("FromBinary() control = 0x%04X, want 0x%04X", decoded.control, want)
+			}
+			if !bytes.Equal(decoded.Binary(), sd.Binary()) {
+				t.Errorf("FromBinary().Binary() = % x, want % x", decoded.Binary(), sd.Binary())
+			}
+
+			// SDDL can't say that a present owner or group is defaulted, these flags are lost
+			if got := decoded.String(); got != tt.wantStr {
+				t.Errorf("String() = %s, want %s", got, tt.wantStr)
+			}
+			parsed, err := FromString(decoded.String())
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			if got := parsed.control; got != want&^tt.wantLost {
+				t.Errorf("FromString(String()) control = 0x%04X, want 0x%04X", got, want&^tt.wantLost)
+			}
+		})
+	}
+}
+
+func TestFromBinary_ComponentBoundaries(t *testing.T) {
+	t.Parallel()
+
+	// encode returns the binary form of the SDDL string s
+	encode := func(t *testing.T, s string) []byte {
+		t.Helper()
+		sd, err := FromString(s)
+		if err != nil {
+			t.Fatalf("FromString() unexpected error = %v", err)
+		}
+		return sd.Binary()
+	}
+
+	// Large enough that the data following the SACL, which comes before the DACL, exceeds 65535 bytes
+	var large strings.Builder
+	large.WriteString("O:SYD:")
+	for i := 0; i < 1800; i++ {
+		large.WriteString("(A;;FA;;;S-1-5-21-1-2-3-1001)")
+	}
+	large.WriteString("S:")
+	for i := 0; i < 1000; i++ {
+		large.WriteString("(AU;SA;FA;;;S-1-5-21-1-2-3-1001)")
+	}
+
+	tests := []struct {
+		name    string
+		data    func(t *testing.T) []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Owner SID ending at the end of the data",
+			data: func(t *testing.T) []byte { return encode(t, "O:SY") },
+			want: "O:SY",
+		},
+		{
+			name: "DACL and its last ACE ending at the end of the data",
+			data: func(t *testing.T) []byte { return encode(t, "O:SYG:BAD:(A;;FA;;;SY)(A;;FR;;;BU)") },
+			want: "O:SYG:BAD:(A;;FA;;;SY)(A;;FR;;;BU)",
+		},
+		{
+			name: "Trailing slack after all components",
+			data: func(t *testing.T) []byte { return append(encode(t, "O:SYD:(A;;FA;;;SY)"), 0, 0, 0, 0) },
+			want: "O:SYD:(A;;FA;;;SY)",
+		},
+		{
+			name: "More than 65535 bytes after the SACL",
+			data: func(t *testing.T) []byte { return encode(t, large.String()) },
+			want: large.String(),
+		},
+		{
+			name: "Owner SID truncated by one byte",
+			data: func(t *testing.T) []byte {
+				data := encode(t, "O:SY")
+				return data[:len(data)-1]
+			},
+			wantErr: true,
+		},
+		{
+			name: "Last ACE truncated by one byte",
+			data: func(t *testing.T) []byte {
+				data := encode(t, "O:SYD:(A;;FA;;;SY)")
+				return data[:len(data)-1]
+			},
+			wantErr: true,
+		},
+		{
+			name: "Owner offset at the end of the data",
+			data: func(t *testing.T) []byte {
+				data := encode(t, "O:SY")
+				binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)))
+				return data
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromBinary(tt.data(t))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromBinary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("FromBinary().String() = %.80s..., want %.80s...", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugParseHeader(t *testing.T) {
+	t.Parallel()
+
+	// A header with every offset set, but no bodies: FromBinary can't decode it
+	header := []byte{
+		0x01, 0x00, 0x14, 0x8C, // revision 1, SE_SELF_RELATIVE, SE_DACL_PRESENT, SE_SACL_PRESENT, auto-inherited
+		0x14, 0x00, 0x00, 0x00, // owner offset
+		0x24, 0x00, 0x00, 0x00, // group offset
+		0x30, 0x00, 0x00, 0x00, // SACL offset
+		0x4C, 0x00, 0x00, 0x00, // DACL offset
+	}
+	if _, err := FromBinary(header); err == nil {
+		t.Fatal("FromBinary() expected error, got nil")
+	}
+
+	sd, err := DebugParseHeader(header)
+	if err != nil {
+		t.Fatalf("DebugParseHeader() unexpected error = %v", err)
+	}
+	want := &SecurityDescriptor{
+		revision:    1,
+		control:     0x8C14,
+		ownerOffset: 0x14,
+		groupOffset: 0x24,
+		saclOffset:  0x30,
+		daclOffset:  0x4C,
+	}
+	if !reflect.DeepEqual(sd, want) {
+		t.Errorf("DebugParseHeader() = %+v, want %+v", sd, want)
+	}
+	if got, wantStr := sd.HeaderString(), "revision=1 sbz1=0 control=0x8C14 owner=0x14 group=0x24 sacl=0x30 dacl=0x4C"; got != wantStr {
+		t.Errorf("HeaderString() = %s, want %s", got, wantStr)
+	}
+
+	// Only the header is read, whatever follows it
+	if _, err := DebugParseHeader(append(header, 0xFF, 0xFF)); err != nil {
+		t.Errorf("DebugParseHeader() with trailing data unexpected error = %v", err)
+	}
+	if _, err := DebugParseHeader(header[:19]); err == nil {
+		t.Error("DebugParseHeader() with a truncated header expected error, got nil")
+	}
+}
+
+func TestFromBinary_SACLWithoutPresentFlag(t *testing.T) {
+	t.Parallel()
+
+	// Like Windows, the SACL offset is ignored without SE_SACL_PRESENT
+	data := mustFromString(t, "O:BAD:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)").Binary()
+	data[2] &^= seSACLPresent
+
+	sd, err := FromBinary(data)
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	if got, want := sd.String(), "O:BAD:(A;;FA;;;SY)"; got != want {
+		t.Errorf("FromBinary() = %s, want %s", got, want)
+	}
+	if err := sd.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+}
+
+func TestDACLFromBinary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sddl     string
+		wantNil  bool
+		corrupt  func(data []byte)
+		wantFail bool
+	}{
+		{
+			name: "Complete descriptor",
+			sddl: "O:BAG:SYD:PAI(A;OICI;FA;;;SY)(D;;FW;;;AN)(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)(A;ID;FR;;;BU)S:AI(AU;SA;FA;;;WD)(ML;;NW;;;LW)",
+		},
+		{
+			name: "Empty DACL",
+			sddl: "O:BAD:",
+		},
+		{
+			name:    "NULL DACL",
+			sddl:    "O:BAD:NO_ACCESS_CONTROL",
+			wantNil: true,
+		},
+		{
+			name:    "No DACL",
+			sddl:    "O:BAG:SYS:(AU;SA;FA;;;WD)",
+			wantNil: true,
+		},
+		{
+			// Only the DACL is decoded, a corrupt owner SID is not noticed
+			name: "Corrupt owner",
+			sddl: "O:BAG:SYD:(A;;FA;;;SY)",
+			corrupt: func(data []byte) {
+				data[binary.LittleEndian.Uint32(data[4:8])+1] = 0xFF // SubAuthorityCount
+			},
+		},
+		{
+			// Like Windows, the DACL offset is ignored without SE_DACL_PRESENT
+			name: "DACL offset without SE_DACL_PRESENT",
+			sddl: "O:BAG:SYD:(A;;FA;;;SY)",
+			corrupt: func(data []byte) {
+				data[2] &^= seDACLPresent
+			},
+			wantNil: true,
+		},
+		{
+			name: "DACL offset out of range",
+			sddl: "O:BAG:SYD:(A;;FA;;;SY)",
+			corrupt: func(data []byte) {
+				binary.LittleEndian.PutUint32(data[16:20], uint32(len(data)))
+			},
+			wantFail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := mustFromString(t, tt.sddl).Binary()
+			want, err := FromBinary(data)
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			if tt.corrupt != nil {
+				tt.corrupt(data)
+			}
+
+			got, err := DACLFromBinary(data)
+			if tt.wantFail {
+				if err == nil {
+					t.Errorf("DACLFromBinary() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DACLFromBinary() unexpected error = %v", err)
+			}
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("DACLFromBinary() = %s, want nil", got)
+				}
+
+				// FromBinary agrees
+				sd, err := FromBinary(data)
+				if err != nil {
+					t.Fatalf("FromBinary() unexpected error = %v", err)
+				}
+				if sd.DACL() != nil {
+					t.Errorf("FromBinary().DACL() = %s, want nil", sd.DACL())
+				}
+				if err := sd.Validate(); err != nil {
+					t.Errorf("FromBinary().Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			compareACLs(t, "DACLFromBinary()", got, want.DACL())
+		})
+	}
+
+	if _, err := DACLFromBinary(make([]byte, DefaultMaxDescriptorSize+1)); !errors.Is(err, ErrDescriptorTooLarge) {
+		t.Errorf("DACLFromBinary() with oversized data error = %v, want %v", err, ErrDescriptorTooLarge)
+	}
+}
+
+func TestFromBinaryWithOptions_Partial(t *testing.T) {
+	t.Parallel()
+
+	sd := mustFromString(t, "O:SYG:BAD:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)")
+	valid := sd.Binary()
+	offsets := make(map[string]int)
+	for _, entry := range sd.BinaryLayout() {
+		offsets[entry.Name] = entry.Offset
+	}
+
+	// corrupt returns a copy of valid with the given bytes overwritten at the offset of the component
+	corrupt := func(component string, at int, b ...byte) []byte {
+		data := slices.Clone(valid)
+		copy(data[offsets[component]+at:], b)
+		return data
+	}
+	// An AceCount of 5 makes the ACL parser read past its AclSize
+	badDACL := corrupt("DACL", 4, 0x05)
+	// 16 sub-authorities are more than a SID can have
+	badOwnerAndDACL := slices.Clone(badDACL)
+	badOwnerAndDACL[offsets["Owner"]+1] = 16
+	badSACLOffset := slices.Clone(valid)
+	binary.LittleEndian.PutUint32(badSACLOffset[12:16], uint32(len(valid))+4)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		opts     ParseOptions
+		want     string // String of the returned security descriptor, "-" for none
+		wantErrs []string
+	}{
+		{
+			name:     "Malformed DACL by default",
+			data:     badDACL,
+			want:     "-",
+			wantErrs: []string{"error parsing DACL"},
+		},
+		{
+			name:     "Malformed DACL",
+			data:     badDACL,
+			opts:     ParseOptions{Partial: true},
+			want:     "O:SYG:BAS:(AU;SA;FA;;;WD)",
+			wantErrs: []string{"error parsing DACL"},
+		},
+		{
+			name:     "Malformed owner and DACL",
+			data:     badOwnerAndDACL,
+			opts:     ParseOptions{Partial: true},
+			want:     "G:BAS:(AU;SA;FA;;;WD)",
+			wantErrs: []string{"error parsing owner SID", "error parsing DACL"},
+		},
+		{
+			name:     "SACL offset out of range",
+			data:     badSACLOffset,
+			opts:     ParseOptions{Partial: true},
+			want:     "O:SYG:BAD:(A;;FA;;;SY)",
+			wantErrs: []string{"SACL offset"},
+		},
+		{
+			name:     "Truncated header",
+			data:     valid[:16],
+			opts:     ParseOptions{Partial: true},
+			want:     "-",
+			wantErrs: []string{"20 bytes"},
+		},
+		{
+			name: "Valid descriptor",
+			data: valid,
+			opts: ParseOptions{Partial: true},
+			want: "O:SYG:BAD:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := FromBinaryWithOptions(tt.data, tt.opts)
+			for _, wantErr := range tt.wantErrs {
+				if err == nil || !strings.Contains(err.Error(), wantErr) {
+					t.Errorf("FromBinaryWithOptions() error = %v, want an error containing %q", err, wantErr)
+				}
+			}
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Fatalf("FromBinaryWithOptions() unexpected error = %v", err)
+			}
+
+			if tt.want == "-" {
+				if got != nil {
+					t.Errorf("FromBinaryWithOptions() = %s, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("FromBinaryWithOptions() = nil, want a security descriptor")
+			}
+			if str := got.String(); str != tt.want {
+				t.Errorf("String() = %s, want %s", str, tt.want)
+			}
+			// A DACL that failed to decode is not a NULL DACL
+			if got.HasNullDACL() {
+				t.Error("HasNullDACL() = true, want false")
+			}
+		})
+	}
+}
+
+func TestFromBinary_DefaultedOwnerOffset(t *testing.T) {
+	t.Parallel()
+
+	// header returns a security descriptor header with the given control flags and owner and group offsets
+	header := func(control uint16, ownerOffset, groupOffset uint32) []byte {
+		data := []byte{0x01, 0x00}
+		data = binary.LittleEndian.AppendUint16(data, control)
+		data = binary.LittleEndian.AppendUint32(data, ownerOffset)
+		data = binary.LittleEndian.AppendUint32(data, groupOffset)
+		return append(data, make([]byte, 8)...) // No SACL nor DACL
+	}
+	system := []byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00}                         // S-1-5-18
+	admins := []byte{0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00, 0x20, 0x02, 0x00, 0x00} // S-1-5-32-544
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantOwner string // "" for no owner
+		wantGroup string // "" for no group
+	}{
+		{
+			name:      "Defaulted owner with an offset",
+			data:      append(header(seSelfRelative|seOwnerDefaulted, 20, 0), system...),
+			wantOwner: "SY",
+		},
+		{
+			name:      "Defaulted owner and group with offsets",
+			data:      append(append(header(seSelfRelative|seOwnerDefaulted|seGroupDefaulted, 20, 32), system...), admins...),
+			wantOwner: "SY",
+			wantGroup: "BA",
+		},
+		{
+			name: "Defaulted owner without offset",
+			data: append(header(seSelfRelative|seOwnerDefaulted, 0, 0), system...),
+		},
+		{
+			name:      "Owner with an offset, defaulted group without offset",
+			data:      append(header(seSelfRelative|seGroupDefaulted, 20, 0), system...),
+			wantOwner: "SY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromBinary(tt.data)
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			for _, c := range []struct {
+				name string
+				sid  *SID
+				want string
+			}{
+				{"owner", sd.ownerSID, tt.wantOwner},
+				{"group", sd.groupSID, tt.wantGroup},
+			} {
+				var got string
+				if c.sid != nil {
+					got = c.sid.String()
+				}
+				if got != c.want {
+					t.Errorf("FromBinary() %s = %q, want %q", c.name, got, c.want)
+				}
+			}
+
+			// The defaulted flags are kept as they are
+			if got, want := sd.control, binary.LittleEndian.Uint16(tt.data[2:4]); got != want {
+				t.Errorf("FromBinary() control = 0x%04X, want 0x%04X", got, want)
+			}
+		})
+	}
+}
+
+func TestFromBinary_CallbackApplicationData(t *testing.T) {
+	t.Parallel()
+
+	// Opaque application data, as found after the SID of callback ACEs
+	applicationData := []byte{'a', 'r', 't', 'x', 0xF8, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	data := []byte{
+		// Header
+		0x01,       // Revision
+		0x00,       // Sbz1
+		0x04, 0x80, // Control (SE_SELF_RELATIVE | SE_DACL_PRESENT)
+		0x00, 0x00, 0x00, 0x00, // Owner offset
+		0x00, 0x00, 0x00, 0x00, // Group offset
+		0x00, 0x00, 0x00, 0x00, // Sacl offset
+		0x14, 0x00, 0x00, 0x00, // Dacl offset (20)
+		// DACL
+		0x02,       // Revision
+		0x00,       // Sbz1
+		0x28, 0x00, // Size (40 bytes)
+		0x01, 0x00, // AceCount
+		0x00, 0x00, // Sbz2
+		// ACE
+		0x09,       // Type (ACCESS_ALLOWED_CALLBACK_ACE_TYPE)
+		0x00,       // Flags
+		0x20, 0x00, // Size (32 bytes)
+		0xFF, 0x01, 0x1F, 0x00, // Access mask (Full Access)
+		0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // Everyone
+	}
+	data = append(data, applicationData...)
+
+	// The application data is part of the content of the ACE, which is not too large for its content
+	for _, opts := range []ParseOptions{{}, {StrictACESize: true}} {
+		sd, err := FromBinaryWithOptions(data, opts)
+		if err != nil {
+			t.Fatalf("FromBinaryWithOptions(%+v) unexpected error = %v", opts, err)
+		}
+
+		ace := sd.DACL().ACEs()[0]
+		if got := ace.ApplicationData(); !bytes.Equal(got, applicationData) {
+			t.Errorf("ApplicationData() = % x, want % x", got, applicationData)
+		}
+		if got := sd.Binary(); !bytes.Equal(got, data) {
+			t.Errorf("Binary() = % x, want % x", got, data)
+		}
+
+		// The application data survives the XML representation, and makes ACEs different
+		xml, err := sd.ToXML()
+		if err != nil {
+			t.Fatalf("ToXML() unexpected error = %v", err)
+		}
+		fromXML, err := FromXML(xml)
+		if err != nil {
+			t.Fatalf("FromXML() unexpected error = %v", err)
+		}
+		if got := fromXML.Binary(); !bytes.Equal(got, data) {
+			t.Errorf("FromXML(ToXML()).Binary() = % x, want % x", got, data)
+		}
+		withoutData := newACE(accessAllowedCallbackACEType, 0, FileAllAccess, NewSID(1, 0))
+		if ace.Equal(withoutData) {
+			t.Error("Equal() = true for callback ACEs with different application data, want false")
+		}
+	}
+}
+
+func TestFromBinary_OwnerWithInvalidRevision(t *testing.T) {
+	t.Parallel()
+
+	// The owner SID has revision 0, which String couldn't write
+	data, err := hex.DecodeString("010027800000000029000000000000001400000002002c000100000000002400ff011f00" +
+		"0105000090000005150000000100000002d7000003000000f40100df")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromBinary(data); err == nil {
+		t.Error("FromBinary() error = nil, want error")
+	}
+}
@@ -1,17 +1,40 @@
 package sddl
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // wellKnownRIDs maps short names to Relative Identifiers (RIDs) for well-known security principals
 // as defined in [MS-DTYP] section 2.4.2.4 Well-known SID Structures.
 // https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/81d92bba-d22b-4a8c-908a-554ab29148ab
+//
+// Aliases of groups of the forest root domain (EA, SA, PA, RO and EK) are resolved against the same
+// domain as the others, as the root domain cannot be known from the descriptor.
 var wellKnownRIDs = map[string]rid{
 	"LA": 500, // DOMAIN_USER_RID_ADMIN (Local Administrator)
 	"LG": 501, // DOMAIN_USER_RID_GUEST (Local Guest)
+	"RO": 498, // Enterprise Read-only Domain Controllers
+	"DA": 512, // Domain Admins
+	"DU": 513, // Domain Users
+	"DG": 514, // Domain Guests
+	"DC": 515, // Domain Computers
+	"DD": 516, // Domain Controllers
+	"CA": 517, // Cert Publishers
+	"SA": 518, // Schema Admins
+	"EA": 519, // Enterprise Admins
+	"PA": 520, // Group Policy Creator Owners
+	"CN": 522, // Cloneable Domain Controllers
+	"AP": 525, // Protected Users
+	"KA": 526, // Key Admins
+	"EK": 527, // Enterprise Key Admins
+	"RS": 553, // RAS and IAS Servers
 }
 
 // sidHolder represents any structure capable of containing zero or more Security Identifiers (SIDs).
@@ -24,27 +47,27 @@ var wellKnownRIDs = map[string]rid{
 // Implementations of this interface should provide a method to access all contained SIDs.
 type sidHolder interface {
 	// sids returns a slice of all SIDs contained within the implementing structure.
-	sids() []sid
+	sids() []SID
 }
 
 // making existing structures implement sidHolder
 
-var _ sidHolder = &sid{}
+var _ sidHolder = &SID{}
 
-func (s *sid) sids() []sid { // implements sidHolder
-	return []sid{*s}
+func (s *SID) sids() []SID { // implements sidHolder
+	return []SID{*s}
 }
 
-var _ sidHolder = &ace{}
+var _ sidHolder = &ACE{}
 
-func (a *ace) sids() []sid { // implements sidHolder
-	return []sid{*a.sid}
+func (a *ACE) sids() []SID { // implements sidHolder
+	return []SID{*a.sid}
 }
 
-var _ sidHolder = &acl{}
+var _ sidHolder = &ACL{}
 
-func (a *acl) sids() []sid { // implements sidHolder
-	var sids []sid
+func (a *ACL) sids() []SID { // implements sidHolder
+	var sids []SID
 	for _, ace := range a.aces {
 		sids = append(sids, ace.sids()...)
 	}
@@ -74,12 +97,12 @@ type parseSIDStringResult interface {
 	//   - previousSIDs: A slice of previously parsed SIDs to provide context
 	//
 	// Returns:
-	//   - *sid: A pointer to the complete SID structure
+	//   - *SID: A pointer to the complete SID structure
 	//   - error: An error if the conversion fails
-	toSID(previousSIDs []sid) (*sid, error)
+	toSID(previousSIDs []SID) (*SID, error)
 }
 
-func (s *sid) toSID(previousSIDs []sid) (*sid, error) {
+func (s *SID) toSID(previousSIDs []SID) (*SID, error) {
 	// sid structure is a valid parseSIDStringResult and represents a complete SID
 	return s, nil
 }
@@ -89,7 +112,7 @@ func (s *sid) toSID(previousSIDs []sid) (*sid, error) {
 // RIDs are typically used in domain environments to uniquely identify users, groups, or other security principals.
 type rid uint32
 
-func (r rid) toSID(previousSIDs []sid) (*sid, error) {
+func (r rid) toSID(previousSIDs []SID) (*SID, error) {
 	if len(previousSIDs) == 0 {
 		return nil, ErrMissingDomainInformation
 	}
@@ -102,8 +125,8 @@ func (r rid) toSID(previousSIDs []sid) (*sid, error) {
 	return s, nil
 }
 
-func (r rid) sids() []sid {
-	return []sid{}
+func (r rid) sids() []SID {
+	return []SID{}
 }
 
 // complete converts a Relative Identifier (RID) into a complete SID by combining it with the information from an existing SID.
@@ -113,9 +136,9 @@ func (r rid) sids() []sid {
 //   - s: An existing SID to provide the domain information
 //
 // Returns:
-//   - *sid: A pointer to a new, complete SID that includes the RID
+//   - *SID: A pointer to a new, complete SID that includes the RID
 //   - error: If the sid does not contain sub authorities (first sub-authority is required)
-func (r rid) complete(s sid) (*sid, error) {
+func (r rid) complete(s SID) (*SID, error) {
 	if len(s.subAuthority) == 0 {
 		return nil, ErrMissingSubAuthorities
 	}
@@ -128,7 +151,7 @@ func (r rid) complete(s sid) (*sid, error) {
 	subAuthorities = append(subAuthorities, domain...)
 	subAuthorities = append(subAuthorities, uint32(r))
 
-	return &sid{
+	return &SID{
 		revision:            s.revision,
 		identifierAuthority: s.identifierAuthority,
 		subAuthority:        subAuthorities,
@@ -136,7 +159,7 @@ func (r rid) complete(s sid) (*sid, error) {
 }
 
 // parseACEStringResult represents the outcome of an ACE parsing operation.
-// It mimics the ACE structure (ace) but instead of a sid, it contains a parseSIDStringResult.
+// It mimics the ACE structure but instead of a SID, it contains a parseSIDStringResult.
 type parseACEStringResult struct {
 	// header contains the ACE header information
 	header *aceHeader
@@ -144,9 +167,17 @@ type parseACEStringResult struct {
 	accessMask uint32
 	// sid represents the Security Identifier (SID) associated with this ACE
 	sid parseSIDStringResult
+	// objectType is the object type GUID of an object ACE, if any
+	objectType *GUID
+	// inheritedObjectType is the inherited object type GUID of an object ACE, if any
+	inheritedObjectType *GUID
+	// applicationData is the encoded condition of a callback ACE or attribute of a resource attribute ACE
+	applicationData []byte
+	// rawMask is set when the ACE was parsed with ParseOptions.RawMasks
+	rawMask bool
 }
 
-func (a *parseACEStringResult) sids() []sid {
+func (a *parseACEStringResult) sids() []SID {
 	return a.sid.sids()
 }
 
@@ -157,30 +188,34 @@ func (a *parseACEStringResult) sids() []sid {
 //   - previousSIDs: A slice of previously parsed SIDs to provide context for incomplete SIDs
 //
 // Returns:
-//   - *ace: A pointer to the complete ACE structure
+//   - *ACE: A pointer to the complete ACE structure
 //   - error: An error if the conversion fails, particularly if SID resolution fails
-func (a *parseACEStringResult) toACE(previousSIDs []sid) (*ace, error) {
+func (a *parseACEStringResult) toACE(previousSIDs []SID) (*ACE, error) {
 	sid, err := a.sid.toSID(previousSIDs)
 	if err != nil {
 		return nil, err
 	}
 
+	ace := &ACE{
+		header:              a.header,
+		accessMask:          a.accessMask,
+		sid:                 sid,
+		objectType:          a.objectType,
+		inheritedObjectType: a.inheritedObjectType,
+		applicationData:     a.applicationData,
+		rawMask:             a.rawMask,
+	}
+
 	// Calculate the total size of the ACE
-	// Size = sizeof(ACE_HEADER) + sizeof(ACCESS_MASK) + size of the SID
-	// SID size = 8 + (4 * number of sub-authorities)
-	sidSize := 8 + (4 * len(sid.subAuthority))
-	aceSize := 4 + 4 + sidSize // 4 (header) + 4 (access mask) + sidSize
-	a.header.aceSize = uint16(aceSize)
-
-	return &ace{
-		header:     a.header,
-		accessMask: a.accessMask,
-		sid:        sid,
-	}, nil
+	// Size = sizeof(ACE_HEADER) + sizeof(ACCESS_MASK) + object fields (object ACEs only) + size of the SID
+	// + application data
+	ace.header.aceSize = uint16(ace.BinarySize())
+
+	return ace, nil
 }
 
 // parseACLStringResult represents the outcome of an ACL parsing operation.
-// It mimics the ACL structure (acl) but instead of a slice of aces, it contains a slice of parseACEStringResult.
+// It mimics the ACL structure but instead of a slice of aces, it contains a slice of parseACEStringResult.
 type parseACLStringResult struct {
 	// aclRevision is the revision level of the ACL structure
 	aclRevision byte
@@ -196,12 +231,16 @@ type parseACLStringResult struct {
 	aclType string
 	// control contains ACL control flags
 	control uint16
+	// sddlFlags holds the ACL flags that have no control flag, see ACL.sddlFlags
+	sddlFlags byte
+	// noAccessControl is set when the ACL is "NO_ACCESS_CONTROL", a NULL ACL that has no structure
+	noAccessControl bool
 	// aces is a slice of parsed ACE results
 	aces []parseACEStringResult
 }
 
-func (a *parseACLStringResult) sids() []sid {
-	var sids []sid
+func (a *parseACLStringResult) sids() []SID {
+	var sids []SID
 	for _, ace := range a.aces {
 		sids = append(sids, ace.sids()...)
 	}
@@ -215,10 +254,10 @@ func (a *parseACLStringResult) sids() []sid {
 //   - previousSIDs: A slice of previously parsed SIDs to provide context for incomplete SIDs in ACEs
 //
 // Returns:
-//   - *acl: A pointer to the complete ACL structure
+//   - *ACL: A pointer to the complete ACL structure
 //   - error: An error if the conversion fails, particularly if SID resolution fails in any ACE
-func (a *parseACLStringResult) toACL(previousSIDs []sid) (*acl, error) {
-	var aces []ace
+func (a *parseACLStringResult) toACL(previousSIDs []SID) (*ACL, error) {
+	var aces []ACE
 	for _, ace := range a.aces {
 		ace, err := ace.toACE(previousSIDs)
 		if err != nil {
@@ -234,7 +273,7 @@ func (a *parseACLStringResult) toACL(previousSIDs []sid) (*acl, error) {
 	}
 	a.aclSize = uint16(totalSize)
 
-	return &acl{
+	return &ACL{
 		aclRevision: a.aclRevision,
 		sbzl:        a.sbzl,
 		aclSize:     a.aclSize,
@@ -242,19 +281,124 @@ func (a *parseACLStringResult) toACL(previousSIDs []sid) (*acl, error) {
 		sbz2:        a.sbz2,
 		aclType:     a.aclType,
 		control:     a.control,
+		sddlFlags:   a.sddlFlags,
 		aces:        aces,
 	}, nil
 }
 
 // FromString parses a security descriptor string in SDDL format.
 // The format is: "O:owner_sidG:group_sidD:dacl_flagsS:sacl_flags"
-// where each component is optional.
+// where each component is optional. As on Windows, an owner or group component must hold a SID: an
+// empty one, such as the group of "O:SYG:D:(A;;FA;;;SY)", is an error, while an empty DACL or SACL is valid.
 //
 // Examples:
 // - "O:SYG:BAD:(A;;FA;;;SY)"            - Owner: SYSTEM, Group: BUILTIN\Administrators, DACL with full access for SYSTEM
 // - "O:SYG:SYD:PAI(A;;FA;;;SY)"         - Protected auto-inherited DACL
 // - "O:SYG:SYD:(A;;FA;;;SY)S:(AU;SA;FA;;;SY)" - With both DACL and SACL
 func FromString(s string) (*SecurityDescriptor, error) {
+	return fromString(s, ParseOptions{})
+}
+
+// FromStringWithOptions is like FromString but parses the security descriptor string according to opts.
+func FromStringWithOptions(s string, opts ParseOptions) (*SecurityDescriptor, error) {
+	return fromString(s, opts)
+}
+
+// ParseError is returned by FromString and FromStringWithOptions when the string is malformed, to tell
+// where parsing failed, e.g. for an editor to highlight it. It wraps ErrInvalidSDFormat and the error
+// that stopped the parsing, so that both can be checked with errors.Is.
+type ParseError struct {
+	// Offset is the byte offset in the string of the ACE that failed to parse, or else of the component
+	// ("O:", "G:", "D:" or "S:") or of the unexpected content. With ParseOptions.StripComments, it is an
+	// offset in the string without its comments.
+	Offset int
+	// ACE is the index of the ACE that failed to parse in its ACL, or -1 if the error is not about an ACE.
+	ACE int
+	// Err is the error that stopped the parsing.
+	Err error
+}
+
+// Error returns the description of the error, including its position.
+func (e *ParseError) Error() string {
+	if e.ACE >= 0 {
+		return fmt.Sprintf("%v at offset %d (ACE %d): %v", ErrInvalidSDFormat, e.Offset, e.ACE, e.Err)
+	}
+	return fmt.Sprintf("%v at offset %d: %v", ErrInvalidSDFormat, e.Offset, e.Err)
+}
+
+// Unwrap returns ErrInvalidSDFormat and the error that stopped the parsing.
+func (e *ParseError) Unwrap() []error {
+	return []error{ErrInvalidSDFormat, e.Err}
+}
+
+// aceParseError is returned by parseACLString for an ACE that failed to parse, to report its position
+// in a ParseError. It reads like the error it wraps.
+type aceParseError struct {
+	// index is the index of the ACE in the ACL
+	index int
+	// offset is the byte offset of the ACE in the ACL string
+	offset int
+	err    error
+}
+
+func (e *aceParseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *aceParseError) Unwrap() error {
+	return e.err
+}
+
+// componentMarkers are the markers starting the components of a security descriptor string, and
+// componentNames their names in errors. A component ends at the next marker, including the markers of
+// the components already parsed, so that a duplicate component is reported as such.
+var (
+	componentMarkers = []string{"O:", "G:", "D:", "S:"}
+	componentNames   = []string{"owner", "group", "DACL", "SACL"}
+)
+
+// errEmptyComponent is returned by parseSIDComponent for an owner or group component without a SID.
+var errEmptyComponent = errors.New("empty component")
+
+// unescape undoes the escaping of s according to u, see ParseOptions.Unescape.
+func unescape(s string, u Unescaping) (string, error) {
+	switch u {
+	case UnescapeJSON:
+		var unescaped string
+		if err := json.Unmarshal([]byte(`"`+s+`"`), &unescaped); err != nil {
+			return "", fmt.Errorf("invalid JSON escaping: %w", err)
+		}
+		return unescaped, nil
+	case UnescapeURL:
+		unescaped, err := url.PathUnescape(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid URL escaping: %w", err)
+		}
+		return unescaped, nil
+	default:
+		return s, nil
+	}
+}
+
+// fromString implements FromString.
+func fromString(s string, opts ParseOptions) (*SecurityDescriptor, error) {
+	if opts.Unescape != UnescapeNone {
+		unescaped, err := unescape(s, opts.Unescape)
+		if err != nil {
+			return nil, &ParseError{Offset: 0, ACE: -1, Err: err}
+		}
+		s = unescaped
+	}
+	if opts.StripComments {
+		s = StripComments(s, opts.commentMarker())
+	}
+	// lead is the length of the leading whitespace trimmed from s, to report offsets in the original string
+	var lead int
+	if opts.Lenient {
+		lead = len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace))
+		s = strings.TrimSpace(s)
+	}
+
 	// Initialize security descriptor with self-relative flag
 	sd := &SecurityDescriptor{
 		revision: 1,
@@ -271,7 +415,7 @@ func FromString(s string) (*SecurityDescriptor, error) {
 
 	// parsing results
 	var (
-		completeSIDs []sid
+		completeSIDs []SID
 		ownerSID     parseSIDStringResult
 		groupSID     parseSIDStringResult
 		dacl         *parseACLStringResult
@@ -281,7 +425,7 @@ func FromString(s string) (*SecurityDescriptor, error) {
 	// Parse each component in order if present
 	// The order doesn't technically matter, so, we are going to keep a list of pending components to parse
 	// and remove them as we go
-	pendingComponents := []string{"O:", "G:", "D:", "S:"}
+	pendingComponents := slices.Clone(componentMarkers)
 	removePendingComponent := func(component string) {
 		for i, c := range pendingComponents {
 			if c == component {
@@ -291,21 +435,55 @@ func FromString(s string) (*SecurityDescriptor, error) {
 		}
 	}
 
+	// duplicateComponent returns an error if remaining starts with the marker of a component already parsed
+	duplicateComponent := func(remaining string) error {
+		for i, marker := range componentMarkers {
+			if strings.HasPrefix(remaining, marker) && !slices.Contains(pendingComponents, marker) {
+				return fmt.Errorf("duplicate %s component", componentNames[i])
+			}
+		}
+		return nil
+	}
+
+	// syntaxError returns a ParseError for err, found in the component starting at offset start in s
+	syntaxError := func(start int, err error) error {
+		pe := &ParseError{Offset: lead + start, ACE: -1, Err: err}
+		var aceErr *aceParseError
+		if errors.As(err, &aceErr) {
+			// The ACL follows the two characters of the component marker
+			pe.Offset += 2 + aceErr.offset
+			pe.ACE = aceErr.index
+		}
+		return pe
+	}
+
 	// If there is data, then, at least one component must be present
 	if findNextComponent(remaining, pendingComponents...) == -1 {
-		return nil, fmt.Errorf("no components found in security descriptor")
+		return nil, syntaxError(0, fmt.Errorf("no components found in security descriptor"))
 	}
 
 	// Parse each component regardless of their order, as long as there are remaining characters and pending components
 	for len(pendingComponents) > 0 && len(remaining) > 0 {
+		if opts.Lenient {
+			// Skip the whitespace between components
+			remaining = strings.TrimLeftFunc(remaining, unicode.IsSpace)
+		}
+		start := len(s) - len(remaining)
+		if err := duplicateComponent(remaining); err != nil {
+			return nil, syntaxError(start, err)
+		}
+
 		switch {
 		case strings.HasPrefix(remaining, "O:"):
 			// remove O: prefix
 			remaining = remaining[2:]
 			removePendingComponent("O:")
-			ownerSID, remaining, err = parseSIDComponent(remaining, pendingComponents...)
+			ownerSID, remaining, err = parseSIDComponent(remaining, opts, componentMarkers...)
 			if err != nil {
-				return nil, fmt.Errorf("error parsing owner SID: %w", err)
+				if errors.Is(err, errEmptyComponent) {
+					return nil, syntaxError(start, errors.New("empty owner component"))
+				}
+				return nil, syntaxError(start, fmt.Errorf("error parsing owner SID: %w", err))
 			}
 			sd.control ^= seOwnerDefaulted
 
@@ -313,9 +491,12 @@ func FromString(s string) (*SecurityDescriptor, error) {
 			// remove G: prefix
 			remaining = remaining[2:]
 			removePendingComponent("G:")
-			groupSID, remaining, err = parseSIDComponent(remaining, pendingComponents...)
+			groupSID, remaining, err = parseSIDComponent(remaining, opts, componentMarkers...)
 			if err != nil {
-				return nil, fmt.Errorf("error parsing group SID: %w", err)
+				if errors.Is(err, errEmptyComponent) {
+					return nil, syntaxError(start, errors.New("empty group component"))
+				}
+				return nil, syntaxError(start, fmt.Errorf("error parsing group SID: %w", err))
 			}
 			sd.control ^= seGroupDefaulted
 
@@ -323,9 +504,9 @@ func FromString(s string) (*SecurityDescriptor, error) {
 			// remove D: prefix
 			remaining = remaining[2:]
 			removePendingComponent("D:")
-			dacl, remaining, err = parseACLComponent("D", remaining, pendingComponents...)
+			dacl, remaining, err = parseACLComponent("D", remaining, opts, componentMarkers...)
 			if err != nil {
-				return nil, fmt.Errorf("error parsing DACL: %w", err)
+				return nil, syntaxError(start, fmt.Errorf("error parsing DACL: %w", err))
 			}
 			sd.control ^= seDACLDefaulted
 			sd.control |= seDACLPresent
@@ -334,18 +515,24 @@ func FromString(s string) (*SecurityDescriptor, error) {
 			// remove S: prefix
 			remaining = remaining[2:]
 			removePendingComponent("S:")
-			sacl, remaining, err = parseACLComponent("S", remaining, pendingComponents...)
+			sacl, remaining, err = parseACLComponent("S", remaining, opts, componentMarkers...)
 			if err != nil {
-				return nil, fmt.Errorf("error parsing SACL: %w", err)
+				return nil, syntaxError(start, fmt.Errorf("error parsing SACL: %w", err))
 			}
 			sd.control ^= seSACLDefaulted
 			sd.control |= seSACLPresent
+
+		default:
+			return nil, syntaxError(start, fmt.Errorf("unexpected content before component: %s", remaining))
 		}
 	}
 
 	// If there's anything left unparsed, it's an error
 	if remaining != "" {
-		return nil, fmt.Errorf("unexpected content after parsing: %s", remaining)
+		if err := duplicateComponent(remaining); err != nil {
+			return nil, syntaxError(len(s)-len(remaining), err)
+		}
+		return nil, syntaxError(len(s)-len(remaining), fmt.Errorf("unexpected content after parsing: %s", remaining))
 	}
 
 	// convert parsed result components into final structures
@@ -396,51 +583,76 @@ func FromString(s string) (*SecurityDescriptor, error) {
 	}
 
 	// update control flags based on ACLs
-	if sd.dacl != nil {
+	if dacl != nil {
 		// Update control flags based on DACL flags
-		if sd.dacl.control&seDACLProtected != 0 {
+		if dacl.control&seDACLProtected != 0 {
 			sd.control |= seDACLProtected
 		}
-		if sd.dacl.control&seDACLAutoInherited != 0 {
+		if dacl.control&seDACLAutoInherited != 0 {
 			sd.control |= seDACLAutoInherited
 		}
-		if sd.dacl.control&seDACLAutoInheritRe != 0 {
+		if dacl.control&seDACLAutoInheritRe != 0 {
 			sd.control |= seDACLAutoInheritRe
 		}
+		if dacl.control&seDACLDefaulted != 0 {
+			sd.control |= seDACLDefaulted
+		}
 	}
-	if sd.sacl != nil {
+	if sacl != nil {
 		// Update control flags based on SACL flags
-		if sd.sacl.control&seSACLProtected != 0 {
+		if sacl.control&seSACLProtected != 0 {
 			sd.control |= seSACLProtected
 		}
-		if sd.sacl.control&seSACLAutoInherited != 0 {
+		if sacl.control&seSACLAutoInherited != 0 {
 			sd.control |= seSACLAutoInherited
 		}
-		if sd.sacl.control&seSACLAutoInheritRe != 0 {
+		if sacl.control&seSACLAutoInheritRe != 0 {
 			sd.control |= seSACLAutoInheritRe
 		}
+		if sacl.control&seSACLDefaulted != 0 {
+			sd.control |= seSACLDefaulted
+		}
 	}
 
-	// Adjust ACL's control flags once they are fully computed
+	// Adjust ACL's control flags once they are fully computed, keeping only the ones describing each ACL
 	if sd.dacl != nil {
-		sd.dacl.control = sd.control
+		sd.dacl.control = aclControl("D", sd.control)
 	}
 	if sd.sacl != nil {
-		sd.sacl.control = sd.control
+		sd.sacl.control = aclControl("S", sd.control)
+	}
+
+	// NULL ACLs are present, but have no ACL structure
+	if dacl != nil && dacl.noAccessControl {
+		sd.dacl = nil
+	}
+	if sacl != nil && sacl.noAccessControl {
+		sd.sacl = nil
 	}
 
 	return sd, nil
 }
 
-func parseSIDComponent(s string, nextMarkers ...string) (sid parseSIDStringResult, remaining string, err error) {
+func parseSIDComponent(s string, opts ParseOptions, nextMarkers ...string) (sid parseSIDStringResult, remaining string, err error) {
 	// Find the next component marker (G:, D:, or S:)
 	sidEnd := findNextComponent(s, nextMarkers...)
 	if sidEnd == -1 {
 		sidEnd = len(s)
 	}
 
+	sidStr := s[:sidEnd]
+	if opts.Lenient {
+		sidStr = strings.TrimSpace(sidStr)
+	}
+
+	// Windows rejects an owner or group without a SID, such as the group of "O:SYG:D:(A;;FA;;;SY)": report
+	// it as such rather than as a malformed SID
+	if sidStr == "" {
+		return nil, "", errEmptyComponent
+	}
+
 	// Parse the SID string
-	sid, err = parseSIDString(s[:sidEnd])
+	sid, err = parseSIDStringWithOptions(sidStr, opts)
 	if err != nil {
 		return nil, "", fmt.Errorf("invalid SID: %w", err)
 	}
@@ -448,7 +660,7 @@ func parseSIDComponent(s string, nextMarkers ...string) (sid parseSIDStringResul
 	return sid, s[sidEnd:], nil
 }
 
-func parseACLComponent(aclType, s string, nextMarkers ...string) (aclr *parseACLStringResult, remaining string, err error) {
+func parseACLComponent(aclType, s string, opts ParseOptions, nextMarkers ...string) (aclr *parseACLStringResult, remaining string, err error) {
 	// Find the next marker (if any)
 	aclEnd := len(s)
 	if len(nextMarkers) > 0 {
@@ -459,7 +671,7 @@ func parseACLComponent(aclType, s string, nextMarkers ...string) (aclr *parseACL
 	}
 
 	// Parse the ACL string
-	aclr, err = parseACLString(aclType, s[:aclEnd])
+	aclr, err = parseACLString(aclType, s[:aclEnd], opts)
 	if err != nil {
 		return nil, "", fmt.Errorf("invalid ACL: %w", err)
 	}
@@ -469,21 +681,51 @@ func parseACLComponent(aclType, s string, nextMarkers ...string) (aclr *parseACL
 
 // findNextComponent looks for the next component marker given in arguments
 // Returns the index of the next component or -1 if none found
+// Markers are only recognized at the top level: text inside the parentheses of an ACE, including
+// nested conditional expressions, and inside double-quoted string literals is skipped, so that
+// it can never be mistaken for a component boundary.
 func findNextComponent(s string, markers ...string) int {
-	minIndex := -1
-	for _, marker := range markers {
-		if idx := strings.Index(s, marker); idx != -1 {
-			if minIndex == -1 || idx < minIndex {
-				minIndex = idx
+	depth := 0
+	inLiteral := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			inLiteral = !inLiteral
+		case inLiteral:
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0:
+			for _, marker := range markers {
+				if strings.HasPrefix(s[i:], marker) {
+					return i
+				}
 			}
 		}
 	}
 
-	return minIndex
+	return -1
 }
 
 // parseAccessMask converts an access mask string to its corresponding uint32 value
+// An empty string is a zero mask, which is also how ACE.String represents it.
+//
+// The mask can also combine several parts separated by "|", such as "FR|0x100|SD", each of them being a
+// well-known mask, a hexadecimal value or a sequence of two-letter codes: the string is split on "|" first,
+// then each part is parsed on its own and the parts are OR-ed, so their order doesn't matter. Windows
+// doesn't know this syntax, so ACE.String never writes it: "FR|0x800000" is written as "0x00920089".
 func parseAccessMask(maskStr string) (uint32, error) {
+	if maskStr == "" {
+		return 0, nil
+	}
+
+	if strings.Contains(maskStr, "|") {
+		return parseAccessMaskParts(maskStr, parseAccessMask)
+	}
+
 	// Check well-known access masks first
 	if value, ok := reverseWellKnownAccessMasks[maskStr]; ok {
 		return value, nil
@@ -499,6 +741,9 @@ func parseAccessMask(maskStr string) (uint32, error) {
 	}
 
 	// If not a hexadecimal, try to use two-letter codes
+	if len(maskStr)%2 != 0 {
+		return 0, fmt.Errorf("unknown access mask: %s", maskStr)
+	}
 
 	var components []string
 	var idx int
@@ -515,22 +760,77 @@ func parseAccessMask(maskStr string) (uint32, error) {
 	return 0, fmt.Errorf("unknown access mask: %s", maskStr)
 }
 
-// parseACEString parses an ACE string in the format "(type;flags;rights;;;sid)" into an ACE structure
+// parseAccessMaskParts parses the access mask maskStr made of parts separated by "|", such as "FR|0x100",
+// each parsed by parsePart, and returns their union.
+func parseAccessMaskParts(maskStr string, parsePart func(string) (uint32, error)) (uint32, error) {
+	var mask uint32
+	for _, part := range strings.Split(maskStr, "|") {
+		if part == "" {
+			return 0, fmt.Errorf("empty part in access mask: %s", maskStr)
+		}
+		value, err := parsePart(part)
+		if err != nil {
+			return 0, err
+		}
+		mask |= value
+	}
+	return mask, nil
+}
+
+// parseRawAccessMask is like parseAccessMask but only accepts single hexadecimal masks, see ParseOptions.RawMasks.
+func parseRawAccessMask(maskStr string) (uint32, error) {
+	if maskStr != "" && (!strings.HasPrefix(maskStr, "0x") || strings.Contains(maskStr, "|")) {
+		return 0, fmt.Errorf("access mask %s is not hexadecimal", maskStr)
+	}
+	return parseAccessMask(maskStr)
+}
+
+// parseLenientAccessMask is like parseAccessMask but also accepts decimal masks, such as "2032127"
+// for "FA", alone or as parts separated by "|" such as "FR|256", see ParseOptions.Lenient. Digits can't
+// be mistaken for mnemonics, which are letters.
+func parseLenientAccessMask(maskStr string) (uint32, error) {
+	if strings.Contains(maskStr, "|") {
+		return parseAccessMaskParts(maskStr, parseLenientAccessMask)
+	}
+	if maskStr != "" && strings.Trim(maskStr, "0123456789") == "" {
+		value, err := strconv.ParseUint(maskStr, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid decimal access mask: %s", maskStr)
+		}
+		return uint32(value), nil
+	}
+	return parseAccessMask(maskStr)
+}
+
+// parseACEString parses an ACE string in the format "(type;flags;rights;objectGUID;inheritObjectGUID;sid)"
+// or "(type;flags;rights;objectGUID;inheritObjectGUID;sid;condition)" into an ACE structure.
 // Example: "(A;;FA;;;SY)" which represents:
 // - Type: A (ACCESS_ALLOWED_ACE_TYPE)
 // - Flags: (none)
 // - Rights: FA (Full Access)
+// - Object GUIDs: (none)
 // - SID: SY (Local System)
-func parseACEString(aceStr string) (*parseACEStringResult, error) {
+//
+// Empty object GUIDs are not present, and an empty condition is absent.
+// The access mask must be hexadecimal if opts.RawMasks is set.
+func parseACEString(aceStr string, opts ParseOptions) (*parseACEStringResult, error) {
 	// Validate basic string format
 	if len(aceStr) < 2 || !strings.HasPrefix(aceStr, "(") || !strings.HasSuffix(aceStr, ")") {
 		return nil, fmt.Errorf("invalid ACE string format: must be enclosed in parentheses")
 	}
 
-	// Remove parentheses and split into components
-	parts := strings.Split(aceStr[1:len(aceStr)-1], ";")
-	if len(parts) != 6 {
-		return nil, fmt.Errorf("invalid ACE string format: expected 6 components separated by semicolons")
+	// Remove parentheses and split into components. Most ACEs have exactly six components, which are
+	// split in place without allocating; the others, with a condition or malformed, take the general path.
+	body := aceStr[1 : len(aceStr)-1]
+	var parts []string
+	fields, ok := splitShortACEString(body)
+	if ok {
+		parts = fields[:]
+	} else {
+		parts = splitACEString(body)
+	}
+	if len(parts) != 6 && len(parts) != 7 {
+		return nil, fmt.Errorf("invalid ACE string format: expected 6 or 7 components separated by semicolons, got %d", len(parts))
 	}
 
 	// Parse ACE type
@@ -540,55 +840,239 @@ func parseACEString(aceStr string) (*parseACEStringResult, error) {
 	}
 
 	// Parse ACE flags with type validation
-	aceFlags, err := parseFlagsForACEType(parts[1], aceType)
+	aceFlags, err := parseFlagsForACEType(parts[1], aceType, opts.Lenient)
 	if err != nil {
 		return nil, fmt.Errorf("invalid ACE flags: %w", err)
 	}
 
 	// Parse access mask
-	accessMask, err := parseAccessMask(parts[2])
+	parseMask := parseAccessMask
+	if opts.RawMasks {
+		parseMask = parseRawAccessMask
+	} else if opts.Lenient {
+		parseMask = parseLenientAccessMask
+	}
+	accessMask, err := parseMask(parts[2])
 	if err != nil {
 		return nil, fmt.Errorf("invalid access mask: %w", err)
 	}
 
-	// Parse SID (parts[3] and parts[4] are object type and inherited object type, which we ignore)
-	sid, err := parseSIDString(parts[5])
+	// Parse object type and inherited object type, only allowed for object ACEs.
+	// The object type can also be the name of an extended right, e.g. "Send-As".
+	objectTypeStr := parts[3]
+	if guid, ok := extendedRightGUID(objectTypeStr); ok {
+		objectTypeStr = guid
+	}
+	objectType, err := parseObjectTypeString(objectTypeStr, aceType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object type: %w", err)
+	}
+	inheritedObjectType, err := parseObjectTypeString(parts[4], aceType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inherited object type: %w", err)
+	}
+
+	// Parse SID
+	sid, err := parseSIDStringWithOptions(parts[5], opts)
 	if err != nil {
 		return nil, fmt.Errorf("invalid SID: %w", err)
 	}
 
+	// The seventh component is the conditional expression of callback ACEs or the attribute of resource
+	// attribute ACEs, both kept in binary form as the application data of the ACE
+	var applicationData []byte
+	if len(parts) == 7 && parts[6] != "" {
+		switch {
+		case isCallbackACEType(aceType):
+			if applicationData, err = encodeCondition(parts[6]); err != nil {
+				return nil, fmt.Errorf("invalid condition: %w", err)
+			}
+		case aceType == systemResourceAttributeACEType:
+			if applicationData, err = encodeResourceAttribute(parts[6]); err != nil {
+				return nil, fmt.Errorf("invalid resource attribute: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("invalid condition: conditional expressions are only valid for callback ACEs")
+		}
+	}
+
 	ace := &parseACEStringResult{
 		header: &aceHeader{
 			aceType:  aceType,
 			aceFlags: aceFlags,
 		},
-		accessMask: accessMask,
-		sid:        sid,
+		accessMask:          accessMask,
+		sid:                 sid,
+		objectType:          objectType,
+		inheritedObjectType: inheritedObjectType,
+		applicationData:     applicationData,
+		rawMask:             opts.RawMasks,
 	}
 
 	return ace, nil
 }
 
-// parseACEType converts an ACE type string to its corresponding byte value
-// The valid types are:
-// - A (ACCESS_ALLOWED_ACE_TYPE): allows access to the object
-// - D (ACCESS_DENIED_ACE_TYPE): denies access to the object
-// - AU (SYSTEM_AUDIT_ACE_TYPE): specifies a system audit ACE
-// - AL (SYSTEM_ALARM_ACE_TYPE): specifies a system alarm ACE
-// - OA (ACCESS_ALLOWED_OBJECT_ACE_TYPE): specifies an object-specific access ACE
+// StripComments removes the comment starting at the first occurrence of marker in the security descriptor
+// string s, along with the whitespace preceding it. Markers within parentheses are not comments: they belong
+// to ACE strings, which may contain them in the string literals of conditional expressions. An empty marker
+// stands for "#", as for ParseOptions.CommentMarker.
+//
+// This is what the StripComments parse option does. It is useful to pass annotated strings to other APIs.
+func StripComments(s, marker string) string {
+	marker = ParseOptions{CommentMarker: marker}.commentMarker()
+	depth := 0
+	inLiteral := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			inLiteral = !inLiteral
+		case inLiteral:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth <= 0 && strings.HasPrefix(s[i:], marker):
+			return strings.TrimRightFunc(s[:i], unicode.IsSpace)
+		}
+	}
+	return s
+}
+
+// SplitSDDL splits a security descriptor string into the substrings of its owner, group, DACL and SACL
+// components, each including its prefix (e.g. "D:P(A;;FA;;;SY)"), without parsing them. Missing components
+// are returned as empty strings.
+//
+// Components may appear in any order. Their boundaries are found the same way FromString does, so that
+// markers within ACE strings are never mistaken for the beginning of a component. Concatenating the
+// components, possibly after editing one of them, gives back a security descriptor string.
+func SplitSDDL(s string) (owner, group, dacl, sacl string, err error) {
+	if s == "" {
+		return "", "", "", "", nil
+	}
+
+	pendingComponents := slices.Clone(componentMarkers)
+	if findNextComponent(s, pendingComponents...) == -1 {
+		return "", "", "", "", fmt.Errorf("no components found in security descriptor")
+	}
+
+	remaining := s
+	for len(remaining) > 0 {
+		i := slices.IndexFunc(pendingComponents, func(marker string) bool {
+			return strings.HasPrefix(remaining, marker)
+		})
+		if i == -1 {
+			if j := slices.IndexFunc(componentMarkers, func(marker string) bool {
+				return strings.HasPrefix(remaining, marker)
+			}); j != -1 {
+				return "", "", "", "", fmt.Errorf("duplicate %s component", componentNames[j])
+			}
+			return "", "", "", "", fmt.Errorf("unexpected content before component: %s", remaining)
+		}
+		marker := pendingComponents[i]
+		pendingComponents = slices.Delete(pendingComponents, i, i+1)
+
+		end := len(remaining)
+		if next := findNextComponent(remaining[len(marker):], componentMarkers...); next != -1 {
+			end = len(marker) + next
+		}
+
+		switch marker {
+		case "O:":
+			owner = remaining[:end]
+		case "G:":
+			group = remaining[:end]
+		case "D:":
+			dacl = remaining[:end]
+		case "S:":
+			sacl = remaining[:end]
+		}
+		remaining = remaining[end:]
+	}
+
+	return owner, group, dacl, sacl, nil
+}
+
+// splitACEString splits the body of an ACE string (without its enclosing parentheses) into its
+// components. The first six components are separated by semicolons, and everything after the sixth
+// semicolon is the conditional expression, kept as is because it may contain semicolons itself.
+func splitACEString(body string) []string {
+	parts := make([]string, 0, 7)
+	for len(parts) < 6 {
+		idx := strings.IndexByte(body, ';')
+		if idx == -1 {
+			break
+		}
+		parts = append(parts, body[:idx])
+		body = body[idx+1:]
+	}
+	return append(parts, body)
+}
+
+// splitShortACEString splits the body of an ACE string (without its enclosing parentheses) made of
+// exactly six components, the most common layout, into an array rather than a slice so that it doesn't
+// allocate. It returns false if the body has more or fewer components, see splitACEString.
+func splitShortACEString(body string) (fields [6]string, ok bool) {
+	for i := 0; i < 5; i++ {
+		idx := strings.IndexByte(body, ';')
+		if idx == -1 {
+			return fields, false
+		}
+		fields[i] = body[:idx]
+		body = body[idx+1:]
+	}
+	if strings.IndexByte(body, ';') != -1 {
+		return fields, false
+	}
+	fields[5] = body
+	return fields, true
+}
+
+// findACEEnd returns the index of the parenthesis closing the ACE string at the beginning of s,
+// or -1 if there is none. Parentheses nested in a conditional expression and characters within
+// its double-quoted string literals are skipped.
+func findACEEnd(s string) int {
+	depth := 0
+	inLiteral := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			inLiteral = !inLiteral
+		case inLiteral:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseObjectTypeString parses the object type or inherited object type field of an ACE string.
+// An empty string means the GUID is not present. GUIDs are only valid for object ACE types.
+func parseObjectTypeString(s string, aceType byte) (*GUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !isObjectACEType(aceType) {
+		return nil, fmt.Errorf("object types are only valid for object ACEs, got %q", s)
+	}
+	guid, err := parseGUID(s)
+	if err != nil {
+		return nil, err
+	}
+	return &guid, nil
+}
+
+// parseACEType converts an ACE type string to its corresponding byte value.
+// The valid types are the SDDL mnemonics of aceTypeMnemonics (e.g. "A" for ACCESS_ALLOWED_ACE_TYPE),
+// and hexadecimal values such as "0x0E" for any type, as written by ACEType.String.
 func parseACEType(typeStr string) (byte, error) {
 	// First check well-known string representations
-	switch typeStr {
-	case "A":
-		return accessAllowedACEType, nil
-	case "D":
-		return accessDeniedACEType, nil
-	case "AU":
-		return systemAuditACEType, nil
-	case "AL":
-		return systemAlarmACEType, nil
-	case "OA":
-		return accessAllowedObjectACEType, nil
+	if aceType, ok := aceTypesByMnemonic[typeStr]; ok {
+		return byte(aceType), nil
 	}
 
 	// If not a well-known type, try to parse as hexadecimal
@@ -601,7 +1085,7 @@ func parseACEType(typeStr string) (byte, error) {
 		return byte(value), nil
 	}
 
-	return 0, fmt.Errorf("invalid ACE type: %s (must be a known type or hexadecimal value)", typeStr)
+	return 0, fmt.Errorf("%s (must be a known type or hexadecimal value)", typeStr)
 }
 
 // parseACLFlags splits a flag string into individualn ACL flags
@@ -633,6 +1117,12 @@ func parseACEType(typeStr string) (byte, error) {
 //	    Specifies the ACL should only be used for inheritance purposes.
 //	    The ACL is not used for access checks on the current object.
 //
+// Keyword:
+//
+//	NO_ACCESS_CONTROL - NULL ACL
+//	    The ACL is present but has no structure. A NULL DACL grants full access to everyone.
+//	    It can follow the other flags (e.g. "D:PNO_ACCESS_CONTROL") but not be followed by ACEs.
+//
 // These flags can be combined in any order after the ACL type identifier:
 // - For DACLs: "D:[flags]", e.g., "D:PAI", "D:AINO"
 // - For SACLs: "S:[flags]", e.g., "S:PAR", "S:ARNO"
@@ -642,6 +1132,13 @@ func parseACEType(typeStr string) (byte, error) {
 func parseACLFlags(s string) ([]string, error) {
 	var flags []string
 	for i := 0; i < len(s); {
+		// NO_ACCESS_CONTROL is the only keyword, it can follow the other flags (e.g. "PNO_ACCESS_CONTROL")
+		if strings.HasPrefix(s[i:], noAccessControl) {
+			flags = append(flags, noAccessControl)
+			i += len(noAccessControl)
+			continue
+		}
+
 		code1 := s[i : i+1]
 		code2 := ""
 		if i+1 < len(s) {
@@ -673,13 +1170,15 @@ func parseACLFlags(s string) ([]string, error) {
 //   - aclType: Either "D" for DACL or "S" for SACL
 //   - s: The ACL string to parse, which may include:
 //   - Optional flags (e.g., "PAI" for Protected and AutoInherited)
-//   - One or more ACEs enclosed in parentheses
+//   - One or more ACEs enclosed in parentheses, or "NO_ACCESS_CONTROL" for a NULL ACL
+//   - opts: The parse options, whitespace around the flags and the ACEs is only accepted if opts.Lenient is set
 //
 // Examples:
 //   - "D:(A;;FA;;;SY)"           // DACL with a single ACE
 //   - "S:PAI(AU;SA;FA;;;SY)"     // Protected auto-inherited SACL with an audit ACE
 //   - "D:(A;;FA;;;SY)(D;;FR;;;WD)" // DACL with two ACEs
-func parseACLString(aclType, s string) (*parseACLStringResult, error) {
+//   - "D:NO_ACCESS_CONTROL"        // NULL DACL, granting full access to everyone
+func parseACLString(aclType, s string, opts ParseOptions) (*parseACLStringResult, error) {
 	// Determine ACL type from prefix
 	var baseControl uint16
 	switch aclType {
@@ -691,6 +1190,13 @@ func parseACLString(aclType, s string) (*parseACLStringResult, error) {
 		return nil, fmt.Errorf("invalid ACL type: must be either 'D' or 'S'")
 	}
 
+	// lead is the length of the leading whitespace trimmed from s, to report the offsets of the ACEs
+	var lead int
+	if opts.Lenient {
+		lead = len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace))
+		s = strings.TrimSpace(s)
+	}
+
 	// Parse flags if present (before the first ACE)
 	var control uint16 = baseControl
 	var flags []string
@@ -705,7 +1211,11 @@ func parseACLString(aclType, s string) (*parseACLStringResult, error) {
 			}
 			flagEnd = len(s)
 		}
-		ff, err := parseACLFlags(s[:flagEnd])
+		flagStr := s[:flagEnd]
+		if opts.Lenient {
+			flagStr = strings.TrimRightFunc(flagStr, unicode.IsSpace)
+		}
+		ff, err := parseACLFlags(flagStr)
 		if err != nil {
 			return nil, fmt.Errorf("error parsing flags: %w", err)
 		}
@@ -714,9 +1224,17 @@ func parseACLString(aclType, s string) (*parseACLStringResult, error) {
 	}
 
 	// Update control flags based on parsed flags
-	// Note: other flags such as NO, IO, etc. are ignored because they do not have a corresponding control flag
+	// Note: NO and IO have no corresponding control flag, they are kept apart to be written back
+	var null bool
+	var sddlFlags byte
 	for _, flag := range flags {
 		switch flag {
+		case noAccessControl:
+			null = true
+		case "NO":
+			sddlFlags |= sddlFlagNO
+		case "IO":
+			sddlFlags |= sddlFlagIO
 		case "P":
 			if aclType == "D" {
 				control |= seDACLProtected
@@ -748,54 +1266,88 @@ func parseACLString(aclType, s string) (*parseACLStringResult, error) {
 	var aces []parseACEStringResult
 	remaining := s[aceStart:]
 
+	// A NULL ACL has no ACE to parse
+	if null {
+		if len(remaining) > 0 {
+			return nil, fmt.Errorf("invalid ACL format: %s cannot be followed by ACEs", noAccessControl)
+		}
+		return &parseACLStringResult{
+			aclType:         aclType,
+			control:         control,
+			noAccessControl: true,
+		}, nil
+	}
+
 	// Handle empty ACL (no ACEs)
 	if len(remaining) == 0 {
 		return &parseACLStringResult{
-			aclRevision: 2,
+			aclRevision: aclRevision,
 			aclSize:     8, // Size of empty ACL (just header)
 			aclType:     aclType,
 			control:     control,
+			sddlFlags:   sddlFlags,
 		}, nil
 	}
 
 	// Extract each ACE string (enclosed in parentheses)
 	for len(remaining) > 0 {
+		// aceError reports the position of the ACE being parsed along with err
+		aceError := func(err error) error {
+			return &aceParseError{index: len(aces), offset: lead + len(s) - len(remaining), err: err}
+		}
+
 		if remaining[0] != '(' {
-			return nil, fmt.Errorf("invalid ACE format: expected '(' but got %q", remaining[0])
+			return nil, aceError(fmt.Errorf("invalid ACE format: expected '(' but got %q", remaining[0]))
 		}
 
-		// Find closing parenthesis
-		closePos := strings.Index(remaining, ")")
+		// Find closing parenthesis, skipping the ones of a conditional expression
+		closePos := findACEEnd(remaining)
 		if closePos == -1 {
-			return nil, fmt.Errorf("invalid ACE format: missing closing parenthesis")
+			return nil, aceError(fmt.Errorf("invalid ACE format: missing closing parenthesis"))
 		}
 
 		// Parse individual ACE
 		aceStr := remaining[:closePos+1]
-		ace, err := parseACEString(aceStr)
+		ace, err := parseACEString(aceStr, opts)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing ACE %q: %w", aceStr, err)
+			return nil, aceError(fmt.Errorf("error parsing ACE %q: %w", aceStr, err))
 		}
 
 		aces = append(aces, *ace)
 		remaining = remaining[closePos+1:]
+		if opts.Lenient {
+			// Skip the whitespace between ACEs
+			remaining = strings.TrimLeftFunc(remaining, unicode.IsSpace)
+		}
+	}
+
+	// ACLs holding object ACEs need the directory service revision, as Windows does
+	revision := byte(aclRevision)
+	for i := range aces {
+		if isObjectACEType(aces[i].header.aceType) {
+			revision = aclRevisionDS
+			break
+		}
 	}
 
 	// Create and return the ACL structure
 	return &parseACLStringResult{
-		aclRevision: 2,
+		aclRevision: revision,
 		sbzl:        0,
 		aceCount:    uint16(len(aces)),
 		sbz2:        0,
 		aclType:     aclType,
 		control:     control,
+		sddlFlags:   sddlFlags,
 		aces:        aces,
 	}, nil
 }
 
 // parseFlagsForACEType converts an ACE flags string to its corresponding byte value,
-// validating that the flags are appropriate for the given ACE type
-func parseFlagsForACEType(flagsStr string, aceType byte) (byte, error) {
+// validating that the flags are appropriate for the given ACE type.
+// The flags are accepted in any order, but only once each. With lenient, they are also accepted in
+// any case, e.g. "ciOi" for "OICI", see ParseOptions.Lenient.
+func parseFlagsForACEType(flagsStr string, aceType byte, lenient bool) (byte, error) {
 	if flagsStr == "" {
 		return 0, nil
 	}
@@ -810,44 +1362,82 @@ func parseFlagsForACEType(flagsStr string, aceType byte) (byte, error) {
 		}
 
 		flag := flagsStr[i : i+2]
+		if lenient {
+			flag = strings.ToUpper(flag)
+		}
+		var bit byte
 		switch flag {
 		// Inheritance flags - valid for all ACE types
 		case "CI":
-			flags |= containerInheritACE
+			bit = containerInheritACE
 		case "OI":
-			flags |= objectInheritACE
+			bit = objectInheritACE
 		case "NP":
-			flags |= noPropagateInheritACE
+			bit = noPropagateInheritACE
 		case "IO":
-			flags |= inheritOnlyACE
+			bit = inheritOnlyACE
 		case "ID":
-			flags |= inheritedACE
+			bit = inheritedACE
 		// Audit flags - only valid for SYSTEM_AUDIT_ACE_TYPE
 		case "SA", "FA":
 			hasAuditFlags = true
-			if aceType != systemAuditACEType {
+			if !isAuditACEType(aceType) {
 				return 0, fmt.Errorf("audit flags (SA/FA) are only valid for audit ACEs")
 			}
 			if flag == "SA" {
-				flags |= successfulAccessACE
+				bit = successfulAccessACE
 			} else {
-				flags |= failedAccessACE
+				bit = failedAccessACE
 			}
 		default:
-			return 0, fmt.Errorf("unknown flag: %s", flag)
+			return 0, fmt.Errorf("unknown flag: %s", flagsStr[i:i+2])
+		}
+		if flags&bit != 0 {
+			return 0, fmt.Errorf("duplicate flag: %s", flagsStr[i:i+2])
 		}
+		flags |= bit
 	}
 
 	// Validate that audit ACEs have at least one audit flag
-	if aceType == systemAuditACEType && !hasAuditFlags {
+	if isAuditACEType(aceType) && !hasAuditFlags {
 		return 0, fmt.Errorf("audit ACEs must specify at least one audit flag (SA/FA)")
 	}
 
 	return flags, nil
 }
 
+// NormalizeSID returns the canonical string representation of a SID string, so that equivalent SIDs
+// can be reliably compared as strings.
+//
+// Surrounding whitespace is trimmed and the SID is rendered as String does: well-known SIDs are
+// replaced by their alias (e.g. "S-1-5-18" becomes "SY") and authorities that don't fit in 32 bits
+// are written as "0x" followed by 12 uppercase hexadecimal digits, regardless of the casing and
+// padding used in the input (e.g. "S-1-0xffff00000000-1" becomes "S-1-0xFFFF00000000-1").
+//
+// Domain relative aliases such as "LA" cannot be normalized without the domain information and
+// return ErrMissingDomainInformation.
+func NormalizeSID(s string) (string, error) {
+	r, err := parseSIDString(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+
+	sid, err := r.toSID(nil)
+	if err != nil {
+		return "", err
+	}
+
+	return sid.String(), nil
+}
+
 // parseSIDString parses a string SID representation into a SID structure
 func parseSIDString(s string) (parseSIDStringResult, error) {
+	return parseSIDStringWithOptions(s, ParseOptions{})
+}
+
+// parseSIDStringWithOptions is like parseSIDString, but also accepts hexadecimal sub-authorities with a "0x"
+// prefix (e.g. "S-1-5-21-0x1F4") if opts.Lenient is set.
+func parseSIDStringWithOptions(s string, opts ParseOptions) (parseSIDStringResult, error) {
 	// First, check if it's a well-known RID abbreviation
 	// hence this parsing will result in an incomplete SID
 	if r, ok := wellKnownRIDs[s]; ok {
@@ -864,14 +1454,15 @@ func parseSIDString(s string) (parseSIDStringResult, error) {
 		return nil, fmt.Errorf("%w: must start with S-", ErrInvalidSIDFormat)
 	}
 
-	// Split the SID string into components
-	parts := strings.Split(s[2:], "-") // Skip "S-" prefix
-	if len(parts) < 2 {
+	// Split the SID string into the revision, the authority and the sub-authorities, in place
+	revisionStr, rest, found := strings.Cut(s[2:], "-") // Skip "S-" prefix
+	if !found {
 		return nil, fmt.Errorf("%w: insufficient components", ErrInvalidSIDFormat)
 	}
+	authStr, subAuthStr, hasSubAuth := strings.Cut(rest, "-")
 
 	// Parse revision
-	revision, err := strconv.ParseUint(parts[0], 10, 8)
+	revision, err := strconv.ParseUint(revisionStr, 10, 8)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidRevision, err)
 	}
@@ -881,7 +1472,6 @@ func parseSIDString(s string) (parseSIDStringResult, error) {
 
 	// Parse authority - can be decimal or hex (with 0x prefix)
 	var authority uint64
-	authStr := parts[1]
 	if strings.HasPrefix(strings.ToLower(authStr), "0x") {
 		// Parse hexadecimal authority
 		authority, err = strconv.ParseUint(authStr[2:], 16, 48)
@@ -902,14 +1492,23 @@ func parseSIDString(s string) (parseSIDStringResult, error) {
 	}
 
 	// Parse sub-authorities
-	subAuthCount := len(parts) - 2 // Subtract revision and authority parts
+	subAuthCount := 0
+	if hasSubAuth {
+		subAuthCount = strings.Count(subAuthStr, "-") + 1
+	}
 	if subAuthCount > 15 {
 		return nil, fmt.Errorf("%w: got %d, maximum is 15", ErrTooManySubAuthorities, subAuthCount)
 	}
 
 	subAuthorities := make([]uint32, subAuthCount)
 	for i := 0; i < subAuthCount; i++ {
-		sa, err := strconv.ParseUint(parts[i+2], 10, 32)
+		var part string
+		part, subAuthStr, _ = strings.Cut(subAuthStr, "-")
+		base := 10
+		if opts.Lenient && strings.HasPrefix(strings.ToLower(part), "0x") {
+			part, base = part[2:], 16
+		}
+		sa, err := strconv.ParseUint(part, base, 32)
 		if err != nil {
 			return nil, fmt.Errorf("%w: invalid sub-authority at position %d: %v",
 				ErrInvalidSubAuthority, i, err)
@@ -917,7 +1516,7 @@ func parseSIDString(s string) (parseSIDStringResult, error) {
 		subAuthorities[i] = uint32(sa)
 	}
 
-	return &sid{
+	return &SID{
 		revision:            byte(revision),
 		identifierAuthority: authority,
 		subAuthority:        subAuthorities,
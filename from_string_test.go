@@ -11,8 +11,8 @@ import (
 
 func TestParseACEString(t *testing.T) {
 	// Helper function to create a SID for testing
-	createTestSID := func(revision byte, authority uint64, subAuth ...uint32) *sid {
-		return &sid{
+	createTestSID := func(revision byte, authority uint64, subAuth ...uint32) *SID {
+		return &SID{
 			revision:            revision,
 			identifierAuthority: authority,
 			subAuthority:        subAuth,
@@ -22,13 +22,13 @@ func TestParseACEString(t *testing.T) {
 	tests := []struct {
 		name    string
 		aceStr  string
-		want    *ace
+		want    *ACE
 		wantErr bool
 	}{
 		{
 			name:   "Basic allow ACE",
 			aceStr: "(A;;FA;;;SY)",
-			want: &ace{
+			want: &ACE{
 				header: &aceHeader{
 					aceType:  accessAllowedACEType,
 					aceFlags: 0,
@@ -42,7 +42,7 @@ func TestParseACEString(t *testing.T) {
 		{
 			name:   "Deny ACE with inheritance flags",
 			aceStr: "(D;OICI;FR;;;BA)",
-			want: &ace{
+			want: &ACE{
 				header: &aceHeader{
 					aceType:  accessDeniedACEType,
 					aceFlags: objectInheritACE | containerInheritACE,
@@ -56,7 +56,7 @@ func TestParseACEString(t *testing.T) {
 		{
 			name:   "Audit ACE with success audit",
 			aceStr: "(AU;SA;FA;;;WD)",
-			want: &ace{
+			want: &ACE{
 				header: &aceHeader{
 					aceType:  systemAuditACEType,
 					aceFlags: successfulAccessACE,
@@ -70,7 +70,7 @@ func TestParseACEString(t *testing.T) {
 		{
 			name:   "Audit ACE with both success and failure",
 			aceStr: "(AU;SAFA;FA;;;SY)",
-			want: &ace{
+			want: &ACE{
 				header: &aceHeader{
 					aceType:  systemAuditACEType,
 					aceFlags: successfulAccessACE | failedAccessACE,
@@ -84,7 +84,7 @@ func TestParseACEString(t *testing.T) {
 		{
 			name:   "Complex inheritance flags",
 			aceStr: "(A;OICIIONP;FA;;;AU)",
-			want: &ace{
+			want: &ACE{
 				header: &aceHeader{
 					aceType:  accessAllowedACEType,
 					aceFlags: objectInheritACE | containerInheritACE | inheritOnlyACE | noPropagateInheritACE,
@@ -98,7 +98,7 @@ func TestParseACEString(t *testing.T) {
 		{
 			name:   "Directory operations access mask",
 			aceStr: "(A;;DCLCRPCR;;;SY)",
-			want: &ace{
+			want: &ACE{
 				header: &aceHeader{
 					aceType:  accessAllowedACEType,
 					aceFlags: 0,
@@ -112,7 +112,7 @@ func TestParseACEString(t *testing.T) {
 		{
 			name:   "Custom access mask",
 			aceStr: "(A;;0x1234ABCD;;;SY)",
-			want: &ace{
+			want: &ACE{
 				header: &aceHeader{
 					aceType:  accessAllowedACEType,
 					aceFlags: 0,
@@ -126,7 +126,7 @@ func TestParseACEString(t *testing.T) {
 		{
 			name:   "Custom ACE type",
 			aceStr: "(0x15;;FA;;;SY)", // SYSTEM_ACCESS_FILTER_ACE_TYPE
-			want: &ace{
+			want: &ACE{
 				header: &aceHeader{
 					aceType:  0x15,
 					aceFlags: 0,
@@ -137,7 +137,45 @@ func TestParseACEString(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "Seven components with empty condition",
+			aceStr: "(A;;FA;;;SY;)",
+			want: &ACE{
+				header: &aceHeader{
+					aceType:  accessAllowedACEType,
+					aceFlags: 0,
+					aceSize:  20,
+				},
+				accessMask: 0x1F01FF,
+				sid:        createTestSID(1, 5, 18),
+			},
+			wantErr: false,
+		},
+		{
+			name:   "Empty access mask",
+			aceStr: "(A;;;;;SY)",
+			want: &ACE{
+				header: &aceHeader{
+					aceType:  accessAllowedACEType,
+					aceFlags: 0,
+					aceSize:  20,
+				},
+				accessMask: 0,
+				sid:        createTestSID(1, 5, 18),
+			},
+			wantErr: false,
+		},
 		// Error cases
+		{
+			name:    "Invalid format - too many components",
+			aceStr:  "(A;;FA;;;SY;;)",
+			wantErr: true,
+		},
+		{
+			name:    "Condition on a non-callback ACE",
+			aceStr:  "(A;;FA;;;SY;(Member_of {SID(BA)}))",
+			wantErr: true,
+		},
 		{
 			name:    "Invalid format - missing parentheses",
 			aceStr:  "A;;FA;;;SY",
@@ -188,11 +226,16 @@ func TestParseACEString(t *testing.T) {
 			aceStr:  "(A;;0xZZZZ;;;SY)",
 			wantErr: true,
 		},
+		{
+			name:    "Odd-length access mask",
+			aceStr:  "(A;;FAR;;;SY)",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotR, err := parseACEString(tt.aceStr)
+			gotR, err := parseACEString(tt.aceStr, ParseOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseACEString() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -242,6 +285,253 @@ func TestParseACEString(t *testing.T) {
 	}
 }
 
+// benchmarkACEStrings are common short ACEs, as found in file system descriptors
+var benchmarkACEStrings = []string{
+	"(A;;FA;;;SY)",
+	"(A;OICI;FA;;;BA)",
+	"(A;;FR;;;BU)",
+	"(A;OICIIO;GA;;;CO)",
+	"(D;;FW;;;AN)",
+	"(A;ID;0x1200a9;;;S-1-5-21-1004336348-1177238915-682003330-1001)",
+}
+
+func BenchmarkParseACEString(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, s := range benchmarkACEStrings {
+			if _, err := parseACEString(s, ParseOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestSplitACEString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "Six components",
+			body: "A;;FA;;;SY",
+			want: []string{"A", "", "FA", "", "", "SY"},
+		},
+		{
+			name: "Six components with object GUIDs",
+			body: "OA;;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;WD",
+			want: []string{"OA", "", "CR", "00299570-246d-11d0-a768-00aa006e0529", "bf967aba-0de6-11d0-a285-00aa003049e2", "WD"},
+		},
+		{
+			name: "Seven components with empty condition",
+			body: "A;;FA;;;SY;",
+			want: []string{"A", "", "FA", "", "", "SY", ""},
+		},
+		{
+			name: "Condition containing semicolons",
+			body: `XA;;FA;;;WD;(@User.Title == "a;b")`,
+			want: []string{"XA", "", "FA", "", "", "WD", `(@User.Title == "a;b")`},
+		},
+		{
+			name: "Too few components",
+			body: "A;FA;;;SY",
+			want: []string{"A", "FA", "", "", "SY"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := splitACEString(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitACEString() = %q, want %q", got, tt.want)
+			}
+
+			// The allocation-free split must agree with the general one on six components ACEs only
+			fields, ok := splitShortACEString(tt.body)
+			if ok != (len(tt.want) == 6) {
+				t.Fatalf("splitShortACEString() ok = %v, want %v", ok, len(tt.want) == 6)
+			}
+			if ok && !reflect.DeepEqual(fields[:], tt.want) {
+				t.Errorf("splitShortACEString() = %q, want %q", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSDDL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		wantOwner string
+		wantGroup string
+		wantDACL  string
+		wantSACL  string
+		wantErr   bool
+	}{
+		{
+			name:      "All components",
+			input:     "O:SYG:BAD:PAI(A;OICI;FA;;;SY)S:AI(AU;SA;FA;;;WD)",
+			wantOwner: "O:SY",
+			wantGroup: "G:BA",
+			wantDACL:  "D:PAI(A;OICI;FA;;;SY)",
+			wantSACL:  "S:AI(AU;SA;FA;;;WD)",
+		},
+		{
+			name:      "Non-standard order of components",
+			input:     "D:(A;;FA;;;SY)O:SY",
+			wantOwner: "O:SY",
+			wantDACL:  "D:(A;;FA;;;SY)",
+		},
+		{
+			name:      "Reversed order",
+			input:     "S:(AU;FA;GA;;;WD)D:(A;;FA;;;BA)G:SYO:BA",
+			wantOwner: "O:BA",
+			wantGroup: "G:SY",
+			wantDACL:  "D:(A;;FA;;;BA)",
+			wantSACL:  "S:(AU;FA;GA;;;WD)",
+		},
+		{
+			name:     "Markers within a condition",
+			input:    `D:(XA;;FA;;;WD;(@User.Dept == "G:S:"))S:(AU;SA;FA;;;WD)`,
+			wantDACL: `D:(XA;;FA;;;WD;(@User.Dept == "G:S:"))`,
+			wantSACL: "S:(AU;SA;FA;;;WD)",
+		},
+		{
+			name:     "NULL DACL",
+			input:    "D:NO_ACCESS_CONTROL",
+			wantDACL: "D:NO_ACCESS_CONTROL",
+		},
+		{
+			name:  "Empty",
+			input: "",
+		},
+		{
+			name:    "No component",
+			input:   "(A;;FA;;;SY)",
+			wantErr: true,
+		},
+		{
+			name:    "Content before the first component",
+			input:   "XO:SY",
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate component",
+			input:   "O:SYD:(A;;FA;;;SY)O:BA",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			owner, group, dacl, sacl, err := SplitSDDL(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitSDDL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if owner != tt.wantOwner {
+				t.Errorf("SplitSDDL() owner = %q, want %q", owner, tt.wantOwner)
+			}
+			if group != tt.wantGroup {
+				t.Errorf("SplitSDDL() group = %q, want %q", group, tt.wantGroup)
+			}
+			if dacl != tt.wantDACL {
+				t.Errorf("SplitSDDL() dacl = %q, want %q", dacl, tt.wantDACL)
+			}
+			if sacl != tt.wantSACL {
+				t.Errorf("SplitSDDL() sacl = %q, want %q", sacl, tt.wantSACL)
+			}
+
+			// Reassembled in the standard order, the components describe the same security descriptor
+			want, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			got, err := FromString(owner + group + dacl + sacl)
+			if err != nil {
+				t.Fatalf("FromString() of the reassembled components unexpected error = %v", err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("reassembled components = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestFindACEEnd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{name: "Simple ACE", s: "(A;;FA;;;SY)(A;;FA;;;BA)", want: 11},
+		{name: "Nested condition", s: "(XA;;FA;;;WD;(Member_of {SID(BA)}))(A;;FA;;;SY)", want: 34},
+		{name: "Parenthesis in string literal", s: `(XA;;FA;;;WD;(@User.Title == ")"))`, want: 33},
+		{name: "Unterminated", s: "(A;;FA;;;SY", want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := findACEEnd(tt.s); got != tt.want {
+				t.Errorf("findACEEnd() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindNextComponent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		s       string
+		markers []string
+		want    int
+	}{
+		{name: "Next component", s: "BAG:SYD:(A;;FA;;;SY)", markers: []string{"G:", "D:"}, want: 2},
+		{name: "Alias ending with marker letter", s: "DDD:(A;;FA;;;SY)", markers: []string{"D:"}, want: 2},
+		{name: "No marker", s: "(A;;FA;;;SY)", markers: []string{"G:", "S:"}, want: -1},
+		{name: "No markers left", s: "(A;;FA;;;SY)S:", markers: nil, want: -1},
+		{name: "Marker inside ACE", s: "(A;;FA;;;S:Y)G:SY", markers: []string{"G:", "S:"}, want: 13},
+		{
+			name:    "Marker inside nested condition",
+			s:       "(XA;;FA;;;WD;(@User.O:x == 1))S:(AU;SA;FA;;;WD)",
+			markers: []string{"O:", "S:"},
+			want:    30,
+		},
+		{
+			name:    "Marker inside string literal",
+			s:       `(XA;;FA;;;WD;(@User.Title == "G:)D:"))G:SY`,
+			markers: []string{"G:", "D:"},
+			want:    38,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := findNextComponent(tt.s, tt.markers...); got != tt.want {
+				t.Errorf("findNextComponent() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseACLString(t *testing.T) {
 	t.Parallel()
 
@@ -249,7 +539,7 @@ func TestParseACLString(t *testing.T) {
 		name      string
 		aclType   string
 		input     string
-		want      *acl
+		want      *ACL
 		wantErr   bool
 		errString string
 	}{
@@ -264,7 +554,7 @@ func TestParseACLString(t *testing.T) {
 			name:    "Empty DACL",
 			aclType: "D",
 			input:   "",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     8,
 				aclType:     "D",
@@ -275,7 +565,7 @@ func TestParseACLString(t *testing.T) {
 			name:    "Empty SACL",
 			aclType: "S",
 			input:   "",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     8,
 				aclType:     "S",
@@ -286,13 +576,13 @@ func TestParseACLString(t *testing.T) {
 			name:    "Basic DACL with single ACE",
 			aclType: "D",
 			input:   "(A;;FA;;;SY)",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     28, // 8 (header) + 20 (ACE size)
 				aceCount:    1,
 				aclType:     "D",
 				control:     seDACLPresent,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  accessAllowedACEType,
@@ -300,7 +590,7 @@ func TestParseACLString(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x1F01FF, // FA - Full Access
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,
 							subAuthority:        []uint32{18}, // SYSTEM
@@ -313,13 +603,13 @@ func TestParseACLString(t *testing.T) {
 			name:    "DACL with multiple ACEs",
 			aclType: "D",
 			input:   "(A;;FA;;;SY)(D;;FR;;;WD)",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     48, // 8 (header) + 20 (first ACE) + 20 (second ACE)
 				aceCount:    2,
 				aclType:     "D",
 				control:     seDACLPresent,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  accessAllowedACEType,
@@ -327,7 +617,7 @@ func TestParseACLString(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x1F01FF, // FA
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,
 							subAuthority:        []uint32{18}, // SYSTEM
@@ -340,7 +630,7 @@ func TestParseACLString(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x120089, // FR
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 1,
 							subAuthority:        []uint32{0}, // Everyone
@@ -353,13 +643,13 @@ func TestParseACLString(t *testing.T) {
 			name:    "SACL with audit ACE",
 			aclType: "S",
 			input:   "(AU;SA;FA;;;SY)",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     28,
 				aceCount:    1,
 				aclType:     "S",
 				control:     seSACLPresent,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  systemAuditACEType,
@@ -367,7 +657,7 @@ func TestParseACLString(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x1F01FF,
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,
 							subAuthority:        []uint32{18},
@@ -380,13 +670,13 @@ func TestParseACLString(t *testing.T) {
 			name:    "DACL with protected flag",
 			aclType: "D",
 			input:   "P(A;;FA;;;SY)",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     28,
 				aceCount:    1,
 				aclType:     "D",
 				control:     seDACLPresent | seDACLProtected,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  accessAllowedACEType,
@@ -394,7 +684,7 @@ func TestParseACLString(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x1F01FF,
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,
 							subAuthority:        []uint32{18},
@@ -407,13 +697,13 @@ func TestParseACLString(t *testing.T) {
 			name:    "DACL with auto-inherited flag",
 			aclType: "D",
 			input:   "AI(A;;FA;;;SY)",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     28,
 				aceCount:    1,
 				aclType:     "D",
 				control:     seDACLPresent | seDACLAutoInherited,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  accessAllowedACEType,
@@ -421,7 +711,7 @@ func TestParseACLString(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x1F01FF,
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,
 							subAuthority:        []uint32{18},
@@ -434,13 +724,13 @@ func TestParseACLString(t *testing.T) {
 			name:    "SACL with multiple flags",
 			aclType: "S",
 			input:   "PAI(AU;SA;FA;;;SY)",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     28,
 				aceCount:    1,
 				aclType:     "S",
 				control:     seSACLPresent | seSACLProtected | seSACLAutoInherited,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  systemAuditACEType,
@@ -448,7 +738,7 @@ func TestParseACLString(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x1F01FF,
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,
 							subAuthority:        []uint32{18},
@@ -475,7 +765,7 @@ func TestParseACLString(t *testing.T) {
 			name:    "Empty DACL with flags",
 			aclType: "D",
 			input:   "PAI",
-			want: &acl{
+			want: &ACL{
 				aclRevision: 2,
 				aclSize:     8,
 				aclType:     "D",
@@ -489,7 +779,7 @@ func TestParseACLString(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			gotR, err := parseACLString(tt.aclType, tt.input)
+			gotR, err := parseACLString(tt.aclType, tt.input, ParseOptions{})
 
 			// Check error cases
 			if tt.wantErr {
@@ -598,7 +888,7 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control:  seSelfRelative | seGroupDefaulted | seDACLDefaulted | seSACLDefaulted,
-				ownerSID: &sid{
+				ownerSID: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{18},
@@ -613,7 +903,7 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control:  seSelfRelative | seOwnerDefaulted | seDACLDefaulted | seSACLDefaulted,
-				groupSID: &sid{
+				groupSID: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{32, 544},
@@ -628,12 +918,12 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control:  seSelfRelative | seDACLDefaulted | seSACLDefaulted,
-				ownerSID: &sid{
+				ownerSID: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{18},
 				},
-				groupSID: &sid{
+				groupSID: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{32, 544},
@@ -648,11 +938,11 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control:  seSelfRelative | seOwnerDefaulted | seGroupDefaulted | seSACLDefaulted | seDACLPresent,
-				dacl: &acl{
+				dacl: &ACL{
 					aclRevision: 2,
 					aclSize:     8,
 					aclType:     "D",
-					control:     seSelfRelative | seOwnerDefaulted | seGroupDefaulted | seSACLDefaulted | seDACLPresent, // This field is a copy of SD.Control
+					control:     seDACLPresent, // Only the DACL flags of SD.Control
 				},
 			},
 			wantErr: false,
@@ -664,11 +954,11 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control:  seSelfRelative | seOwnerDefaulted | seGroupDefaulted | seDACLDefaulted | seSACLPresent,
-				sacl: &acl{
+				sacl: &ACL{
 					aclRevision: 2,
 					aclSize:     8,
 					aclType:     "S",
-					control:     seSelfRelative | seOwnerDefaulted | seGroupDefaulted | seDACLDefaulted | seSACLPresent, // This field is a copy of SD.Control
+					control:     seSACLPresent, // Only the SACL flags of SD.Control
 				},
 			},
 			wantErr: false,
@@ -680,13 +970,13 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control:  seSelfRelative | seOwnerDefaulted | seGroupDefaulted | seSACLDefaulted | seDACLPresent | seDACLProtected,
-				dacl: &acl{
+				dacl: &ACL{
 					aclRevision: 2,
 					aclSize:     28,
 					aceCount:    1,
 					aclType:     "D",
-					control:     seSelfRelative | seOwnerDefaulted | seGroupDefaulted | seSACLDefaulted | seDACLPresent | seDACLProtected, // This field is a copy of SD.Control
-					aces: []ace{
+					control:     seDACLPresent | seDACLProtected, // Only the DACL flags of SD.Control
+					aces: []ACE{
 						{
 							header: &aceHeader{
 								aceType:  accessAllowedACEType,
@@ -694,7 +984,7 @@ func TestFromString(t *testing.T) {
 								aceSize:  20,
 							},
 							accessMask: 0x1F01FF,
-							sid: &sid{
+							sid: &SID{
 								revision:            1,
 								identifierAuthority: 5,
 								subAuthority:        []uint32{18},
@@ -712,24 +1002,23 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control:  seDACLAutoInherited | seDACLPresent | seDACLProtected | seSACLAutoInherited | seSACLPresent | seSelfRelative,
-				ownerSID: &sid{
+				ownerSID: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{18},
 				},
-				groupSID: &sid{
+				groupSID: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{32, 544},
 				},
-				dacl: &acl{
+				dacl: &ACL{
 					aclRevision: 2,
 					aclSize:     48, // 4 bytes for AceCount and Sbz1, 40 bytes for the two ACEs, 4 bytes for Sbz2
 					aceCount:    2,
 					aclType:     "D",
-					control: seDACLAutoInherited | seDACLPresent | seDACLProtected |
-						seSACLAutoInherited | seSACLPresent | seSelfRelative, // This field is a copy of SD.Control
-					aces: []ace{
+					control:     seDACLPresent | seDACLAutoInherited | seDACLProtected, // Only the DACL flags of SD.Control
+					aces: []ACE{
 						{
 							header: &aceHeader{
 								aceType:  accessAllowedACEType,
@@ -737,7 +1026,7 @@ func TestFromString(t *testing.T) {
 								aceSize:  20, // 4 bytes for ACE header + 4 bytes for mask + 12 bytes for SID
 							},
 							accessMask: 0x1F01FF,
-							sid: &sid{
+							sid: &SID{
 								revision:            1,
 								identifierAuthority: 5,
 								subAuthority:        []uint32{18},
@@ -750,7 +1039,7 @@ func TestFromString(t *testing.T) {
 								aceSize:  20, // 4 bytes for ACE header + 4 bytes for mask + 12 bytes for SID
 							},
 							accessMask: 0x120089,
-							sid: &sid{
+							sid: &SID{
 								revision:            1,
 								identifierAuthority: 1,
 								subAuthority:        []uint32{0},
@@ -758,14 +1047,13 @@ func TestFromString(t *testing.T) {
 						},
 					},
 				},
-				sacl: &acl{
+				sacl: &ACL{
 					aclRevision: 2,
 					aclSize:     32, // 4 bytes for AceCount and Sbz1, 24 bytes for the single ACE, 4 bytes for Sbz2
 					aceCount:    1,
 					aclType:     "S",
-					control: seDACLAutoInherited | seDACLPresent | seDACLProtected |
-						seSACLAutoInherited | seSACLPresent | seSelfRelative, // This field is a copy of SD.Control
-					aces: []ace{
+					control:     seSACLPresent | seSACLAutoInherited, // Only the SACL flags of SD.Control
+					aces: []ACE{
 						{
 							header: &aceHeader{
 								aceType:  systemAuditACEType,
@@ -773,7 +1061,7 @@ func TestFromString(t *testing.T) {
 								aceSize:  24, // 4 bytes for ACE header, 4 bytes for access mask, 8 bytes for SID header, 4 bytes for 1 sub-authority
 							},
 							accessMask: 0x1F01FF,
-							sid: &sid{
+							sid: &SID{
 								revision:            1,
 								identifierAuthority: 5,
 								subAuthority:        []uint32{32, 544},
@@ -816,13 +1104,13 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control:  seSelfRelative | seGroupDefaulted | seSACLDefaulted | seDACLPresent,
-				dacl: &acl{
+				dacl: &ACL{
 					aclRevision: 2,
 					aclSize:     28, // 4 bytes for AceCount and Sbz1, 20 bytes for the single ACE, 4 bytes for Sbz2
 					aceCount:    1,
 					aclType:     "D",
-					control:     seSelfRelative | seGroupDefaulted | seSACLDefaulted | seDACLPresent, // This field is a copy of SD.Control
-					aces: []ace{
+					control:     seDACLPresent, // Only the DACL flags of SD.Control
+					aces: []ACE{
 						{
 							header: &aceHeader{
 								aceType:  accessAllowedACEType,
@@ -830,7 +1118,7 @@ func TestFromString(t *testing.T) {
 								aceSize:  20, // 4 bytes for ACE header + 4 bytes for mask + 12 bytes for SID
 							},
 							accessMask: 0x1F01FF,
-							sid: &sid{
+							sid: &SID{
 								revision:            1,
 								identifierAuthority: 5,
 								subAuthority:        []uint32{18},
@@ -838,7 +1126,48 @@ func TestFromString(t *testing.T) {
 						},
 					},
 				},
-				ownerSID: &sid{
+				ownerSID: &SID{
+					revision:            1,
+					identifierAuthority: 5,
+					subAuthority:        []uint32{18},
+				},
+			},
+		},
+
+		{
+			name:  "Owner after the DACL",
+			input: "G:SYD:(A;;FA;;;BA)O:BA",
+			want: &SecurityDescriptor{
+				revision: 1,
+				control:  seSelfRelative | seSACLDefaulted | seDACLPresent,
+				dacl: &ACL{
+					aclRevision: 2,
+					aclSize:     32, // 8 bytes for the ACL header, 24 bytes for the single ACE
+					aceCount:    1,
+					aclType:     "D",
+					control:     seDACLPresent,
+					aces: []ACE{
+						{
+							header: &aceHeader{
+								aceType:  accessAllowedACEType,
+								aceFlags: 0,
+								aceSize:  24, // 4 bytes for ACE header + 4 bytes for mask + 16 bytes for SID
+							},
+							accessMask: 0x1F01FF,
+							sid: &SID{
+								revision:            1,
+								identifierAuthority: 5,
+								subAuthority:        []uint32{32, 544},
+							},
+						},
+					},
+				},
+				ownerSID: &SID{
+					revision:            1,
+					identifierAuthority: 5,
+					subAuthority:        []uint32{32, 544},
+				},
+				groupSID: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{18},
@@ -852,26 +1181,22 @@ func TestFromString(t *testing.T) {
 			want: &SecurityDescriptor{
 				revision: 1,
 				control: seSelfRelative | seOwnerDefaulted | seGroupDefaulted |
-					seDACLPresent | seSACLPresent |
+					seDACLPresent | seSACLPresent | seDACLDefaulted | seSACLDefaulted |
 					seDACLProtected | seDACLAutoInherited | seDACLAutoInheritRe |
 					seSACLProtected | seSACLAutoInherited | seSACLAutoInheritRe,
-				dacl: &acl{
+				dacl: &ACL{
 					aclRevision: 2,
 					aclSize:     8,
 					aclType:     "D",
-					control: seSelfRelative | seOwnerDefaulted | seGroupDefaulted |
-						seDACLPresent | seSACLPresent |
-						seDACLProtected | seDACLAutoInherited | seDACLAutoInheritRe |
-						seSACLProtected | seSACLAutoInherited | seSACLAutoInheritRe, // This field is a copy of SD.Control
+					control:     seDACLPresent | seDACLDefaulted | seDACLAutoInheritRe | seDACLAutoInherited | seDACLProtected, // Only the DACL flags of SD.Control
+					sddlFlags:   sddlFlagNO | sddlFlagIO,
 				},
-				sacl: &acl{
+				sacl: &ACL{
 					aclRevision: 2,
 					aclSize:     8,
 					aclType:     "S",
-					control: seSelfRelative | seOwnerDefaulted | seGroupDefaulted |
-						seDACLPresent | seSACLPresent |
-						seDACLProtected | seDACLAutoInherited | seDACLAutoInheritRe |
-						seSACLProtected | seSACLAutoInherited | seSACLAutoInheritRe, // This field is a copy of SD.Control
+					control:     seSACLPresent | seSACLDefaulted | seSACLAutoInheritRe | seSACLAutoInherited | seSACLProtected, // Only the SACL flags of SD.Control
+					sddlFlags:   sddlFlagNO | sddlFlagIO,
 				},
 			},
 			wantErr: false,
@@ -909,13 +1234,13 @@ func TestParseSIDString(t *testing.T) {
 	tests := []struct {
 		name    string
 		input   string
-		want    *sid
+		want    *SID
 		wantErr error
 	}{
 		{
 			name:  "Well-known SID short form (SYSTEM)",
 			input: "SY",
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{18},
@@ -924,7 +1249,7 @@ func TestParseSIDString(t *testing.T) {
 		{
 			name:  "Well-known SID full form (SYSTEM)",
 			input: "S-1-5-18",
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{18},
@@ -933,7 +1258,7 @@ func TestParseSIDString(t *testing.T) {
 		{
 			name:  "Complex SID",
 			input: "S-1-5-21-3623811015-3361044348-30300820-1013",
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{21, 3623811015, 3361044348, 30300820, 1013},
@@ -942,7 +1267,7 @@ func TestParseSIDString(t *testing.T) {
 		{
 			name:  "Minimum valid SID",
 			input: "S-1-0-0",
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: 0,
 				subAuthority:        []uint32{0},
@@ -951,7 +1276,7 @@ func TestParseSIDString(t *testing.T) {
 		{
 			name:  "Maximum sub-authorities",
 			input: "S-1-5-21-1-2-3-4-5-6-7-8-9-10-11-12-13-14",
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{21, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14},
@@ -1000,7 +1325,7 @@ func TestParseSIDString(t *testing.T) {
 		{
 			name:  "High authority value in hex",
 			input: "S-1-0xFFFFFFFF0000-1-2",
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: 0xFFFFFFFF0000,
 				subAuthority:        []uint32{1, 2},
@@ -1009,7 +1334,7 @@ func TestParseSIDString(t *testing.T) {
 		{
 			name:  "Authority value just below 2^32 in decimal",
 			input: "S-1-4294967295-1-2",
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: 4294967295,
 				subAuthority:        []uint32{1, 2},
@@ -1018,7 +1343,7 @@ func TestParseSIDString(t *testing.T) {
 		{
 			name:  "Authority value maximum (2^48-1) in hex",
 			input: fmt.Sprintf("S-1-0x%X-1-2", maxAuthority),
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: maxAuthority,
 				subAuthority:        []uint32{1, 2},
@@ -1100,23 +1425,101 @@ func TestParseSIDString(t *testing.T) {
 	}
 }
 
+func TestParseSIDStringWithOptions_HexSubAuthorities(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Hexadecimal RID",
+			input: "S-1-5-21-1-2-3-0x1F4",
+			want:  "S-1-5-21-1-2-3-500",
+		},
+		{
+			name:  "All sub-authorities in hexadecimal, mixed case",
+			input: "S-1-5-0X20-0x220",
+			want:  "S-1-5-32-544",
+		},
+		{
+			name:  "Maximum value",
+			input: "S-1-5-21-0xffffffff",
+			want:  "S-1-5-21-4294967295",
+		},
+		{
+			name:    "Out of range",
+			input:   "S-1-5-21-0x100000000",
+			wantErr: true,
+		},
+		{
+			name:    "Missing digits",
+			input:   "S-1-5-21-0x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Hexadecimal sub-authorities are only accepted in lenient mode
+			if _, err := parseSIDString(tt.input); err == nil {
+				t.Errorf("parseSIDString() expected error, got nil")
+			}
+
+			r, err := parseSIDStringWithOptions(tt.input, ParseOptions{Lenient: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSIDStringWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := r.toSID(nil)
+			if err != nil {
+				t.Fatalf("toSID() unexpected error = %v", err)
+			}
+			want, err := parseSIDString(tt.want)
+			if err != nil {
+				t.Fatalf("parseSIDString() unexpected error = %v", err)
+			}
+			wantSID, _ := want.toSID(nil)
+			if !got.Equal(wantSID) {
+				t.Errorf("parseSIDStringWithOptions() = %s, want %s", got.rawString(), tt.want)
+			}
+		})
+	}
+
+	// Security descriptor strings are written back with decimal sub-authorities
+	sd, err := FromStringWithOptions("O:S-1-5-21-1-2-3-0x3E9D:(A;;FA;;;S-1-5-0x20-0x221)", ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("FromStringWithOptions() unexpected error = %v", err)
+	}
+	if got, want := sd.String(), "O:S-1-5-21-1-2-3-1001D:(A;;FA;;;BU)"; got != want {
+		t.Errorf("FromStringWithOptions().String() = %s, want %s", got, want)
+	}
+}
+
 func TestComplete(t *testing.T) {
 	tests := []struct {
 		name    string
 		r       rid
-		s       sid
-		want    *sid
+		s       SID
+		want    *SID
 		wantErr error
 	}{
 		{
 			name: "Valid completion",
 			r:    rid(300), // on purpose is not a well-known RID so we can verify in test report
-			s: sid{
+			s: SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{21, 123, 456, 789, 2983},
 			},
-			want: &sid{
+			want: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{21, 123, 456, 789, 300},
@@ -1126,7 +1529,7 @@ func TestComplete(t *testing.T) {
 		{
 			name: "Empty sub-authority",
 			r:    rid(300),
-			s: sid{
+			s: SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{},
@@ -1156,7 +1559,7 @@ func TestComplete(t *testing.T) {
 }
 
 // Helper function to compare ACL fields
-func compareACLs(t *testing.T, prefix string, got, want *acl) {
+func compareACLs(t *testing.T, prefix string, got, want *ACL) {
 	t.Helper()
 
 	if got.aclRevision != want.aclRevision {
@@ -1189,6 +1592,12 @@ func compareACLs(t *testing.T, prefix string, got, want *acl) {
 		return
 	}
 
+	if got.sddlFlags != want.sddlFlags {
+		t.Errorf("%s.sddlFlags = %v, want %v", prefix, got.sddlFlags, want.sddlFlags)
+		t.FailNow()
+		return
+	}
+
 	// Compare ACEs
 	if len(got.aces) != len(want.aces) {
 		t.Errorf("%s.ACEs length = %v, want %v", prefix, len(got.aces), len(want.aces))
@@ -1226,7 +1635,7 @@ func compareACLs(t *testing.T, prefix string, got, want *acl) {
 }
 
 // Helper function to compare ACE fields
-func compareACEs(t *testing.T, prefix string, got, want *ace) {
+func compareACEs(t *testing.T, prefix string, got, want *ACE) {
 	t.Helper()
 
 	// Compare ACE Header
@@ -1401,7 +1810,7 @@ func compareSecurityDescriptors(t *testing.T, got, want *SecurityDescriptor) {
 }
 
 // Helper function to compare SID fields
-func compareSIDs(t *testing.T, prefix string, got, want *sid) {
+func compareSIDs(t *testing.T, prefix string, got, want *SID) {
 	t.Helper()
 
 	if got.revision != want.revision {
@@ -1430,3 +1839,1092 @@ func compareSIDs(t *testing.T, prefix string, got, want *sid) {
 		}
 	}
 }
+
+func TestNormalizeSID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "Lowercase hex authority",
+			input: "S-1-0xffff00000000-1-2",
+			want:  "S-1-0xFFFF00000000-1-2",
+		},
+		{
+			name:  "Mixed case hex authority",
+			input: "S-1-0xAbCdEf012345-1",
+			want:  "S-1-0xABCDEF012345-1",
+		},
+		{
+			name:  "Uppercase hex prefix",
+			input: "S-1-0XABCDEF012345-1",
+			want:  "S-1-0xABCDEF012345-1",
+		},
+		{
+			name:  "Unpadded hex authority",
+			input: "S-1-0x100000000-7",
+			want:  "S-1-0x000100000000-7",
+		},
+		{
+			name:  "Hex authority that fits in 32 bits",
+			input: "S-1-0x5-21-1-2-3-1001",
+			want:  "S-1-5-21-1-2-3-1001",
+		},
+		{
+			name:  "Surrounding whitespace",
+			input: "  S-1-5-21-1-2-3-1001\t",
+			want:  "S-1-5-21-1-2-3-1001",
+		},
+		{
+			name:  "Well-known SID",
+			input: "S-1-5-18",
+			want:  "SY",
+		},
+		{
+			name:  "Well-known alias",
+			input: "BA",
+			want:  "BA",
+		},
+		{
+			name:    "Domain relative alias",
+			input:   "LA",
+			wantErr: ErrMissingDomainInformation,
+		},
+		{
+			name:    "Invalid SID",
+			input:   "S-1-X",
+			wantErr: ErrInvalidAuthority,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NormalizeSID(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("NormalizeSID() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeSID() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeSID() = %s, want %s", got, tt.want)
+			}
+
+			// Normalizing is idempotent
+			again, err := NormalizeSID(got)
+			if err != nil || again != got {
+				t.Errorf("NormalizeSID(%q) = %q, %v, want %q", got, again, err, got)
+			}
+		})
+	}
+}
+
+func TestFromStringWithOptions_StripComments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    ParseOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Trailing comment",
+			input: "O:SY # owner is system",
+			opts:  ParseOptions{StripComments: true},
+			want:  "O:SY",
+		},
+		{
+			name:  "Comment after ACEs",
+			input: "O:SYD:(A;;FA;;;SY)(A;;FR;;;WD)\t# everyone can read",
+			opts:  ParseOptions{StripComments: true},
+			want:  "O:SYD:(A;;FA;;;SY)(A;;FR;;;WD)",
+		},
+		{
+			name:  "Custom marker",
+			input: "O:SYG:BA // annotated",
+			opts:  ParseOptions{StripComments: true, CommentMarker: "//"},
+			want:  "O:SYG:BA",
+		},
+		{
+			name:  "Comment only",
+			input: "# nothing to see",
+			opts:  ParseOptions{StripComments: true},
+			want:  "",
+		},
+		{
+			name:  "No comment",
+			input: "O:SYG:BA",
+			opts:  ParseOptions{StripComments: true},
+			want:  "O:SYG:BA",
+		},
+		{
+			name:    "Comments not stripped by default",
+			input:   "O:SY # owner is system",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromStringWithOptions(tt.input, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromStringWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripComments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		marker string
+		want   string
+	}{
+		{name: "No comment", input: "O:SYD:(A;;FA;;;SY)", marker: "#", want: "O:SYD:(A;;FA;;;SY)"},
+		{name: "Trailing comment", input: "O:SY   # owner", marker: "#", want: "O:SY"},
+		{name: "Marker in a conditional expression", input: `D:(XA;;FA;;;WD;(@User.Tag == "#1")) # tagged`, marker: "#", want: `D:(XA;;FA;;;WD;(@User.Tag == "#1"))`},
+		{name: "Marker in nested parentheses", input: "D:(XA;;FA;;;WD;(Member_of {SID(BA)} #x)) #y", marker: "#", want: "D:(XA;;FA;;;WD;(Member_of {SID(BA)} #x))"},
+		{name: "Custom marker", input: "O:SY // owner # not a comment", marker: "//", want: "O:SY"},
+		{name: "Empty marker stands for #", input: "O:SY # owner", marker: "", want: "O:SY"},
+		{name: "Empty marker without comment", input: "O:SYD:(A;;FA;;;SY)", marker: "", want: "O:SYD:(A;;FA;;;SY)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := StripComments(tt.input, tt.marker); got != tt.want {
+				t.Errorf("StripComments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromString_ACLRevision(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		wantDACL byte
+		wantSACL byte
+	}{
+		{
+			name:     "No object ACEs",
+			input:    "D:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)",
+			wantDACL: aclRevision,
+			wantSACL: aclRevision,
+		},
+		{
+			name:     "Empty ACLs",
+			input:    "D:S:",
+			wantDACL: aclRevision,
+			wantSACL: aclRevision,
+		},
+		{
+			name:     "Object ACE in the DACL only",
+			input:    "D:(A;;FA;;;SY)(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)S:(AU;SA;FA;;;WD)",
+			wantDACL: aclRevisionDS,
+			wantSACL: aclRevision,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			if got := sd.DACL().aclRevision; got != tt.wantDACL {
+				t.Errorf("DACL revision = %d, want %d", got, tt.wantDACL)
+			}
+			if got := sd.SACL().aclRevision; got != tt.wantSACL {
+				t.Errorf("SACL revision = %d, want %d", got, tt.wantSACL)
+			}
+		})
+	}
+}
+
+func TestFromString_EmptyAccessMask(t *testing.T) {
+	t.Parallel()
+
+	const input = "D:(A;;;;;SY)(D;OICI;;;;WD)"
+
+	sd, err := FromString(input)
+	if err != nil {
+		t.Fatalf("FromString() unexpected error = %v", err)
+	}
+	for i, ace := range sd.DACL().ACEs() {
+		if ace.accessMask != 0 {
+			t.Errorf("ACE %d access mask = 0x%08X, want 0", i, ace.accessMask)
+		}
+	}
+	if got := sd.String(); got != input {
+		t.Errorf("String() = %s, want %s", got, input)
+	}
+
+	back, err := FromBinary(sd.Binary())
+	if err != nil {
+		t.Fatalf("Binary() -> FromBinary() unexpected error = %v", err)
+	}
+	if got := back.String(); got != input {
+		t.Errorf("Binary() -> FromBinary() -> String() = %s, want %s", got, input)
+	}
+}
+
+func TestFromString_WindowsStyleDescriptors(t *testing.T) {
+	t.Parallel()
+
+	// Descriptors written by hand in the form Windows uses for common objects, covering the SID aliases, the
+	// ACE types and the ACL flags
+	corpus := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Windows directory",
+			input: "O:S-1-5-80-956008885-3418522649-1831038044-1853292631-2271478464G:S-1-5-80-956008885-3418522649-1831038044-1853292631-2271478464D:PAI(A;;0x1301bf;;;SY)(A;OICIIO;GA;;;SY)(A;;0x1301bf;;;BA)(A;OICIIO;GA;;;BA)(A;;0x1200a9;;;BU)(A;OICIIO;GXGR;;;BU)(A;OICIIO;GA;;;CO)(A;;0x1200a9;;;AC)(A;OICIIO;GXGR;;;AC)(A;;0x1200a9;;;S-1-15-2-2)(A;OICIIO;GXGR;;;S-1-15-2-2)",
+		},
+		{
+			name:  "User profile directory with integrity label",
+			input: "O:S-1-5-21-1004336348-1177238915-682003330-1001G:S-1-5-21-1004336348-1177238915-682003330-513D:P(A;OICI;FA;;;SY)(A;OICI;FA;;;BA)(A;OICI;FA;;;S-1-5-21-1004336348-1177238915-682003330-1001)S:AI(ML;OICIID;NW;;;ME)",
+		},
+		{
+			name:  "Low integrity directory",
+			input: "O:BAG:SYD:(A;OICI;FA;;;SY)(A;OICI;FA;;;BA)(A;OICI;FA;;;AU)S:(ML;OICI;NRNWNX;;;LW)",
+		},
+		{
+			name:  "Registry key",
+			input: "O:BAG:SYD:PAI(A;CIIO;KA;;;CO)(A;CI;KA;;;SY)(A;CI;KA;;;BA)(A;CI;KR;;;BU)(A;CI;KR;;;AC)(A;CI;KR;;;S-1-15-3-1024-1065365936-1281604716-3511738428-1654721687-432734479-3232135806-4053264122-3456934681)",
+		},
+		{
+			name:  "Service",
+			input: "D:(A;;CCLCSWRPWPDTLOCRRC;;;SY)(A;;CCDCLCSWRPWPDTLOCRSDRCWDWO;;;BA)(A;;CCLCSWLOCRRC;;;IU)(A;;CCLCSWLOCRRC;;;SU)S:(AU;FA;CCDCLCSWRPWPDTLOCRSDRCWDWO;;;WD)",
+		},
+		{
+			name:  "Service accounts",
+			input: "O:NSG:NSD:(A;;FA;;;NS)(A;;FA;;;LS)(A;;FR;;;WR)(A;;FR;;;OW)",
+		},
+		{
+			name:  "Domain groups",
+			input: "O:DAG:DUD:AI(A;;FA;;;S-1-5-21-1004336348-1177238915-682003330-1001)(A;;FA;;;DA)(A;;FR;;;DU)(A;;FA;;;EA)(D;;FA;;;DG)(A;;FR;;;DC)(A;;FR;;;DD)(A;;FR;;;AP)",
+		},
+		{
+			name:  "NULL DACL",
+			input: "O:BAG:SYD:NO_ACCESS_CONTROL",
+		},
+		{
+			name:  "Protected NULL DACL",
+			input: "O:SYG:SYD:PNO_ACCESS_CONTROLS:(ML;;NW;;;HI)",
+		},
+		{
+			name:  "Process trust label",
+			input: "S:(TL;;0x0200;;;S-1-19-512-4096)",
+		},
+		{
+			name:  "Built-in groups",
+			input: "D:(A;;FR;;;MU)(A;;FR;;;LU)(A;;FR;;;IS)(A;;FR;;;CY)(A;;FR;;;ER)(A;;FR;;;CD)(A;;FR;;;RA)(A;;FR;;;ES)(A;;FR;;;MS)(A;;FR;;;HA)(A;;FR;;;AA)(A;;FR;;;RM)(A;;FR;;;UD)",
+		},
+		{
+			name:  "Conditional ACE",
+			input: `D:(XA;;FX;;;S-1-1-0;(@User.Title=="PM"))`,
+		},
+		{
+			name:  "Conditional deny and audit ACEs",
+			input: `D:(XD;;FW;;;WD;(!(Member_of {SID(BA), SID(SY)})))S:(XU;SA;FR;;;WD;(@Resource.Secrecy >= 3 && Exists @Device.Managed))`,
+		},
+		{
+			name:  "Conditional object ACE",
+			input: `D:(ZA;;CR;00299570-246d-11d0-a768-00aa006e0529;;AU;(@User.Department Any_of {"Sales", "HR"}))`,
+		},
+		{
+			name:  "Central access policy resources",
+			input: `S:(RA;;;;;WD;("Project",TS,0x0,"Windows","SQL"))(RA;;;;;WD;("Secrecy",TU,0x0,3))(RA;;;;;WD;("Owners",TD,0x0,BA,S-1-5-32-545))`,
+		},
+	}
+
+	for _, tt := range corpus {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			if err := sd.Validate(); err != nil {
+				t.Fatalf("Validate() unexpected error = %v", err)
+			}
+
+			// The string form is canonical, so it must survive a trip through the binary form and back
+			back, err := FromBinary(sd.Binary())
+			if err != nil {
+				t.Fatalf("Binary() -> FromBinary() unexpected error = %v", err)
+			}
+			if got, want := back.String(), sd.String(); got != want {
+				t.Errorf("Binary() -> FromBinary() -> String() = %s, want %s", got, want)
+			}
+			reparsed, err := FromString(sd.String())
+			if err != nil {
+				t.Fatalf("String() -> FromString() unexpected error = %v", err)
+			}
+			compareSecurityDescriptors(t, reparsed, sd)
+		})
+	}
+}
+
+func TestFromString_NoAccessControl(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		wantNull bool
+		wantErr  bool
+	}{
+		{name: "NULL DACL", input: "D:NO_ACCESS_CONTROL", want: "D:NO_ACCESS_CONTROL", wantNull: true},
+		{name: "Protected NULL DACL", input: "O:SYD:PAINO_ACCESS_CONTROL", want: "O:SYD:PAINO_ACCESS_CONTROL", wantNull: true},
+		{name: "NULL SACL", input: "D:(A;;FA;;;SY)S:NO_ACCESS_CONTROL", want: "D:(A;;FA;;;SY)S:NO_ACCESS_CONTROL"},
+		{name: "Empty DACL is not NULL", input: "D:", want: "D:"},
+		{name: "ACEs after NO_ACCESS_CONTROL", input: "D:NO_ACCESS_CONTROL(A;;FA;;;SY)", wantErr: true},
+		{name: "Truncated keyword", input: "D:NO_ACCESS", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := sd.HasNullDACL(); got != tt.wantNull {
+				t.Errorf("HasNullDACL() = %v, want %v", got, tt.wantNull)
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+
+			// NULL ACLs are written with a zero offset and the present flag
+			back, err := FromBinary(sd.Binary())
+			if err != nil {
+				t.Fatalf("Binary() -> FromBinary() unexpected error = %v", err)
+			}
+			if got := back.String(); got != tt.want {
+				t.Errorf("Binary() -> FromBinary() -> String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessMask_Keywords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  uint32
+	}{
+		{input: "KA", want: 0x000f003f},
+		{input: "KR", want: 0x00020019},
+		{input: "KW", want: 0x00020006},
+		{input: "KX", want: 0x00020019},
+		{input: "NW", want: 0x00000001},
+		{input: "NR", want: 0x00000002},
+		{input: "NX", want: 0x00000004},
+		{input: "NRNWNX", want: 0x00000007},
+		{input: "FRFW", want: 0x0012019f},
+		{input: "KRSD", want: 0x00030019},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseAccessMask(tt.input)
+			if err != nil {
+				t.Fatalf("parseAccessMask() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseAccessMask() = 0x%08X, want 0x%08X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessMask_Combined(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    uint32
+		wantErr bool
+	}{
+		{input: "FR|0x100", want: 0x00120189},
+		{input: "0x100|FR", want: 0x00120189},
+		{input: "FR|0x100|SD", want: 0x00130189},
+		{input: "RPWP|CR", want: 0x00000130},
+		{input: "FA|FA", want: 0x001f01ff},
+		{input: "NW|NR", want: 0x00000003},
+		{input: "FR0x100", wantErr: true},
+		{input: "FR|", wantErr: true},
+		{input: "|FR", wantErr: true},
+		{input: "FR||SD", wantErr: true},
+		{input: "FR|XX", wantErr: true},
+		{input: "FR|0xZZ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseAccessMask(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAccessMask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAccessMask() = 0x%08X, want 0x%08X", got, tt.want)
+			}
+		})
+	}
+
+	// Combined masks are written in the usual form, which parses back to the same mask
+	sd := mustFromString(t, "D:(A;;FR|0x100;;;WD)(A;;RP|WP;;;AU)(A;;FR|0x800000;;;BU)")
+	if got, want := sd.String(), "D:(A;;CCSWLOCRRCSY;;;WD)(A;;RPWP;;;AU)(A;;0x00920089;;;BU)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	compareSecurityDescriptors(t, mustFromString(t, sd.String()), sd)
+
+	// Raw masks must be a single hexadecimal value
+	if _, err := FromStringWithOptions("D:(A;;0x1|0x2;;;WD)", ParseOptions{RawMasks: true}); err == nil {
+		t.Errorf("FromStringWithOptions() expected error for a combined raw mask, got nil")
+	}
+}
+
+func TestFromStringWithOptions_Lenient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		strictErr bool
+	}{
+		{
+			name:      "Spaces between ACEs",
+			input:     "D: (A;;FA;;;SY) (D;;FR;;;WD)",
+			want:      "D:(A;;FA;;;SY)(D;;FR;;;WD)",
+			strictErr: true,
+		},
+		{
+			name:      "Spaces between components",
+			input:     "O:SY G:BA D:PAI (A;;FA;;;SY)\tS:(AU;SA;FA;;;WD)",
+			want:      "O:SYG:BAD:PAI(A;;FA;;;SY)S:(AU;SA;FA;;;WD)",
+			strictErr: true,
+		},
+		{
+			name:      "Surrounding whitespace and line breaks",
+			input:     "  O:SY\nD:\n  (A;;FA;;;SY)\n  (A;;FR;;;BU)\n",
+			want:      "O:SYD:(A;;FA;;;SY)(A;;FR;;;BU)",
+			strictErr: true,
+		},
+		{
+			name:      "Spaced NULL DACL",
+			input:     "D: NO_ACCESS_CONTROL ",
+			want:      "D:NO_ACCESS_CONTROL",
+			strictErr: true,
+		},
+		{
+			name:  "Canonical input",
+			input: "O:SYD:(A;;FA;;;SY)",
+			want:  "O:SYD:(A;;FA;;;SY)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := FromString(tt.input); (err != nil) != tt.strictErr {
+				t.Errorf("FromString() error = %v, wantErr %v", err, tt.strictErr)
+			}
+
+			sd, err := FromStringWithOptions(tt.input, ParseOptions{Lenient: true})
+			if err != nil {
+				t.Fatalf("FromStringWithOptions() unexpected error = %v", err)
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("FromStringWithOptions().String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Whitespace is only skipped around components and ACEs, and content that is not a component is
+	// still rejected in both modes
+	for _, input := range []string{"D:(A; ;FA;;;SY)", "D:(A;;FA;;;SY) x", "XO:SY", "O:SY x G:BA"} {
+		if _, err := FromString(input); err == nil {
+			t.Errorf("FromString(%q) expected error, got nil", input)
+		}
+		if _, err := FromStringWithOptions(input, ParseOptions{Lenient: true}); err == nil {
+			t.Errorf("FromStringWithOptions(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestFromStringWithOptions_RawMasks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Full access",
+			input: "D:(A;;0x1F01FF;;;SY)",
+			want:  "D:(A;;0x1F01FF;;;SY)",
+		},
+		{
+			name:  "Mandatory label policy",
+			input: "S:(ML;;0x1;;;LW)",
+			want:  "S:(ML;;0x1;;;LW)",
+		},
+		{
+			name:  "Padded and empty masks",
+			input: "D:(A;;0x00120089;;;BU)(D;;;;;WD)",
+			want:  "D:(A;;0x120089;;;BU)(D;;0x0;;;WD)",
+		},
+		{
+			name:    "Well-known mnemonic rejected",
+			input:   "D:(A;;FA;;;SY)",
+			wantErr: true,
+		},
+		{
+			name:    "Combined mnemonics rejected",
+			input:   "D:(A;;RPWP;;;SY)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromStringWithOptions(tt.input, ParseOptions{RawMasks: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromStringWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("FromStringWithOptions().String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Binary descriptors decoded with RawMasks write their masks in hexadecimal as well, while the
+	// default stays mnemonic-aware
+	data := mustFromString(t, "O:SYD:(A;;FA;;;SY)").Binary()
+	sd, err := FromBinaryWithOptions(data, ParseOptions{RawMasks: true})
+	if err != nil {
+		t.Fatalf("FromBinaryWithOptions() unexpected error = %v", err)
+	}
+	if got, want := sd.String(), "O:SYD:(A;;0x1F01FF;;;SY)"; got != want {
+		t.Errorf("FromBinaryWithOptions().String() = %s, want %s", got, want)
+	}
+	if got, want := mustFromString(t, "D:(A;;0x1F01FF;;;SY)").String(), "D:(A;;FA;;;SY)"; got != want {
+		t.Errorf("FromString().String() = %s, want %s", got, want)
+	}
+}
+
+func TestFromString_ParseErrorPosition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		input      string
+		opts       ParseOptions
+		wantOffset int
+		wantACE    int
+	}{
+		{
+			name:       "Content after the last ACE",
+			input:      "O:SYG:BAD:(A;;FA;;;SY)garbage",
+			wantOffset: 22,
+			wantACE:    1,
+		},
+		{
+			name:       "Unexpected content before the components",
+			input:      "xO:SY",
+			wantOffset: 0,
+			wantACE:    -1,
+		},
+		{
+			name:       "Invalid owner",
+			input:      "G:BAO:S-1-X",
+			wantOffset: 4,
+			wantACE:    -1,
+		},
+		{
+			name:       "Invalid ACL flags",
+			input:      "O:SYD:PXY(A;;FA;;;SY)",
+			wantOffset: 4,
+			wantACE:    -1,
+		},
+		{
+			name:       "Invalid second ACE",
+			input:      "O:SYD:PAI(A;;FA;;;SY)(A;;XX;;;BA)",
+			wantOffset: 21,
+			wantACE:    1,
+		},
+		{
+			name:       "Invalid first SACL ACE",
+			input:      "D:(A;;FA;;;SY)S:(AU;SA;FA;;;ZZ)",
+			wantOffset: 16,
+			wantACE:    0,
+		},
+		{
+			name:       "Missing closing parenthesis",
+			input:      "D:(A;;FA;;;SY)(A;;FA;;;BA",
+			wantOffset: 14,
+			wantACE:    1,
+		},
+		{
+			name:       "Lenient whitespace",
+			input:      "  O:SY D: (A;;FA;;;SY) (A;;XX;;;BA)",
+			opts:       ParseOptions{Lenient: true},
+			wantOffset: 23,
+			wantACE:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := FromStringWithOptions(tt.input, tt.opts)
+			if !errors.Is(err, ErrInvalidSDFormat) {
+				t.Fatalf("FromStringWithOptions() error = %v, want %v", err, ErrInvalidSDFormat)
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("FromStringWithOptions() error = %T, want *ParseError", err)
+			}
+			if pe.Offset != tt.wantOffset || pe.ACE != tt.wantACE {
+				t.Errorf("ParseError = offset %d ACE %d, want offset %d ACE %d (%v)", pe.Offset, pe.ACE, tt.wantOffset, tt.wantACE, err)
+			}
+		})
+	}
+
+	// The underlying errors are still wrapped
+	_, err := FromString("O:S-2-5-18")
+	if !errors.Is(err, ErrInvalidRevision) || !errors.Is(err, ErrInvalidSDFormat) {
+		t.Errorf("FromString() error = %v, want %v and %v", err, ErrInvalidRevision, ErrInvalidSDFormat)
+	}
+}
+
+func TestFromString_NoControlACLFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "D:AINO", want: "D:AINO"},
+		{input: "D:NOAI", want: "D:AINO"},
+		{input: "D:IO(A;;FA;;;SY)", want: "D:IO(A;;FA;;;SY)"},
+		{input: "D:IONOP(A;;FA;;;SY)S:ARNO", want: "D:PNOIO(A;;FA;;;SY)S:ARNO"},
+		{input: "D:PAIARRNOIOS:PAIARRNOIO", want: "D:PARAIRNOIOS:PARAIRNOIO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.input)
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+			if got := mustFromString(t, sd.String()).String(); got != tt.want {
+				t.Errorf("String() -> FromString() -> String() = %s, want %s", got, tt.want)
+			}
+			if got := sd.ExplicitOnly().String(); got != tt.want {
+				t.Errorf("ExplicitOnly().String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// The flags have no binary representation
+	sd, err := FromBinary(mustFromString(t, "D:AINO(A;;FA;;;SY)").Binary())
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	if got, want := sd.String(), "D:AI(A;;FA;;;SY)"; got != want {
+		t.Errorf("Binary() -> FromBinary() -> String() = %s, want %s", got, want)
+	}
+}
+
+func TestFromString_DuplicateComponent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		input      string
+		wantErr    string
+		wantOffset int
+	}{
+		{
+			name:       "Duplicate owner",
+			input:      "O:SYO:BA",
+			wantErr:    "duplicate owner component",
+			wantOffset: 4,
+		},
+		{
+			name:       "Duplicate group after another component",
+			input:      "G:SYD:(A;;FA;;;SY)G:BA",
+			wantErr:    "duplicate group component",
+			wantOffset: 18,
+		},
+		{
+			name:       "Duplicate DACL",
+			input:      "D:(A;;FA;;;SY)D:(A;;FA;;;BA)",
+			wantErr:    "duplicate DACL component",
+			wantOffset: 14,
+		},
+		{
+			name:       "Duplicate SACL after all components",
+			input:      "O:SYG:BAD:S:S:",
+			wantErr:    "duplicate SACL component",
+			wantOffset: 12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := FromString(tt.input)
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("FromString() error = %v, want *ParseError", err)
+			}
+			if pe.Err.Error() != tt.wantErr || pe.Offset != tt.wantOffset {
+				t.Errorf("FromString() error = %q at offset %d, want %q at offset %d", pe.Err, pe.Offset, tt.wantErr, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestFromString_EmptyComponent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		input      string
+		opts       ParseOptions
+		wantErr    string
+		wantOffset int
+	}{
+		{
+			name:       "Empty group before DACL",
+			input:      "O:SYG:D:(A;;FA;;;SY)",
+			wantErr:    "empty group component",
+			wantOffset: 4,
+		},
+		{
+			name:       "Empty owner before group",
+			input:      "O:G:SY",
+			wantErr:    "empty owner component",
+			wantOffset: 0,
+		},
+		{
+			name:       "Empty owner at end",
+			input:      "D:(A;;FA;;;SY)O:",
+			wantErr:    "empty owner component",
+			wantOffset: 14,
+		},
+		{
+			name:       "Blank group with lenient parsing",
+			input:      "O:SY G: D:(A;;FA;;;SY)",
+			opts:       ParseOptions{Lenient: true},
+			wantErr:    "empty group component",
+			wantOffset: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := FromStringWithOptions(tt.input, tt.opts)
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("FromStringWithOptions() error = %v, want *ParseError", err)
+			}
+			if pe.Err.Error() != tt.wantErr || pe.Offset != tt.wantOffset {
+				t.Errorf("FromStringWithOptions() error = %q at offset %d, want %q at offset %d", pe.Err, pe.Offset, tt.wantErr, tt.wantOffset)
+			}
+		})
+	}
+
+	// An empty DACL or SACL is valid and different from a missing one
+	if _, err := FromString("O:SYG:BAD:S:"); err != nil {
+		t.Errorf("FromString() unexpected error = %v", err)
+	}
+}
+
+func TestFromString_DecimalAccessMask(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     ParseOptions
+		wantMask uint32
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Decimal mask with a mnemonic",
+			input:    "D:(A;;2032127;;;SY)",
+			opts:     ParseOptions{Lenient: true},
+			wantMask: FileAllAccess,
+			want:     "D:(A;;FA;;;SY)",
+		},
+		{
+			name:     "Decimal mask without mnemonic",
+			input:    "D:(A;;8388608;;;SY)",
+			opts:     ParseOptions{Lenient: true},
+			wantMask: 0x00800000,
+			want:     "D:(A;;0x00800000;;;SY)",
+		},
+		{
+			name:     "Maximum decimal mask",
+			input:    "D:(A;;4294967295;;;SY)",
+			opts:     ParseOptions{Lenient: true},
+			wantMask: 0xFFFFFFFF,
+			want:     "D:(A;;0xFFFFFFFF;;;SY)",
+		},
+		{
+			name:     "Mnemonics are still accepted",
+			input:    "D:(A;;FR;;;SY)",
+			opts:     ParseOptions{Lenient: true},
+			wantMask: FileGenericRead,
+			want:     "D:(A;;FR;;;SY)",
+		},
+		{
+			name:     "Decimal part with a mnemonic",
+			input:    "D:(A;;FR|256;;;SY)",
+			opts:     ParseOptions{Lenient: true},
+			wantMask: FileGenericRead | 256,
+			want:     "D:(A;;CCSWLOCRRCSY;;;SY)",
+		},
+		{
+			name:     "Decimal and hexadecimal parts",
+			input:    "D:(A;;256|0x1;;;SY)",
+			opts:     ParseOptions{Lenient: true},
+			wantMask: 0x00000101,
+			want:     "D:(A;;CCCR;;;SY)",
+		},
+		{
+			name:    "Empty part with a decimal part",
+			input:   "D:(A;;FR||256;;;SY)",
+			opts:    ParseOptions{Lenient: true},
+			wantErr: true,
+		},
+		{
+			name:    "Decimal part overflow",
+			input:   "D:(A;;FR|99999999999;;;SY)",
+			opts:    ParseOptions{Lenient: true},
+			wantErr: true,
+		},
+		{
+			name:    "Decimal part in strict mode",
+			input:   "D:(A;;FR|256;;;SY)",
+			wantErr: true,
+		},
+		{
+			name:    "Decimal mask overflow",
+			input:   "D:(A;;4294967296;;;SY)",
+			opts:    ParseOptions{Lenient: true},
+			wantErr: true,
+		},
+		{
+			name:    "Decimal mask in strict mode",
+			input:   "D:(A;;2032127;;;SY)",
+			wantErr: true,
+		},
+		{
+			name:    "Decimal mask with raw masks",
+			input:   "D:(A;;2032127;;;SY)",
+			opts:    ParseOptions{Lenient: true, RawMasks: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromStringWithOptions(tt.input, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromStringWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := sd.DACL().ACEs()[0].AccessMask(); got != tt.wantMask {
+				t.Errorf("AccessMask() = 0x%08X, want 0x%08X", got, tt.wantMask)
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromStringWithOptions_Unescape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    ParseOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "JSON unicode escapes",
+			input: `O:SYG:BAD:\u0028A;;FA;;;SY\u0029(A;;FR;;;S-1-5-21-1-2-3-1001)`,
+			opts:  ParseOptions{Unescape: UnescapeJSON},
+			want:  "O:SYG:BAD:(A;;FA;;;SY)(A;;FR;;;S-1-5-21-1-2-3-1001)",
+		},
+		{
+			name:    "Invalid JSON escape",
+			input:   `O:SYD:\x28A;;FA;;;SY)`,
+			opts:    ParseOptions{Unescape: UnescapeJSON},
+			wantErr: true,
+		},
+		{
+			name:    "Unescaped double quote in JSON",
+			input:   `O:SYD:(A;;FA;;;"SY")`,
+			opts:    ParseOptions{Unescape: UnescapeJSON},
+			wantErr: true,
+		},
+		{
+			name:  "URL escaped",
+			input: "O:SYD:%28A%3B%3BFA%3B%3B%3BSY%29",
+			opts:  ParseOptions{Unescape: UnescapeURL},
+			want:  "O:SYD:(A;;FA;;;SY)",
+		},
+		{
+			name:    "Invalid URL escape",
+			input:   "O:SYD:%2",
+			opts:    ParseOptions{Unescape: UnescapeURL},
+			wantErr: true,
+		},
+		{
+			name:    "No unescaping by default",
+			input:   `O:SYD:\u0028A;;FA;;;SY\u0029`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromStringWithOptions(tt.input, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromStringWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSDFormat) {
+					t.Errorf("FromStringWithOptions() error = %v, want %v", err, ErrInvalidSDFormat)
+				}
+				return
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlagsForACEType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		flags   string
+		aceType byte
+		lenient bool
+		want    byte
+		wantErr bool
+	}{
+		{name: "Canonical order", flags: "OICI", aceType: accessAllowedACEType, want: objectInheritACE | containerInheritACE},
+		{name: "Reordered", flags: "CIOI", aceType: accessAllowedACEType, want: objectInheritACE | containerInheritACE},
+		{name: "Reordered with inherited", flags: "IDCIIOOI", aceType: accessAllowedACEType, want: objectInheritACE | containerInheritACE | inheritOnlyACE | inheritedACE},
+		{name: "Audit flags reordered", flags: "FASA", aceType: systemAuditACEType, want: successfulAccessACE | failedAccessACE},
+		{name: "Lowercase in strict mode", flags: "oici", aceType: accessAllowedACEType, wantErr: true},
+		{name: "Lowercase", flags: "oici", aceType: accessAllowedACEType, lenient: true, want: objectInheritACE | containerInheritACE},
+		{name: "Mixed case reordered", flags: "cIOiNp", aceType: accessAllowedACEType, lenient: true, want: objectInheritACE | containerInheritACE | noPropagateInheritACE},
+		{name: "Lowercase audit flags", flags: "saci", aceType: systemAuditACEType, lenient: true, want: successfulAccessACE | containerInheritACE},
+		{name: "Duplicate flag", flags: "CIOICI", aceType: accessAllowedACEType, wantErr: true},
+		{name: "Duplicate flag in different cases", flags: "CIci", aceType: accessAllowedACEType, lenient: true, wantErr: true},
+		{name: "Unknown lowercase flag", flags: "xx", aceType: accessAllowedACEType, lenient: true, wantErr: true},
+		{name: "Lowercase audit flag on allow ACE", flags: "sa", aceType: accessAllowedACEType, lenient: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseFlagsForACEType(tt.flags, tt.aceType, tt.lenient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlagsForACEType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseFlagsForACEType() = 0x%02X, want 0x%02X", got, tt.want)
+			}
+		})
+	}
+
+	// Flags are written back in uppercase and in the usual order
+	sd, err := FromStringWithOptions("D:(A;ciOi;FA;;;SY)", ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("FromStringWithOptions() unexpected error = %v", err)
+	}
+	if got, want := sd.String(), "D:(A;OICI;FA;;;SY)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package sddl
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// GUID represents a Windows GUID (globally unique identifier) as stored in binary structures.
+//
+// GUIDs are used by object ACEs to identify the type of object, property set or extended right the
+// ACE applies to. The 16 bytes follow the Windows GUID structure layout, which mixes endianness:
+//   - Data1 (4 bytes, little-endian)
+//   - Data2 (2 bytes, little-endian)
+//   - Data3 (2 bytes, little-endian)
+//   - Data4 (8 bytes, stored as is)
+//
+// See https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/49e490b8-f972-45d6-a3a4-99f924998d97
+type GUID [16]byte
+
+// String returns the RFC 4122 string representation of the GUID in lowercase, as used in SDDL
+// (e.g. "bf967aba-0de6-11d0-a285-00aa003049e2").
+func (g GUID) String() string {
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(g[0:4]),
+		binary.LittleEndian.Uint16(g[4:6]),
+		binary.LittleEndian.Uint16(g[6:8]),
+		g[8:10],
+		g[10:16],
+	)
+}
+
+// parseGUID parses a GUID from its RFC 4122 string representation ("xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx").
+// Hexadecimal digits are accepted in any case.
+func parseGUID(s string) (GUID, error) {
+	var g GUID
+
+	parts := strings.Split(s, "-")
+	if len(parts) != 5 || len(parts[0]) != 8 || len(parts[1]) != 4 || len(parts[2]) != 4 || len(parts[3]) != 4 || len(parts[4]) != 12 {
+		return g, fmt.Errorf("invalid GUID %q: expected format xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx", s)
+	}
+
+	raw, err := hex.DecodeString(strings.Join(parts, ""))
+	if err != nil {
+		return g, fmt.Errorf("invalid GUID %q: %w", s, err)
+	}
+
+	// The first three fields are stored in little-endian order, the last two as they are written
+	binary.LittleEndian.PutUint32(g[0:4], binary.BigEndian.Uint32(raw[0:4]))
+	binary.LittleEndian.PutUint16(g[4:6], binary.BigEndian.Uint16(raw[4:6]))
+	binary.LittleEndian.PutUint16(g[6:8], binary.BigEndian.Uint16(raw[6:8]))
+	copy(g[8:], raw[8:])
+
+	return g, nil
+}
+
+// equalGUIDs reports whether a and b are both missing or hold the same GUID.
+func equalGUIDs(a, b *GUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// extendedRights maps the GUIDs of common Active Directory extended rights, which object ACEs granting
+// the control access right ("CR") reference as their object type, to their name (the CN of their
+// controlAccessRight object). See https://learn.microsoft.com/en-us/windows/win32/adschema/extended-rights
+var extendedRights = map[string]string{
+	"00299570-246d-11d0-a768-00aa006e0529": "User-Force-Change-Password", // Reset Password
+	"ab721a53-1e2f-11d0-9819-00aa0040529b": "User-Change-Password",       // Change Password
+	"ab721a54-1e2f-11d0-9819-00aa0040529b": "Send-As",
+	"ab721a55-1e2f-11d0-9819-00aa0040529b": "Send-To",
+	"ab721a56-1e2f-11d0-9819-00aa0040529b": "Receive-As",
+	"ccc2dc7d-a6ad-4a7a-8846-c04e3cc53501": "Unexpire-Password",
+	"1131f6aa-9c07-11d1-f79f-00c04fc2dcd2": "DS-Replication-Get-Changes",
+	"1131f6ad-9c07-11d1-f79f-00c04fc2dcd2": "DS-Replication-Get-Changes-All",
+	"89e95b76-444d-4c62-991a-0facbeda640c": "DS-Replication-Get-Changes-In-Filtered-Set",
+	"1131f6ab-9c07-11d1-f79f-00c04fc2dcd2": "DS-Replication-Synchronize",
+	"1131f6ac-9c07-11d1-f79f-00c04fc2dcd2": "DS-Replication-Manage-Topology",
+	"45ec5156-db7e-47bb-b53f-dbeb2d03c40f": "Reanimate-Tombstones",
+	"68b1d179-0d15-4d4f-ab71-46152e79a7bc": "Allowed-To-Authenticate",
+	"edacfd8f-ffb3-11d1-b41d-00a0c968f939": "Apply-Group-Policy",
+	"0e10c968-78fb-11d2-90d4-00c04f79dc55": "Certificate-Enrollment",
+}
+
+// ExtendedRightName returns the name of the Active Directory extended right the GUID identifies, such as
+// "Send-As" for ab721a54-1e2f-11d0-9819-00aa0040529b, if it is one of the common extended rights this
+// package knows. The name is only used for display by ACE.StringIndent, and accepted in place of the
+// GUID when parsing the object type of an ACE: SDDL strings always hold the GUID.
+func (g GUID) ExtendedRightName() (string, bool) {
+	name, ok := extendedRights[g.String()]
+	return name, ok
+}
+
+// extendedRightGUID returns the GUID string of the extended right with the given name, see
+// GUID.ExtendedRightName. Names are compared case-insensitively, as Active Directory does.
+func extendedRightGUID(name string) (string, bool) {
+	for guid, rightName := range extendedRights {
+		if strings.EqualFold(rightName, name) {
+			return guid, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,206 @@
+package sddl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGUID_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  GUID
+		str   string
+	}{
+		{
+			name:  "User class",
+			input: "bf967aba-0de6-11d0-a285-00aa003049e2",
+			want:  GUID{0xba, 0x7a, 0x96, 0xbf, 0xe6, 0x0d, 0xd0, 0x11, 0xa2, 0x85, 0x00, 0xaa, 0x00, 0x30, 0x49, 0xe2},
+			str:   "bf967aba-0de6-11d0-a285-00aa003049e2",
+		},
+		{
+			name:  "User-Force-Change-Password extended right",
+			input: "00299570-246d-11d0-a768-00aa006e0529",
+			want:  GUID{0x70, 0x95, 0x29, 0x00, 0x6d, 0x24, 0xd0, 0x11, 0xa7, 0x68, 0x00, 0xaa, 0x00, 0x6e, 0x05, 0x29},
+			str:   "00299570-246d-11d0-a768-00aa006e0529",
+		},
+		{
+			name:  "Uppercase input is rendered lowercase",
+			input: "AB721A53-1E2F-11D0-9819-00AA0040529B",
+			want:  GUID{0x53, 0x1a, 0x72, 0xab, 0x2f, 0x1e, 0xd0, 0x11, 0x98, 0x19, 0x00, 0xaa, 0x00, 0x40, 0x52, 0x9b},
+			str:   "ab721a53-1e2f-11d0-9819-00aa0040529b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseGUID(tt.input)
+			if err != nil {
+				t.Fatalf("parseGUID() unexpected error = %v", err)
+			}
+			if !bytes.Equal(got[:], tt.want[:]) {
+				t.Errorf("parseGUID() = % x, want % x", got[:], tt.want[:])
+			}
+			if s := got.String(); s != tt.str {
+				t.Errorf("GUID.String() = %s, want %s", s, tt.str)
+			}
+		})
+	}
+}
+
+func TestParseGUID_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Empty", input: ""},
+		{name: "Braces", input: "{bf967aba-0de6-11d0-a285-00aa003049e2}"},
+		{name: "Missing group", input: "bf967aba-0de6-11d0-00aa003049e2"},
+		{name: "Wrong group length", input: "bf967ab-a0de6-11d0-a285-00aa003049e2"},
+		{name: "Not hexadecimal", input: "bf967abz-0de6-11d0-a285-00aa003049e2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := parseGUID(tt.input); err == nil {
+				t.Errorf("parseGUID(%q) expected error, got nil", tt.input)
+			}
+		})
+	}
+}
+
+func TestObjectACE_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Both object types",
+			input: "D:(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;WD)",
+			want:  "D:(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;WD)",
+		},
+		{
+			name:  "Object type only, uppercase",
+			input: "D:(OD;;RP;AB721A53-1E2F-11D0-9819-00AA0040529B;;BA)",
+			want:  "D:(OD;;RP;ab721a53-1e2f-11d0-9819-00aa0040529b;;BA)",
+		},
+		{
+			name:  "Inherited object type only",
+			input: "D:(OA;CIIO;GA;;bf967aba-0de6-11d0-a285-00aa003049e2;PS)",
+			want:  "D:(OA;CIIO;GA;;bf967aba-0de6-11d0-a285-00aa003049e2;PS)",
+		},
+		{
+			name:  "Object audit ACE",
+			input: "S:(OU;SA;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)",
+			want:  "S:(OU;SA;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)",
+		},
+		{
+			name:  "No object types",
+			input: "D:(OA;;GA;;;SY)",
+			want:  "D:(OA;;GA;;;SY)",
+		},
+		{
+			name:    "Object type on non-object ACE",
+			input:   "D:(A;;GA;00299570-246d-11d0-a768-00aa006e0529;;SY)",
+			wantErr: true,
+		},
+		{
+			name:    "Malformed GUID",
+			input:   "D:(OA;;GA;00299570-246d;;SY)",
+			wantErr: true,
+		},
+		{
+			name:  "Extended right names",
+			input: "D:(OA;;CR;Send-As;;WD)(OA;;CR;user-force-change-password;bf967aba-0de6-11d0-a285-00aa003049e2;BA)",
+			want:  "D:(OA;;CR;ab721a54-1e2f-11d0-9819-00aa0040529b;;WD)(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;BA)",
+		},
+		{
+			name:    "Extended right name as inherited object type",
+			input:   "D:(OA;;CR;;Send-As;WD)",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown extended right name",
+			input:   "D:(OA;;CR;Send-Everything;;WD)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+
+			decoded, err := FromBinary(sd.Binary())
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			if got := decoded.String(); got != tt.want {
+				t.Errorf("FromBinary().String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGUID_ExtendedRightName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		guid   string
+		want   string
+		wantOk bool
+	}{
+		{guid: "ab721a54-1e2f-11d0-9819-00aa0040529b", want: "Send-As", wantOk: true},
+		{guid: "00299570-246d-11d0-a768-00aa006e0529", want: "User-Force-Change-Password", wantOk: true},
+		{guid: "1131f6ad-9c07-11d1-f79f-00c04fc2dcd2", want: "DS-Replication-Get-Changes-All", wantOk: true},
+		{guid: "bf967aba-0de6-11d0-a285-00aa003049e2"}, // The user class, not an extended right
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.guid, func(t *testing.T) {
+			t.Parallel()
+
+			guid, err := parseGUID(tt.guid)
+			if err != nil {
+				t.Fatalf("parseGUID() unexpected error = %v", err)
+			}
+			got, ok := guid.ExtendedRightName()
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ExtendedRightName() = (%s, %v), want (%s, %v)", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	// StringIndent shows the name after the GUID, String only the GUID
+	ace := mustFromString(t, "D:(OA;;CR;Send-As;bf967aba-0de6-11d0-a285-00aa003049e2;WD)").DACL().ACEs()[0]
+	want := "(OA;;CR;ab721a54-1e2f-11d0-9819-00aa0040529b [Send-As];bf967aba-0de6-11d0-a285-00aa003049e2;WD [S-1-1-0])"
+	if got := ace.StringIndent(0); got != want {
+		t.Errorf("StringIndent() = %s, want %s", got, want)
+	}
+	if got, want := ace.String(), "(OA;;CR;ab721a54-1e2f-11d0-9819-00aa0040529b;bf967aba-0de6-11d0-a285-00aa003049e2;WD)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
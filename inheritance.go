@@ -0,0 +1,155 @@
+package sddl
+
+// ComputeInherited returns the ACL that a newly created child object would inherit from this ACL,
+// following the Windows inheritance rules:
+//   - Only ACEs with CONTAINER_INHERIT_ACE (CI) are inherited by containers and only ACEs with
+//     OBJECT_INHERIT_ACE (OI) are inherited by objects (non-containers).
+//   - Every inherited ACE has the INHERITED_ACE (ID) flag set.
+//   - INHERIT_ONLY_ACE (IO) on the parent ACE is cleared on the child, so the inherited ACE applies to it.
+//   - ACEs with NO_PROPAGATE_INHERIT_ACE (NP) are inherited by the direct children only: the child ACE
+//     has all its inheritance flags cleared.
+//   - Objects cannot have children, so ACEs inherited by objects have all their inheritance flags cleared.
+//   - A container inherits ACEs that only have OI (and no NP) as inherit-only ACEs, so they keep flowing
+//     down to the objects it will contain without applying to the container itself.
+//   - CREATOR OWNER (CO) and CREATOR GROUP (CG) in ACEs applying to the child are replaced with the
+//     owner and group of the child. If the ACE keeps propagating, an inherit-only copy referencing
+//     the placeholder is kept after it, so the grandchildren get their own owner and group.
+//
+// Parameters:
+//   - isContainer: true if the child is a container (e.g. a directory), false for objects (e.g. a file)
+//   - owner: the owner of the child, or nil to keep CREATOR OWNER as it is
+//   - group: the primary group of the child, or nil to keep CREATOR GROUP as it is
+//
+// The returned ACL has the same type and revision as the receiver, and its size and ACE count are computed
+// from the inherited ACEs. Its control flags are not copied from the receiver: a protected (P) parent or
+// one requesting auto-inheritance (AR) doesn't make the child so, and the child ACL is marked
+// auto-inherited (AI) when it holds inherited ACEs. Other placeholders such as SELF are kept as they are.
+func (a *ACL) ComputeInherited(isContainer bool, owner, group *SID) *ACL {
+	const inheritanceFlags = objectInheritACE | containerInheritACE | noPropagateInheritACE | inheritOnlyACE
+
+	var aces []ACE
+	for i := range a.aces {
+		parent := &a.aces[i]
+		flags := parent.header.aceFlags
+
+		var childFlags byte
+		switch {
+		case !isContainer && flags&objectInheritACE != 0:
+			// Objects are leaves, inheritance stops here
+			childFlags = flags &^ inheritanceFlags
+		case isContainer && flags&containerInheritACE != 0:
+			if flags&noPropagateInheritACE != 0 {
+				childFlags = flags &^ inheritanceFlags
+			} else {
+				childFlags = flags &^ inheritOnlyACE
+			}
+		case isContainer && flags&objectInheritACE != 0 && flags&noPropagateInheritACE == 0:
+			// Only meant for objects: keep it for the container's children but don't apply it to the container
+			childFlags = flags | inheritOnlyACE
+		default:
+			continue
+		}
+
+		child := parent.clone()
+		child.header.aceFlags = childFlags | inheritedACE
+
+		creator := creatorReplacement(parent.sid, owner, group)
+		if creator == nil || childFlags&inheritOnlyACE != 0 {
+			aces = append(aces, *child)
+			continue
+		}
+
+		// The ACE applies to the child: it grants or denies access to its actual owner or group
+		effective := child.clone()
+		effective.header.aceFlags = childFlags&^inheritanceFlags | inheritedACE
+		effective.sid = creator.clone()
+		effective.header.aceSize = uint16(effective.BinarySize())
+		aces = append(aces, *effective)
+
+		if childFlags&(objectInheritACE|containerInheritACE) != 0 {
+			child.header.aceFlags |= inheritOnlyACE
+			aces = append(aces, *child)
+		}
+	}
+
+	inherited := &ACL{
+		aclRevision: a.aclRevision,
+		aclType:     a.aclType,
+		control:     inheritedACLControl(a.aclType, len(aces) > 0),
+		aces:        aces,
+	}
+	inherited.updateSize()
+
+	return inherited
+}
+
+// inheritedACLControl returns the control of an ACL of the given type ("D" or "S") inherited by a child:
+// the ACL is present and, if it holds inherited ACEs, auto-inherited. The protection and the auto-inherit
+// request of the parent describe the parent only.
+func inheritedACLControl(aclType string, hasACEs bool) uint16 {
+	control := uint16(seDACLPresent | seSACLPresent)
+	if hasACEs {
+		control |= seDACLAutoInherited | seSACLAutoInherited
+	}
+	return aclControl(aclType, control)
+}
+
+// creatorReplacement returns the SID replacing sid in inherited ACEs: owner for CREATOR OWNER and group
+// for CREATOR GROUP. It returns nil if sid is not one of these placeholders or if its replacement is nil.
+func creatorReplacement(sid, owner, group *SID) *SID {
+	if !sid.IsCreatorPlaceholder() {
+		return nil
+	}
+	if sid.subAuthority[0] == 0 {
+		return owner
+	}
+	return group
+}
+
+// ExplicitOnly returns a copy of the security descriptor whose DACL and SACL only hold the explicit ACEs,
+// the ones without the INHERITED_ACE (ID) flag, with their sizes and ACE counts computed again. It is the
+// baseline to re-apply on an object without the ACEs it got from its parent, e.g.
+// "D:AI(A;;FA;;;SY)(A;ID;FR;;;BU)" gives "D:AI(A;;FA;;;SY)".
+//
+// The revision, the control flags, the resource manager control bits, the owner and the group are kept
+// as they are, and so are NULL ACLs.
+// The receiver is not modified.
+func (sd *SecurityDescriptor) ExplicitOnly() *SecurityDescriptor {
+	explicit := &SecurityDescriptor{
+		revision: sd.revision,
+		sbzl:     sd.sbzl,
+		control:  sd.control,
+	}
+	if sd.ownerSID != nil {
+		explicit.ownerSID = sd.ownerSID.clone()
+	}
+	if sd.groupSID != nil {
+		explicit.groupSID = sd.groupSID.clone()
+	}
+	if sd.dacl != nil {
+		explicit.dacl = sd.dacl.explicitOnly()
+	}
+	if sd.sacl != nil {
+		explicit.sacl = sd.sacl.explicitOnly()
+	}
+	return explicit
+}
+
+// explicitOnly returns a copy of the ACL holding only its explicit ACEs, see SecurityDescriptor.ExplicitOnly.
+func (a *ACL) explicitOnly() *ACL {
+	explicit := &ACL{
+		aclRevision: a.aclRevision,
+		aclType:     a.aclType,
+		control:     a.control,
+		sddlFlags:   a.sddlFlags,
+	}
+	for i := range a.aces {
+		ace := &a.aces[i]
+		if ace.header != nil && ace.header.aceFlags&inheritedACE != 0 {
+			continue
+		}
+		explicit.aces = append(explicit.aces, *ace.clone())
+	}
+	explicit.updateSize()
+	return explicit
+}
@@ -0,0 +1,191 @@
+package sddl
+
+import "testing"
+
+func TestACL_ComputeInherited(t *testing.T) {
+	t.Parallel()
+
+	owner := NewSID(5, 21, 1, 2, 3, 1001)
+	group := NewSID(5, 21, 1, 2, 3, 513)
+
+	tests := []struct {
+		name        string
+		parent      string
+		isContainer bool
+		keepCreator bool
+		want        string
+	}{
+		{
+			name:        "Container child",
+			parent:      "D:(A;OICI;FA;;;BA)(A;CI;FR;;;BU)(A;OI;FX;;;WD)(A;OICIIO;GA;;;SY)(A;;FA;;;AU)",
+			isContainer: true,
+			want:        "AI(A;OICIID;FA;;;BA)(A;CIID;FR;;;BU)(A;OIIOID;FX;;;WD)(A;OICIID;GA;;;SY)",
+		},
+		{
+			name:        "Object child",
+			parent:      "D:(A;OICI;FA;;;BA)(A;CI;FR;;;BU)(A;OI;FX;;;WD)(A;OICIIO;GA;;;SY)(A;;FA;;;AU)",
+			isContainer: false,
+			want:        "AI(A;ID;FA;;;BA)(A;ID;FX;;;WD)(A;ID;GA;;;SY)",
+		},
+		{
+			name:        "No propagate to container",
+			parent:      "D:(A;CINP;FA;;;BA)(A;OICINPIO;FR;;;BU)(A;OINP;FX;;;WD)",
+			isContainer: true,
+			want:        "AI(A;ID;FA;;;BA)(A;ID;FR;;;BU)",
+		},
+		{
+			name:        "No propagate to object",
+			parent:      "D:(A;CINP;FA;;;BA)(A;OICINPIO;FR;;;BU)(A;OINP;FX;;;WD)",
+			isContainer: false,
+			want:        "AI(A;ID;FR;;;BU)(A;ID;FX;;;WD)",
+		},
+		{
+			name:        "Already inherited ACEs keep propagating",
+			parent:      "D:(A;OICIID;FA;;;SY)",
+			isContainer: true,
+			want:        "AI(A;OICIID;FA;;;SY)",
+		},
+		{
+			name:        "Creator owner to object",
+			parent:      "D:(A;OICIIO;FA;;;CO)(A;OICIIO;FR;;;CG)",
+			isContainer: false,
+			want:        "AI(A;ID;FA;;;S-1-5-21-1-2-3-1001)(A;ID;FR;;;S-1-5-21-1-2-3-513)",
+		},
+		{
+			name:        "Creator owner to container",
+			parent:      "D:(A;OICIIO;FA;;;CO)",
+			isContainer: true,
+			want:        "AI(A;ID;FA;;;S-1-5-21-1-2-3-1001)(A;OICIIOID;FA;;;CO)",
+		},
+		{
+			name:        "Creator owner to container without propagation",
+			parent:      "D:(A;CINPIO;FA;;;CO)",
+			isContainer: true,
+			want:        "AI(A;ID;FA;;;S-1-5-21-1-2-3-1001)",
+		},
+		{
+			name:        "Creator owner for objects only stays a placeholder in containers",
+			parent:      "D:(A;OIIO;FA;;;CO)",
+			isContainer: true,
+			want:        "AI(A;OIIOID;FA;;;CO)",
+		},
+		{
+			name:        "Creator owner without replacement",
+			parent:      "D:(A;OICIIO;FA;;;CO)",
+			isContainer: false,
+			keepCreator: true,
+			want:        "AI(A;ID;FA;;;CO)",
+		},
+		{
+			name:        "Protected parent",
+			parent:      "D:PAI(A;OICI;FA;;;SY)",
+			isContainer: true,
+			want:        "AI(A;OICIID;FA;;;SY)",
+		},
+		{
+			name:        "Parent requesting auto-inheritance",
+			parent:      "D:PARAI(A;OI;FA;;;SY)",
+			isContainer: false,
+			want:        "AI(A;ID;FA;;;SY)",
+		},
+		{
+			name:        "Nothing inheritable from protected parent",
+			parent:      "D:P(A;;FA;;;SY)",
+			isContainer: true,
+			want:        "",
+		},
+		{
+			name:        "Nothing inheritable",
+			parent:      "D:(A;;FA;;;SY)(D;;FR;;;WD)",
+			isContainer: true,
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.parent)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+
+			before := sd.String()
+			childOwner, childGroup := owner, group
+			if tt.keepCreator {
+				childOwner, childGroup = nil, nil
+			}
+			got := sd.DACL().ComputeInherited(tt.isContainer, childOwner, childGroup)
+			if gotStr := got.String(); gotStr != tt.want {
+				t.Errorf("ComputeInherited() = %s, want %s", gotStr, tt.want)
+			}
+
+			// Inherited ACLs must be consistent so they can be serialized
+			if err := got.validate(); err != nil {
+				t.Errorf("ComputeInherited() returned an invalid ACL: %v", err)
+			}
+
+			// The parent must not be modified
+			if after := sd.String(); after != before {
+				t.Errorf("parent ACL modified: got %s, want %s", after, before)
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_ExplicitOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sddl string
+		want string
+	}{
+		{
+			name: "Mixed DACL",
+			sddl: "O:BAG:SYD:AI(D;;FA;;;AN)(A;OICI;FA;;;SY)(A;OICIID;FA;;;BA)(A;ID;FR;;;BU)",
+			want: "O:BAG:SYD:AI(D;;FA;;;AN)(A;OICI;FA;;;SY)",
+		},
+		{
+			name: "Only inherited ACEs",
+			sddl: "D:AI(A;ID;FA;;;SY)S:AI(AU;IDSA;FA;;;WD)",
+			want: "D:AIS:AI",
+		},
+		{
+			name: "SACL",
+			sddl: "S:(AU;SA;FA;;;WD)(AU;IDFA;FA;;;BA)",
+			want: "S:(AU;SA;FA;;;WD)",
+		},
+		{
+			name: "NULL DACL",
+			sddl: "D:NO_ACCESS_CONTROL",
+			want: "D:NO_ACCESS_CONTROL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			explicit := sd.ExplicitOnly()
+			if got := explicit.String(); got != tt.want {
+				t.Errorf("ExplicitOnly() = %s, want %s", got, tt.want)
+			}
+			if got := sd.String(); got != mustFromString(t, tt.sddl).String() {
+				t.Errorf("ExplicitOnly() modified the receiver: %s", got)
+			}
+
+			// Sizes and ACE counts are the ones of the descriptor parsed from the expected string
+			compareSecurityDescriptors(t, explicit, mustFromString(t, tt.want))
+		})
+	}
+
+	// The resource manager control bits are kept
+	sd := mustFromString(t, "D:AI(A;;FA;;;SY)(A;ID;FR;;;BU)")
+	sd.SetResourceManagerControl(0x5A)
+	if rmControl, ok := sd.ExplicitOnly().ResourceManagerControl(); !ok || rmControl != 0x5A {
+		t.Errorf("ExplicitOnly().ResourceManagerControl() = %#x, %v, want 0x5a, true", rmControl, ok)
+	}
+}
@@ -0,0 +1,123 @@
+package sddl
+
+// Integrity levels, the last sub-authority of the S-1-16-<level> SIDs of mandatory label ACEs.
+// See https://learn.microsoft.com/en-us/windows/win32/secauthz/mandatory-integrity-control
+const (
+	// IntegrityLevelUntrusted - Untrusted integrity level (SECURITY_MANDATORY_UNTRUSTED_RID)
+	IntegrityLevelUntrusted = 0x0000
+	// IntegrityLevelLow - Low integrity level, "LW" in SDDL (SECURITY_MANDATORY_LOW_RID)
+	IntegrityLevelLow = 0x1000
+	// IntegrityLevelMedium - Medium integrity level, "ME" in SDDL (SECURITY_MANDATORY_MEDIUM_RID)
+	IntegrityLevelMedium = 0x2000
+	// IntegrityLevelMediumPlus - Medium plus integrity level, "MP" in SDDL (SECURITY_MANDATORY_MEDIUM_PLUS_RID)
+	IntegrityLevelMediumPlus = 0x2100
+	// IntegrityLevelHigh - High integrity level, "HI" in SDDL (SECURITY_MANDATORY_HIGH_RID)
+	IntegrityLevelHigh = 0x3000
+	// IntegrityLevelSystem - System integrity level, "SI" in SDDL (SECURITY_MANDATORY_SYSTEM_RID)
+	IntegrityLevelSystem = 0x4000
+	// IntegrityLevelProtectedProcess - Protected process integrity level (SECURITY_MANDATORY_PROTECTED_PROCESS_RID)
+	IntegrityLevelProtectedProcess = 0x5000
+)
+
+// Mandatory policies, the access mask of mandatory label ACEs. They restrict the access of subjects
+// whose integrity level is lower than the one of the object.
+const (
+	// MandatoryPolicyNoWriteUp - Deny write access, "NW" in SDDL (SYSTEM_MANDATORY_LABEL_NO_WRITE_UP)
+	MandatoryPolicyNoWriteUp = 0x00000001
+	// MandatoryPolicyNoReadUp - Deny read access, "NR" in SDDL (SYSTEM_MANDATORY_LABEL_NO_READ_UP)
+	MandatoryPolicyNoReadUp = 0x00000002
+	// MandatoryPolicyNoExecuteUp - Deny execute access, "NX" in SDDL (SYSTEM_MANDATORY_LABEL_NO_EXECUTE_UP)
+	MandatoryPolicyNoExecuteUp = 0x00000004
+)
+
+// mandatoryLabelAuthority is the identifier authority of the integrity level SIDs (SECURITY_MANDATORY_LABEL_AUTHORITY)
+const mandatoryLabelAuthority = 16
+
+// IntegrityLevel returns the integrity level and the mandatory policy of the object, as found in the first
+// mandatory label ACE of the SACL that applies to the object itself. ok is false if there is no such ACE,
+// in which case Windows treats the object as having a medium integrity level and the NW policy.
+func (sd *SecurityDescriptor) IntegrityLevel() (level, policy uint32, ok bool) {
+	if sd.sacl == nil {
+		return 0, 0, false
+	}
+
+	if ace := sd.sacl.integrityLabel(); ace != nil {
+		return ace.sid.subAuthority[0], ace.accessMask, true
+	}
+	return 0, 0, false
+}
+
+// SetIntegrityLevel sets the integrity level and the mandatory policy of the object, a combination of
+// the MandatoryPolicy constants. The first mandatory label ACE of the SACL that applies to the object
+// itself is updated, keeping its flags, or a new one is appended to the SACL. The SACL is created if
+// there is none or it is NULL, in which case SE_SACL_DEFAULTED is cleared, and SE_SACL_PRESENT is set.
+//
+// For example, SetIntegrityLevel(IntegrityLevelLow, MandatoryPolicyNoWriteUp) results in the SACL
+// "S:(ML;;NW;;;LW)" on a descriptor without SACL.
+func (sd *SecurityDescriptor) SetIntegrityLevel(level, policy uint32) {
+	sid := NewSID(mandatoryLabelAuthority, level)
+
+	if sd.sacl == nil {
+		// The SACL is now set explicitly rather than by a default mechanism
+		sd.control &^= seSACLDefaulted
+		sd.sacl = &ACL{
+			aclRevision: aclRevision,
+			aclType:     "S",
+			control:     aclControl("S", sd.control),
+		}
+	}
+	sd.control |= seSACLPresent
+	sd.sacl.control |= seSACLPresent
+
+	if ace := sd.sacl.integrityLabel(); ace != nil {
+		ace.accessMask = policy
+		ace.sid = sid
+		ace.header.aceSize = uint16(ace.BinarySize())
+	} else {
+		sd.sacl.aces = append(sd.sacl.aces, *newACE(systemMandatoryLabelACEType, 0, policy, sid))
+	}
+	sd.sacl.updateSize()
+}
+
+// integrityLabel returns the first mandatory label ACE of the ACL that applies to the object itself
+// and holds an integrity level SID, or nil if there is none.
+func (a *ACL) integrityLabel() *ACE {
+	for i := range a.aces {
+		ace := &a.aces[i]
+		if ace.header == nil || ace.header.aceType != systemMandatoryLabelACEType || ace.header.aceFlags&inheritOnlyACE != 0 {
+			continue
+		}
+		if ace.sid == nil || ace.sid.identifierAuthority != mandatoryLabelAuthority || len(ace.sid.subAuthority) != 1 {
+			continue
+		}
+		return ace
+	}
+	return nil
+}
+
+// mandatoryPolicies lists the mandatory policies in the order they are written in SDDL
+var mandatoryPolicies = []struct {
+	mask    uint32
+	keyword string
+}{
+	{MandatoryPolicyNoReadUp, "NR"},
+	{MandatoryPolicyNoWriteUp, "NW"},
+	{MandatoryPolicyNoExecuteUp, "NX"},
+}
+
+// mandatoryPolicyString returns the SDDL keywords of the access mask of a mandatory label ACE (e.g. "NW"),
+// which would otherwise be written as the file rights sharing the same bits. ok is false if the mask is
+// empty or has bits that are not mandatory policies.
+func mandatoryPolicyString(mask uint32) (string, bool) {
+	if mask == 0 || mask&^(MandatoryPolicyNoReadUp|MandatoryPolicyNoWriteUp|MandatoryPolicyNoExecuteUp) != 0 {
+		return "", false
+	}
+
+	var s string
+	for _, p := range mandatoryPolicies {
+		if mask&p.mask != 0 {
+			s += p.keyword
+		}
+	}
+	return s, true
+}
@@ -0,0 +1,164 @@
+package sddl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecurityDescriptor_IntegrityLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		sddl       string
+		wantLevel  uint32
+		wantPolicy uint32
+		wantOK     bool
+	}{
+		{
+			name:       "Low, no write up",
+			sddl:       "O:BAS:(ML;;NW;;;LW)",
+			wantLevel:  IntegrityLevelLow,
+			wantPolicy: MandatoryPolicyNoWriteUp,
+			wantOK:     true,
+		},
+		{
+			name:       "After an audit ACE",
+			sddl:       "S:(AU;SA;FA;;;WD)(ML;;NRNW;;;HI)",
+			wantLevel:  IntegrityLevelHigh,
+			wantPolicy: MandatoryPolicyNoWriteUp | MandatoryPolicyNoReadUp,
+			wantOK:     true,
+		},
+		{
+			name:       "Inherit-only label ignored",
+			sddl:       "S:(ML;OICIIO;NW;;;HI)(ML;;NX;;;SI)",
+			wantLevel:  IntegrityLevelSystem,
+			wantPolicy: MandatoryPolicyNoExecuteUp,
+			wantOK:     true,
+		},
+		{
+			name: "Only inherit-only label",
+			sddl: "S:(ML;OICIIO;NW;;;HI)",
+		},
+		{
+			name: "No label",
+			sddl: "S:(AU;SA;FA;;;WD)",
+		},
+		{
+			name: "No SACL",
+			sddl: "O:BAD:(A;;FA;;;SY)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			level, policy, ok := sd.IntegrityLevel()
+			if level != tt.wantLevel || policy != tt.wantPolicy || ok != tt.wantOK {
+				t.Errorf("IntegrityLevel() = (%#x, %#x, %v), want (%#x, %#x, %v)",
+					level, policy, ok, tt.wantLevel, tt.wantPolicy, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_SetIntegrityLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sddl     string
+		level    uint32
+		policy   uint32
+		want     string
+		wantSACL []byte
+	}{
+		{
+			name:   "No SACL",
+			sddl:   "O:BAD:P(A;;FA;;;SY)",
+			level:  IntegrityLevelLow,
+			policy: MandatoryPolicyNoWriteUp,
+			want:   "O:BAD:P(A;;FA;;;SY)S:(ML;;NW;;;LW)",
+			wantSACL: []byte{
+				0x02, 0x00, 0x1C, 0x00, 0x01, 0x00, 0x00, 0x00, // ACL header, 1 ACE
+				0x11, 0x00, 0x14, 0x00, 0x01, 0x00, 0x00, 0x00, // ML, no flags, NW
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x00, 0x00, // S-1-16-4096
+			},
+		},
+		{
+			name:   "Appended to audit ACEs",
+			sddl:   "S:(AU;SA;FA;;;WD)",
+			level:  IntegrityLevelHigh,
+			policy: MandatoryPolicyNoWriteUp | MandatoryPolicyNoReadUp,
+			want:   "S:(AU;SA;FA;;;WD)(ML;;NRNW;;;HI)",
+			wantSACL: []byte{
+				0x02, 0x00, 0x30, 0x00, 0x02, 0x00, 0x00, 0x00, // ACL header, 2 ACEs
+				0x02, 0x40, 0x14, 0x00, 0xFF, 0x01, 0x1F, 0x00, // AU, SA, FA
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // S-1-1-0
+				0x11, 0x00, 0x14, 0x00, 0x03, 0x00, 0x00, 0x00, // ML, no flags, NW and NR
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x30, 0x00, 0x00, // S-1-16-12288
+			},
+		},
+		{
+			name:   "Existing label updated, keeping its flags",
+			sddl:   "S:(ML;OICI;NW;;;LW)(AU;SA;FA;;;WD)",
+			level:  IntegrityLevelSystem,
+			policy: MandatoryPolicyNoExecuteUp,
+			want:   "S:(ML;OICI;NX;;;SI)(AU;SA;FA;;;WD)",
+		},
+		{
+			name:   "Inherit-only label left alone",
+			sddl:   "S:(ML;OICIIO;NW;;;HI)",
+			level:  IntegrityLevelMedium,
+			policy: MandatoryPolicyNoWriteUp,
+			want:   "S:(ML;OICIIO;NW;;;HI)(ML;;NW;;;ME)",
+		},
+		{
+			name:   "NULL SACL",
+			sddl:   "S:NO_ACCESS_CONTROL",
+			level:  IntegrityLevelLow,
+			policy: MandatoryPolicyNoWriteUp,
+			want:   "S:(ML;;NW;;;LW)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			sd.SetIntegrityLevel(tt.level, tt.policy)
+
+			if err := sd.Validate(); err != nil {
+				t.Fatalf("Validate() unexpected error = %v", err)
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+			if sd.control&seSACLPresent == 0 {
+				t.Errorf("control = %#04x, want SE_SACL_PRESENT set", sd.control)
+			}
+			if tt.wantSACL != nil {
+				if got := sd.SACL().Binary(); !bytes.Equal(got, tt.wantSACL) {
+					t.Errorf("SACL().Binary() = % x, want % x", got, tt.wantSACL)
+				}
+			}
+
+			level, policy, ok := sd.IntegrityLevel()
+			if level != tt.level || policy != tt.policy || !ok {
+				t.Errorf("IntegrityLevel() = (%#x, %#x, %v), want (%#x, %#x, true)", level, policy, ok, tt.level, tt.policy)
+			}
+
+			// The descriptor round-trips through binary
+			back, err := FromBinary(sd.Binary())
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			if got := back.String(); got != tt.want {
+				t.Errorf("FromBinary().String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
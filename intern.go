@@ -0,0 +1,43 @@
+package sddl
+
+// internedSIDs maps the binary form of the well-known SIDs of wellKnownSids to a single shared instance,
+// returned by parsers with InternSIDs set instead of decoding a new SID every time.
+var internedSIDs = newInternedSIDs()
+
+// newInternedSIDs builds internedSIDs.
+func newInternedSIDs() map[string]*SID {
+	interned := make(map[string]*SID, len(wellKnownSids))
+	for s := range wellKnownSids {
+		r, err := parseSIDString(s)
+		if err != nil {
+			panic("invalid well-known SID " + s + ": " + err.Error())
+		}
+		sid, err := r.toSID(nil)
+		if err != nil {
+			panic("invalid well-known SID " + s + ": " + err.Error())
+		}
+		interned[string(sid.Binary())] = sid
+	}
+	return interned
+}
+
+// decodeSIDBinary is like parseSIDBinary, but returns the shared instance of well-known SIDs if
+// opts.internSIDs is set.
+func decodeSIDBinary(data []byte, opts ParseOptions) (*SID, error) {
+	if sid := internedSID(data, opts); sid != nil {
+		return sid, nil
+	}
+	return parseSIDBinary(data)
+}
+
+// internedSID returns the shared instance of the well-known SID at the start of data if opts.internSIDs is
+// set, or nil.
+func internedSID(data []byte, opts ParseOptions) *SID {
+	if opts.internSIDs && len(data) >= 8 {
+		if size := 8 + 4*int(data[1]); len(data) >= size {
+			// The conversion of the key doesn't allocate
+			return internedSIDs[string(data[:size])]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package sddl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// NFSv4 ACE types (ACE4_*_ACE_TYPE), see RFC 7530 section 6.2.1.1
+const (
+	nfs4AccessAllowedACEType = 0
+	nfs4AccessDeniedACEType  = 1
+	nfs4SystemAuditACEType   = 2
+	nfs4SystemAlarmACEType   = 3
+)
+
+// NFSv4 ACE flags (ACE4_*), see RFC 7530 section 6.2.1.4
+const (
+	nfs4FileInheritACE          = 0x00000001
+	nfs4DirectoryInheritACE     = 0x00000002
+	nfs4NoPropagateInheritACE   = 0x00000004
+	nfs4InheritOnlyACE          = 0x00000008
+	nfs4SuccessfulAccessACEFlag = 0x00000010
+	nfs4FailedAccessACEFlag     = 0x00000020
+	nfs4IdentifierGroup         = 0x00000040
+	nfs4InheritedACE            = 0x00000080
+)
+
+// NFSv4 special principals, see RFC 7530 section 6.2.1.5
+const (
+	nfs4OwnerPrincipal    = "OWNER@"
+	nfs4GroupPrincipal    = "GROUP@"
+	nfs4EveryonePrincipal = "EVERYONE@"
+)
+
+const (
+	// nfs4KnownACEFlags holds all the NFSv4 ACE flags, the other bits are rejected
+	nfs4KnownACEFlags = 0x000000FF
+	// nfs4ACEHeaderSize is the size of the fields of an XDR encoded ACE preceding the principal: its type,
+	// flags, access mask and principal length
+	nfs4ACEHeaderSize = 16
+	// nfs4MaxPrincipalLength bounds the length of principals, which are user or group names
+	nfs4MaxPrincipalLength = 1024
+)
+
+// nfs4ACETypes maps the NFSv4 ACE types to the ACE types they are converted to. Alarm ACEs are left out:
+// like Windows, NFSv4 servers don't implement them.
+var nfs4ACETypes = map[uint32]byte{
+	nfs4AccessAllowedACEType: accessAllowedACEType,
+	nfs4AccessDeniedACEType:  accessDeniedACEType,
+	nfs4SystemAuditACEType:   systemAuditACEType,
+}
+
+// nfs4ACEFlags maps the NFSv4 ACE flags to the ACE flags they are converted to
+var nfs4ACEFlags = []struct {
+	nfs4 uint32
+	flag byte
+}{
+	{nfs4FileInheritACE, objectInheritACE},
+	{nfs4DirectoryInheritACE, containerInheritACE},
+	{nfs4NoPropagateInheritACE, noPropagateInheritACE},
+	{nfs4InheritOnlyACE, inheritOnlyACE},
+	{nfs4SuccessfulAccessACEFlag, successfulAccessACE},
+	{nfs4FailedAccessACEFlag, failedAccessACE},
+	{nfs4InheritedACE, inheritedACE},
+}
+
+// NFS4PrincipalResolver returns the SID of an NFSv4 principal, such as "OWNER@" or "alice@example.com".
+// isGroup reports whether the ACE has the ACE4_IDENTIFIER_GROUP flag, meaning that the principal is a group.
+type NFS4PrincipalResolver func(principal string, isGroup bool) (*SID, error)
+
+// DefaultNFS4PrincipalResolver resolves the special principals "OWNER@" and "GROUP@" to CREATOR OWNER
+// (S-1-3-0) and CREATOR GROUP (S-1-3-1), as they designate whoever owns the file, and principals that are
+// SID strings, such as "S-1-5-21-1-2-3-1001", to that SID. Other principals return an error: resolving
+// them requires a directory lookup that only the caller can do.
+func DefaultNFS4PrincipalResolver(principal string, isGroup bool) (*SID, error) {
+	switch principal {
+	case nfs4OwnerPrincipal:
+		return NewSID(3, 0), nil
+	case nfs4GroupPrincipal:
+		return NewSID(3, 1), nil
+	}
+
+	if strings.HasPrefix(principal, "S-") {
+		r, err := parseSIDString(principal)
+		if err != nil {
+			return nil, err
+		}
+		return r.toSID(nil)
+	}
+
+	return nil, fmt.Errorf("no SID for NFSv4 principal %q", principal)
+}
+
+// FromNFS4ACL converts an NFSv4 ACL, as found in the system.nfs4_acl extended attribute of files on
+// NFSv4-backed filesystems, to a security descriptor. The attribute holds the XDR encoding of the ACL:
+// the number of ACEs followed by the ACEs, each made of its type, flags, access mask and principal, all
+// of them big-endian 32-bit integers except for the principal, a length-prefixed string padded to 4 bytes.
+//
+// NFSv4 ACLs were modeled after Windows ACLs, so the conversion is direct:
+//   - ALLOW and DENY ACEs become A and D ACEs of the DACL, and AUDIT ACEs become AU ACEs of the SACL,
+//     in order. There is always a DACL, and a SACL only if there are audit ACEs. ALARM ACEs, which
+//     neither Windows nor NFSv4 servers implement, are rejected.
+//   - The FILE_INHERIT, DIRECTORY_INHERIT, NO_PROPAGATE_INHERIT, INHERIT_ONLY, SUCCESSFUL_ACCESS,
+//     FAILED_ACCESS and INHERITED flags become OI, CI, NP, IO, SA, FA and ID.
+//   - The access mask is kept as is, as the NFSv4 access bits have the values of the Windows file rights
+//     (e.g. ACE4_READ_DATA is FILE_READ_DATA and ACE4_WRITE_ACL is WRITE_DAC).
+//   - "EVERYONE@" becomes Everyone (S-1-1-0) and the other principals are converted by resolve, which
+//     also gets the IDENTIFIER_GROUP flag. If resolve is nil, DefaultNFS4PrincipalResolver is used.
+//
+// The security descriptor has no owner nor group, as they are not part of the ACL. An error is returned
+// if the ACL is malformed, has unknown ACE types or flags, or if a principal cannot be resolved.
+func FromNFS4ACL(data []byte, resolve NFS4PrincipalResolver) (*SecurityDescriptor, error) {
+	if resolve == nil {
+		resolve = DefaultNFS4PrincipalResolver
+	}
+
+	if len(data) < 4 {
+		return nil, fmt.Errorf("invalid NFSv4 ACL: too short, got %d bytes but need at least 4", len(data))
+	}
+	aceCount := binary.BigEndian.Uint32(data[0:4])
+	// Each ACE takes at least its header, which bounds the ACE count before allocating anything
+	if uint64(aceCount)*nfs4ACEHeaderSize > uint64(len(data)-4) {
+		return nil, fmt.Errorf("invalid NFSv4 ACL: %d ACEs don't fit in %d bytes", aceCount, len(data))
+	}
+	offset := 4
+
+	dacl := &ACL{aclRevision: aclRevision, aclType: "D", control: seDACLPresent}
+	var sacl *ACL
+	for i := uint32(0); i < aceCount; i++ {
+		ace, size, err := parseNFS4ACE(data[offset:], resolve)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NFSv4 ACE %d: %w", i, err)
+		}
+		offset += size
+
+		if ace.header.aceType == systemAuditACEType {
+			if sacl == nil {
+				sacl = &ACL{aclRevision: aclRevision, aclType: "S", control: seSACLPresent}
+			}
+			sacl.aces = append(sacl.aces, *ace)
+		} else {
+			dacl.aces = append(dacl.aces, *ace)
+		}
+	}
+	if offset != len(data) {
+		return nil, fmt.Errorf("invalid NFSv4 ACL: %d trailing bytes after the ACEs", len(data)-offset)
+	}
+
+	sd := &SecurityDescriptor{
+		revision: 1,
+		control:  seSelfRelative | seDACLPresent,
+		dacl:     dacl,
+	}
+	dacl.updateSize()
+	if sacl != nil {
+		sd.control |= seSACLPresent
+		sd.sacl = sacl
+		sacl.updateSize()
+	}
+
+	return sd, nil
+}
+
+// parseNFS4ACE converts the XDR encoded NFSv4 ACE at the start of data, returning it along with its size.
+func parseNFS4ACE(data []byte, resolve NFS4PrincipalResolver) (*ACE, int, error) {
+	if len(data) < nfs4ACEHeaderSize {
+		return nil, 0, fmt.Errorf("too short, got %d bytes but need at least %d", len(data), nfs4ACEHeaderSize)
+	}
+	nfs4Type := binary.BigEndian.Uint32(data[0:4])
+	nfs4Flags := binary.BigEndian.Uint32(data[4:8])
+	accessMask := binary.BigEndian.Uint32(data[8:12])
+	principalLen := binary.BigEndian.Uint32(data[12:16])
+
+	aceType, ok := nfs4ACETypes[nfs4Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported type %d", nfs4Type)
+	}
+	if nfs4Flags&^nfs4KnownACEFlags != 0 {
+		return nil, 0, fmt.Errorf("unknown flags 0x%X", nfs4Flags&^nfs4KnownACEFlags)
+	}
+	if principalLen == 0 || principalLen > nfs4MaxPrincipalLength {
+		return nil, 0, fmt.Errorf("invalid principal length %d", principalLen)
+	}
+
+	// The principal is padded to a multiple of 4 bytes
+	size := nfs4ACEHeaderSize + int(principalLen+3)&^3
+	if len(data) < size {
+		return nil, 0, fmt.Errorf("principal of %d bytes exceeds the ACL", principalLen)
+	}
+	principal := string(data[nfs4ACEHeaderSize : nfs4ACEHeaderSize+principalLen])
+
+	var aceFlags byte
+	for _, f := range nfs4ACEFlags {
+		if nfs4Flags&f.nfs4 != 0 {
+			aceFlags |= f.flag
+		}
+	}
+
+	var sid *SID
+	if principal == nfs4EveryonePrincipal {
+		sid = NewSID(1, 0)
+	} else {
+		var err error
+		if sid, err = resolve(principal, nfs4Flags&nfs4IdentifierGroup != 0); err != nil {
+			return nil, 0, fmt.Errorf("error resolving principal %q: %w", principal, err)
+		}
+		if sid == nil {
+			return nil, 0, fmt.Errorf("no SID for principal %q", principal)
+		}
+		if err := sid.Validate(); err != nil {
+			return nil, 0, fmt.Errorf("invalid SID for principal %q: %w", principal, err)
+		}
+	}
+
+	return newACE(aceType, aceFlags, accessMask, sid), size, nil
+}
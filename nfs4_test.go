@@ -0,0 +1,192 @@
+package sddl
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// nfs4ACE describes an NFSv4 ACE for encodeNFS4ACL
+type nfs4ACE struct {
+	aceType, flags, mask uint32
+	principal            string
+}
+
+// encodeNFS4ACL returns the XDR encoding of an NFSv4 ACL, as found in the system.nfs4_acl extended attribute.
+func encodeNFS4ACL(aces ...nfs4ACE) []byte {
+	data := binary.BigEndian.AppendUint32(nil, uint32(len(aces)))
+	for _, ace := range aces {
+		data = binary.BigEndian.AppendUint32(data, ace.aceType)
+		data = binary.BigEndian.AppendUint32(data, ace.flags)
+		data = binary.BigEndian.AppendUint32(data, ace.mask)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(ace.principal)))
+		data = append(data, ace.principal...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+	return data
+}
+
+func TestFromNFS4ACL(t *testing.T) {
+	t.Parallel()
+
+	resolve := func(principal string, isGroup bool) (*SID, error) {
+		switch {
+		case principal == "alice@example.com" && !isGroup:
+			return NewSID(5, 21, 1, 2, 3, 1001), nil
+		case principal == "staff@example.com" && isGroup:
+			return NewSID(5, 21, 1, 2, 3, 1002), nil
+		}
+		return DefaultNFS4PrincipalResolver(principal, isGroup)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			// The ACL nfs4_setfacl writes for "A::OWNER@:rwatTnNcCy A:g:GROUP@:rtncy A::EVERYONE@:rtncy"
+			name: "Mode 644 equivalent",
+			data: []byte{
+				0x00, 0x00, 0x00, 0x03, // 3 ACEs
+				0x00, 0x00, 0x00, 0x00, // ALLOW
+				0x00, 0x00, 0x00, 0x00, // No flags
+				0x00, 0x16, 0x01, 0x9F, // rwatTnNcCy
+				0x00, 0x00, 0x00, 0x06, 'O', 'W', 'N', 'E', 'R', '@', 0x00, 0x00, // OWNER@, padded
+				0x00, 0x00, 0x00, 0x00, // ALLOW
+				0x00, 0x00, 0x00, 0x40, // IDENTIFIER_GROUP
+				0x00, 0x12, 0x00, 0x89, // rtncy
+				0x00, 0x00, 0x00, 0x06, 'G', 'R', 'O', 'U', 'P', '@', 0x00, 0x00, // GROUP@, padded
+				0x00, 0x00, 0x00, 0x00, // ALLOW
+				0x00, 0x00, 0x00, 0x00, // No flags
+				0x00, 0x12, 0x00, 0x89, // rtncy
+				0x00, 0x00, 0x00, 0x09, 'E', 'V', 'E', 'R', 'Y', 'O', 'N', 'E', '@', 0x00, 0x00, 0x00, // EVERYONE@, padded
+			},
+			want: "D:(A;;CCDCLCSWRPLOCRRCWDSY;;;CO)(A;;FR;;;CG)(A;;FR;;;WD)",
+		},
+		{
+			name: "Inheritance flags and named principals",
+			data: encodeNFS4ACL(
+				nfs4ACE{nfs4AccessDeniedACEType, nfs4FileInheritACE | nfs4DirectoryInheritACE, 0x00000002, "alice@example.com"},
+				nfs4ACE{nfs4AccessAllowedACEType, nfs4IdentifierGroup | nfs4DirectoryInheritACE | nfs4InheritOnlyACE | nfs4NoPropagateInheritACE, 0x001f01ff, "staff@example.com"},
+				nfs4ACE{nfs4AccessAllowedACEType, nfs4InheritedACE, 0x00120089, "S-1-5-18"},
+			),
+			want: "D:(D;OICI;DC;;;S-1-5-21-1-2-3-1001)(A;CINPIO;FA;;;S-1-5-21-1-2-3-1002)(A;ID;FR;;;SY)",
+		},
+		{
+			name: "Audit ACEs",
+			data: encodeNFS4ACL(
+				nfs4ACE{nfs4AccessAllowedACEType, 0, 0x001f01ff, "OWNER@"},
+				nfs4ACE{nfs4SystemAuditACEType, nfs4SuccessfulAccessACEFlag | nfs4FailedAccessACEFlag, 0x001f01ff, "EVERYONE@"},
+				nfs4ACE{nfs4SystemAuditACEType, nfs4FailedAccessACEFlag, 0x00120116, "EVERYONE@"},
+			),
+			want: "D:(A;;FA;;;CO)S:(AU;SAFA;FA;;;WD)(AU;FA;FW;;;WD)",
+		},
+		{
+			name: "Empty ACL",
+			data: encodeNFS4ACL(),
+			want: "D:",
+		},
+		{
+			name:    "Too short",
+			data:    []byte{0x00, 0x00},
+			wantErr: true,
+		},
+		{
+			name:    "ACE count larger than the ACEs",
+			data:    append([]byte{0x00, 0x00, 0x00, 0x02}, encodeNFS4ACL(nfs4ACE{0, 0, 1, "EVERYONE@"})[4:]...),
+			wantErr: true,
+		},
+		{
+			name:    "Trailing bytes",
+			data:    append(encodeNFS4ACL(nfs4ACE{0, 0, 1, "EVERYONE@"}), 0x00, 0x00, 0x00, 0x00),
+			wantErr: true,
+		},
+		{
+			name:    "Truncated principal",
+			data:    encodeNFS4ACL(nfs4ACE{0, 0, 1, "EVERYONE@"})[:24],
+			wantErr: true,
+		},
+		{
+			name:    "Alarm type",
+			data:    encodeNFS4ACL(nfs4ACE{nfs4SystemAlarmACEType, nfs4FailedAccessACEFlag, 1, "EVERYONE@"}),
+			wantErr: true,
+		},
+		{
+			name:    "Unknown type",
+			data:    encodeNFS4ACL(nfs4ACE{4, 0, 1, "EVERYONE@"}),
+			wantErr: true,
+		},
+		{
+			name:    "Unknown flags",
+			data:    encodeNFS4ACL(nfs4ACE{0, 0x100, 1, "EVERYONE@"}),
+			wantErr: true,
+		},
+		{
+			name:    "Empty principal",
+			data:    encodeNFS4ACL(nfs4ACE{0, 0, 1, ""}),
+			wantErr: true,
+		},
+		{
+			name:    "Unresolved principal",
+			data:    encodeNFS4ACL(nfs4ACE{0, 0, 1, "bob@example.com"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromNFS4ACL(tt.data, resolve)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromNFS4ACL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := sd.String(); got != tt.want {
+				t.Errorf("FromNFS4ACL().String() = %s, want %s", got, tt.want)
+			}
+			if err := sd.Validate(); err != nil {
+				t.Fatalf("Validate() unexpected error = %v", err)
+			}
+
+			// The descriptor is the same as the one parsed from its SDDL string, sizes included
+			want := mustFromString(t, tt.want)
+			compareACLs(t, "DACL", sd.DACL(), want.DACL())
+			if sd.SACL() != nil || want.SACL() != nil {
+				compareACLs(t, "SACL", sd.SACL(), want.SACL())
+			}
+		})
+	}
+}
+
+func TestFromNFS4ACL_Resolver(t *testing.T) {
+	t.Parallel()
+
+	data := encodeNFS4ACL(nfs4ACE{nfs4AccessAllowedACEType, nfs4IdentifierGroup, 0x00120089, "GROUP@"})
+
+	// The default resolver maps the special principals to the creator placeholders
+	sd, err := FromNFS4ACL(data, nil)
+	if err != nil {
+		t.Fatalf("FromNFS4ACL() unexpected error = %v", err)
+	}
+	if got, want := sd.String(), "D:(A;;FR;;;CG)"; got != want {
+		t.Errorf("FromNFS4ACL().String() = %s, want %s", got, want)
+	}
+
+	// Errors of the resolver are returned, and so is a missing SID
+	errResolve := errors.New("lookup failed")
+	_, err = FromNFS4ACL(data, func(string, bool) (*SID, error) { return nil, errResolve })
+	if !errors.Is(err, errResolve) {
+		t.Errorf("FromNFS4ACL() error = %v, want %v", err, errResolve)
+	}
+	if _, err := FromNFS4ACL(data, func(string, bool) (*SID, error) { return nil, nil }); err == nil {
+		t.Errorf("FromNFS4ACL() expected error for a nil SID, got nil")
+	}
+}
@@ -0,0 +1,92 @@
+package sddl
+
+// DefaultMaxDescriptorSize is the default maximum size in bytes of a binary security descriptor accepted
+// by FromBinary. It is the largest size a well-formed self-relative security descriptor can have: the
+// 20 bytes header, two SIDs of at most 68 bytes (15 sub-authorities) and two ACLs whose AclSize is a
+// uint16. Larger inputs can't be legitimate and are rejected before parsing, so that hostile inputs
+// don't make callers process arbitrary amounts of data.
+const DefaultMaxDescriptorSize = 20 + 2*68 + 2*65535
+
+// ParseOptions controls how security descriptors are decoded.
+// The zero value gives the default behavior of FromString and FromBinary.
+// Options that only apply to one of the formats are ignored by the other.
+type ParseOptions struct {
+	// StrictACESize rejects binary ACEs whose AceSize is larger than the size needed by their
+	// content. By default, trailing bytes after the SID (e.g. padding to a DWORD boundary) are
+	// accepted and preserved so that the ACE is re-encoded byte for byte.
+	StrictACESize bool
+
+	// MaxDescriptorSize is the maximum size in bytes of a binary security descriptor. Larger inputs are
+	// rejected with ErrDescriptorTooLarge. Zero or negative values mean DefaultMaxDescriptorSize.
+	MaxDescriptorSize int
+
+	// StripComments removes the comment ending a security descriptor string before parsing it, e.g.
+	// "O:SY # owner is system". A comment starts at the first CommentMarker found outside parentheses,
+	// so that markers in the string literals of conditional expressions are kept.
+	StripComments bool
+
+	// CommentMarker is the marker starting a comment when StripComments is set. Empty means "#".
+	CommentMarker string
+
+	// Unescape undoes the escaping of a security descriptor string extracted from another format before
+	// parsing it, e.g. `D:\u0028A;;FA;;;SY\u0029` from a JSON string or "D:%28A%3B%3BFA%3B%3B%3BSY%29" from
+	// a URL. It is applied first, before StripComments, and the offsets of a ParseError are offsets in the
+	// unescaped string. The default, UnescapeNone, parses the string as is.
+	Unescape Unescaping
+
+	// Lenient accepts security descriptor strings that don't strictly follow the SDDL syntax, as found in
+	// hand-edited input: whitespace around the string, between components and between ACEs, e.g.
+	// "O:SY D: (A;;FA;;;SY) (D;;FR;;;WD)", ACE flags in any case, e.g. "ciOi" for "CIOI", hexadecimal SID
+	// sub-authorities with a "0x" prefix, as found in some exported logs, e.g. "S-1-5-21-0x1F4" for
+	// "S-1-5-21-500", and decimal access masks, as written by some exporters, e.g. "2032127" for "FA".
+	// By default, such strings are rejected like Windows does. SIDs are always written with decimal
+	// sub-authorities, ACE flags in uppercase, and access masks with mnemonics or in hexadecimal, never in
+	// decimal. Decimal masks are not accepted with RawMasks.
+	Lenient bool
+
+	// RawMasks keeps access masks numeric: in security descriptor strings, only hexadecimal masks such as
+	// "0x1F01FF" are accepted and mnemonics such as "FA" are rejected, and the ACEs of the parsed security
+	// descriptor always write their mask in hexadecimal. This gives a representation that does not depend
+	// on the table of well-known access masks. By default, masks are read and written with their mnemonics.
+	RawMasks bool
+
+	// Partial makes FromBinaryWithOptions return the components of a damaged binary security descriptor that
+	// could be decoded, for forensic analysis, instead of failing as a whole: the owner, group, DACL and SACL
+	// that failed to decode are left out of the returned security descriptor, and the returned error joins
+	// their errors. The present flag of an ACL that failed is cleared, so that it is not mistaken for a NULL
+	// ACL. A header that can't be decoded still fails, with a nil security descriptor.
+	Partial bool
+
+	// internSIDs returns the shared instance of well-known SIDs when decoding binary descriptors,
+	// see Parser.InternSIDs
+	internSIDs bool
+}
+
+// Unescaping is the escaping undone on security descriptor strings before parsing them, see ParseOptions.Unescape.
+type Unescaping int
+
+const (
+	// UnescapeNone parses security descriptor strings as they are.
+	UnescapeNone Unescaping = iota
+	// UnescapeJSON decodes the escape sequences of the content of a JSON string, such as \" and \u0028,
+	// without the enclosing double quotes.
+	UnescapeJSON
+	// UnescapeURL decodes the percent-encoded sequences of a URL component, such as %28. A "+" is kept as is.
+	UnescapeURL
+)
+
+// commentMarker returns the effective marker starting a comment.
+func (o ParseOptions) commentMarker() string {
+	if o.CommentMarker == "" {
+		return "#"
+	}
+	return o.CommentMarker
+}
+
+// maxDescriptorSize returns the effective maximum size of a binary security descriptor.
+func (o ParseOptions) maxDescriptorSize() int {
+	if o.MaxDescriptorSize <= 0 {
+		return DefaultMaxDescriptorSize
+	}
+	return o.MaxDescriptorSize
+}
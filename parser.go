@@ -0,0 +1,104 @@
+package sddl
+
+import (
+	"slices"
+	"sync"
+)
+
+// decodeBuffers holds scratch space reused across binary decoding calls.
+// Nothing referencing these buffers is ever returned to the caller.
+type decodeBuffers struct {
+	// aces accumulates the ACEs of the ACL being decoded
+	aces []ACE
+	// subAuthorities accumulates the sub-authorities of the SIDs of the descriptor being decoded, moved to
+	// a single slice shared by these SIDs by release
+	subAuthorities []uint32
+	// sids holds the SIDs whose sub-authorities are in subAuthorities, along with the index of the first one
+	sids []decodedSID
+}
+
+// decodedSID is a SID whose sub-authorities are in decodeBuffers.subAuthorities, starting at index start.
+type decodedSID struct {
+	sid   *SID
+	start int
+}
+
+// decodeSID decodes the binary SID at the start of data like decodeSIDBinary, but stores its sub-authorities
+// in the scratch space until release is called. If b is nil, it just calls decodeSIDBinary.
+func (b *decodeBuffers) decodeSID(data []byte, opts ParseOptions) (*SID, error) {
+	if b == nil {
+		return decodeSIDBinary(data, opts)
+	}
+	if sid := internedSID(data, opts); sid != nil {
+		return sid, nil
+	}
+
+	count, err := checkSIDBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	start := len(b.subAuthorities)
+	b.subAuthorities = slices.Grow(b.subAuthorities, count)[:start+count]
+	sid := decodeCheckedSIDBinary(data, b.subAuthorities[start:start+count:start+count])
+	b.sids = append(b.sids, decodedSID{sid: sid, start: start})
+	return sid, nil
+}
+
+// release moves the sub-authorities of the SIDs decoded by decodeSID to a single slice of the exact size,
+// shared by these SIDs, so that the descriptor owns its memory with one allocation rather than one per SID.
+// It then resets the scratch space for the next descriptor.
+func (b *decodeBuffers) release() {
+	if len(b.sids) > 0 {
+		subAuthorities := make([]uint32, len(b.subAuthorities))
+		copy(subAuthorities, b.subAuthorities)
+		for _, d := range b.sids {
+			end := d.start + len(d.sid.subAuthority)
+			// The capacity is capped so that appending to a SID never overwrites the next one
+			d.sid.subAuthority = subAuthorities[d.start:end:end]
+		}
+	}
+
+	b.subAuthorities = b.subAuthorities[:0]
+	clear(b.sids)
+	b.sids = b.sids[:0]
+}
+
+// Parser decodes binary security descriptors reusing scratch buffers between calls to reduce
+// allocations and GC pressure when many descriptors are parsed, e.g. on every request of a server:
+// the ACEs of each ACL are accumulated in a reused buffer, and the sub-authorities of all the SIDs of a
+// descriptor are stored in a single slice rather than one per SID. The other parts of a descriptor, such
+// as its SIDs and ACE headers, are still allocated one by one, so it saves about a quarter of the
+// allocations of FromBinary for typical descriptors, see BenchmarkParser_ParseBinary.
+//
+// The descriptors returned by a Parser own all their memory and are safe to retain and modify,
+// only the internal scratch space is reused, unless InternSIDs is set. A Parser is safe for concurrent
+// use by multiple goroutines; the zero value is ready to use.
+type Parser struct {
+	// InternSIDs makes the parser return a single shared *SID for each well-known SID (e.g. SY, BA or
+	// WD), instead of allocating a new one for every owner, group and ACE, which saves memory when many
+	// descriptors are retained. Interned SIDs are shared by all the descriptors decoded this way, so they
+	// must not be modified: calling SID.UnmarshalText on a SID returned by Owner, Group or ACE.SID would
+	// change it in every one of them. It must be set before the Parser is first used.
+	InternSIDs bool
+
+	pool sync.Pool
+}
+
+// NewParser returns a new Parser.
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// ParseBinary takes a binary security descriptor in relative format and returns the parsed
+// SecurityDescriptor. It behaves exactly like FromBinary, except for the sharing of SIDs if InternSIDs is set.
+func (p *Parser) ParseBinary(data []byte) (*SecurityDescriptor, error) {
+	buf, ok := p.pool.Get().(*decodeBuffers)
+	if !ok {
+		buf = &decodeBuffers{}
+	}
+	defer p.pool.Put(buf)
+
+	sd, err := fromBinary(data, buf, ParseOptions{internSIDs: p.InternSIDs})
+	buf.release()
+	return sd, err
+}
@@ -0,0 +1,221 @@
+package sddl
+
+// FilePermissions is a simplified view of file access rights, for presentation purposes. Each field is set
+// from the bits of an access mask as follows, generic rights counting as the file rights they map to:
+//   - Read: FILE_READ_DATA, or GENERIC_READ
+//   - Write: FILE_WRITE_DATA or FILE_APPEND_DATA, or GENERIC_WRITE
+//   - Execute: FILE_EXECUTE, or GENERIC_EXECUTE
+//   - Delete: DELETE
+//   - FullControl: all the FILE_ALL_ACCESS rights ("FA" in SDDL), or GENERIC_ALL
+//
+// GENERIC_ALL sets all the fields. The other rights, such as reading attributes or changing permissions,
+// only count towards FullControl.
+type FilePermissions struct {
+	Read        bool
+	Write       bool
+	Execute     bool
+	Delete      bool
+	FullControl bool
+}
+
+// filePermissions returns the FilePermissions of an access mask, see FilePermissions.
+func filePermissions(mask uint32) FilePermissions {
+	mask = mapGenericFileRights(mask)
+	return FilePermissions{
+		Read:        mask&FileReadData != 0,
+		Write:       mask&(FileWriteData|FileAppendData) != 0,
+		Execute:     mask&FileExecute != 0,
+		Delete:      mask&Delete != 0,
+		FullControl: mask&FileAllAccess == FileAllAccess,
+	}
+}
+
+// GenericMapping tells which specific and standard rights each generic right maps to for a type of
+// objects, like the Windows GENERIC_MAPPING structure. See FileGenericMapping.
+type GenericMapping struct {
+	GenericRead    uint32
+	GenericWrite   uint32
+	GenericExecute uint32
+	GenericAll     uint32
+}
+
+// fileGenericMapping is the generic mapping of files and directories, see FileGenericMapping
+var fileGenericMapping = GenericMapping{
+	GenericRead:    FileGenericRead,
+	GenericWrite:   FileGenericWrite,
+	GenericExecute: FileGenericExecute,
+	GenericAll:     FileAllAccess,
+}
+
+// FileGenericMapping returns the generic mapping Windows uses for files and directories:
+//   - GENERIC_READ ("GR") maps to FILE_GENERIC_READ ("FR")
+//   - GENERIC_WRITE ("GW") maps to FILE_GENERIC_WRITE ("FW")
+//   - GENERIC_EXECUTE ("GX") maps to FILE_GENERIC_EXECUTE ("FX")
+//   - GENERIC_ALL ("GA") maps to FILE_ALL_ACCESS ("FA")
+func FileGenericMapping() GenericMapping {
+	return fileGenericMapping
+}
+
+// Map returns the access mask with its generic rights replaced by the rights they map to, as Windows
+// does before checking access. The other rights of the mask are kept.
+func (m GenericMapping) Map(mask uint32) uint32 {
+	if mask&GenericRead != 0 {
+		mask |= m.GenericRead
+	}
+	if mask&GenericWrite != 0 {
+		mask |= m.GenericWrite
+	}
+	if mask&GenericExecute != 0 {
+		mask |= m.GenericExecute
+	}
+	if mask&GenericAll != 0 {
+		mask |= m.GenericAll
+	}
+	return mask &^ (GenericRead | GenericWrite | GenericExecute | GenericAll)
+}
+
+// mapGenericFileRights returns the access mask with its generic rights replaced by the file rights
+// they map to, as Windows does before checking access to a file.
+func mapGenericFileRights(mask uint32) uint32 {
+	return fileGenericMapping.Map(mask)
+}
+
+// MapGenericRights replaces the generic rights of the access masks of the ACEs of the DACL and the SACL
+// with the rights they map to according to mapping, e.g. FileGenericMapping() for files, so that the
+// descriptor only holds concrete rights: "(A;;GR;;;BU)" becomes "(A;;FR;;;BU)". The masks of mandatory
+// label ACEs, which are policies rather than rights, and of ACEs of unknown type are left unchanged.
+func (sd *SecurityDescriptor) MapGenericRights(mapping GenericMapping) {
+	for _, acl := range []*ACL{sd.dacl, sd.sacl} {
+		if acl == nil {
+			continue
+		}
+		for i := range acl.aces {
+			ace := &acl.aces[i]
+			if ace.header == nil || ace.isRaw() || ace.header.aceType == systemMandatoryLabelACEType {
+				continue
+			}
+			ace.accessMask = mapping.Map(ace.accessMask)
+		}
+	}
+}
+
+// FilePermissions returns the simplified file permissions of the access mask of the ACE, whatever its type:
+// for a deny ACE, they are the permissions it denies.
+func (e *ACE) FilePermissions() FilePermissions {
+	return filePermissions(e.accessMask)
+}
+
+// TrusteePermissions returns the file permissions that the DACL grants to each trustee appearing in its
+// allow or deny ACEs, keyed by the string form of their SID (e.g. "SY" or "S-1-5-21-...-1001").
+//
+// The ACEs are evaluated in order like Windows does: a right denied by an ACE is not granted by the
+// following ones. Inherit-only ACEs, which don't apply to the object itself, are ignored. Each trustee
+// is considered alone: the rights a user gets through its group memberships are not combined.
+// The conditional expressions of callback ACEs are not evaluated: callback allow ACEs are ignored, as their
+// condition may not hold, while callback deny ACEs apply as if it did.
+// PRINCIPAL_SELF (PS) ACEs are not expanded to the object's own SID: they only match the literal S-1-5-10
+// SID, and are reported under "PS".
+// It returns nil if there is no DACL or a NULL DACL.
+func (sd *SecurityDescriptor) TrusteePermissions() map[string]FilePermissions {
+	if sd.dacl == nil {
+		return nil
+	}
+
+	type rights struct{ granted, denied uint32 }
+	masks := make(map[string]*rights)
+	for i := range sd.dacl.aces {
+		ace := &sd.dacl.aces[i]
+		if ace.header == nil || ace.sid == nil || ace.header.aceFlags&inheritOnlyACE != 0 {
+			continue
+		}
+		allow, deny := isAllowACEType(ace.header.aceType), isDenyACEType(ace.header.aceType)
+		if allow && isCallbackACEType(ace.header.aceType) {
+			allow = false
+		}
+		if !allow && !deny {
+			continue
+		}
+
+		trustee := ace.sid.String()
+		r, ok := masks[trustee]
+		if !ok {
+			r = &rights{}
+			masks[trustee] = r
+		}
+
+		mask := mapGenericFileRights(ace.accessMask)
+		if allow {
+			r.granted |= mask &^ r.denied
+		} else {
+			r.denied |= mask &^ r.granted
+		}
+	}
+
+	permissions := make(map[string]FilePermissions, len(masks))
+	for trustee, r := range masks {
+		permissions[trustee] = filePermissions(r.granted)
+	}
+	return permissions
+}
+
+// GrantsRight reports whether the DACL grants all the bits of right, e.g. DELETE (0x00010000), to the trustee,
+// a user who is a member of groups. Only the ACEs whose SID is the trustee or one of the groups apply, and they
+// are evaluated in order like Windows does: a bit denied by an ACE is not granted by the following ones, so
+// "D:(D;;SD;;;WD)(A;;FA;;;WD)" doesn't grant DELETE to Everyone. Generic rights in ACEs are mapped to the file
+// rights, and inherit-only ACEs, which don't apply to the object itself, are ignored.
+//
+// The conditional expressions of callback ACEs (XA, XD, ZA) are not evaluated, so the answer errs on the side
+// of denial: callback allow ACEs are ignored, as their condition may not hold, while callback deny ACEs are
+// applied as if it did. PRINCIPAL_SELF (PS) ACEs are not expanded to the object's own SID: they only match
+// the literal S-1-5-10 SID, see SID.IsSelf.
+//
+// Groups must hold the well-known groups the trustee implicitly belongs to, such as Everyone (S-1-1-0), and
+// the implicit rights of the owner of the object are not considered. Without DACL or with a NULL DACL,
+// every right is granted. Unlike TrusteePermissions, it doesn't allocate.
+func (sd *SecurityDescriptor) GrantsRight(trustee *SID, right uint32, groups []*SID) bool {
+	if sd.dacl == nil {
+		return true
+	}
+
+	var granted uint32
+	for i := range sd.dacl.aces {
+		if granted&right == right {
+			break
+		}
+
+		ace := &sd.dacl.aces[i]
+		if ace.header == nil || ace.sid == nil || ace.header.aceFlags&inheritOnlyACE != 0 {
+			continue
+		}
+		allow, deny := isAllowACEType(ace.header.aceType), isDenyACEType(ace.header.aceType)
+		if allow && isCallbackACEType(ace.header.aceType) {
+			allow = false
+		}
+		if (!allow && !deny) || !ace.sid.appliesTo(trustee, groups) {
+			continue
+		}
+
+		mask := mapGenericFileRights(ace.accessMask) & right &^ granted
+		if deny && mask != 0 {
+			return false
+		}
+		if allow {
+			granted |= mask
+		}
+	}
+
+	return granted&right == right
+}
+
+// appliesTo reports whether the SID is trustee or one of groups.
+func (s *SID) appliesTo(trustee *SID, groups []*SID) bool {
+	if s.Equal(trustee) {
+		return true
+	}
+	for _, group := range groups {
+		if s.Equal(group) {
+			return true
+		}
+	}
+	return false
+}
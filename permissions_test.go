@@ -0,0 +1,350 @@
+package sddl
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestACE_FilePermissions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		mask uint32
+		want FilePermissions
+	}{
+		{
+			name: "FA",
+			mask: FileAllAccess,
+			want: FilePermissions{Read: true, Write: true, Execute: true, Delete: true, FullControl: true},
+		},
+		{
+			name: "FR",
+			mask: FileGenericRead,
+			want: FilePermissions{Read: true},
+		},
+		{
+			name: "FW",
+			mask: FileGenericWrite,
+			want: FilePermissions{Write: true},
+		},
+		{
+			name: "FX",
+			mask: FileGenericExecute,
+			want: FilePermissions{Execute: true},
+		},
+		{
+			name: "Modify",
+			mask: FileGenericRead | FileGenericWrite | FileGenericExecute | Delete,
+			want: FilePermissions{Read: true, Write: true, Execute: true, Delete: true},
+		},
+		{
+			name: "GA",
+			mask: GenericAll,
+			want: FilePermissions{Read: true, Write: true, Execute: true, Delete: true, FullControl: true},
+		},
+		{
+			name: "GRGX",
+			mask: GenericRead | GenericExecute,
+			want: FilePermissions{Read: true, Execute: true},
+		},
+		{
+			name: "Append only",
+			mask: FileAppendData,
+			want: FilePermissions{Write: true},
+		},
+		{
+			name: "Attributes and permissions only",
+			mask: FileReadAttributes | ReadControl | WriteDAC,
+			want: FilePermissions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ace := newACE(accessAllowedACEType, 0, tt.mask, NewSID(1, 0))
+			if got := ace.FilePermissions(); got != tt.want {
+				t.Errorf("FilePermissions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_TrusteePermissions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sddl string
+		want map[string]FilePermissions
+	}{
+		{
+			name: "Allow ACEs combined per trustee",
+			sddl: "D:(A;;FA;;;SY)(A;;FR;;;BU)(A;;FX;;;BU)(A;;FW;;;S-1-5-21-1-2-3-1001)",
+			want: map[string]FilePermissions{
+				"SY":                  {Read: true, Write: true, Execute: true, Delete: true, FullControl: true},
+				"BU":                  {Read: true, Execute: true},
+				"S-1-5-21-1-2-3-1001": {Write: true},
+			},
+		},
+		{
+			name: "Deny before allow",
+			sddl: "D:(D;;FW;;;BU)(A;;FA;;;BU)(D;;FR;;;AN)",
+			want: map[string]FilePermissions{
+				"BU": {Read: true, Execute: true, Delete: true},
+				"AN": {},
+			},
+		},
+		{
+			name: "Deny after allow is too late",
+			sddl: "D:(A;;FA;;;BU)(D;;FW;;;BU)",
+			want: map[string]FilePermissions{
+				"BU": {Read: true, Write: true, Execute: true, Delete: true, FullControl: true},
+			},
+		},
+		{
+			name: "Generic rights and inherit-only ACEs",
+			sddl: "D:(A;;GRGX;;;WD)(A;OICIIO;GA;;;CO)(A;OICI;GA;;;BA)",
+			want: map[string]FilePermissions{
+				"WD": {Read: true, Execute: true},
+				"BA": {Read: true, Write: true, Execute: true, Delete: true, FullControl: true},
+			},
+		},
+		{
+			name: "Callback ACEs",
+			sddl: `D:(XA;;FA;;;BU;(@User.Title == "PM"))(XD;;FW;;;BA;(@User.Title == "PM"))(A;;FA;;;BA)`,
+			want: map[string]FilePermissions{
+				"BA": {Read: true, Execute: true, Delete: true},
+			},
+		},
+		{
+			name: "Empty DACL",
+			sddl: "D:",
+			want: map[string]FilePermissions{},
+		},
+		{
+			name: "NULL DACL",
+			sddl: "D:NO_ACCESS_CONTROL",
+		},
+		{
+			name: "No DACL",
+			sddl: "O:BA",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := mustFromString(t, tt.sddl).TrusteePermissions()
+			if (got == nil) != (tt.want == nil) || !maps.Equal(got, tt.want) {
+				t.Errorf("TrusteePermissions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_GrantsRight(t *testing.T) {
+	t.Parallel()
+
+	user := NewSID(5, 21, 1, 2, 3, 1001)
+	users := NewSID(5, 32, 545)
+	everyone := NewSID(1, 0)
+
+	tests := []struct {
+		name   string
+		sddl   string
+		right  uint32
+		groups []*SID
+		want   bool
+	}{
+		{
+			name:  "Allowed to the trustee",
+			sddl:  "D:(A;;FA;;;S-1-5-21-1-2-3-1001)",
+			right: Delete,
+			want:  true,
+		},
+		{
+			name:   "Allowed to a group",
+			sddl:   "D:(A;;FR;;;BU)",
+			right:  FileReadData,
+			groups: []*SID{everyone, users},
+			want:   true,
+		},
+		{
+			name:   "Allowed to a group the trustee is not in",
+			sddl:   "D:(A;;FR;;;BU)",
+			right:  FileReadData,
+			groups: []*SID{everyone},
+		},
+		{
+			name:   "Deny overrides a later allow",
+			sddl:   "D:(D;;SD;;;WD)(A;;FA;;;S-1-5-21-1-2-3-1001)",
+			right:  Delete,
+			groups: []*SID{everyone},
+		},
+		{
+			name:   "Deny of another right",
+			sddl:   "D:(D;;FW;;;WD)(A;;FA;;;S-1-5-21-1-2-3-1001)",
+			right:  Delete,
+			groups: []*SID{everyone},
+			want:   true,
+		},
+		{
+			name:   "Deny after allow is too late",
+			sddl:   "D:(A;;FA;;;BU)(D;;SD;;;WD)",
+			right:  Delete,
+			groups: []*SID{everyone, users},
+			want:   true,
+		},
+		{
+			name:   "Rights combined from several ACEs",
+			sddl:   "D:(A;;FR;;;BU)(A;;FW;;;S-1-5-21-1-2-3-1001)",
+			right:  FileReadData | FileWriteData,
+			groups: []*SID{users},
+			want:   true,
+		},
+		{
+			name:   "Partially denied rights",
+			sddl:   "D:(A;;FR;;;BU)(D;;FW;;;S-1-5-21-1-2-3-1001)(A;;FW;;;BU)",
+			right:  FileReadData | FileWriteData,
+			groups: []*SID{users},
+		},
+		{
+			name:  "Generic rights",
+			sddl:  "D:(A;;GA;;;S-1-5-21-1-2-3-1001)",
+			right: WriteDAC,
+			want:  true,
+		},
+		{
+			name:  "Inherit-only ACEs ignored",
+			sddl:  "D:(D;OICIIO;SD;;;S-1-5-21-1-2-3-1001)(A;;FA;;;S-1-5-21-1-2-3-1001)",
+			right: Delete,
+			want:  true,
+		},
+		{
+			name:  "Callback allow ACEs ignored",
+			sddl:  `D:(XA;;FA;;;S-1-5-21-1-2-3-1001;(@User.Title == "PM"))`,
+			right: Delete,
+		},
+		{
+			name:  "Callback object allow ACEs ignored",
+			sddl:  `D:(ZA;;FA;00299570-246d-11d0-a768-00aa006e0529;;S-1-5-21-1-2-3-1001;(@User.Title == "PM"))`,
+			right: Delete,
+		},
+		{
+			name:   "Callback deny ACEs applied",
+			sddl:   `D:(XD;;SD;;;WD;(@User.Title == "PM"))(A;;FA;;;S-1-5-21-1-2-3-1001)`,
+			right:  Delete,
+			groups: []*SID{everyone},
+		},
+		{
+			name:  "Empty DACL",
+			sddl:  "D:",
+			right: FileReadData,
+		},
+		{
+			name:  "NULL DACL",
+			sddl:  "D:NO_ACCESS_CONTROL",
+			right: Delete,
+			want:  true,
+		},
+		{
+			name:  "No DACL",
+			sddl:  "O:BA",
+			right: Delete,
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			if got := sd.GrantsRight(user, tt.right, tt.groups); got != tt.want {
+				t.Errorf("GrantsRight() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_MapGenericRights(t *testing.T) {
+	t.Parallel()
+
+	// A mapping with a single bit per generic right, to tell them apart
+	custom := GenericMapping{GenericRead: 0x1, GenericWrite: 0x2, GenericExecute: 0x4, GenericAll: 0xF}
+
+	tests := []struct {
+		name    string
+		sddl    string
+		mapping GenericMapping
+		want    string
+	}{
+		{
+			name:    "Generic read",
+			sddl:    "D:(A;;GR;;;BU)",
+			mapping: FileGenericMapping(),
+			want:    "D:(A;;FR;;;BU)",
+		},
+		{
+			name:    "All generic rights",
+			sddl:    "D:(A;;GR;;;BU)(A;;GW;;;BU)(A;;GX;;;BU)(A;OICIIO;GA;;;CO)",
+			mapping: FileGenericMapping(),
+			want:    "D:(A;;FR;;;BU)(A;;FW;;;BU)(A;;FX;;;BU)(A;OICIIO;FA;;;CO)",
+		},
+		{
+			name:    "Generic and specific rights",
+			sddl:    "D:(D;;GWSD;;;BG)(A;;GRGX;;;WD)",
+			mapping: FileGenericMapping(),
+			want:    "D:(D;;DCLCRPCRSDRCSY;;;BG)(A;;CCSWWPLORCSY;;;WD)",
+		},
+		{
+			name:    "SACL",
+			sddl:    "S:(AU;SA;GA;;;WD)",
+			mapping: FileGenericMapping(),
+			want:    "S:(AU;SA;FA;;;WD)",
+		},
+		{
+			name:    "Mandatory label",
+			sddl:    "S:(ML;;NW;;;HI)",
+			mapping: custom,
+			want:    "S:(ML;;NW;;;HI)",
+		},
+		{
+			name:    "Custom mapping",
+			sddl:    "D:(A;;GRGX;;;WD)(A;;GA;;;SY)",
+			mapping: custom,
+			want:    "D:(A;;CCLC;;;WD)(A;;CCDCLCSW;;;SY)",
+		},
+		{
+			name:    "No generic rights",
+			sddl:    "O:SYD:PAI(A;;FA;;;SY)S:NO_ACCESS_CONTROL",
+			mapping: FileGenericMapping(),
+			want:    "O:SYD:PAI(A;;FA;;;SY)S:NO_ACCESS_CONTROL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			sd.MapGenericRights(tt.mapping)
+			if got := sd.String(); got != tt.want {
+				t.Errorf("MapGenericRights() = %s, want %s", got, tt.want)
+			}
+			if err := sd.Validate(); err != nil {
+				t.Errorf("Validate() unexpected error = %v", err)
+			}
+		})
+	}
+
+	// GR expands to the file read rights
+	if got := NamedRights(FileGenericMapping().Map(GenericRead)); !slices.Equal(got, []string{"FILE_READ_DATA", "FILE_READ_EA", "FILE_READ_ATTRIBUTES", "READ_CONTROL", "SYNCHRONIZE"}) {
+		t.Errorf("NamedRights(Map(GENERIC_READ)) = %v", got)
+	}
+}
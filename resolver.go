@@ -0,0 +1,115 @@
+package sddl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resolver looks up the account names of SIDs and the SIDs of account names, such as "CONTOSO\alice".
+// Name resolution depends on the environment (local accounts, domain, directory), so this package
+// never does it while parsing: it is only used on demand, see SecurityDescriptor.ResolveNames.
+//
+// The methods return an error wrapping ErrNoMapping when the SID or the name is unknown.
+type Resolver interface {
+	// LookupName returns the account name of the SID.
+	LookupName(sid *SID) (string, error)
+	// LookupSID returns the SID of the account name.
+	LookupSID(name string) (*SID, error)
+}
+
+// NopResolver is a Resolver that knows no account: its lookups always return ErrNoMapping.
+type NopResolver struct{}
+
+// LookupName implements Resolver, it always returns ErrNoMapping.
+func (NopResolver) LookupName(sid *SID) (string, error) {
+	return "", fmt.Errorf("%w: %s", ErrNoMapping, sid)
+}
+
+// LookupSID implements Resolver, it always returns ErrNoMapping.
+func (NopResolver) LookupSID(name string) (*SID, error) {
+	return nil, fmt.Errorf("%w: %s", ErrNoMapping, name)
+}
+
+// ResolveNames returns a human-readable rendering of the security descriptor, with one line per component
+// and per ACE like StringIndent, where the owner, the group and the trustee of each ACE are followed by
+// their account name when r resolves it, e.g.:
+//
+//	O: S-1-5-21-1-2-3-1001 (CONTOSO\alice)
+//	D:
+//	    (A;;FA;;;S-1-5-21-1-2-3-1001) (CONTOSO\alice)
+//	    (A;;FA;;;SY) (NT AUTHORITY\SYSTEM)
+//
+// Each SID is looked up once. The SIDs that r cannot resolve, whatever the error, are shown as they are.
+func (sd *SecurityDescriptor) ResolveNames(r Resolver) string {
+	lookup := cachedLookup(r)
+	annotate := func(s string, sid *SID) string {
+		if sid == nil {
+			return s
+		}
+		if name := lookup(sid); name != "" {
+			return s + " (" + name + ")"
+		}
+		return s
+	}
+
+	var b strings.Builder
+	if sd.ownerSID != nil {
+		b.WriteString(annotate("O: "+sd.ownerSID.String(), sd.ownerSID) + "\n")
+	}
+	if sd.groupSID != nil {
+		b.WriteString(annotate("G: "+sd.groupSID.String(), sd.groupSID) + "\n")
+	}
+	for _, acl := range []struct {
+		aclType string
+		acl     *ACL
+		present uint16
+	}{
+		{"D", sd.dacl, seDACLPresent},
+		{"S", sd.sacl, seSACLPresent},
+	} {
+		switch {
+		case acl.acl != nil:
+			b.WriteString(acl.aclType + ":" + acl.acl.FlagsString() + "\n")
+			for i := range acl.acl.aces {
+				ace := &acl.acl.aces[i]
+				b.WriteString(annotate("    "+ace.String(), ace.sid) + "\n")
+			}
+		case sd.control&acl.present != 0:
+			b.WriteString(acl.aclType + ": " + sd.nullACLString(acl.aclType) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// StringWithNames returns the SDDL representation of the security descriptor, like String, where the SIDs
+// that r resolves are replaced with their account name followed by their full SID, e.g.
+// "O:CONTOSO\alice (S-1-5-21-1-2-3-1001)G:DUD:(A;;FA;;;NT AUTHORITY\SYSTEM (S-1-5-18))".
+// The SIDs that r cannot resolve, whatever the error, are written as in String.
+//
+// It is meant for human-facing reports: the result is not valid SDDL and cannot be parsed back.
+// Each SID is looked up once.
+func (sd *SecurityDescriptor) StringWithNames(r Resolver) string {
+	lookup := cachedLookup(r)
+	return sd.stringWithSIDs(func(sid *SID) string {
+		if name := lookup(sid); name != "" {
+			return name + " (" + sid.rawString() + ")"
+		}
+		return sid.String()
+	})
+}
+
+// cachedLookup returns a function returning the account name of a SID with r, or "" if r cannot resolve
+// it, that looks up each SID once.
+func cachedLookup(r Resolver) func(*SID) string {
+	names := make(map[string]string)
+	return func(sid *SID) string {
+		key := sid.rawString()
+		name, ok := names[key]
+		if !ok {
+			name, _ = r.LookupName(sid)
+			names[key] = name
+		}
+		return name
+	}
+}
@@ -0,0 +1,146 @@
+package sddl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakeResolver resolves the accounts of its map, counting the name lookups
+type fakeResolver struct {
+	names   map[string]string
+	lookups int
+}
+
+func (r *fakeResolver) LookupName(sid *SID) (string, error) {
+	r.lookups++
+	if name, ok := r.names[sid.rawString()]; ok {
+		return name, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoMapping, sid)
+}
+
+func (r *fakeResolver) LookupSID(name string) (*SID, error) {
+	for s, n := range r.names {
+		if n == name {
+			parsed, err := parseSIDString(s)
+			if err != nil {
+				return nil, err
+			}
+			return parsed.toSID(nil)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoMapping, name)
+}
+
+func TestSecurityDescriptor_ResolveNames(t *testing.T) {
+	t.Parallel()
+
+	resolver := &fakeResolver{names: map[string]string{
+		"S-1-5-21-1-2-3-1001": `CONTOSO\alice`,
+		"S-1-5-18":            `NT AUTHORITY\SYSTEM`,
+	}}
+
+	tests := []struct {
+		name     string
+		sddl     string
+		resolver Resolver
+		want     string
+	}{
+		{
+			name:     "Resolved and unresolved SIDs",
+			sddl:     "O:S-1-5-21-1-2-3-1001G:DUD:PAI(A;;FA;;;S-1-5-21-1-2-3-1001)(A;;FA;;;SY)(A;;FR;;;BU)S:(AU;SA;FA;;;SY)",
+			resolver: resolver,
+			want: `O: S-1-5-21-1-2-3-1001 (CONTOSO\alice)
+G: S-1-5-21-1-2-3-513
+D:PAI
+    (A;;FA;;;S-1-5-21-1-2-3-1001) (CONTOSO\alice)
+    (A;;FA;;;SY) (NT AUTHORITY\SYSTEM)
+    (A;;FR;;;BU)
+S:
+    (AU;SA;FA;;;SY) (NT AUTHORITY\SYSTEM)
+`,
+		},
+		{
+			name:     "No-op resolver",
+			sddl:     "O:SYD:(A;;FA;;;SY)S:NO_ACCESS_CONTROL",
+			resolver: NopResolver{},
+			want: `O: SY
+D:
+    (A;;FA;;;SY)
+S: NO_ACCESS_CONTROL
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sd := mustFromString(t, tt.sddl)
+			if got := sd.ResolveNames(tt.resolver); got != tt.want {
+				t.Errorf("ResolveNames() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// Each SID is looked up once: alice, SYSTEM, DU and BU
+	if resolver.lookups != 4 {
+		t.Errorf("ResolveNames() did %d lookups, want 4", resolver.lookups)
+	}
+}
+
+func TestNopResolver(t *testing.T) {
+	t.Parallel()
+
+	var r Resolver = NopResolver{}
+	if _, err := r.LookupName(NewSID(5, 18)); !errors.Is(err, ErrNoMapping) {
+		t.Errorf("LookupName() error = %v, want %v", err, ErrNoMapping)
+	}
+	if _, err := r.LookupSID(`NT AUTHORITY\SYSTEM`); !errors.Is(err, ErrNoMapping) {
+		t.Errorf("LookupSID() error = %v, want %v", err, ErrNoMapping)
+	}
+}
+
+func TestSecurityDescriptor_StringWithNames(t *testing.T) {
+	t.Parallel()
+
+	resolver := &fakeResolver{names: map[string]string{
+		"S-1-5-21-1-2-3-1001": `CONTOSO\alice`,
+		"S-1-5-18":            `NT AUTHORITY\SYSTEM`,
+	}}
+
+	tests := []struct {
+		name     string
+		sddl     string
+		resolver Resolver
+		want     string
+	}{
+		{
+			name:     "Resolved and unresolved SIDs",
+			sddl:     "O:S-1-5-21-1-2-3-1001G:DUD:PAI(A;;FA;;;S-1-5-21-1-2-3-1001)(A;;FA;;;SY)(A;;FR;;;BU)S:(AU;SA;FA;;;SY)",
+			resolver: resolver,
+			want: `O:CONTOSO\alice (S-1-5-21-1-2-3-1001)G:S-1-5-21-1-2-3-513` +
+				`D:PAI(A;;FA;;;CONTOSO\alice (S-1-5-21-1-2-3-1001))(A;;FA;;;NT AUTHORITY\SYSTEM (S-1-5-18))(A;;FR;;;BU)` +
+				`S:(AU;SA;FA;;;NT AUTHORITY\SYSTEM (S-1-5-18))`,
+		},
+		{
+			name:     "No-op resolver",
+			sddl:     "O:SYD:(A;;FA;;;SY)S:NO_ACCESS_CONTROL",
+			resolver: NopResolver{},
+			want:     "O:SYD:(A;;FA;;;SY)S:NO_ACCESS_CONTROL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sd := mustFromString(t, tt.sddl)
+			if got := sd.StringWithNames(tt.resolver); got != tt.want {
+				t.Errorf("StringWithNames() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Each SID is looked up once: alice, DU, SYSTEM and BU
+	if resolver.lookups != 4 {
+		t.Errorf("StringWithNames() did %d lookups, want 4", resolver.lookups)
+	}
+}
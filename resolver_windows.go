@@ -0,0 +1,50 @@
+package sddl
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// WindowsResolver is a Resolver backed by LookupAccountSid and LookupAccountName, which resolve the
+// local accounts, the well-known SIDs and, on a domain member, the domain accounts.
+type WindowsResolver struct {
+	// System is the name of the remote computer to do the lookups on, or empty for the local computer.
+	System string
+}
+
+// LookupName implements Resolver, returning names in the "DOMAIN\account" form, e.g. "NT AUTHORITY\SYSTEM".
+func (r WindowsResolver) LookupName(sid *SID) (string, error) {
+	wsid, err := windows.StringToSid(sid.rawString())
+	if err != nil {
+		return "", fmt.Errorf("converting SID %s: %w", sid.rawString(), err)
+	}
+	account, domain, _, err := wsid.LookupAccount(r.System)
+	if err != nil {
+		if errors.Is(err, windows.ERROR_NONE_MAPPED) {
+			return "", fmt.Errorf("%w: %s", ErrNoMapping, sid.rawString())
+		}
+		return "", fmt.Errorf("looking up SID %s: %w", sid.rawString(), err)
+	}
+	if domain == "" {
+		return account, nil
+	}
+	return domain + `\` + account, nil
+}
+
+// LookupSID implements Resolver. The name can be qualified by its domain, e.g. "CONTOSO\alice".
+func (r WindowsResolver) LookupSID(name string) (*SID, error) {
+	wsid, _, _, err := windows.LookupSID(r.System, name)
+	if err != nil {
+		if errors.Is(err, windows.ERROR_NONE_MAPPED) {
+			return nil, fmt.Errorf("%w: %s", ErrNoMapping, name)
+		}
+		return nil, fmt.Errorf("looking up account %s: %w", name, err)
+	}
+	parsed, err := parseSIDString(wsid.String())
+	if err != nil {
+		return nil, err
+	}
+	return parsed.toSID(nil)
+}
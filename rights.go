@@ -0,0 +1,172 @@
+package sddl
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Access rights of the access mask of an ACE, named after the Windows constants.
+//
+// The access mask is made of:
+//   - Object specific rights (0x0000FFFF), here the file and directory ones
+//   - Standard rights (0x001F0000), common to all securable objects
+//   - Access to the SACL (0x01000000) and the maximum allowed marker (0x02000000)
+//   - Generic rights (0xF0000000), mapped to specific and standard rights by each object type
+//   - Reserved bits (0x0CE00000), see ReservedAccessBits
+//
+// See https://learn.microsoft.com/en-us/windows/win32/fileio/file-access-rights-constants
+// and https://learn.microsoft.com/en-us/windows/win32/secauthz/access-mask-format
+const (
+	// File specific rights
+
+	// FileReadData - Read the file data, or list a directory (FILE_READ_DATA, FILE_LIST_DIRECTORY)
+	FileReadData = 0x00000001
+	// FileWriteData - Write the file data, or create a file in a directory (FILE_WRITE_DATA, FILE_ADD_FILE)
+	FileWriteData = 0x00000002
+	// FileAppendData - Append data to the file, or create a subdirectory (FILE_APPEND_DATA, FILE_ADD_SUBDIRECTORY)
+	FileAppendData = 0x00000004
+	// FileReadEA - Read extended attributes (FILE_READ_EA)
+	FileReadEA = 0x00000008
+	// FileWriteEA - Write extended attributes (FILE_WRITE_EA)
+	FileWriteEA = 0x00000010
+	// FileExecute - Execute the file, or traverse a directory (FILE_EXECUTE, FILE_TRAVERSE)
+	FileExecute = 0x00000020
+	// FileDeleteChild - Delete a directory and all the files it contains (FILE_DELETE_CHILD)
+	FileDeleteChild = 0x00000040
+	// FileReadAttributes - Read file attributes (FILE_READ_ATTRIBUTES)
+	FileReadAttributes = 0x00000080
+	// FileWriteAttributes - Write file attributes (FILE_WRITE_ATTRIBUTES)
+	FileWriteAttributes = 0x00000100
+
+	// Standard rights
+
+	// Delete - Delete the object (DELETE)
+	Delete = 0x00010000
+	// ReadControl - Read the security descriptor, except the SACL (READ_CONTROL)
+	ReadControl = 0x00020000
+	// WriteDAC - Modify the DACL (WRITE_DAC)
+	WriteDAC = 0x00040000
+	// WriteOwner - Change the owner (WRITE_OWNER)
+	WriteOwner = 0x00080000
+	// Synchronize - Use the object for synchronization (SYNCHRONIZE)
+	Synchronize = 0x00100000
+
+	// AccessSystemSecurity - Read or write the SACL (ACCESS_SYSTEM_SECURITY)
+	AccessSystemSecurity = 0x01000000
+	// MaximumAllowed - Request the maximum access allowed (MAXIMUM_ALLOWED)
+	MaximumAllowed = 0x02000000
+
+	// ReservedAccessBits - The bits of the access mask that no access right uses: the end of the standard
+	// rights (0x00E00000) and the bits between MAXIMUM_ALLOWED and the generic rights (0x0C000000).
+	// Windows doesn't define them, they are only found in corrupted or mistyped masks, see
+	// SecurityDescriptor.ValidateStrict.
+	ReservedAccessBits = 0x0CE00000
+
+	// Generic rights
+
+	// GenericAll - All possible access rights (GENERIC_ALL)
+	GenericAll = 0x10000000
+	// GenericExecute - Execute access (GENERIC_EXECUTE)
+	GenericExecute = 0x20000000
+	// GenericWrite - Write access (GENERIC_WRITE)
+	GenericWrite = 0x40000000
+	// GenericRead - Read access (GENERIC_READ)
+	GenericRead = 0x80000000
+
+	// Combined rights
+
+	// StandardRightsRequired - DELETE, READ_CONTROL, WRITE_DAC and WRITE_OWNER (STANDARD_RIGHTS_REQUIRED)
+	StandardRightsRequired = 0x000F0000
+	// FileAllAccess - All file access rights, "FA" in SDDL (FILE_ALL_ACCESS)
+	FileAllAccess = StandardRightsRequired | Synchronize | 0x1FF
+	// FileGenericRead - Generic read rights for files, "FR" in SDDL (FILE_GENERIC_READ)
+	FileGenericRead = ReadControl | FileReadData | FileReadAttributes | FileReadEA | Synchronize
+	// FileGenericWrite - Generic write rights for files, "FW" in SDDL (FILE_GENERIC_WRITE)
+	FileGenericWrite = ReadControl | FileWriteData | FileWriteAttributes | FileWriteEA | FileAppendData | Synchronize
+	// FileGenericExecute - Generic execute rights for files, "FX" in SDDL (FILE_GENERIC_EXECUTE)
+	FileGenericExecute = ReadControl | FileReadAttributes | FileExecute | Synchronize
+)
+
+// namedRights maps each access right bit to its Windows constant name, by increasing bit value
+var namedRights = []struct {
+	mask uint32
+	name string
+}{
+	{FileReadData, "FILE_READ_DATA"},
+	{FileWriteData, "FILE_WRITE_DATA"},
+	{FileAppendData, "FILE_APPEND_DATA"},
+	{FileReadEA, "FILE_READ_EA"},
+	{FileWriteEA, "FILE_WRITE_EA"},
+	{FileExecute, "FILE_EXECUTE"},
+	{FileDeleteChild, "FILE_DELETE_CHILD"},
+	{FileReadAttributes, "FILE_READ_ATTRIBUTES"},
+	{FileWriteAttributes, "FILE_WRITE_ATTRIBUTES"},
+	{Delete, "DELETE"},
+	{ReadControl, "READ_CONTROL"},
+	{WriteDAC, "WRITE_DAC"},
+	{WriteOwner, "WRITE_OWNER"},
+	{Synchronize, "SYNCHRONIZE"},
+	{AccessSystemSecurity, "ACCESS_SYSTEM_SECURITY"},
+	{MaximumAllowed, "MAXIMUM_ALLOWED"},
+	{GenericAll, "GENERIC_ALL"},
+	{GenericExecute, "GENERIC_EXECUTE"},
+	{GenericWrite, "GENERIC_WRITE"},
+	{GenericRead, "GENERIC_READ"},
+}
+
+// NamedRights returns the Windows constant names of the access rights set in mask, by increasing bit value.
+// File specific rights are named after the file constants (e.g. FILE_READ_DATA rather than FILE_LIST_DIRECTORY).
+// Bits without a name are returned as hexadecimal values (e.g. "0x00000200").
+//
+// For example, NamedRights(FileGenericRead) returns:
+//
+//	[FILE_READ_DATA FILE_READ_EA FILE_READ_ATTRIBUTES READ_CONTROL SYNCHRONIZE]
+func NamedRights(mask uint32) []string {
+	names := make([]string, 0, bits.OnesCount32(mask))
+	for _, right := range namedRights {
+		if mask&right.mask != 0 {
+			names = append(names, right.name)
+			mask &^= right.mask
+		}
+	}
+
+	// Unnamed bits, one by one
+	for mask != 0 {
+		bit := uint32(1) << bits.TrailingZeros32(mask)
+		names = append(names, fmt.Sprintf("0x%08X", bit))
+		mask &^= bit
+	}
+
+	return names
+}
+
+// RightsFromNames returns the access mask made of the access rights with the given Windows constant names
+// or hexadecimal values, as returned by NamedRights. It returns an error if a name is unknown.
+func RightsFromNames(names []string) (uint32, error) {
+	var mask uint32
+	for _, name := range names {
+		if strings.HasPrefix(name, "0x") {
+			value, err := strconv.ParseUint(name[2:], 16, 32)
+			if err != nil {
+				return 0, fmt.Errorf("invalid hexadecimal access right: %s", name)
+			}
+			mask |= uint32(value)
+			continue
+		}
+
+		found := false
+		for _, right := range namedRights {
+			if right.name == name {
+				mask |= right.mask
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown access right: %s", name)
+		}
+	}
+	return mask, nil
+}
@@ -0,0 +1,174 @@
+package sddl
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNamedRights(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		mask uint32
+		want []string
+	}{
+		{
+			name: "FILE_GENERIC_READ",
+			mask: 0x00120089,
+			want: []string{"FILE_READ_DATA", "FILE_READ_EA", "FILE_READ_ATTRIBUTES", "READ_CONTROL", "SYNCHRONIZE"},
+		},
+		{
+			name: "FILE_GENERIC_WRITE",
+			mask: 0x00120116,
+			want: []string{"FILE_WRITE_DATA", "FILE_APPEND_DATA", "FILE_WRITE_EA", "FILE_WRITE_ATTRIBUTES", "READ_CONTROL", "SYNCHRONIZE"},
+		},
+		{
+			name: "FILE_GENERIC_EXECUTE",
+			mask: 0x001200A0,
+			want: []string{"FILE_EXECUTE", "FILE_READ_ATTRIBUTES", "READ_CONTROL", "SYNCHRONIZE"},
+		},
+		{
+			name: "Generic rights",
+			mask: 0xF0000000,
+			want: []string{"GENERIC_ALL", "GENERIC_EXECUTE", "GENERIC_WRITE", "GENERIC_READ"},
+		},
+		{
+			name: "Unnamed bits",
+			mask: WriteDAC | 0x00000200 | 0x00800000,
+			want: []string{"WRITE_DAC", "0x00000200", "0x00800000"},
+		},
+		{
+			name: "Empty mask",
+			mask: 0,
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NamedRights(tt.mask)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NamedRights(0x%08X) = %v, want %v", tt.mask, got, tt.want)
+			}
+
+			back, err := RightsFromNames(got)
+			if err != nil {
+				t.Fatalf("RightsFromNames() unexpected error = %v", err)
+			}
+			if back != tt.mask {
+				t.Errorf("RightsFromNames() = 0x%08X, want 0x%08X", back, tt.mask)
+			}
+		})
+	}
+}
+
+func TestRightsConstants(t *testing.T) {
+	t.Parallel()
+
+	// The combined rights must match the masks of the SDDL file rights
+	tests := []struct {
+		name string
+		mask uint32
+		sddl string
+	}{
+		{name: "FILE_ALL_ACCESS", mask: FileAllAccess, sddl: "FA"},
+		{name: "FILE_GENERIC_READ", mask: FileGenericRead, sddl: "FR"},
+		{name: "FILE_GENERIC_WRITE", mask: FileGenericWrite, sddl: "FW"},
+		{name: "FILE_GENERIC_EXECUTE", mask: FileGenericExecute, sddl: "FX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mask, err := parseAccessMask(tt.sddl)
+			if err != nil {
+				t.Fatalf("parseAccessMask() unexpected error = %v", err)
+			}
+			if mask != tt.mask {
+				t.Errorf("%s = 0x%08X, want 0x%08X", tt.name, tt.mask, mask)
+			}
+		})
+	}
+}
+
+func TestRightsFromNames_Unknown(t *testing.T) {
+	t.Parallel()
+
+	if _, err := RightsFromNames([]string{"READ_CONTROL", "FILE_READ_EVERYTHING"}); err == nil {
+		t.Errorf("RightsFromNames() expected error, got nil")
+	}
+	if _, err := RightsFromNames([]string{"0xZZ"}); err == nil {
+		t.Errorf("RightsFromNames() expected error, got nil")
+	}
+}
+
+func TestACE_AuditedRights(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sddl string
+		want []string
+	}{
+		{
+			name: "File all access",
+			sddl: "S:(AU;SA;FA;;;SY)",
+			want: []string{
+				"FILE_READ_DATA", "FILE_WRITE_DATA", "FILE_APPEND_DATA", "FILE_READ_EA", "FILE_WRITE_EA",
+				"FILE_EXECUTE", "FILE_DELETE_CHILD", "FILE_READ_ATTRIBUTES", "FILE_WRITE_ATTRIBUTES",
+				"DELETE", "READ_CONTROL", "WRITE_DAC", "WRITE_OWNER", "SYNCHRONIZE",
+			},
+		},
+		{
+			name: "Failed writes",
+			sddl: "S:(AU;FA;WDWO;;;WD)",
+			want: []string{"WRITE_DAC", "WRITE_OWNER"},
+		},
+		{
+			name: "Object audit ACE",
+			sddl: "S:(OU;SA;CR;ab721a54-1e2f-11d0-9819-00aa0040529b;;WD)",
+			want: []string{"FILE_WRITE_ATTRIBUTES"}, // NamedRights names the bits after the file rights
+		},
+		{
+			name: "Allow ACE",
+			sddl: "D:(A;;FA;;;SY)",
+			want: nil,
+		},
+		{
+			name: "Mandatory label",
+			sddl: "S:(ML;;NW;;;HI)",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			acl := sd.SACL()
+			if acl == nil {
+				acl = sd.DACL()
+			}
+			ace := acl.ACEs()[0]
+			if got := ace.AuditedRights(); !slices.Equal(got, tt.want) {
+				t.Errorf("AuditedRights() = %v, want %v", got, tt.want)
+			}
+
+			// The mask is shown as audited in the debugging output only, the SDDL form is unchanged
+			wantAudited := tt.want != nil
+			if got := strings.Contains(ace.StringIndent(0), " [audited]"); got != wantAudited {
+				t.Errorf("StringIndent() = %s, audited annotation %v, want %v", ace.StringIndent(0), got, wantAudited)
+			}
+			if got := sd.String(); got != tt.sddl {
+				t.Errorf("String() = %s, want %s", got, tt.sddl)
+			}
+		})
+	}
+}
@@ -4,18 +4,24 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 )
 
 // Define common errors
 var (
+	ErrDescriptorTooLarge       = errors.New("security descriptor too large")
+	ErrInvalidACLRevision       = errors.New("invalid ACL revision")
 	ErrInvalidAuthority         = errors.New("invalid authority value")
 	ErrInvalidRevision          = errors.New("invalid SID revision")
+	ErrInvalidSDFormat          = errors.New("invalid security descriptor format")
 	ErrInvalidSIDFormat         = errors.New("invalid SID format")
 	ErrInvalidSubAuthority      = errors.New("invalid sub-authority value")
 	ErrMissingDomainInformation = errors.New("missing domain information")
 	ErrMissingSubAuthorities    = errors.New("missing sub-authorities")
+	ErrNoMapping                = errors.New("no mapping between account name and SID")
+	ErrReservedAccessBits       = errors.New("reserved access mask bits")
 	ErrTooManySubAuthorities    = errors.New("too many sub-authorities")
 )
 
@@ -82,12 +88,24 @@ const (
 	// seSACLProtected - SACL is protected (SE_SACL_PROTECTED)
 	seSACLProtected = 0x2000
 	// seResourceManagerControlValid - Resource manager control is valid (SE_RESOURCE_MANAGER_CONTROL_VALID)
-	// This flag is set when the resource manager has verified that the security descriptor is valid.
-	// It is used by the system to ensure that the security descriptor was set by a trusted entity.
+	// This flag is set when the Sbz1 field of the security descriptor holds resource manager control bits,
+	// whose meaning is private to the resource manager, see SecurityDescriptor.ResourceManagerControl.
 	seResourceManagerControlValid = 0x4000
 	// seSelfRelative - Self relative flag which means the information is packed in a contiguous region of memory (SE_SELF_RELATIVE)
 	seSelfRelative = 0x8000
 
+	// daclControlMask - Control flags describing the DACL, kept in the control of DACLs
+	daclControlMask = seDACLPresent | seDACLDefaulted | seDACLTrusted | seDACLAutoInheritRe | seDACLAutoInherited | seDACLProtected
+	// saclControlMask - Control flags describing the SACL, kept in the control of SACLs
+	saclControlMask = seSACLPresent | seSACLDefaulted | seSACLAutoInheritRe | seSACLAutoInherited | seSACLProtected
+
+	// ACL revisions
+
+	// aclRevision - Revision of ACLs that don't contain object ACEs (ACL_REVISION)
+	aclRevision = 2
+	// aclRevisionDS - Revision of ACLs that contain object ACEs (ACL_REVISION_DS)
+	aclRevisionDS = 4
+
 	// ACE types
 
 	// accessAllowedACEType - Access allowed (ACCESS_ALLOWED_ACE_TYPE)
@@ -102,8 +120,51 @@ const (
 	// This ACE type is used to specify system-level alarms for an object.
 	// It allows the system to generate alarms in response to access to the object.
 	systemAlarmACEType = 0x3
+	// accessAllowedCompoundACEType - Access allowed compound (ACCESS_ALLOWED_COMPOUND_ACE_TYPE)
+	// This ACE type is reserved and only found in ACL_REVISION3 ACLs. It has no SDDL representation,
+	// so decoded compound ACEs are kept as raw data, see ACE.CompoundSIDs.
+	accessAllowedCompoundACEType = 0x4
 	// accessAllowedObjectACEType - Access allowed object (ACCESS_ALLOWED_OBJECT_ACE_TYPE)
 	accessAllowedObjectACEType = 0x5
+	// accessDeniedObjectACEType - Access denied object (ACCESS_DENIED_OBJECT_ACE_TYPE)
+	accessDeniedObjectACEType = 0x6
+	// systemAuditObjectACEType - System audit object (SYSTEM_AUDIT_OBJECT_ACE_TYPE)
+	systemAuditObjectACEType = 0x7
+	// systemAlarmObjectACEType - System alarm object (SYSTEM_ALARM_OBJECT_ACE_TYPE)
+	systemAlarmObjectACEType = 0x8
+	// accessAllowedCallbackACEType - Access allowed callback (ACCESS_ALLOWED_CALLBACK_ACE_TYPE)
+	accessAllowedCallbackACEType = 0x9
+	// accessDeniedCallbackACEType - Access denied callback (ACCESS_DENIED_CALLBACK_ACE_TYPE)
+	accessDeniedCallbackACEType = 0xA
+	// accessAllowedCallbackObjectACEType - Access allowed callback object (ACCESS_ALLOWED_CALLBACK_OBJECT_ACE_TYPE)
+	accessAllowedCallbackObjectACEType = 0xB
+	// accessDeniedCallbackObjectACEType - Access denied callback object (ACCESS_DENIED_CALLBACK_OBJECT_ACE_TYPE)
+	accessDeniedCallbackObjectACEType = 0xC
+	// systemAuditCallbackACEType - System audit callback (SYSTEM_AUDIT_CALLBACK_ACE_TYPE)
+	systemAuditCallbackACEType = 0xD
+	// systemAlarmCallbackACEType - System alarm callback (SYSTEM_ALARM_CALLBACK_ACE_TYPE)
+	systemAlarmCallbackACEType = 0xE
+	// systemAuditCallbackObjectACEType - System audit callback object (SYSTEM_AUDIT_CALLBACK_OBJECT_ACE_TYPE)
+	systemAuditCallbackObjectACEType = 0xF
+	// systemAlarmCallbackObjectACEType - System alarm callback object (SYSTEM_ALARM_CALLBACK_OBJECT_ACE_TYPE)
+	systemAlarmCallbackObjectACEType = 0x10
+	// systemMandatoryLabelACEType - System mandatory label (SYSTEM_MANDATORY_LABEL_ACE_TYPE)
+	systemMandatoryLabelACEType = 0x11
+	// systemResourceAttributeACEType - System resource attribute (SYSTEM_RESOURCE_ATTRIBUTE_ACE_TYPE)
+	systemResourceAttributeACEType = 0x12
+	// systemScopedPolicyIDACEType - System scoped policy ID (SYSTEM_SCOPED_POLICY_ID_ACE_TYPE)
+	systemScopedPolicyIDACEType = 0x13
+	// systemProcessTrustLabelACEType - System process trust label (SYSTEM_PROCESS_TRUST_LABEL_ACE_TYPE)
+	systemProcessTrustLabelACEType = 0x14
+	// systemAccessFilterACEType - System access filter (SYSTEM_ACCESS_FILTER_ACE_TYPE)
+	systemAccessFilterACEType = 0x15
+
+	// Object ACE flags
+
+	// aceObjectTypePresent - The ObjectType GUID is present in the object ACE (ACE_OBJECT_TYPE_PRESENT)
+	aceObjectTypePresent = 0x1
+	// aceInheritedObjectTypePresent - The InheritedObjectType GUID is present in the object ACE (ACE_INHERITED_OBJECT_TYPE_PRESENT)
+	aceInheritedObjectTypePresent = 0x2
 
 	// ACE flags
 
@@ -129,43 +190,64 @@ const (
 	failedAccessACE = 0x80
 )
 
-// wellKnownSids maps short SID names to their full string representation as
-// documented in the Microsoft documentation: https://docs.microsoft.com/en-us/windows/win32/secauthz/well-known-sids
+// noAccessControl is the SDDL keyword of a NULL ACL, an ACL that is present but has no structure.
+// A NULL DACL grants full access to everyone, unlike an empty DACL which grants no access at all.
+const noAccessControl = "NO_ACCESS_CONTROL"
+
+// wellKnownSids maps well-known SIDs to their SDDL alias, as documented in MS-DTYP:
+// https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/f4296d69-1c0f-491f-9587-a960b292d070
+// Domain relative aliases are in wellKnownRIDs.
 var wellKnownSids = map[string]string{
-	"S-1-0-0":      "NULL",
-	"S-1-1-0":      "WD", // Everyone
-	"S-1-2-0":      "LG", // Local GROUP
-	"S-1-3-0":      "CC", // CREATOR CREATOR
-	"S-1-3-1":      "CO", // CREATOR OWNER
-	"S-1-3-2":      "CG", // CREATOR GROUP
-	"S-1-3-3":      "OW", // OWNER RIGHTS
-	"S-1-5-1":      "DU", // DIALUP
-	"S-1-5-2":      "AN", // NETWORK
-	"S-1-5-3":      "BT", // BATCH
-	"S-1-5-4":      "IU", // INTERACTIVE
-	"S-1-5-6":      "SU", // SERVICE
-	"S-1-5-7":      "AS", // ANONYMOUS
-	"S-1-5-8":      "PS", // PROXY
-	"S-1-5-9":      "ED", // ENTERPRISE DOMAIN CONTROLLERS
-	"S-1-5-10":     "SS", // SELF
-	"S-1-5-11":     "AU", // Authenticated Users
-	"S-1-5-12":     "RC", // RESTRICTED CODE
-	"S-1-5-18":     "SY", // LOCAL SYSTEM
-	"S-1-5-32-544": "BA", // BUILTIN\Administrators
-	"S-1-5-32-545": "BU", // BUILTIN\Users
-	"S-1-5-32-546": "BG", // BUILTIN\Guests
-	"S-1-5-32-547": "PU", // BUILTIN\Power Users
-	"S-1-5-32-548": "AO", // BUILTIN\Account Operators
-	"S-1-5-32-549": "SO", // BUILTIN\Server Operators
-	"S-1-5-32-550": "PO", // BUILTIN\Print Operators
-	"S-1-5-32-551": "BO", // BUILTIN\Backup Operators
-	"S-1-5-32-552": "RE", // BUILTIN\Replicator
-	"S-1-5-32-554": "RU", // BUILTIN\Pre-Windows 2000 Compatible Access
-	"S-1-5-32-555": "RD", // BUILTIN\Remote Desktop Users
-	"S-1-5-32-556": "NO", // BUILTIN\Network Configuration Operators
-	"S-1-5-64-10":  "AA", // Administrator Access
-	"S-1-5-64-14":  "RA", // Remote Access
-	"S-1-5-64-21":  "OA", // Operation Access
+	"S-1-0-0":            "NULL",
+	"S-1-1-0":            "WD", // Everyone
+	"S-1-3-0":            "CO", // CREATOR OWNER
+	"S-1-3-1":            "CG", // CREATOR GROUP
+	"S-1-3-4":            "OW", // OWNER RIGHTS
+	"S-1-5-2":            "NU", // NETWORK
+	"S-1-5-4":            "IU", // INTERACTIVE
+	"S-1-5-6":            "SU", // SERVICE
+	"S-1-5-7":            "AN", // ANONYMOUS LOGON
+	"S-1-5-9":            "ED", // ENTERPRISE DOMAIN CONTROLLERS
+	"S-1-5-10":           "PS", // PRINCIPAL SELF
+	"S-1-5-11":           "AU", // Authenticated Users
+	"S-1-5-12":           "RC", // RESTRICTED CODE
+	"S-1-5-18":           "SY", // LOCAL SYSTEM
+	"S-1-5-19":           "LS", // LOCAL SERVICE
+	"S-1-5-20":           "NS", // NETWORK SERVICE
+	"S-1-5-33":           "WR", // WRITE RESTRICTED CODE
+	"S-1-5-32-544":       "BA", // BUILTIN\Administrators
+	"S-1-5-32-545":       "BU", // BUILTIN\Users
+	"S-1-5-32-546":       "BG", // BUILTIN\Guests
+	"S-1-5-32-547":       "PU", // BUILTIN\Power Users
+	"S-1-5-32-548":       "AO", // BUILTIN\Account Operators
+	"S-1-5-32-549":       "SO", // BUILTIN\Server Operators
+	"S-1-5-32-550":       "PO", // BUILTIN\Print Operators
+	"S-1-5-32-551":       "BO", // BUILTIN\Backup Operators
+	"S-1-5-32-552":       "RE", // BUILTIN\Replicator
+	"S-1-5-32-554":       "RU", // BUILTIN\Pre-Windows 2000 Compatible Access
+	"S-1-5-32-555":       "RD", // BUILTIN\Remote Desktop Users
+	"S-1-5-32-556":       "NO", // BUILTIN\Network Configuration Operators
+	"S-1-5-32-558":       "MU", // BUILTIN\Performance Monitor Users
+	"S-1-5-32-559":       "LU", // BUILTIN\Performance Log Users
+	"S-1-5-32-568":       "IS", // BUILTIN\IIS_IUSRS
+	"S-1-5-32-569":       "CY", // BUILTIN\Cryptographic Operators
+	"S-1-5-32-573":       "ER", // BUILTIN\Event Log Readers
+	"S-1-5-32-574":       "CD", // BUILTIN\Certificate Service DCOM Access
+	"S-1-5-32-575":       "RA", // BUILTIN\RDS Remote Access Servers
+	"S-1-5-32-576":       "ES", // BUILTIN\RDS Endpoint Servers
+	"S-1-5-32-577":       "MS", // BUILTIN\RDS Management Servers
+	"S-1-5-32-578":       "HA", // BUILTIN\Hyper-V Administrators
+	"S-1-5-32-579":       "AA", // BUILTIN\Access Control Assistance Operators
+	"S-1-5-32-580":       "RM", // BUILTIN\Remote Management Users
+	"S-1-5-84-0-0-0-0-0": "UD", // USER MODE DRIVERS
+	"S-1-15-2-1":         "AC", // ALL APPLICATION PACKAGES
+	"S-1-16-4096":        "LW", // Low integrity level
+	"S-1-16-8192":        "ME", // Medium integrity level
+	"S-1-16-8448":        "MP", // Medium Plus integrity level
+	"S-1-16-12288":       "HI", // High integrity level
+	"S-1-16-16384":       "SI", // System integrity level
+	"S-1-18-1":           "AS", // Authentication authority asserted identity
+	"S-1-18-2":           "SS", // Service asserted identity
 }
 
 // accessMaskComponents maps permission codes to their bit values
@@ -176,9 +258,13 @@ var accessMaskComponents = map[string]uint32{
 	"GW": 0x40000000, // Generic Write
 	"GR": 0x80000000, // Generic Read
 
-	// ??
-	"MA": 0x02000000, // Maximum Allowed
-	"AS": 0x01000000, // Access System Security
+	// Special rights (0x03000000)
+	// MA requests the maximum access the caller can be granted (MAXIMUM_ALLOWED), and AS grants the right
+	// to read and write the SACL (ACCESS_SYSTEM_SECURITY). Like SY and WD, these codes are also SID aliases:
+	// codes are only looked up in the rights field of an ACE, and aliases in the trustee field, so
+	// "(A;;AS;;;AS)" grants ACCESS_SYSTEM_SECURITY to S-1-18-1.
+	"MA": 0x02000000, // Maximum Allowed (MAXIMUM_ALLOWED)
+	"AS": 0x01000000, // Access System Security (ACCESS_SYSTEM_SECURITY)
 
 	// Standard Rights (0x001F0000)
 	"SY": 0x00100000, // Synchronize
@@ -199,6 +285,22 @@ var accessMaskComponents = map[string]uint32{
 	"CC": 0x00000001, // Create Child
 }
 
+// accessMaskKeywords maps the access right keywords that are only accepted when parsing to their values.
+// They are never produced by String because several of them share the value of another code
+// (e.g. KR and KX, or NR and CC), and the access mask alone doesn't tell which one was meant.
+var accessMaskKeywords = map[string]uint32{
+	// Registry key rights
+	"KA": 0x000f003f, // Key All (KEY_ALL_ACCESS)
+	"KR": 0x00020019, // Key Read (KEY_READ)
+	"KW": 0x00020006, // Key Write (KEY_WRITE)
+	"KX": 0x00020019, // Key Execute (KEY_EXECUTE)
+
+	// Mandatory label rights, only meaningful in mandatory label ACEs
+	"NR": 0x00000002, // No Read Up (SYSTEM_MANDATORY_LABEL_NO_READ_UP)
+	"NW": 0x00000001, // No Write Up (SYSTEM_MANDATORY_LABEL_NO_WRITE_UP)
+	"NX": 0x00000004, // No Execute Up (SYSTEM_MANDATORY_LABEL_NO_EXECUTE_UP)
+}
+
 // WellKnownAccessMasks maps common combined access masks to their string representations
 var wellKnownAccessMasks = map[uint32]string{
 	0x001f01ff: "FA", // File All (STANDARD_RIGHTS_REQUIRED | SYNCHRONIZE | 0x1FF)
@@ -207,8 +309,14 @@ var wellKnownAccessMasks = map[uint32]string{
 	0x001200a0: "FX", // File Execute (READ_CONTROL | FILE_READ_ATTRIBUTES | FILE_EXECUTE | SYNCHRONIZE)
 }
 
-// reversedAccessMaskComponents maps access mask values to their short names
-var reversedAccessMaskComponents = make(map[uint32]string)
+// accessMaskComponentOrder lists the codes of accessMaskComponents in the order Windows writes them,
+// by increasing bit value, e.g. "CCDCLCSWRPWPDTLOCRSDRCWDWO" for 0x000F01FF
+var accessMaskComponentOrder = []string{
+	"CC", "DC", "LC", "SW", "RP", "WP", "DT", "LO", "CR",
+	"SD", "RC", "WD", "WO", "SY",
+	"AS", "MA",
+	"GA", "GX", "GW", "GR",
+}
 
 // reverseWellKnownSids maps short SID names to their full string representation
 var reverseWellKnownSids = make(map[string]string)
@@ -226,18 +334,33 @@ func init() {
 	for k, v := range wellKnownAccessMasks {
 		reverseWellKnownAccessMasks[v] = k
 	}
+}
 
-	// Initialize the reverse mapping of accessMaskComponents
-	for k, v := range accessMaskComponents {
-		reversedAccessMaskComponents[v] = k
-	}
+// WellKnownSIDs returns the well-known SID aliases the package knows, mapped to their SID, e.g. "SY" to
+// "S-1-5-18". The aliases relative to the domain, such as "DA" for Domain Admins, are left out as their SID
+// depends on the domain. The map is a copy that the caller can modify.
+func WellKnownSIDs() map[string]string {
+	return maps.Clone(reverseWellKnownSids)
+}
+
+// WellKnownAccessMasks returns the well-known access mask mnemonics, mapped to their value, e.g. "FA" to
+// 0x001F01FF. The map is a copy that the caller can modify.
+func WellKnownAccessMasks() map[string]uint32 {
+	return maps.Clone(reverseWellKnownAccessMasks)
 }
 
-// ace represents a Windows Access Control Entry (ACE)
-// The ace structure is used in the ACL data structure to specify access control information for an object.
+// AccessMaskComponents returns the two-letter access right codes that make up access masks, mapped to
+// their value, e.g. "RC" to 0x00020000. The keywords that are only accepted when parsing, such as "KA"
+// or "NW", are left out. The map is a copy that the caller can modify.
+func AccessMaskComponents() map[string]uint32 {
+	return maps.Clone(accessMaskComponents)
+}
+
+// ACE represents a Windows Access Control Entry (ACE)
+// The ACE structure is used in the ACL data structure to specify access control information for an object.
 // It contains information such as the type of ace, the access control information, and the SID of the trustee.
 // See https://docs.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-ace
-type ace struct {
+type ACE struct {
 	// header is the ACE header, which contains the type of ACE, flags, and size.
 	header *aceHeader
 	// accessMask is the access mask containing the access rights that are being granted or denied.
@@ -245,11 +368,42 @@ type ace struct {
 	// See https://docs.microsoft.com/en-us/windows/win32/consent/access-mask-format
 	accessMask uint32
 	// sid is the sid of the trustee, which is the user or group that the ACE is granting or denying access to.
-	sid *sid
+	sid *SID
+	// objectType is the GUID of the object type, property set or extended right an object ACE applies to.
+	// It is nil for non-object ACEs and for object ACEs that apply to all object types.
+	objectType *GUID
+	// inheritedObjectType is the GUID of the type of child objects that can inherit an object ACE.
+	// It is nil for non-object ACEs and for object ACEs that can be inherited by any type of child object.
+	inheritedObjectType *GUID
+	// rawData holds the bytes following the access mask of a decoded ACE whose type is unknown, and whose
+	// layout therefore cannot be decoded. It is nil for all other ACEs. Raw ACEs have no SID, and rawData
+	// is written back as is by Binary.
+	rawData []byte
+	// applicationData holds the bytes following the SID of a callback ACE, such as the "artx" conditional
+	// expression of ACCESS_ALLOWED_CALLBACK_ACE, or of a resource attribute ACE, its attribute. They are
+	// kept verbatim and written back as is by Binary, and decoded by String when it can write them in SDDL.
+	// It is nil for all other ACEs.
+	applicationData []byte
+	// padding holds the bytes found after the SID within the declared AceSize of a decoded ACE,
+	// such as alignment to a DWORD boundary. They are written back as is by Binary.
+	padding []byte
+	// rawMask is set on ACEs parsed with ParseOptions.RawMasks, whose access mask is always written
+	// in hexadecimal, without mnemonics.
+	rawMask bool
 }
 
 // accessString returns a string representation of the access mask, checking for well-known combinations first
-func (e *ace) accessString() string {
+func (e *ACE) accessString() string {
+	if e.rawMask {
+		return fmt.Sprintf("0x%X", e.accessMask)
+	}
+
+	if e.header != nil && e.header.aceType == systemMandatoryLabelACEType {
+		if policyStr, ok := mandatoryPolicyString(e.accessMask); ok {
+			return policyStr
+		}
+	}
+
 	var accessStr string
 	if value, ok := wellKnownAccessMasks[e.accessMask]; ok {
 		accessStr = value
@@ -272,8 +426,15 @@ func (e *ace) accessString() string {
 //   - AceSize (2 bytes, little-endian)
 //
 // - AccessMask (4 bytes, little-endian)
+// - Only for object ACEs:
+//   - Flags (4 bytes, little-endian), telling which of the following GUIDs are present
+//   - ObjectType (16 bytes, only if present)
+//   - InheritedObjectType (16 bytes, only if present)
+//
 // - SID in binary format (variable size)
-func (e *ace) Binary() []byte {
+//
+// ACEs of unknown type are written as their header and access mask followed by their raw data.
+func (e *ACE) Binary() []byte {
 	// Validate ACE structure
 	if e == nil {
 		panic("cannot convert nil ACE to binary")
@@ -281,15 +442,23 @@ func (e *ace) Binary() []byte {
 	if e.header == nil {
 		panic("cannot convert ACE with nil header to binary")
 	}
+	return e.appendBinary(make([]byte, 0, e.BinarySize()))
+}
+
+// appendBinary appends the binary representation of the ACE to b, see Binary.
+func (e *ACE) appendBinary(b []byte) []byte {
+	if e.header == nil {
+		panic("cannot convert ACE with nil header to binary")
+	}
+	if e.isRaw() {
+		return e.appendRawBinary(b)
+	}
 	if e.sid == nil {
 		panic("cannot convert ACE with nil SID to binary")
 	}
 
-	// Convert SID to binary first to get its size
-	sidBinary := e.sid.Binary()
-
-	// Calculate total ACE size: 4 (header) + 4 (access mask) + len(sidBinary)
-	aceSize := 4 + 4 + len(sidBinary)
+	// Calculate total ACE size: 4 (header) + 4 (access mask) + object fields + SID + application data
+	aceSize := e.BinarySize()
 	if aceSize > 65535 { // Check if size fits in uint16
 		panic("ACE size exceeds maximum size of 65535 bytes")
 	}
@@ -299,76 +468,430 @@ func (e *ace) Binary() []byte {
 		panic("calculated ACE size doesn't match header size")
 	}
 
-	// Create result buffer
-	result := make([]byte, aceSize)
+	// Set ACE header and access mask (4 bytes, little-endian)
+	b = append(b, e.header.aceType, e.header.aceFlags)
+	b = binary.LittleEndian.AppendUint16(b, uint16(aceSize))
+	b = binary.LittleEndian.AppendUint32(b, e.accessMask)
 
-	// Set ACE header
-	result[0] = e.header.aceType
-	result[1] = e.header.aceFlags
-	binary.LittleEndian.PutUint16(result[2:4], uint16(aceSize))
+	// Set object flags and GUIDs for object ACEs
+	if isObjectACEType(e.header.aceType) {
+		b = binary.LittleEndian.AppendUint32(b, e.objectFlags())
+		if e.objectType != nil {
+			b = append(b, e.objectType[:]...)
+		}
+		if e.inheritedObjectType != nil {
+			b = append(b, e.inheritedObjectType[:]...)
+		}
+	}
 
-	// Set access mask (4 bytes, little-endian)
-	binary.LittleEndian.PutUint32(result[4:8], e.accessMask)
+	// Append SID binary representation, followed by any application data and padding
+	b = e.sid.appendBinary(b)
+	b = append(b, e.applicationData...)
+	return append(b, e.padding...)
+}
 
-	// Copy SID binary representation
-	copy(result[8:], sidBinary)
+// BinarySize returns the size in bytes of the binary representation of the ACE, as returned by Binary,
+// without building it.
+func (e *ACE) BinarySize() int {
+	size := 4 + 4 // header + access mask
+	if e.isRaw() {
+		return size + len(e.rawData)
+	}
+	if e.header != nil && isObjectACEType(e.header.aceType) {
+		size += 4 // object flags
+		if e.objectType != nil {
+			size += 16
+		}
+		if e.inheritedObjectType != nil {
+			size += 16
+		}
+	}
+	if e.sid != nil {
+		size += e.sid.BinarySize()
+	}
+	return size + len(e.applicationData) + len(e.padding)
+}
 
-	return result
+// clone returns a copy of the ACE that does not share its header or SID with the original.
+func (e *ACE) clone() *ACE {
+	c := *e
+	if e.header != nil {
+		header := *e.header
+		c.header = &header
+	}
+	if e.sid != nil {
+		c.sid = e.sid.clone()
+	}
+	if e.objectType != nil {
+		objectType := *e.objectType
+		c.objectType = &objectType
+	}
+	if e.inheritedObjectType != nil {
+		inheritedObjectType := *e.inheritedObjectType
+		c.inheritedObjectType = &inheritedObjectType
+	}
+	c.rawData = slices.Clone(e.rawData)
+	c.applicationData = slices.Clone(e.applicationData)
+	c.padding = slices.Clone(e.padding)
+	return &c
+}
+
+// AuditedRights returns the Windows constant names of the access rights audited by an audit ACE ("AU",
+// "OU" or the callback "XU"), as returned by NamedRights: the mask of these ACEs has the layout of an access mask, but it
+// tells which accesses generate audit events, on success or on failure according to the flags of the ACE.
+// It returns nil for the other ACE types, whose mask grants, denies or labels accesses.
+func (e *ACE) AuditedRights() []string {
+	if e.header == nil || !isAuditACEType(e.header.aceType) {
+		return nil
+	}
+	return NamedRights(e.accessMask)
+}
+
+// WithSID returns a copy of the ACE applying to sid instead of its trustee, with its size recomputed.
+// The ACE is left unchanged, and the copy doesn't share sid. It panics if sid is nil.
+func (e *ACE) WithSID(sid *SID) *ACE {
+	if sid == nil {
+		panic("cannot set a nil SID on an ACE")
+	}
+	c := e.clone()
+	c.sid = sid.clone()
+	c.updateSize()
+	return c
+}
+
+// WithMask returns a copy of the ACE with the access mask mask instead of its own. The ACE is left unchanged.
+func (e *ACE) WithMask(mask uint32) *ACE {
+	c := e.clone()
+	c.accessMask = mask
+	return c
+}
+
+// WithFlags returns a copy of the ACE with the flags flags instead of its own, e.g. to make an inherited
+// ACE explicit. The ACE is left unchanged.
+func (e *ACE) WithFlags(flags ACEFlags) *ACE {
+	c := e.clone()
+	if c.header != nil {
+		c.header.aceFlags = byte(flags)
+	}
+	return c
+}
+
+// updateSize recomputes the size in the header of the ACE from its content.
+func (e *ACE) updateSize() {
+	if e.header != nil {
+		e.header.aceSize = uint16(e.BinarySize())
+	}
+}
+
+// Equal reports whether the ACE and other grant, deny or audit the same access in the same way:
+// same type, flags, access mask, trustee, object types, raw data and application data. The size and the
+// padding found after the SID of decoded ACEs are ignored, as they carry no meaning.
+func (e *ACE) Equal(other *ACE) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+	if (e.header == nil) != (other.header == nil) {
+		return false
+	}
+	if e.header != nil && (e.header.aceType != other.header.aceType || e.header.aceFlags != other.header.aceFlags) {
+		return false
+	}
+	return e.accessMask == other.accessMask &&
+		e.sid.Equal(other.sid) &&
+		equalGUIDs(e.objectType, other.objectType) &&
+		equalGUIDs(e.inheritedObjectType, other.inheritedObjectType) &&
+		slices.Equal(e.rawData, other.rawData) &&
+		slices.Equal(e.applicationData, other.applicationData)
 }
 
 // flagsString converts the ACE flags to string
-func (e *ace) flagsString() string {
-	var flagsStr string
-	if e.header.aceType == systemAuditACEType {
-		if e.header.aceFlags&successfulAccessACE != 0 {
-			flagsStr += "SA"
-		}
-		if e.header.aceFlags&failedAccessACE != 0 {
-			flagsStr += "FA"
-		}
+func (e *ACE) flagsString() string {
+	flags := ACEFlags(e.header.aceFlags)
+	if !isAuditACEType(e.header.aceType) {
+		// Audit flags are only meaningful for audit ACEs
+		flags &^= ACEFlagSuccessfulAccess | ACEFlagFailedAccess
 	}
+	return flags.String()
+}
 
-	// Add inheritance flags
-	if e.header.aceFlags&objectInheritACE != 0 {
-		flagsStr += "OI"
+// objectFlags returns the flags of an object ACE, which tell which of the object type GUIDs are present.
+func (e *ACE) objectFlags() uint32 {
+	var flags uint32
+	if e.objectType != nil {
+		flags |= aceObjectTypePresent
 	}
-	if e.header.aceFlags&containerInheritACE != 0 {
-		flagsStr += "CI"
+	if e.inheritedObjectType != nil {
+		flags |= aceInheritedObjectTypePresent
 	}
-	if e.header.aceFlags&inheritOnlyACE != 0 {
-		flagsStr += "IO"
+	return flags
+}
+
+// objectTypeStrings returns the string representation of the object type and inherited object type
+// GUIDs of the ACE. Missing GUIDs are represented as empty strings.
+func (e *ACE) objectTypeStrings() (objectType, inheritedObjectType string) {
+	if e.objectType != nil {
+		objectType = e.objectType.String()
+	}
+	if e.inheritedObjectType != nil {
+		inheritedObjectType = e.inheritedObjectType.String()
 	}
-	if e.header.aceFlags&inheritedACE != 0 {
-		flagsStr += "ID"
+	return objectType, inheritedObjectType
+}
+
+// isRaw reports whether the ACE is of an unknown type and only holds raw data instead of a SID.
+func (e *ACE) isRaw() bool {
+	return e.rawData != nil
+}
+
+// RawData returns the bytes following the access mask of a decoded ACE of unknown type, which are kept
+// verbatim because their layout cannot be decoded. It returns nil for all other ACEs.
+func (e *ACE) RawData() []byte {
+	return slices.Clone(e.rawData)
+}
+
+// ApplicationData returns a copy of the application data following the SID of a callback ACE (types 0x09
+// to 0x10), such as the binary conditional expression of ACCESS_ALLOWED_CALLBACK_ACE, which starts with
+// "artx", or of a resource attribute ACE (RA), its CLAIM_SECURITY_ATTRIBUTE_RELATIVE_V1 attribute. It is kept
+// so that the ACE is encoded again byte for byte by Binary. It returns nil for the other ACEs, and for
+// callback and resource attribute ACEs without application data.
+func (e *ACE) ApplicationData() []byte {
+	return slices.Clone(e.applicationData)
+}
+
+// compoundACEImpersonation is the only type of compound ACE (COMPOUND_ACE_IMPERSONATION)
+const compoundACEImpersonation = 1
+
+// CompoundSIDs returns the server and client SIDs of a decoded ACCESS_ALLOWED_COMPOUND_ACE, which grants
+// its access mask to the server when it impersonates the client. Compound ACEs are found in ACL_REVISION3
+// ACLs and are kept as raw data (see RawData), so that they are written back as is by Binary.
+// ok is false for other ACEs, or if the raw data doesn't hold a COMPOUND_ACE_IMPERSONATION followed by
+// the two SIDs.
+func (e *ACE) CompoundSIDs() (server, client *SID, ok bool) {
+	if e.header == nil || e.header.aceType != accessAllowedCompoundACEType || len(e.rawData) < 4 {
+		return nil, nil, false
+	}
+	// CompoundAceType (2 bytes), Reserved (2 bytes), then the server and client SIDs
+	if binary.LittleEndian.Uint16(e.rawData[0:2]) != compoundACEImpersonation {
+		return nil, nil, false
+	}
+	server, err := parseSIDBinary(e.rawData[4:])
+	if err != nil {
+		return nil, nil, false
+	}
+	client, err = parseSIDBinary(e.rawData[4+server.BinarySize():])
+	if err != nil {
+		return nil, nil, false
+	}
+	return server, client, true
+}
+
+// appendRawBinary appends the binary representation of an ACE of unknown type to b: its header, its
+// access mask and its raw data.
+func (e *ACE) appendRawBinary(b []byte) []byte {
+	aceSize := e.BinarySize()
+	if aceSize > 65535 { // Check if size fits in uint16
+		panic("ACE size exceeds maximum size of 65535 bytes")
+	}
+	if uint16(aceSize) != e.header.aceSize {
+		panic("calculated ACE size doesn't match header size")
 	}
 
-	return flagsStr
+	b = append(b, e.header.aceType, e.header.aceFlags)
+	b = binary.LittleEndian.AppendUint16(b, e.header.aceSize)
+	b = binary.LittleEndian.AppendUint32(b, e.accessMask)
+	return append(b, e.rawData...)
 }
 
+// AccessMask returns the access mask of the ACE.
+func (e *ACE) AccessMask() uint32 {
+	return e.accessMask
+}
+
+// AccessMaskString returns the access mask of the ACE as written in SDDL: a well-known mnemonic such as "FA",
+// a concatenation of component mnemonics such as "RPWP", or the hexadecimal value if some bits have no mnemonic.
+func (e *ACE) AccessMaskString() string {
+	return e.accessString()
+}
+
+// SID returns the SID of the trustee the ACE applies to.
+// It returns nil for ACEs of unknown type, see RawData.
+func (e *ACE) SID() *SID {
+	return e.sid
+}
+
+// nilTrustee is shown in place of the trustee of an ACE without SID. It is different from "NULL",
+// the alias of the NULL SID (S-1-0-0), so that a missing SID is never mistaken for a real one.
+const nilTrustee = "<nil>"
+
 // String returns a string representation of the ACE.
-func (e *ace) String() string {
-	return fmt.Sprintf("(%s;%s;%s;;;%s)", e.typeString(), e.flagsString(), e.accessString(), e.sid.String())
+// ACEs of unknown type are shown with their hexadecimal type and an empty trustee, as their content
+// cannot be represented in SDDL. An ACE without SID is shown with a "<nil>" trustee, which is not valid
+// SDDL, consistently with Binary and Validate which reject it.
+func (e *ACE) String() string {
+	return e.stringWithSIDs((*SID).String)
+}
+
+// stringWithSIDs is like String but writes the trustee with sidString.
+func (e *ACE) stringWithSIDs(sidString func(*SID) string) string {
+	objectType, inheritedObjectType := e.objectTypeStrings()
+	var trustee string
+	switch {
+	case e.isRaw():
+	case e.sid == nil:
+		trustee = nilTrustee
+	default:
+		trustee = sidString(e.sid)
+	}
+	return fmt.Sprintf("(%s;%s;%s;%s;%s;%s%s)", e.typeString(), e.flagsString(), e.accessString(), objectType, inheritedObjectType, trustee, e.applicationDataString())
+}
+
+// applicationDataString returns the SDDL form of the application data of the ACE, preceded by the
+// semicolon separating it from the trustee: the conditional expression of a callback ACE or the attribute
+// of a resource attribute ACE. It returns "" if the ACE has no application data or if it can't be decoded,
+// in which case it is only kept in binary form.
+func (e *ACE) applicationDataString() string {
+	if len(e.applicationData) == 0 || e.header == nil {
+		return ""
+	}
+	var s string
+	var err error
+	switch {
+	case isCallbackACEType(e.header.aceType):
+		s, err = conditionString(e.applicationData)
+	case e.header.aceType == systemResourceAttributeACEType:
+		s, err = resourceAttributeString(e.applicationData)
+	default:
+		return ""
+	}
+	if err != nil {
+		return ""
+	}
+	return ";" + s
 }
 
 // StringIndent returns a string representation of the ACE with the specified indentation margin.
 // The margin parameter specifies the number of spaces to prepend to the output.
-func (e *ace) StringIndent(margin int) string {
-	eStr := fmt.Sprintf("(%s;%s;%s;;;%s)", e.typeString(), e.flagsString(), e.accessString(), e.sid.DebugString())
+// Unlike String, the flags are followed by their names (see ACEFlagNames) and the trustee by its SID,
+// e.g. "(A;OICI [OBJECT_INHERIT_ACE|CONTAINER_INHERIT_ACE];FA;;;SY [S-1-5-18])", and an object type that
+// is a known extended right by its name (see GUID.ExtendedRightName), e.g. "ab721a54-...-00aa0040529b [Send-As]".
+func (e *ACE) StringIndent(margin int) string {
+	objectType, inheritedObjectType := e.objectTypeStrings()
+	if e.objectType != nil {
+		if name, ok := e.objectType.ExtendedRightName(); ok {
+			objectType = fmt.Sprintf("%s [%s]", objectType, name)
+		}
+	}
+	var trustee string
+	switch {
+	case e.isRaw():
+	case e.sid == nil:
+		trustee = nilTrustee
+	default:
+		trustee = e.sid.DebugString()
+	}
+	// Flags are followed by their Windows constant names, the same way the trustee is followed by its SID
+	flags := e.flagsString()
+	if e.header.aceFlags != 0 {
+		flags = fmt.Sprintf("%s [%s]", flags, strings.Join(ACEFlagNames(e.header.aceFlags), "|"))
+	}
+	// The mask of an audit ACE is the accesses it audits rather than the ones it grants or denies
+	access := e.accessString()
+	if e.header != nil && isAuditACEType(e.header.aceType) {
+		access += " [audited]"
+	}
+	eStr := fmt.Sprintf("(%s;%s;%s;%s;%s;%s%s)", e.typeString(), flags, access, objectType, inheritedObjectType, trustee, e.applicationDataString())
 	return strings.Repeat(" ", margin) + eStr
 }
 
 // typeString returns a string representation of the ACE type
-func (e *ace) typeString() string {
-	switch e.header.aceType {
-	case accessAllowedACEType:
-		return "A"
-	case accessDeniedACEType:
-		return "D"
-	case systemAuditACEType:
-		return "AU"
-	default:
-		return fmt.Sprintf("0x%02X", e.header.aceType)
+func (e *ACE) typeString() string {
+	return ACEType(e.header.aceType).String()
+}
+
+// validate returns an error if the ACE cannot be converted to its binary representation.
+// It checks the same conditions that make Binary panic.
+func (e *ACE) validate() error {
+	if e.header == nil {
+		return fmt.Errorf("ACE has nil header")
 	}
+	if e.isRaw() {
+		if aceSize := e.BinarySize(); uint16(aceSize) != e.header.aceSize {
+			return fmt.Errorf("calculated ACE size %d doesn't match header size %d", aceSize, e.header.aceSize)
+		}
+		return nil
+	}
+	if e.sid == nil {
+		return fmt.Errorf("ACE has nil SID")
+	}
+	if err := e.sid.Validate(); err != nil {
+		return fmt.Errorf("invalid ACE SID: %w", err)
+	}
+
+	if !isObjectACEType(e.header.aceType) && (e.objectType != nil || e.inheritedObjectType != nil) {
+		return fmt.Errorf("ACE type 0x%02X cannot have object types", e.header.aceType)
+	}
+
+	aceSize := e.BinarySize()
+	if uint16(aceSize) != e.header.aceSize {
+		return fmt.Errorf("calculated ACE size %d doesn't match header size %d", aceSize, e.header.aceSize)
+	}
+
+	return nil
+}
+
+// isAllowACEType reports whether the ACE type grants access.
+func isAllowACEType(aceType byte) bool {
+	switch aceType {
+	case accessAllowedACEType, accessAllowedObjectACEType, accessAllowedCallbackACEType, accessAllowedCallbackObjectACEType:
+		return true
+	}
+	return false
+}
+
+// isDenyACEType reports whether the ACE type denies access.
+func isDenyACEType(aceType byte) bool {
+	switch aceType {
+	case accessDeniedACEType, accessDeniedObjectACEType, accessDeniedCallbackACEType, accessDeniedCallbackObjectACEType:
+		return true
+	}
+	return false
+}
+
+// isAuditACEType reports whether the ACE type is a system audit ACE type, which accepts the audit flags.
+func isAuditACEType(aceType byte) bool {
+	switch aceType {
+	case systemAuditACEType, systemAuditObjectACEType, systemAuditCallbackACEType, systemAuditCallbackObjectACEType:
+		return true
+	}
+	return false
+}
+
+// isCallbackACEType reports whether the ACE type is a callback ACE type, which can carry a conditional expression.
+func isCallbackACEType(aceType byte) bool {
+	return aceType >= accessAllowedCallbackACEType && aceType <= systemAlarmCallbackObjectACEType
+}
+
+// hasApplicationData reports whether ACEs of the type carry application data after their SID: the
+// conditional expression of callback ACEs or the attribute of resource attribute ACEs.
+func hasApplicationData(aceType byte) bool {
+	return isCallbackACEType(aceType) || aceType == systemResourceAttributeACEType
+}
+
+// isKnownACEType reports whether the binary layout of the ACE type is known, i.e. the access mask is followed
+// by the object fields (object ACEs only) and the trustee SID. Decoded ACEs of other types are kept as raw data.
+func isKnownACEType(aceType byte) bool {
+	return aceType <= systemAccessFilterACEType && aceType != accessAllowedCompoundACEType
+}
+
+// isObjectACEType reports whether the ACE type is an object ACE type, which carries object type GUIDs.
+func isObjectACEType(aceType byte) bool {
+	switch aceType {
+	case accessAllowedObjectACEType, accessDeniedObjectACEType, systemAuditObjectACEType, systemAlarmObjectACEType,
+		accessAllowedCallbackObjectACEType, accessDeniedCallbackObjectACEType,
+		systemAuditCallbackObjectACEType, systemAlarmCallbackObjectACEType:
+		return true
+	}
+	return false
 }
 
 // aceHeader represents the Windows ACE_HEADER structure, which is the header of an Access Control Entry (ACE)
@@ -382,10 +905,11 @@ type aceHeader struct {
 	aceSize uint16
 }
 
-// acl represents the Windows Access Control List (ACL) structure
+// ACL represents the Windows Access Control List (ACL) structure
 // See https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/20233ed8-a6c6-4097-aafa-dd545ed24428
-type acl struct {
-	// aclRevision is the revision of the ACL format. Currently, only revision 2 is supported. See
+type ACL struct {
+	// aclRevision is the revision of the ACL format: 2 (ACL_REVISION), 3 (ACL_REVISION3), which allows
+	// compound ACEs, or 4 (ACL_REVISION_DS), which allows object ACEs. It is kept as decoded.
 	aclRevision byte
 
 	// Sbz1 is reserved; must be zero
@@ -409,14 +933,37 @@ type acl struct {
 
 	// control are the Security Descriptor control flags defined in
 	// https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/7d4dac05-9cef-4563-a058-f108abecce1d
+	// that describe this ACL: the SE_DACL_* flags of a DACL, or the SE_SACL_* flags of a SACL (see aclControl).
+	// The security descriptor control remains the source of truth for the binary representation, and the
+	// flags of the other ACL or of the descriptor itself are never stored here.
 	//
 	// This field is not part of original structure, but it is used in conjuntion with AclType to build the string representation
 	control uint16
 
+	// sddlFlags holds the "NO" and "IO" flags of an ACL parsed from SDDL (see sddlFlagNO and sddlFlagIO),
+	// which have no control flag: they are only kept to be written back by String.
+	//
+	// This field is not part of original structure, and it has no binary representation.
+	sddlFlags byte
+
 	// aces is the list of Access Control Entries (ACEs)
 	//
 	// This field is not part of original structure, but it is used to build the string representation.
-	aces []ace
+	aces []ACE
+}
+
+// Bits of ACL.sddlFlags, the ACL flags accepted in SDDL strings that have no control flag
+const (
+	// sddlFlagNO - "NO" flag
+	sddlFlagNO = 0x01
+	// sddlFlagIO - "IO" flag
+	sddlFlagIO = 0x02
+)
+
+// ACEs returns the Access Control Entries of the ACL in the order they appear in the ACL.
+// The returned slice is a copy, modifying it does not change the ACL.
+func (a *ACL) ACEs() []ACE {
+	return slices.Clone(a.aces)
 }
 
 // Binary converts an ACL structure to its binary representation following Windows format.
@@ -430,18 +977,31 @@ type acl struct {
 //   - Sbz2 (2 bytes, reserved)
 //
 // - Array of ACEs in binary format (variable size)
-func (a *acl) Binary() []byte {
-	// Convert all ACEs to binary first to validate them and calculate total size
-	aceBinaries := make([][]byte, len(a.aces))
-	totalAceSize := 0
+func (a *ACL) Binary() []byte {
+	return a.appendBinary(make([]byte, 0, a.BinarySize()))
+}
+
+// appendBinary appends the binary representation of the ACL to b, see Binary.
+func (a *ACL) appendBinary(b []byte) []byte {
+	// Set ACL header, its size being set once the ACEs are written
+	start := len(b)
+	b = append(b, a.aclRevision, a.sbzl, 0, 0) // Revision, reserved byte and size
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(a.aces)))
+	b = binary.LittleEndian.AppendUint16(b, a.sbz2) // Reserved bytes
 
 	for i := range a.aces {
-		aceBinaries[i] = a.aces[i].Binary()
-		totalAceSize += len(aceBinaries[i])
+		aceStart := len(b)
+		b = a.aces[i].appendBinary(b)
+
+		// ACEs are DWORD aligned: pad those whose content isn't, and account for it in their AceSize
+		if size := len(b) - aceStart; alignACESize(size) != size {
+			b = append(b, make([]byte, alignACESize(size)-size)...)
+			binary.LittleEndian.PutUint16(b[aceStart+2:], uint16(len(b)-aceStart))
+		}
 	}
 
 	// Calculate total ACL size: 8 (header) + sum of ACE sizes
-	aclSize := 8 + totalAceSize
+	aclSize := len(b) - start
 	if aclSize > 65535 { // Check if size fits in uint16
 		panic(fmt.Errorf("ACL size %d exceeds maximum size of 65535 bytes", aclSize))
 	}
@@ -456,75 +1016,183 @@ func (a *acl) Binary() []byte {
 		panic(fmt.Errorf("actual ACE count %d doesn't match header count %d", len(a.aces), a.aceCount))
 	}
 
-	// Create result buffer
-	result := make([]byte, aclSize)
+	binary.LittleEndian.PutUint16(b[start+2:], uint16(aclSize))
+	return b
+}
+
+// BinarySize returns the size in bytes of the binary representation of the ACL, as returned by Binary,
+// without building it. ACLs larger than 65535 bytes cannot be represented, see Validate.
+func (a *ACL) BinarySize() int {
+	size := 8 // ACL header size
+	for i := range a.aces {
+		size += alignACESize(a.aces[i].BinarySize())
+	}
+	return size
+}
 
-	// Set ACL header
-	result[0] = a.aclRevision
-	result[1] = a.sbzl // Reserved byte
-	binary.LittleEndian.PutUint16(result[2:4], uint16(aclSize))
-	binary.LittleEndian.PutUint16(result[4:6], uint16(len(a.aces)))
-	binary.LittleEndian.PutUint16(result[6:8], a.sbz2) // Reserved bytes
+// alignACESize returns the size of an ACE of the given content size within an ACL, where ACEs are aligned
+// on a DWORD boundary. ACEs holding a SID always are, unless they were decoded with unaligned padding or
+// raw data: Binary pads them with zeros, which are decoded back as padding or raw data.
+func alignACESize(size int) int {
+	return (size + 3) &^ 3
+}
 
-	// Copy each ACE's binary representation
-	offset := 8
-	for _, aceBinary := range aceBinaries {
-		copy(result[offset:], aceBinary)
-		offset += len(aceBinary)
+// Conflicts returns the index pairs of the allow and deny ACEs of the ACL that apply to the same trustee
+// with overlapping access masks, such as (A;;FA;;;BU) and (D;;FW;;;BU). The first index of each pair is the
+// lower one, and the pairs are sorted. ACEs with different inheritance flags or object types are still
+// reported, even though they may not apply to the same objects.
+// It is a diagnostic only, the ACL is left unchanged.
+func (a *ACL) Conflicts() [][2]int {
+	var conflicts [][2]int
+	for i := range a.aces {
+		for j := i + 1; j < len(a.aces); j++ {
+			x, y := &a.aces[i], &a.aces[j]
+			if x.header == nil || y.header == nil || x.sid == nil || !x.sid.Equal(y.sid) {
+				continue
+			}
+			opposite := (isAllowACEType(x.header.aceType) && isDenyACEType(y.header.aceType)) ||
+				(isDenyACEType(x.header.aceType) && isAllowACEType(y.header.aceType))
+			if opposite && x.accessMask&y.accessMask != 0 {
+				conflicts = append(conflicts, [2]int{i, j})
+			}
+		}
 	}
+	return conflicts
+}
 
-	return result
+// Duplicates returns the index pairs of the ACEs of the ACL that are identical, as defined by ACE.Equal.
+// The first index of each pair is the lower one, and the pairs are sorted. An ACE present three times
+// is reported as three pairs.
+// It is a diagnostic only, the ACL is left unchanged.
+func (a *ACL) Duplicates() [][2]int {
+	var duplicates [][2]int
+	for i := range a.aces {
+		for j := i + 1; j < len(a.aces); j++ {
+			if a.aces[i].Equal(&a.aces[j]) {
+				duplicates = append(duplicates, [2]int{i, j})
+			}
+		}
+	}
+	return duplicates
+}
+
+// Subtract returns a new ACL with copies of the ACEs of the ACL that have no equal ACE in other, as defined
+// by ACE.Equal, e.g. the access a template grants that an existing ACL doesn't. The ACEs keep their order,
+// and the ACL keeps the revision, control and flags of the receiver with its size and count recomputed.
+// A nil other removes nothing. The receiver and other are left unchanged.
+func (a *ACL) Subtract(other *ACL) *ACL {
+	difference := &ACL{
+		aclRevision: a.aclRevision,
+		aclType:     a.aclType,
+		control:     a.control,
+		sddlFlags:   a.sddlFlags,
+	}
+	for i := range a.aces {
+		ace := &a.aces[i]
+		if other != nil && slices.ContainsFunc(other.aces, func(o ACE) bool { return ace.Equal(&o) }) {
+			continue
+		}
+		difference.aces = append(difference.aces, *ace.clone())
+	}
+	difference.updateSize()
+	return difference
+}
+
+// InsertCanonical inserts a copy of ace into the ACL, at the position given by the Windows canonical order
+// rather than at the end: explicit deny ACEs first, then the other explicit ACEs, then the inherited ACEs,
+// which keep their order. The ACE is inserted after the ACEs of its own group, so that inserting several
+// ACEs into a canonical ACL keeps it canonical, and the ACE size and count are updated accordingly.
+// The revision of the ACL is raised to 4 (ACL_REVISION_DS) when inserting an object ACE.
+//
+// The ACE typically comes from another ACL, see ACEs.
+func (a *ACL) InsertCanonical(ace *ACE) {
+	inserted := ace.clone()
+	rank := canonicalRank(inserted)
+
+	i := len(a.aces)
+	for i > 0 && canonicalRank(&a.aces[i-1]) > rank {
+		i--
+	}
+	a.aces = slices.Insert(a.aces, i, *inserted)
+
+	if inserted.header != nil && isObjectACEType(inserted.header.aceType) && a.aclRevision < aclRevisionDS {
+		a.aclRevision = aclRevisionDS
+	}
+	a.updateSize()
+}
+
+// canonicalRank returns the rank of the group of the ACE in the Windows canonical order of ACEs:
+// 0 for explicit deny ACEs, 1 for the other explicit ACEs and 2 for inherited ACEs.
+func canonicalRank(ace *ACE) int {
+	switch {
+	case ace.header == nil:
+		return 1
+	case ace.header.aceFlags&inheritedACE != 0:
+		return 2
+	case isDenyACEType(ace.header.aceType):
+		return 0
+	default:
+		return 1
+	}
 }
 
 // FlagsString returns a string representation of the ACL flags.
 // It constructs the flag string based on the ACL type (DACL or SACL) and the control flags.
-// The returned string format is "Type:Flags", where Type is either "D" for DACL or "S" for SACL,
-// and Flags is a combination of the following:
+// The flags are written in the order Windows writes them, which is:
 //   - "P" for Protected
-//   - "AI" for Auto-Inherited
 //   - "AR" for Auto-Inherit Required
-//   - "R" for Read-Only
+//   - "AI" for Auto-Inherited
+//   - "R" for Read-Only, which Windows doesn't write, see SecurityDescriptor.String
+//   - "NO" and "IO", which have no control flag and are only kept for ACLs parsed from SDDL strings,
+//     as the binary representation has no room for them
 //
-// If no flags are set, it returns just the ACL type.
-func (a *acl) FlagsString() string {
+// For example, a protected auto-inherited DACL with the auto-inherit required flag gives "PARAI".
+// If no flags are set, it returns an empty string.
+func (a *ACL) FlagsString() string {
+	var protected, autoInheritRe, autoInherited, defaulted uint16
+	switch a.aclType {
+	case "D":
+		protected, autoInheritRe, autoInherited, defaulted = seDACLProtected, seDACLAutoInheritRe, seDACLAutoInherited, seDACLDefaulted
+	case "S":
+		protected, autoInheritRe, autoInherited, defaulted = seSACLProtected, seSACLAutoInheritRe, seSACLAutoInherited, seSACLDefaulted
+	default:
+		return ""
+	}
+
 	var aclFlags []string
-	if a.aclType == "D" {
-		if a.control&seDACLProtected != 0 {
-			aclFlags = append(aclFlags, "P")
-		}
-		if a.control&seDACLAutoInherited != 0 {
-			aclFlags = append(aclFlags, "AI")
-		}
-		if a.control&seDACLAutoInheritRe != 0 {
-			aclFlags = append(aclFlags, "AR")
-		}
-		if a.control&seDACLDefaulted != 0 {
-			aclFlags = append(aclFlags, "R")
-		}
-	} else if a.aclType == "S" {
-		if a.control&seSACLProtected != 0 {
-			aclFlags = append(aclFlags, "P")
-		}
-		if a.control&seSACLAutoInherited != 0 {
-			aclFlags = append(aclFlags, "AI")
-		}
-		if a.control&seSACLAutoInheritRe != 0 {
-			aclFlags = append(aclFlags, "AR")
-		}
-		if a.control&seSACLDefaulted != 0 {
-			aclFlags = append(aclFlags, "R")
-		}
+	if a.control&protected != 0 {
+		aclFlags = append(aclFlags, "P")
+	}
+	if a.control&autoInheritRe != 0 {
+		aclFlags = append(aclFlags, "AR")
+	}
+	if a.control&autoInherited != 0 {
+		aclFlags = append(aclFlags, "AI")
+	}
+	if a.control&defaulted != 0 {
+		aclFlags = append(aclFlags, "R")
+	}
+	if a.sddlFlags&sddlFlagNO != 0 {
+		aclFlags = append(aclFlags, "NO")
+	}
+	if a.sddlFlags&sddlFlagIO != 0 {
+		aclFlags = append(aclFlags, "IO")
 	}
 
 	return strings.Join(aclFlags, "")
 }
 
-func (a *acl) String() string {
+func (a *ACL) String() string {
+	return a.stringWithSIDs((*SID).String)
+}
+
+// stringWithSIDs is like String but writes the trustees of the ACEs with sidString.
+func (a *ACL) stringWithSIDs(sidString func(*SID) string) string {
 	result := a.FlagsString()
 
 	var aces []string
 	for _, ace := range a.aces {
-		aces = append(aces, ace.String())
+		aces = append(aces, ace.stringWithSIDs(sidString))
 	}
 
 	return result + strings.Join(aces, "")
@@ -538,7 +1206,7 @@ func (a *acl) String() string {
 //   - margin: number of spaces to prepend to each line
 //
 // Returns a multi-line string with the ACL flags followed by indented ACEs.
-func (a *acl) StringIndent(margin int) string {
+func (a *ACL) StringIndent(margin int) string {
 	marginStr := strings.Repeat(" ", margin)
 	bldr := strings.Builder{}
 	bldr.WriteString(marginStr + a.FlagsString() + "\n")
@@ -548,6 +1216,76 @@ func (a *acl) StringIndent(margin int) string {
 	return bldr.String()
 }
 
+// aclControl returns the flags of the security descriptor control that describe an ACL of the given
+// type ("D" or "S"), which are the only ones stored in the control of an ACL.
+func aclControl(aclType string, control uint16) uint16 {
+	switch aclType {
+	case "D":
+		return control & daclControlMask
+	case "S":
+		return control & saclControlMask
+	default:
+		return 0
+	}
+}
+
+// updateSize recomputes the ACL size and ACE count from its ACEs.
+func (a *ACL) updateSize() {
+	aclSize := 8 // ACL header size
+	for _, ace := range a.aces {
+		aclSize += alignACESize(int(ace.header.aceSize))
+	}
+	a.aclSize = uint16(aclSize)
+	a.aceCount = uint16(len(a.aces))
+}
+
+// validate returns an error if the ACL cannot be converted to its binary representation.
+// It checks the same conditions that make Binary panic.
+func (a *ACL) validate() error {
+	for i := range a.aces {
+		if err := a.aces[i].validate(); err != nil {
+			return fmt.Errorf("ACE %d: %w", i, err)
+		}
+	}
+
+	aclSize := a.BinarySize()
+	if aclSize > 65535 {
+		return fmt.Errorf("ACL size %d exceeds maximum size of 65535 bytes", aclSize)
+	}
+	if uint16(aclSize) != a.aclSize {
+		return fmt.Errorf("calculated ACL size %d doesn't match header size %d", aclSize, a.aclSize)
+	}
+	if uint16(len(a.aces)) != a.aceCount {
+		return fmt.Errorf("actual ACE count %d doesn't match header count %d", len(a.aces), a.aceCount)
+	}
+
+	return nil
+}
+
+// validateStrict returns an error if the ACL fails the checks that SecurityDescriptor.ValidateStrict adds
+// to those of validate.
+func (a *ACL) validateStrict() error {
+	if a.aclRevision < aclRevision || a.aclRevision > aclRevisionDS {
+		return fmt.Errorf("%w: %d, must be 2, 3 or 4", ErrInvalidACLRevision, a.aclRevision)
+	}
+
+	if a.aclRevision != aclRevisionDS {
+		for i := range a.aces {
+			if a.aces[i].header != nil && isObjectACEType(a.aces[i].header.aceType) {
+				return fmt.Errorf("%w: %d, ACE %d is an object ACE which requires revision 4", ErrInvalidACLRevision, a.aclRevision, i)
+			}
+		}
+	}
+
+	for i := range a.aces {
+		if reserved := a.aces[i].accessMask & ReservedAccessBits; reserved != 0 {
+			return fmt.Errorf("%w: 0x%08X in the mask 0x%08X of ACE %d", ErrReservedAccessBits, reserved, a.aces[i].accessMask, i)
+		}
+	}
+
+	return nil
+}
+
 // SecurityDescriptor represents the Windows SECURITY_DESCRIPTOR structure.
 //
 // A security descriptor is a data structure that contains the security
@@ -568,7 +1306,8 @@ type SecurityDescriptor struct {
 	// in revision 1, the offset is 4 bytes, and in revision 2, the offset is 8 bytes.
 	revision byte
 
-	// sbzl is Reserved; must be zero
+	// sbzl is the Sbz1 field. It is reserved and zero, unless SE_RESOURCE_MANAGER_CONTROL_VALID is set,
+	// in which case it holds the resource manager control bits. It is kept as is by FromBinary and Binary.
 	sbzl byte
 
 	// control flags
@@ -592,12 +1331,12 @@ type SecurityDescriptor struct {
 	// ownerSID is the Owner of the SID.
 	//
 	// This field is not part of original structure, but it is used to build the string representation.
-	ownerSID *sid
+	ownerSID *SID
 
 	// groupSID is the Group of the SID.
 	//
 	// This field is not part of original structure, but it is used to build the string representation.
-	groupSID *sid
+	groupSID *SID
 
 	// sacl is the System Access Control List (SACL).
 	//
@@ -605,14 +1344,14 @@ type SecurityDescriptor struct {
 	// It is used to generate audit logs when a user or group attempts to access a securable object in a certain way.
 	//
 	// This field is not part of original structure, but it is used to build the string representation.
-	sacl *acl
+	sacl *ACL
 
 	// dacl is the Discretionary Access Control List (DACL).
 	//
 	// The dacl controls access to the securable object based on the user or group that is accessing it.
 	//
 	// This field is not part of original structure, but it is used to build the string representation.
-	dacl *acl
+	dacl *ACL
 }
 
 // Binary converts a SecurityDescriptor structure to its binary representation in self-relative format.
@@ -635,104 +1374,410 @@ func (sd *SecurityDescriptor) Binary() []byte {
 	// Force SE_SELF_RELATIVE flag as we're creating a self-relative security descriptor
 	sd.control |= seSelfRelative
 
-	// Convert all components to binary first to calculate total size and validate
-	var ownerBinary, groupBinary, saclBinary, daclBinary []byte
+	// Write all components into a single buffer sized up front, setting the offset of each in the
+	// fixed header as it is appended
+	result := make([]byte, 20, sd.BinarySize())
 
-	// Convert Owner SID if present
+	// Set fixed header
+	result[0] = sd.revision
+	result[1] = sd.sbzl
+	binary.LittleEndian.PutUint16(result[2:4], sd.control)
+
+	// Append Owner SID and set its offset if present
 	if sd.ownerSID != nil {
-		ownerBinary = sd.ownerSID.Binary()
+		binary.LittleEndian.PutUint32(result[4:8], uint32(len(result)))
+		result = sd.ownerSID.appendBinary(result)
 	}
 
-	// Convert Group SID if present
+	// Append Group SID and set its offset if present
 	if sd.groupSID != nil {
-		groupBinary = sd.groupSID.Binary()
+		binary.LittleEndian.PutUint32(result[8:12], uint32(len(result)))
+		result = sd.groupSID.appendBinary(result)
 	}
 
-	// Convert SACL if present and control flags indicate it should be
+	// Append SACL if present and control flags indicate it should be
+	// A nil SACL with SE_SACL_PRESENT set is a NULL SACL, written with a zero offset
 	if sd.sacl != nil {
 		if sd.control&seSACLPresent == 0 {
 			panic("SACL present but SE_SACL_PRESENT flag not set")
 		}
-		saclBinary = sd.sacl.Binary()
-	} else if sd.control&seSACLPresent != 0 {
-		panic("SE_SACL_PRESENT flag set but SACL is nil")
+		binary.LittleEndian.PutUint32(result[12:16], uint32(len(result)))
+		result = sd.sacl.appendBinary(result)
 	}
 
-	// Convert DACL if present and control flags indicate it should be
+	// Append DACL if present and control flags indicate it should be
+	// A nil DACL with SE_DACL_PRESENT set is a NULL DACL, written with a zero offset
 	if sd.dacl != nil {
 		if sd.control&seDACLPresent == 0 {
 			panic("DACL present but SE_DACL_PRESENT flag not set")
 		}
-		daclBinary = sd.dacl.Binary()
-	} else if sd.control&seDACLPresent != 0 {
-		panic("SE_DACL_PRESENT flag set but DACL is nil")
+		binary.LittleEndian.PutUint32(result[16:20], uint32(len(result)))
+		result = sd.dacl.appendBinary(result)
 	}
 
-	// Calculate total size: 20 (fixed header) + sizes of all components
-	totalSize := 20 + len(ownerBinary) + len(groupBinary) + len(saclBinary) + len(daclBinary)
+	return result
+}
 
-	// Create result buffer
-	result := make([]byte, totalSize)
+// BinarySize returns the size in bytes of the self-relative binary representation of the security
+// descriptor, as returned by Binary, without building it. This allows callers to size buffers up front.
+func (sd *SecurityDescriptor) BinarySize() int {
+	size := 20 // fixed header size
+	if sd.ownerSID != nil {
+		size += sd.ownerSID.BinarySize()
+	}
+	if sd.groupSID != nil {
+		size += sd.groupSID.BinarySize()
+	}
+	if sd.sacl != nil {
+		size += sd.sacl.BinarySize()
+	}
+	if sd.dacl != nil {
+		size += sd.dacl.BinarySize()
+	}
+	return size
+}
 
-	// Set fixed header
-	result[0] = sd.revision
-	result[1] = sd.sbzl
-	binary.LittleEndian.PutUint16(result[2:4], sd.control)
+// LayoutEntry describes a part of the self-relative binary representation of a security descriptor,
+// see BinaryLayout.
+type LayoutEntry struct {
+	// Name is the name of the part: "Header", "Owner", "Group", "SACL" or "DACL".
+	Name string
+	// Offset is the byte offset of the part in the binary representation.
+	Offset int
+	// Length is the size in bytes of the part.
+	Length int
+}
 
-	// Initialize current offset for variable part
-	currentOffset := 20
+// BinaryLayout returns the parts of the binary representation of the security descriptor, in the order
+// and at the offsets Binary writes them: the 20 bytes header, then the owner, the group, the SACL and the
+// DACL, those that are absent (including NULL ACLs, written with a zero offset) being left out.
+// It helps to correlate a hex dump of Binary with the structure of the security descriptor.
+func (sd *SecurityDescriptor) BinaryLayout() []LayoutEntry {
+	layout := []LayoutEntry{{Name: "Header", Offset: 0, Length: 20}}
+	offset := 20
+	add := func(name string, length int) {
+		layout = append(layout, LayoutEntry{Name: name, Offset: offset, Length: length})
+		offset += length
+	}
 
-	// Set Owner SID and its offset if present
-	if ownerBinary != nil {
-		binary.LittleEndian.PutUint32(result[4:8], uint32(currentOffset))
-		copy(result[currentOffset:], ownerBinary)
-		currentOffset += len(ownerBinary)
+	if sd.ownerSID != nil {
+		add("Owner", sd.ownerSID.BinarySize())
+	}
+	if sd.groupSID != nil {
+		add("Group", sd.groupSID.BinarySize())
+	}
+	if sd.sacl != nil {
+		add("SACL", sd.sacl.BinarySize())
+	}
+	if sd.dacl != nil {
+		add("DACL", sd.dacl.BinarySize())
 	}
+	return layout
+}
+
+// DACL returns the Discretionary Access Control List of the security descriptor, or nil if it is not present.
+// It is also nil for a NULL DACL ("D:NO_ACCESS_CONTROL"), which has SE_DACL_PRESENT set and grants full
+// access to everyone, see HasNullDACL.
+func (sd *SecurityDescriptor) DACL() *ACL {
+	return sd.dacl
+}
+
+// HeaderString returns the fields of the fixed header of the security descriptor, as decoded by FromBinary
+// or DebugParseHeader, e.g. "revision=1 sbz1=0 control=0x8004 owner=0x14 group=0x24 sacl=0x0 dacl=0x34".
+// The offsets are zero for security descriptors that were not decoded from their binary form.
+func (sd *SecurityDescriptor) HeaderString() string {
+	return fmt.Sprintf("revision=%d sbz1=%d control=0x%04X owner=0x%X group=0x%X sacl=0x%X dacl=0x%X",
+		sd.revision, sd.sbzl, sd.control, sd.ownerOffset, sd.groupOffset, sd.saclOffset, sd.daclOffset)
+}
+
+// HasNullDACL reports whether the security descriptor has a NULL DACL ("D:NO_ACCESS_CONTROL"):
+// the DACL is present but has no structure, which grants full access to everyone.
+func (sd *SecurityDescriptor) HasNullDACL() bool {
+	return sd.dacl == nil && sd.control&seDACLPresent != 0
+}
+
+// HasNullSACL reports whether the security descriptor has a NULL SACL ("S:NO_ACCESS_CONTROL"): the SACL
+// is present but has no structure. Like an empty SACL, it audits nothing.
+func (sd *SecurityDescriptor) HasNullSACL() bool {
+	return sd.sacl == nil && sd.control&seSACLPresent != 0
+}
+
+// HasEmptySACL reports whether the security descriptor has an empty SACL ("S:"), a SACL without ACE.
+// It audits nothing, like a missing SACL, but is explicitly set, as some security baselines require:
+// it is written as an 8-byte ACL with SE_SACL_PRESENT set.
+func (sd *SecurityDescriptor) HasEmptySACL() bool {
+	return sd.sacl != nil && len(sd.sacl.aces) == 0
+}
+
+// AddDACLAllow adds an ACE granting mask to the trustee to the DACL, at its place in the canonical order,
+// see ACL.InsertCanonical. flags are the inheritance flags of the ACE, such as ACEFlagObjectInherit.
+// For example, on a descriptor without DACL, AddDACLAllow(NewSID(5, 18), FileAllAccess, 0) followed by
+// AddDACLDeny(NewSID(1, 0), Delete, 0) results in the DACL "D:(D;;SD;;;WD)(A;;FA;;;SY)".
+//
+// The DACL is created if there is none or it is NULL, in which case SE_DACL_DEFAULTED is cleared, and
+// SE_DACL_PRESENT is set. Note that replacing a NULL DACL, which grants full access to everyone, restricts
+// the access to the added ACE. The SID is copied, and it panics if the trustee is nil.
+func (sd *SecurityDescriptor) AddDACLAllow(trustee *SID, mask uint32, flags ACEFlags) {
+	sd.addDACLACE(accessAllowedACEType, trustee, mask, flags)
+}
+
+// AddDACLDeny adds an ACE denying mask to the trustee to the DACL, see AddDACLAllow.
+func (sd *SecurityDescriptor) AddDACLDeny(trustee *SID, mask uint32, flags ACEFlags) {
+	sd.addDACLACE(accessDeniedACEType, trustee, mask, flags)
+}
 
-	// Set Group SID and its offset if present
-	if groupBinary != nil {
-		binary.LittleEndian.PutUint32(result[8:12], uint32(currentOffset))
-		copy(result[currentOffset:], groupBinary)
-		currentOffset += len(groupBinary)
+// addDACLACE implements AddDACLAllow and AddDACLDeny.
+func (sd *SecurityDescriptor) addDACLACE(aceType byte, trustee *SID, mask uint32, flags ACEFlags) {
+	if trustee == nil {
+		panic("DACL ACE requires a trustee")
 	}
 
-	// Set SACL and its offset if present
-	if saclBinary != nil {
-		binary.LittleEndian.PutUint32(result[12:16], uint32(currentOffset))
-		copy(result[currentOffset:], saclBinary)
-		currentOffset += len(saclBinary)
+	if sd.dacl == nil {
+		// The DACL is now set explicitly rather than by a default mechanism
+		sd.control &^= seDACLDefaulted
+		sd.dacl = &ACL{
+			aclRevision: aclRevision,
+			aclType:     "D",
+			control:     aclControl("D", sd.control),
+		}
 	}
+	sd.control |= seDACLPresent
+	sd.dacl.control |= seDACLPresent
 
-	// Set DACL and its offset if present
-	if daclBinary != nil {
-		binary.LittleEndian.PutUint32(result[16:20], uint32(currentOffset))
-		copy(result[currentOffset:], daclBinary)
+	sd.dacl.InsertCanonical(newACE(aceType, byte(flags), mask, trustee.clone()))
+}
+
+// ResourceManagerControl returns the resource manager control bits of the security descriptor, held in its
+// Sbz1 field, like GetSecurityDescriptorRMControl. ok is false if SE_RESOURCE_MANAGER_CONTROL_VALID is not set.
+// They have no SDDL representation and are lost when converting to SDDL and back.
+func (sd *SecurityDescriptor) ResourceManagerControl() (rmControl byte, ok bool) {
+	if sd.control&seResourceManagerControlValid == 0 {
+		return 0, false
 	}
+	return sd.sbzl, true
+}
 
-	return result
+// SetResourceManagerControl sets the resource manager control bits of the security descriptor, along with
+// SE_RESOURCE_MANAGER_CONTROL_VALID, like SetSecurityDescriptorRMControl.
+func (sd *SecurityDescriptor) SetResourceManagerControl(rmControl byte) {
+	sd.sbzl = rmControl
+	sd.control |= seResourceManagerControlValid
+}
+
+// IsDACLDefaulted reports whether SE_DACL_DEFAULTED is set, meaning that the DACL was supplied by a default
+// mechanism, such as the default DACL of the creator's token, rather than set explicitly. The flag is
+// independent of SE_DACL_PRESENT, which tells whether there is a DACL at all: FromString sets both flags
+// for a DACL with the "R" flag, and only SE_DACL_DEFAULTED when the DACL component is missing.
+func (sd *SecurityDescriptor) IsDACLDefaulted() bool {
+	return sd.control&seDACLDefaulted != 0
+}
+
+// IsSACLDefaulted reports whether SE_SACL_DEFAULTED is set, see IsDACLDefaulted.
+func (sd *SecurityDescriptor) IsSACLDefaulted() bool {
+	return sd.control&seSACLDefaulted != 0
+}
+
+// SetDACLDefaulted sets or clears SE_DACL_DEFAULTED, see IsDACLDefaulted. SE_DACL_PRESENT is left as it is.
+func (sd *SecurityDescriptor) SetDACLDefaulted(defaulted bool) {
+	sd.setACLControl(sd.dacl, seDACLDefaulted, defaulted)
+}
+
+// SetSACLDefaulted sets or clears SE_SACL_DEFAULTED, see IsDACLDefaulted. SE_SACL_PRESENT is left as it is.
+func (sd *SecurityDescriptor) SetSACLDefaulted(defaulted bool) {
+	sd.setACLControl(sd.sacl, seSACLDefaulted, defaulted)
+}
+
+// setACLControl sets or clears the control flag of the security descriptor, and of acl if it is not nil.
+func (sd *SecurityDescriptor) setACLControl(acl *ACL, flag uint16, set bool) {
+	if set {
+		sd.control |= flag
+	} else {
+		sd.control &^= flag
+	}
+	if acl != nil {
+		acl.control = aclControl(acl.aclType, sd.control)
+	}
+}
+
+// Equal reports whether the security descriptor and other have the same control flags, owner, group,
+// DACL and SACL, ACEs being compared in order with ACE.Equal. The "NO" and "IO" ACL flags of descriptors
+// parsed from SDDL are compared too, as they are written back by String: "D:NO(A;;FA;;;SY)" is not equal to
+// "D:(A;;FA;;;SY)". The SE_SELF_RELATIVE flag is ignored as it only describes the memory layout, and so are
+// the fields derived from the content such as sizes and offsets.
+func (sd *SecurityDescriptor) Equal(other *SecurityDescriptor) bool {
+	return sd.equal(other, false)
+}
+
+// EqualExplicit is like Equal, but only compares the explicit ACEs of the ACLs, the ones without the
+// INHERITED_ACE flag. It is useful to check that a descriptor set on an object is still in place, as the
+// descriptor read back also holds the ACEs inherited from the parent. For the same reason, the
+// SE_DACL_AUTO_INHERITED and SE_SACL_AUTO_INHERITED control flags, the ACL revisions and the "NO" and "IO"
+// ACL flags, which have no binary form, are ignored.
+func (sd *SecurityDescriptor) EqualExplicit(other *SecurityDescriptor) bool {
+	return sd.equal(other, true)
+}
+
+// equal implements Equal and EqualExplicit.
+func (sd *SecurityDescriptor) equal(other *SecurityDescriptor, explicitOnly bool) bool {
+	if sd == nil || other == nil {
+		return sd == other
+	}
+
+	ignoredControl := uint16(seSelfRelative)
+	if explicitOnly {
+		ignoredControl |= seDACLAutoInherited | seSACLAutoInherited
+	}
+	if sd.revision != other.revision || sd.control&^ignoredControl != other.control&^ignoredControl {
+		return false
+	}
+	if !sd.ownerSID.Equal(other.ownerSID) || !sd.groupSID.Equal(other.groupSID) {
+		return false
+	}
+	return equalACLs(sd.dacl, other.dacl, explicitOnly) && equalACLs(sd.sacl, other.sacl, explicitOnly)
+}
+
+// equalACLs reports whether a and b are both missing or hold the same ACEs. If explicitOnly is set,
+// inherited ACEs, the revisions and the SDDL flags are ignored.
+func equalACLs(a, b *ACL, explicitOnly bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !explicitOnly {
+		return a.aclRevision == b.aclRevision && a.sddlFlags == b.sddlFlags &&
+			slices.EqualFunc(a.aces, b.aces, func(x, y ACE) bool { return x.Equal(&y) })
+	}
+
+	explicit := func(aces []ACE) []*ACE {
+		var result []*ACE
+		for i := range aces {
+			if aces[i].header == nil || aces[i].header.aceFlags&inheritedACE == 0 {
+				result = append(result, &aces[i])
+			}
+		}
+		return result
+	}
+	return slices.EqualFunc(explicit(a.aces), explicit(b.aces), (*ACE).Equal)
+}
+
+// Group returns the primary group SID of the security descriptor, or nil if it is not present.
+func (sd *SecurityDescriptor) Group() *SID {
+	return sd.groupSID
+}
+
+// Owner returns the owner SID of the security descriptor, or nil if it is not present.
+func (sd *SecurityDescriptor) Owner() *SID {
+	return sd.ownerSID
+}
+
+// SACL returns the System Access Control List of the security descriptor, or nil if it is not present.
+// It is also nil for a NULL SACL ("S:NO_ACCESS_CONTROL"), which has SE_SACL_PRESENT set.
+func (sd *SecurityDescriptor) SACL() *ACL {
+	return sd.sacl
 }
 
+// String returns the SDDL representation of the security descriptor.
+//
+// The components present are always written in the order Windows writes them: owner, group, DACL and
+// then SACL ("O:...G:...D:...S:..."). FromString accepts them in any order, so converting a string back
+// and forth gives this canonical order; callers may rely on it, e.g. to compare or hash strings.
+//
+// SDDL has no marker for the SE_OWNER_DEFAULTED and SE_GROUP_DEFAULTED control flags (defaulted ACLs
+// have the "R" flag): FromString sets them when the owner or group is missing only. An owner or group
+// that is both present and defaulted, as read by FromBinary, loses its defaulted flag when converted to
+// SDDL and back. Binary and FromBinary preserve the control flags exactly.
 func (sd *SecurityDescriptor) String() string {
+	return sd.stringWithSIDs((*SID).String)
+}
+
+// stringWithSIDs is like String but writes the owner, the group and the trustees of the ACEs with sidString.
+func (sd *SecurityDescriptor) stringWithSIDs(sidString func(*SID) string) string {
 	var parts []string
 	if sd.ownerSID != nil {
-		ownerSIDString := sd.ownerSID.String()
+		ownerSIDString := sidString(sd.ownerSID)
 		parts = append(parts, fmt.Sprintf("O:%s", ownerSIDString))
 	}
 	if sd.groupSID != nil {
-		groupSIDString := sd.groupSID.String()
+		groupSIDString := sidString(sd.groupSID)
 		parts = append(parts, fmt.Sprintf("G:%s", groupSIDString))
 	}
 	if sd.dacl != nil {
-		daclStr := sd.dacl.String()
+		daclStr := sd.dacl.stringWithSIDs(sidString)
 		parts = append(parts, fmt.Sprintf("D:%s", daclStr))
+	} else if sd.control&seDACLPresent != 0 {
+		parts = append(parts, fmt.Sprintf("D:%s", sd.nullACLString("D")))
 	}
 	if sd.sacl != nil {
-		saclStr := sd.sacl.String()
+		saclStr := sd.sacl.stringWithSIDs(sidString)
 		parts = append(parts, fmt.Sprintf("S:%s", saclStr))
+	} else if sd.control&seSACLPresent != 0 {
+		parts = append(parts, fmt.Sprintf("S:%s", sd.nullACLString("S")))
 	}
 	return strings.Join(parts, "")
 }
 
+// OwnerString returns the owner component of the string representation of the security descriptor,
+// e.g. "O:BA", as found in String. ok is false if there is no owner, and an error is returned if the
+// owner SID cannot be represented, instead of panicking like String.
+func (sd *SecurityDescriptor) OwnerString() (s string, ok bool, err error) {
+	return sidComponentString("O", "owner", sd.ownerSID)
+}
+
+// GroupString returns the group component of the string representation of the security descriptor,
+// e.g. "G:SY", see OwnerString.
+func (sd *SecurityDescriptor) GroupString() (s string, ok bool, err error) {
+	return sidComponentString("G", "group", sd.groupSID)
+}
+
+// DACLString returns the DACL component of the string representation of the security descriptor,
+// e.g. "D:PAI(A;;FA;;;SY)" or "D:NO_ACCESS_CONTROL" for a NULL DACL, as found in String. It is the form
+// expected by the APIs that only take a DACL. ok is false if there is no DACL, and an error is returned if
+// the SID of an ACE cannot be represented, instead of panicking like String.
+func (sd *SecurityDescriptor) DACLString() (s string, ok bool, err error) {
+	return sd.aclComponentString("D", sd.dacl, seDACLPresent)
+}
+
+// SACLString returns the SACL component of the string representation of the security descriptor,
+// e.g. "S:(AU;SA;FA;;;WD)", see DACLString.
+func (sd *SecurityDescriptor) SACLString() (s string, ok bool, err error) {
+	return sd.aclComponentString("S", sd.sacl, seSACLPresent)
+}
+
+// sidComponentString implements OwnerString and GroupString, name being the name of the component in errors.
+func sidComponentString(component, name string, sid *SID) (string, bool, error) {
+	if sid == nil {
+		return "", false, nil
+	}
+	if err := sid.Validate(); err != nil {
+		return "", true, fmt.Errorf("invalid %s SID: %w", name, err)
+	}
+	return component + ":" + sid.String(), true, nil
+}
+
+// aclComponentString implements DACLString and SACLString, present being the control flag of the ACL.
+func (sd *SecurityDescriptor) aclComponentString(aclType string, acl *ACL, present uint16) (string, bool, error) {
+	if acl == nil {
+		if sd.control&present == 0 {
+			return "", false, nil
+		}
+		return aclType + ":" + sd.nullACLString(aclType), true, nil
+	}
+	for i := range acl.aces {
+		if sid := acl.aces[i].sid; sid != nil {
+			if err := sid.Validate(); err != nil {
+				return "", true, fmt.Errorf("invalid %sACL: ACE %d has an invalid SID: %w", aclType, i, err)
+			}
+		}
+	}
+	return aclType + ":" + acl.String(), true, nil
+}
+
+// nullACLString returns the string representation of a NULL ACL of the given type ("D" or "S"),
+// which is its flags followed by "NO_ACCESS_CONTROL".
+func (sd *SecurityDescriptor) nullACLString(aclType string) string {
+	acl := &ACL{aclType: aclType, control: aclControl(aclType, sd.control)}
+	return acl.FlagsString() + noAccessControl
+}
+
 // StringIndent returns a formatted string representation of the SecurityDescriptor with the specified
 // indentation margin. It includes the control flags, owner, group, and ACLs (if present), each
 // properly indented for better readability.
@@ -745,6 +1790,10 @@ func (sd *SecurityDescriptor) StringIndent(margin int) string {
 	marginStr := strings.Repeat(" ", margin)
 	bldr := strings.Builder{}
 
+	if rmControl, ok := sd.ResourceManagerControl(); ok {
+		bldr.WriteString(fmt.Sprintf("%sRM: 0x%02X\n", marginStr, rmControl))
+	}
+
 	if sd.ownerSID != nil {
 		bldr.WriteString(marginStr + "O: " + sd.ownerSID.String() + "\n")
 	}
@@ -755,19 +1804,105 @@ func (sd *SecurityDescriptor) StringIndent(margin int) string {
 
 	if sd.dacl != nil {
 		bldr.WriteString(marginStr + "D:\n" + sd.dacl.StringIndent(margin+4) + "\n")
+	} else if sd.control&seDACLPresent != 0 {
+		bldr.WriteString(marginStr + "D: " + sd.nullACLString("D") + "\n")
 	}
 
 	if sd.sacl != nil {
 		bldr.WriteString(marginStr + "S:\n" + sd.sacl.StringIndent(margin+4) + "\n")
+	} else if sd.control&seSACLPresent != 0 {
+		bldr.WriteString(marginStr + "S: " + sd.nullACLString("S") + "\n")
 	}
 
 	return bldr.String()
 }
 
-// sid represents a Windows Security Identifier (SID)
+// Validate checks that the security descriptor is structurally consistent, meaning that it can be
+// converted to its binary and string representations without panicking.
+//
+// It verifies that:
+//   - the revision is 1
+//   - the SE_DACL_PRESENT and SE_SACL_PRESENT control flags are set when the ACLs are present
+//     (the flags may be set without ACL, for NULL ACLs)
+//   - every SID has revision 1, at most 15 sub-authorities and a 48-bit authority
+//   - the ACL and ACE sizes and counts match their contents
+//
+// Descriptors returned by FromBinary and FromString are expected to be valid, Validate is mostly
+// useful for descriptors that have been modified after parsing.
+func (sd *SecurityDescriptor) Validate() error {
+	if sd.revision != 1 {
+		return fmt.Errorf("invalid security descriptor: revision must be 1, was %d", sd.revision)
+	}
+
+	if sd.ownerSID != nil {
+		if err := sd.ownerSID.Validate(); err != nil {
+			return fmt.Errorf("invalid owner SID: %w", err)
+		}
+	}
+
+	if sd.groupSID != nil {
+		if err := sd.groupSID.Validate(); err != nil {
+			return fmt.Errorf("invalid group SID: %w", err)
+		}
+	}
+
+	if sd.dacl != nil {
+		if sd.control&seDACLPresent == 0 {
+			return fmt.Errorf("invalid security descriptor: DACL present but SE_DACL_PRESENT flag not set")
+		}
+		if err := sd.dacl.validate(); err != nil {
+			return fmt.Errorf("invalid DACL: %w", err)
+		}
+	}
+
+	if sd.sacl != nil {
+		if sd.control&seSACLPresent == 0 {
+			return fmt.Errorf("invalid security descriptor: SACL present but SE_SACL_PRESENT flag not set")
+		}
+		if err := sd.sacl.validate(); err != nil {
+			return fmt.Errorf("invalid SACL: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateStrict is like Validate, but also rejects security descriptors that Windows may not accept,
+// even though this package can convert them. In addition to the checks of Validate, it verifies that:
+//   - the revision of each ACL is 2, 3 or 4, and is 4 (ACL_REVISION_DS) if the ACL contains object ACEs,
+//     failing with ErrInvalidACLRevision
+//   - the access mask of each ACE has none of the ReservedAccessBits set, failing with ErrReservedAccessBits,
+//     which catches corrupted masks and typos in hand-written SDDL strings
+//
+// Decoded descriptors may legitimately fail these checks, which is why Validate doesn't perform them.
+// ValidateStrict is meant for descriptors built or modified by the caller.
+func (sd *SecurityDescriptor) ValidateStrict() error {
+	if err := sd.Validate(); err != nil {
+		return err
+	}
+
+	if sd.dacl != nil {
+		if err := sd.dacl.validateStrict(); err != nil {
+			return fmt.Errorf("invalid DACL: %w", err)
+		}
+	}
+	if sd.sacl != nil {
+		if err := sd.sacl.validateStrict(); err != nil {
+			return fmt.Errorf("invalid SACL: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SID represents a Windows Security Identifier (SID)
+//
+// A SID may have no sub-authority at all, such as S-1-5 (the NT AUTHORITY itself): like IsValidSid,
+// this package accepts from 0 to 15 sub-authorities, and such SIDs are parsed, written and compared
+// like any other.
 //
 // Note: SubAuthorityCount  is needed for parsing, but once the structure is built, it can be determined from SubAuthority, hence the field is omitted in the structure
-type sid struct {
+type SID struct {
 	// revision indicates the revision level of the SID structure.
 	// It is used to determine the format of the SID structure.
 	// The current revision level is 1.
@@ -788,18 +1923,73 @@ type sid struct {
 	subAuthority []uint32
 }
 
+// NewSID returns the SID with revision 1 and the given identifier authority and sub-authorities.
+// For example, NewSID(5, 32, 544) returns BUILTIN\Administrators (S-1-5-32-544).
+//
+// It panics if the authority doesn't fit in 48 bits or if there are more than 15 sub-authorities,
+// see NewSIDChecked for a variant returning an error.
+func NewSID(authority uint64, subAuthorities ...uint32) *SID {
+	sid, err := NewSIDChecked(authority, subAuthorities...)
+	if err != nil {
+		panic(err)
+	}
+	return sid
+}
+
+// NewSIDChecked is like NewSID, but returns ErrInvalidAuthority or ErrTooManySubAuthorities instead
+// of panicking when the SID cannot be represented.
+func NewSIDChecked(authority uint64, subAuthorities ...uint32) (*SID, error) {
+	sid := &SID{
+		revision:            1,
+		identifierAuthority: authority,
+		subAuthority:        slices.Clone(subAuthorities),
+	}
+	if err := sid.Validate(); err != nil {
+		return nil, err
+	}
+	return sid, nil
+}
+
+// NewNTSID returns the SID of the NT authority (5) with the given sub-authorities.
+// For example, NewNTSID(18) returns LOCAL SYSTEM (S-1-5-18). It panics like NewSID.
+func NewNTSID(subAuthorities ...uint32) *SID {
+	return NewSID(5, subAuthorities...)
+}
+
+// AccountDomainSID returns the SID of the domain a domain account belongs to, which is the account SID
+// without its RID: for S-1-5-21-a-b-c-1001 it returns S-1-5-21-a-b-c. This applies both to accounts of an
+// Active Directory domain and to local accounts of a machine, whose machine SID has the same form.
+//
+// Returns:
+//   - *SID: a new SID holding the domain, the receiver is not modified
+//   - bool: false if the SID is not a domain account (S-1-5-21-a-b-c-RID), in which case the SID is nil
+func (s *SID) AccountDomainSID() (*SID, bool) {
+	if s.identifierAuthority != 5 || len(s.subAuthority) != 5 || s.subAuthority[0] != 21 {
+		return nil, false
+	}
+	return &SID{
+		revision:            s.revision,
+		identifierAuthority: s.identifierAuthority,
+		subAuthority:        slices.Clone(s.subAuthority[:4]),
+	}, true
+}
+
 // Binary converts a SID structure to its binary representation following Windows format.
 // The binary format is:
 // - Revision (1 byte)
 // - SubAuthorityCount (1 byte)
 // - IdentifierAuthority (6 bytes, big-endian)
 // - SubAuthorities (4 bytes each, little-endian)
-func (s *sid) Binary() []byte {
+func (s *SID) Binary() []byte {
 	// Validate SID structure
 	if s == nil {
 		panic("cannot convert nil SID to binary")
 	}
+	return s.appendBinary(make([]byte, 0, s.BinarySize()))
+}
 
+// appendBinary appends the binary representation of the SID to b, see Binary.
+func (s *SID) appendBinary(b []byte) []byte {
 	if s.revision != 1 {
 		panic(fmt.Errorf("%w: revision must be 1, was %d", ErrInvalidSIDFormat, s.revision))
 	}
@@ -814,40 +2004,44 @@ func (s *sid) Binary() []byte {
 		panic(fmt.Errorf("%w: value %d exceeds maximum of 2^48-1", ErrInvalidAuthority, s.identifierAuthority))
 	}
 
-	// Calculate total size:
-	// 1 byte revision + 1 byte count + 6 bytes authority + (4 bytes × number of sub-authorities)
-	size := 8 + (4 * len(s.subAuthority))
-	result := make([]byte, size)
-
-	// Set revision
-	result[0] = s.revision
-
-	// Set sub-authority count
-	result[1] = byte(len(s.subAuthority))
+	// Set revision and sub-authority count
+	b = append(b, s.revision, byte(len(s.subAuthority)))
 
 	// Set authority value - convert uint64 to 6 bytes in big-endian order
 	// We're using big-endian because Windows stores the authority as a 6-byte
 	// value in network byte order (big-endian)
-	auth := s.identifierAuthority
-	for i := 7; i >= 2; i-- {
-		result[i] = byte(auth & 0xFF)
-		auth >>= 8
+	for shift := 40; shift >= 0; shift -= 8 {
+		b = append(b, byte(s.identifierAuthority>>shift))
 	}
 
 	// Set sub-authorities in little-endian order
 	// Windows stores these as 32-bit integers in little-endian format
-	for i, subAuth := range s.subAuthority {
-		offset := 8 + (4 * i)
-		binary.LittleEndian.PutUint32(result[offset:], subAuth)
+	for _, subAuth := range s.subAuthority {
+		b = binary.LittleEndian.AppendUint32(b, subAuth)
 	}
 
-	return result
+	return b
+}
+
+// BinarySize returns the size in bytes of the binary representation of the SID, as returned by Binary,
+// without building it.
+func (s *SID) BinarySize() int {
+	return 8 + 4*len(s.subAuthority)
+}
+
+// clone returns a deep copy of the SID.
+func (s *SID) clone() *SID {
+	return &SID{
+		revision:            s.revision,
+		identifierAuthority: s.identifierAuthority,
+		subAuthority:        slices.Clone(s.subAuthority),
+	}
 }
 
 // DebugString returns a string representation of the SID with additional debugging information.
 // It includes the raw string representation whithout converting to well-known SID, alongside the
 // final SID (in case they were different)
-func (s *sid) DebugString() string {
+func (s *SID) DebugString() string {
 	st := s.String()
 	rs := s.rawString()
 
@@ -858,26 +2052,69 @@ func (s *sid) DebugString() string {
 	return st
 }
 
-// Domain returns a slice of uint32 containing all sub-authorities between the first and last one.
+// Domain returns a copy of all sub-authorities between the first and last one.
 // For example, if the SID is S-1-5-21-a-b-c-123, it will return [a,b,c].
 // If there are not enough sub-authorities (less than 3), it returns an empty slice.
-func (s *sid) Domain() []uint32 {
+func (s *SID) Domain() []uint32 {
 	if len(s.subAuthority) < 3 {
 		return []uint32{}
 	}
-	return s.subAuthority[1 : len(s.subAuthority)-1]
+	return slices.Clone(s.subAuthority[1 : len(s.subAuthority)-1])
+}
+
+// Equal reports whether the SID and other identify the same security principal.
+// Two nil SIDs are equal.
+func (s *SID) Equal(other *SID) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return s.revision == other.revision &&
+		s.identifierAuthority == other.identifierAuthority &&
+		slices.Equal(s.subAuthority, other.subAuthority)
+}
+
+// IsCreatorPlaceholder reports whether the SID is the CREATOR OWNER (S-1-3-0, "CO") or the CREATOR GROUP
+// (S-1-3-1, "CG") placeholder. A nil SID is not a placeholder.
+//
+// These SIDs are templates meant for inheritable ACEs: when a child object inherits such an ACE, the
+// placeholder is replaced with the owner or primary group of the child, see ACL.ComputeInherited.
+// Elsewhere, this package keeps them as they are.
+func (s *SID) IsCreatorPlaceholder() bool {
+	return s != nil && s.revision == 1 && s.identifierAuthority == 3 &&
+		len(s.subAuthority) == 1 && s.subAuthority[0] <= 1
+}
+
+// IsSelf reports whether the SID is the SELF placeholder (S-1-5-10, "PS" for PRINCIPAL SELF).
+//
+// SELF is not a real principal: it is replaced at access check time by the SID of the object the
+// descriptor is attached to (e.g. a user or computer account in Active Directory). This package does
+// not perform that substitution, ACEs granting access to SELF are kept as they are. A nil SID is not SELF.
+func (s *SID) IsSelf() bool {
+	return s != nil && s.revision == 1 && s.identifierAuthority == 5 && slices.Equal(s.subAuthority, []uint32{10})
+}
+
+// IsLogonSession reports whether the SID is a logon session SID (S-1-5-5-X-Y), which identifies an
+// interactive logon session and is granted access to its window station and desktop. These SIDs have
+// no alias, they are read and written in their full form. A nil SID is not a logon session SID.
+func (s *SID) IsLogonSession() bool {
+	return s != nil && s.revision == 1 && s.identifierAuthority == 5 &&
+		len(s.subAuthority) == 3 && s.subAuthority[0] == 5
 }
 
-func (s *sid) isGeneric() bool {
+func (s *SID) isGeneric() bool {
 	raw := s.rawString()
 	_, ok := wellKnownSids[raw]
 	return ok
 }
 
-func (s *sid) rawString() string {
+// rawString returns the "S-R-I-S-S..." representation of the SID without converting it to a well-known alias.
+//
+// The authority is written in decimal when it fits in 32 bits, otherwise it is written as "0x" followed by
+// 12 uppercase hexadecimal digits (e.g. "S-1-0x0000FFFF0000-1"), which is the canonical form of the SID.
+func (s *SID) rawString() string {
 	authority := fmt.Sprintf("%d", s.identifierAuthority)
 	if s.identifierAuthority >= 1<<32 {
-		authority = fmt.Sprintf("0x%x", s.identifierAuthority)
+		authority = fmt.Sprintf("0x%012X", s.identifierAuthority)
 	}
 
 	sidStr := fmt.Sprintf("S-%d-%s", s.revision, authority)
@@ -892,10 +2129,18 @@ func (s *sid) rawString() string {
 // SID, the short well-known SID name will be returned instead of the full SID string.
 //
 // The returned string will be in the format
-// "S-<revision>-<authority>-<sub-authority1>-<sub-authority2>-...-<sub-authorityN>".
+// "S-<revision>-<authority>-<sub-authority1>-<sub-authority2>-...-<sub-authorityN>", the sub-authorities
+// being always written in decimal, even if they were parsed from hexadecimal (see ParseOptions.Lenient).
+// Like ConvertSidToStringSid, the authority is written in decimal up to 2^32-1 included, and from 2^32 on
+// as "0x" followed by 12 uppercase hexadecimal digits: S-1-4294967295-1 and S-1-0x000100000000-1. Both
+// forms are parsed for any authority, so "S-1-0x5-18" and "S-1-4294967296-1" are accepted and written
+// in the form matching their value.
 // If the SID is well-known, the string will be in the format "<well-known SID name>".
-func (s *sid) String() string {
-	s.Validate()
+// It panics if the SID is invalid, see Validate. SIDs decoded by FromBinary are always valid.
+func (s *SID) String() string {
+	if err := s.Validate(); err != nil {
+		panic(err)
+	}
 
 	sidStr := s.rawString()
 
@@ -917,36 +2162,98 @@ func (s *sid) String() string {
 	return sidStr
 }
 
-func (s *sid) Validate() {
+// MarshalText implements encoding.TextMarshaler. It returns the full "S-1-..." form of the SID, without
+// converting it to a well-known alias, so that the text is a stable identifier whatever the aliases this
+// package knows. Wrap the SID in an AliasedSID to write the aliases instead.
+func (s *SID) MarshalText() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(s.rawString()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the "S-1-..." form of a SID as well as
+// its well-known alias, such as "SY". Domain relative aliases, such as "DA", are rejected with
+// ErrMissingDomainInformation as the text holds no domain.
+//
+// As map keys, *SID values are compared by address: decode the keys as strings instead, then unmarshal them.
+// The SID is overwritten in place, so it must not be called on SIDs shared by descriptors, see Parser.InternSIDs.
+func (s *SID) UnmarshalText(text []byte) error {
+	r, err := parseSIDString(string(text))
+	if err != nil {
+		return err
+	}
+	sid, err := r.toSID(nil)
+	if err != nil {
+		return err
+	}
+	*s = *sid
+	return nil
+}
+
+// AliasedSID wraps a SID to write it as text with its well-known alias, such as "SY" rather than "S-1-5-18",
+// for configuration files meant to be read by people. SIDs without alias are written in their "S-1-..."
+// form, and so are the users LA and LG, whose aliases are relative to a domain and couldn't be parsed back.
+// It is the alternative to the full form written by SID.MarshalText.
+type AliasedSID struct {
+	*SID
+}
+
+// MarshalText implements encoding.TextMarshaler. It returns an error wrapping ErrInvalidSIDFormat if there
+// is no SID.
+func (a AliasedSID) MarshalText() ([]byte, error) {
+	if a.SID == nil {
+		return nil, fmt.Errorf("%w: nil SID", ErrInvalidSIDFormat)
+	}
+	if err := a.SID.Validate(); err != nil {
+		return nil, err
+	}
+	s := a.SID.rawString()
+	if alias, ok := wellKnownSids[s]; ok {
+		s = alias
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the same forms as SID.UnmarshalText, and
+// sets the wrapped SID to a new SID rather than overwriting it.
+func (a *AliasedSID) UnmarshalText(text []byte) error {
+	sid := &SID{}
+	if err := sid.UnmarshalText(text); err != nil {
+		return err
+	}
+	a.SID = sid
+	return nil
+}
+
+// Validate returns an error describing why the SID cannot be represented in binary or string form: an
+// authority that doesn't fit in 48 bits, more than 15 sub-authorities or a revision other than 1.
+func (s *SID) Validate() error {
 	// Check authority value fits in 48 bits
 	if s.identifierAuthority >= 1<<48 {
-		panic(fmt.Errorf("%w: value %d exceeds maximum of 2^48-1", ErrInvalidAuthority, s.identifierAuthority))
+		return fmt.Errorf("%w: value %d exceeds maximum of 2^48-1", ErrInvalidAuthority, s.identifierAuthority)
 	}
 
 	// Check number of sub-authorities (maximum is 15 in Windows)
 	if len(s.subAuthority) > 15 {
-		panic(fmt.Errorf("%w: got %d, maximum is 15", ErrTooManySubAuthorities, len(s.subAuthority)))
+		return fmt.Errorf("%w: got %d, maximum is 15", ErrTooManySubAuthorities, len(s.subAuthority))
 	}
 
 	if s.revision != 1 {
-		panic(fmt.Errorf("%w: revision must be 1, was %d", ErrInvalidSIDFormat, s.revision))
+		return fmt.Errorf("%w: revision must be 1, was %d", ErrInvalidSIDFormat, s.revision)
 	}
+
+	return nil
 }
 
-// decomposeAccessMask breaks down an access mask into its individual components
-// it also returns the mask without the components
+// decomposeAccessMask breaks down an access mask into its individual components, in the order Windows
+// writes them (see accessMaskComponentOrder), so that the output can be compared with Windows descriptors.
+// It also returns the mask without the components
 func decomposeAccessMask(mask uint32) ([]string, uint32) {
 	var components []string
 
-	// Check components in order (least significant bits first)
-	maskValues := make([]uint32, 0, len(reversedAccessMaskComponents))
-	for val := range reversedAccessMaskComponents {
-		maskValues = append(maskValues, val)
-	}
-
-	slices.Sort(maskValues)
-	for _, val := range maskValues {
-		name := reversedAccessMaskComponents[val]
+	for _, name := range accessMaskComponentOrder {
+		val := accessMaskComponents[name]
 		if mask&val == val {
 			components = append(components, name)
 			mask ^= val
@@ -964,6 +2271,10 @@ func composeAccessMask(components []string) (uint32, []string) {
 	for _, code := range components {
 		if val, ok := accessMaskComponents[code]; ok {
 			mask |= val
+		} else if val, ok := reverseWellKnownAccessMasks[code]; ok {
+			mask |= val
+		} else if val, ok := accessMaskKeywords[code]; ok {
+			mask |= val
 		} else {
 			remaining = append(remaining, code)
 		}
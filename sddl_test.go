@@ -2,7 +2,12 @@ package sddl
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/bits"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -10,19 +15,19 @@ import (
 func TestACE_Binary(t *testing.T) {
 	tests := []struct {
 		name string
-		ace  *ace
+		ace  *ACE
 		want []byte
 	}{
 		{
 			name: "valid basic ACE (SYSTEM - Full Access)",
-			ace: &ace{
+			ace: &ACE{
 				header: &aceHeader{
 					aceType:  accessAllowedACEType,
 					aceFlags: 0,
 					aceSize:  20,
 				},
 				accessMask: 0x1F01FF,
-				sid: &sid{
+				sid: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{18},
@@ -44,14 +49,14 @@ func TestACE_Binary(t *testing.T) {
 		},
 		{
 			name: "valid audit ACE with flags",
-			ace: &ace{
+			ace: &ACE{
 				header: &aceHeader{
 					aceType:  systemAuditACEType,
 					aceFlags: successfulAccessACE | failedAccessACE,
 					aceSize:  20,
 				},
 				accessMask: 0x120089, // File Read
-				sid: &sid{
+				sid: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{18},
@@ -73,14 +78,14 @@ func TestACE_Binary(t *testing.T) {
 		},
 		{
 			name: "valid ACE with inheritance flags",
-			ace: &ace{
+			ace: &ACE{
 				header: &aceHeader{
 					aceType:  accessAllowedACEType,
 					aceFlags: containerInheritACE | objectInheritACE,
 					aceSize:  24,
 				},
 				accessMask: 0x1F01FF,
-				sid: &sid{
+				sid: &SID{
 					revision:            1,
 					identifierAuthority: 5,
 					subAuthority:        []uint32{32, 544}, // BUILTIN\Administrators
@@ -106,6 +111,9 @@ func TestACE_Binary(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := tt.ace.Binary()
+			if size := tt.ace.BinarySize(); size != len(got) {
+				t.Errorf("ACE.BinarySize() = %d, want len(Binary()) = %d", size, len(got))
+			}
 
 			if !bytes.Equal(got, tt.want) {
 				t.Errorf("ACE.Binary() = %v, want %v", got, tt.want)
@@ -123,7 +131,7 @@ func TestACE_Binary(t *testing.T) {
 			}
 
 			// Check reversibility for both binary and string
-			back, err := parseACEBinary(got)
+			back, err := parseACEBinary(got, nil, ParseOptions{})
 			if err != nil {
 				t.Errorf("Binary() -> parseACEBinary() error parsing back binary representation: %v", err)
 				return
@@ -131,7 +139,7 @@ func TestACE_Binary(t *testing.T) {
 			compareACEs(t, "Binary() -> parseACEBinary()", back, tt.ace)
 
 			str := tt.ace.String()
-			backR, err := parseACEString(str)
+			backR, err := parseACEString(str, ParseOptions{})
 			if err != nil {
 				t.Errorf("Binary() -> ACE.String() -> parseACEString() error parsing back string representation: %v", err)
 				return
@@ -148,6 +156,103 @@ func TestACE_Binary(t *testing.T) {
 	}
 }
 
+func TestACE_ObjectFlags(t *testing.T) {
+	t.Parallel()
+
+	const (
+		objectType          = "00299570-246d-11d0-a768-00aa006e0529"
+		inheritedObjectType = "bf967aba-0de6-11d0-a285-00aa003049e2"
+	)
+
+	// The object flags are derived from the GUIDs present in the string, and the GUIDs present in the
+	// string from the object flags of the binary form
+	tests := []struct {
+		name      string
+		ace       string
+		wantFlags uint32
+	}{
+		{
+			name:      "No GUID",
+			ace:       "(OA;;CR;;;WD)",
+			wantFlags: 0,
+		},
+		{
+			name:      "Object type",
+			ace:       "(OA;;CR;" + objectType + ";;WD)",
+			wantFlags: aceObjectTypePresent,
+		},
+		{
+			name:      "Inherited object type",
+			ace:       "(OA;;CR;;" + inheritedObjectType + ";WD)",
+			wantFlags: aceInheritedObjectTypePresent,
+		},
+		{
+			name:      "Both object types",
+			ace:       "(OA;;CR;" + objectType + ";" + inheritedObjectType + ";WD)",
+			wantFlags: aceObjectTypePresent | aceInheritedObjectTypePresent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ace := mustFromString(t, "D:"+tt.ace).DACL().ACEs()[0]
+			data := ace.Binary()
+
+			// Header, access mask, object flags, one GUID per flag and S-1-1-0
+			wantSize := 4 + 4 + 4 + 16*bits.OnesCount32(tt.wantFlags) + 12
+			if len(data) != wantSize {
+				t.Fatalf("Binary() size = %d, want %d", len(data), wantSize)
+			}
+			if flags := binary.LittleEndian.Uint32(data[8:12]); flags != tt.wantFlags {
+				t.Errorf("Binary() object flags = %d, want %d", flags, tt.wantFlags)
+			}
+
+			back, err := parseACEBinary(data, nil, ParseOptions{})
+			if err != nil {
+				t.Fatalf("parseACEBinary() unexpected error = %v", err)
+			}
+			if got := back.String(); got != tt.ace {
+				t.Errorf("parseACEBinary().String() = %s, want %s", got, tt.ace)
+			}
+			if !bytes.Equal(back.Binary(), data) {
+				t.Errorf("parseACEBinary().Binary() = % x, want % x", back.Binary(), data)
+			}
+		})
+	}
+}
+
+func TestACE_String_NilSID(t *testing.T) {
+	t.Parallel()
+
+	ace := &ACE{
+		header: &aceHeader{
+			aceType:  accessAllowedACEType,
+			aceFlags: 0,
+			aceSize:  20,
+		},
+		accessMask: 0x1F01FF,
+	}
+
+	want := "(A;;FA;;;<nil>)"
+	if got := ace.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got := ace.StringIndent(2); got != "  "+want {
+		t.Errorf("StringIndent() = %q, want %q", got, "  "+want)
+	}
+
+	// The string is not valid SDDL, as the ACE can't be converted to binary either,
+	// and must not be mistaken for the NULL SID
+	if _, err := FromString("D:" + ace.String()); err == nil {
+		t.Errorf("FromString(%q) expected error, got nil", "D:"+ace.String())
+	}
+	if err := ace.validate(); err == nil {
+		t.Errorf("validate() expected error, got nil")
+	}
+}
+
 func TestACL_Binary(t *testing.T) {
 	t.Parallel()
 
@@ -172,12 +277,12 @@ func TestACL_Binary(t *testing.T) {
 
 	tests := []struct {
 		name string
-		acl  *acl
+		acl  *ACL
 		want []byte
 	}{
 		{
 			name: "Empty ACL",
-			acl: &acl{
+			acl: &ACL{
 				aclRevision: 2,
 				sbzl:        0,
 				aclSize:     8, // Just header size
@@ -196,7 +301,7 @@ func TestACL_Binary(t *testing.T) {
 		},
 		{
 			name: "ACL with single ACE - Allow System Full Access",
-			acl: &acl{
+			acl: &ACL{
 				aclRevision: 2,
 				sbzl:        0,
 				aclSize:     28, // 8 (header) + 20 (ACE)
@@ -204,7 +309,7 @@ func TestACL_Binary(t *testing.T) {
 				sbz2:        0,
 				aclType:     "D",
 				control:     seDACLPresent,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  accessAllowedACEType,
@@ -212,7 +317,7 @@ func TestACL_Binary(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x1F01FF, // Full Access
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,            // NT Authority
 							subAuthority:        []uint32{18}, // Local System
@@ -241,7 +346,7 @@ func TestACL_Binary(t *testing.T) {
 		},
 		{
 			name: "ACL with multiple ACEs",
-			acl: &acl{
+			acl: &ACL{
 				aclRevision: 2,
 				sbzl:        0,
 				aclSize:     48, // 8 (header) + 20 (first ACE) + 20 (second ACE)
@@ -249,7 +354,7 @@ func TestACL_Binary(t *testing.T) {
 				sbz2:        0,
 				aclType:     "D",
 				control:     seDACLPresent,
-				aces: []ace{
+				aces: []ACE{
 					{
 						header: &aceHeader{
 							aceType:  accessAllowedACEType,
@@ -257,7 +362,7 @@ func TestACL_Binary(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x1F01FF, // Full Access
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 5,
 							subAuthority:        []uint32{18}, // System
@@ -270,7 +375,7 @@ func TestACL_Binary(t *testing.T) {
 							aceSize:  20,
 						},
 						accessMask: 0x120089, // Read Access
-						sid: &sid{
+						sid: &SID{
 							revision:            1,
 							identifierAuthority: 1,
 							subAuthority:        []uint32{0}, // Everyone
@@ -312,13 +417,16 @@ func TestACL_Binary(t *testing.T) {
 			t.Parallel()
 
 			got := tt.acl.Binary()
+			if size := tt.acl.BinarySize(); size != len(got) {
+				t.Errorf("ACL.BinarySize() = %d, want len(Binary()) = %d", size, len(got))
+			}
 
 			if !bytes.Equal(got, tt.want) {
 				t.Errorf("ACL.Binary() =\n%v\nwant\n%v", formatBytes(got), formatBytes(tt.want))
 			}
 
 			// Check reversibility for both binary and string
-			back, err := parseACLBinary(got, tt.acl.aclType, tt.acl.control)
+			back, err := parseACLBinary(got, tt.acl.aclType, tt.acl.control, nil, ParseOptions{})
 			if err != nil {
 				t.Errorf("ACL.Binary() -> parseACLBinary() got error: %v", err)
 				return
@@ -326,7 +434,7 @@ func TestACL_Binary(t *testing.T) {
 			compareACLs(t, "ACL.Binary() -> parseACLBinary()", back, tt.acl)
 
 			str := tt.acl.String()
-			backR, err := parseACLString(tt.acl.aclType, str)
+			backR, err := parseACLString(tt.acl.aclType, str, ParseOptions{})
 			if err != nil {
 				t.Errorf("ACL.Binary() -> ACL.String() -> parseACLString() got error: %v", err)
 				return
@@ -341,12 +449,422 @@ func TestACL_Binary(t *testing.T) {
 	}
 }
 
+func TestACL_Binary_Alignment(t *testing.T) {
+	t.Parallel()
+
+	// An ACE of unknown type with 3 bytes of data, followed by one with 2 bytes of padding:
+	// neither body is a multiple of 4 bytes
+	unaligned := ACE{
+		header:     &aceHeader{aceType: 0x16, aceSize: 11},
+		accessMask: 1,
+		rawData:    []byte{1, 2, 3},
+	}
+	padded := newACE(accessAllowedACEType, 0, FileAllAccess, NewSID(1, 0))
+	padded.padding = []byte{0xAA, 0xBB}
+	padded.header.aceSize = uint16(padded.BinarySize())
+
+	acl := &ACL{aclRevision: aclRevision, aclType: "D", aces: []ACE{unaligned, *padded}}
+	acl.updateSize()
+	if acl.aclSize != 8+12+24 {
+		t.Fatalf("updateSize() aclSize = %d, want %d", acl.aclSize, 8+12+24)
+	}
+	if size := acl.BinarySize(); size != 8+12+24 {
+		t.Errorf("BinarySize() = %d, want %d", size, 8+12+24)
+	}
+	if err := acl.validate(); err != nil {
+		t.Fatalf("validate() unexpected error = %v", err)
+	}
+
+	data := acl.Binary()
+	want := []byte{
+		0x02, 0x00, 0x2C, 0x00, 0x02, 0x00, 0x00, 0x00, // ACL header, AclSize 44
+		0x16, 0x00, 0x0C, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x00, // raw ACE padded to 12 bytes
+		0x00, 0x00, 0x18, 0x00, 0xFF, 0x01, 0x1F, 0x00, // ACE header and mask, AceSize 24
+		0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // S-1-1-0
+		0xAA, 0xBB, 0x00, 0x00, // padding, aligned
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("Binary() = % x, want % x", data, want)
+	}
+
+	// The alignment bytes are decoded back as raw data and padding, so the ACL round-trips from now on
+	back, err := parseACLBinary(data, "D", 0, nil, ParseOptions{})
+	if err != nil {
+		t.Fatalf("parseACLBinary() unexpected error = %v", err)
+	}
+	if got := back.aces[0].rawData; !bytes.Equal(got, []byte{1, 2, 3, 0}) {
+		t.Errorf("parseACLBinary() raw data = % x, want 01 02 03 00", got)
+	}
+	if got := back.aces[1].padding; !bytes.Equal(got, []byte{0xAA, 0xBB, 0, 0}) {
+		t.Errorf("parseACLBinary() padding = % x, want aa bb 00 00", got)
+	}
+	if got := back.Binary(); !bytes.Equal(got, data) {
+		t.Errorf("parseACLBinary().Binary() = % x, want % x", got, data)
+	}
+}
+
+func TestACL_FlagsString(t *testing.T) {
+	t.Parallel()
+
+	// Windows writes the flags of both ACLs in the order P, AR, AI, whatever the input order
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Protected auto-inherited DACL",
+			input: "D:PAI(A;;FA;;;SY)",
+			want:  "D:PAI(A;;FA;;;SY)",
+		},
+		{
+			name:  "All Windows DACL flags",
+			input: "D:AIARP(A;;FA;;;SY)",
+			want:  "D:PARAI(A;;FA;;;SY)",
+		},
+		{
+			name:  "All Windows SACL flags",
+			input: "S:AIPAR(AU;SA;FA;;;WD)",
+			want:  "S:PARAI(AU;SA;FA;;;WD)",
+		},
+		{
+			name:  "Auto-inherit required and auto-inherited",
+			input: "D:AIAR(A;;FA;;;SY)S:AIAR(AU;SA;FA;;;WD)",
+			want:  "D:ARAI(A;;FA;;;SY)S:ARAI(AU;SA;FA;;;WD)",
+		},
+		{
+			name:  "Defaulted last",
+			input: "D:RPAI(A;;FA;;;SY)",
+			want:  "D:PAIR(A;;FA;;;SY)",
+		},
+		{
+			name:  "No flags",
+			input: "D:(A;;FA;;;SY)S:",
+			want:  "D:(A;;FA;;;SY)S:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.input)
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestACL_Control(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		wantDACL string
+		wantSACL string
+	}{
+		{
+			name:     "Different flags on each ACL",
+			input:    "O:SYD:PAI(A;;FA;;;SY)S:AR(AU;SA;FA;;;WD)",
+			wantDACL: "PAI(A;;FA;;;SY)",
+			wantSACL: "AR(AU;SA;FA;;;WD)",
+		},
+		{
+			name:     "Flags on the SACL only",
+			input:    "D:(A;;FA;;;SY)S:PAI(AU;SA;FA;;;WD)",
+			wantDACL: "(A;;FA;;;SY)",
+			wantSACL: "PAI(AU;SA;FA;;;WD)",
+		},
+		{
+			name:     "Flags on the DACL only",
+			input:    "D:PARAI(A;;FA;;;SY)S:(AU;SA;FA;;;WD)",
+			wantDACL: "PARAI(A;;FA;;;SY)",
+			wantSACL: "(AU;SA;FA;;;WD)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fromString, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			fromBinary, err := FromBinary(fromString.Binary())
+			if err != nil {
+				t.Fatalf("Binary() -> FromBinary() unexpected error = %v", err)
+			}
+
+			for _, sd := range []*SecurityDescriptor{fromString, fromBinary} {
+				dacl, sacl := sd.DACL(), sd.SACL()
+				if dacl.control&^daclControlMask != 0 {
+					t.Errorf("DACL control = 0x%04X, has flags outside of 0x%04X", dacl.control, daclControlMask)
+				}
+				if sacl.control&^saclControlMask != 0 {
+					t.Errorf("SACL control = 0x%04X, has flags outside of 0x%04X", sacl.control, saclControlMask)
+				}
+				if got := dacl.String(); got != tt.wantDACL {
+					t.Errorf("DACL.String() = %s, want %s", got, tt.wantDACL)
+				}
+				if got := sacl.String(); got != tt.wantSACL {
+					t.Errorf("SACL.String() = %s, want %s", got, tt.wantSACL)
+				}
+			}
+
+			// The descriptor control is still the source of truth of the binary form
+			if got, want := fromBinary.control, fromString.control|seSelfRelative; got != want {
+				t.Errorf("Binary() control = 0x%04X, want 0x%04X", got, want)
+			}
+		})
+	}
+}
+
+func TestACL_InsertCanonical(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		dacl   string
+		insert []string
+		want   string
+	}{
+		{
+			name:   "Allow then deny",
+			dacl:   "D:",
+			insert: []string{"(A;;FA;;;SY)", "(D;;FW;;;AN)"},
+			want:   "D:(D;;FW;;;AN)(A;;FA;;;SY)",
+		},
+		{
+			name:   "Deny after other deny ACEs",
+			dacl:   "D:(D;;FW;;;AN)(A;;FA;;;SY)",
+			insert: []string{"(D;;FA;;;BG)"},
+			want:   "D:(D;;FW;;;AN)(D;;FA;;;BG)(A;;FA;;;SY)",
+		},
+		{
+			name:   "Explicit before inherited",
+			dacl:   "D:AI(A;;FA;;;SY)(D;ID;FW;;;AN)(A;ID;FR;;;BU)",
+			insert: []string{"(A;;FR;;;WD)", "(D;;FA;;;BG)"},
+			want:   "D:AI(D;;FA;;;BG)(A;;FA;;;SY)(A;;FR;;;WD)(D;ID;FW;;;AN)(A;ID;FR;;;BU)",
+		},
+		{
+			name:   "Inherited at the end",
+			dacl:   "D:AI(A;;FA;;;SY)(A;ID;FR;;;BU)",
+			insert: []string{"(D;ID;FW;;;AN)"},
+			want:   "D:AI(A;;FA;;;SY)(A;ID;FR;;;BU)(D;ID;FW;;;AN)",
+		},
+		{
+			name:   "Object ACE raises the revision",
+			dacl:   "D:(A;;FA;;;SY)",
+			insert: []string{"(OD;;WP;bf967aba-0de6-11d0-a285-00aa003049e2;;BG)"},
+			want:   "D:(OD;;WP;bf967aba-0de6-11d0-a285-00aa003049e2;;BG)(A;;FA;;;SY)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.dacl)
+			for _, s := range tt.insert {
+				ace := mustFromString(t, "D:"+s).DACL().ACEs()[0]
+				sd.DACL().InsertCanonical(&ace)
+			}
+
+			if err := sd.ValidateStrict(); err != nil {
+				t.Fatalf("ValidateStrict() unexpected error = %v", err)
+			}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+
+			// The ACL is the one FromString builds for the same string
+			want := mustFromString(t, tt.want)
+			compareACLs(t, "InsertCanonical()", sd.DACL(), want.DACL())
+		})
+	}
+}
+
+func TestACL_Duplicates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  [][2]int
+	}{
+		{
+			name:  "Exact duplicates",
+			input: "D:(A;;FA;;;SY)(A;;FR;;;BU)(A;;FA;;;SY)(A;;FR;;;BU)(A;;FA;;;SY)",
+			want:  [][2]int{{0, 2}, {0, 4}, {1, 3}, {2, 4}},
+		},
+		{
+			name:  "Different flags, mask or type",
+			input: "D:(A;;FA;;;SY)(A;OICI;FA;;;SY)(A;;FR;;;SY)(D;;FA;;;SY)",
+			want:  nil,
+		},
+		{
+			name:  "Object ACEs",
+			input: "D:(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)(OA;;CR;ab721a53-1e2f-11d0-9819-00aa0040529b;;WD)(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)",
+			want:  [][2]int{{0, 2}},
+		},
+		{
+			name:  "Empty ACL",
+			input: "D:",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			if got := sd.DACL().Duplicates(); !slices.Equal(got, tt.want) {
+				t.Errorf("Duplicates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestACL_Subtract(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		acl   string
+		other string // "" for a nil ACL
+		want  string
+	}{
+		{
+			name:  "Overlapping ACLs",
+			acl:   "D:PAI(D;;FW;;;BG)(A;;FA;;;SY)(A;;FA;;;BA)(A;OICI;FR;;;BU)",
+			other: "D:(A;;FA;;;BA)(A;;FA;;;SY)(A;;FR;;;BU)",
+			want:  "D:PAI(D;;FW;;;BG)(A;OICI;FR;;;BU)",
+		},
+		{
+			name:  "Same ACEs",
+			acl:   "D:(A;;FA;;;SY)(A;;FA;;;BA)",
+			other: "D:(A;;FA;;;BA)(A;;FA;;;SY)",
+			want:  "D:",
+		},
+		{
+			name:  "Disjoint ACLs",
+			acl:   "D:(A;;FA;;;SY)",
+			other: "D:(D;;FA;;;SY)",
+			want:  "D:(A;;FA;;;SY)",
+		},
+		{
+			name:  "Duplicates are all removed",
+			acl:   "D:(A;;FA;;;SY)(A;;FR;;;WD)(A;;FA;;;SY)",
+			other: "D:(A;;FA;;;SY)",
+			want:  "D:(A;;FR;;;WD)",
+		},
+		{
+			name:  "Object ACEs",
+			acl:   "D:(OA;;CR;ab721a54-1e2f-11d0-9819-00aa0040529b;;WD)(OA;;CR;ab721a56-1e2f-11d0-9819-00aa0040529b;;WD)",
+			other: "D:(OA;;CR;ab721a56-1e2f-11d0-9819-00aa0040529b;;WD)",
+			want:  "D:(OA;;CR;ab721a54-1e2f-11d0-9819-00aa0040529b;;WD)",
+		},
+		{
+			name: "Nil ACL",
+			acl:  "D:(A;;FA;;;SY)",
+			want: "D:(A;;FA;;;SY)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			acl := mustFromString(t, tt.acl).DACL()
+			before := acl.String()
+			var other *ACL
+			if tt.other != "" {
+				other = mustFromString(t, tt.other).DACL()
+			}
+
+			got := acl.Subtract(other)
+			if s := "D:" + got.String(); s != tt.want {
+				t.Errorf("Subtract() = %s, want %s", s, tt.want)
+			}
+			if acl.String() != before {
+				t.Errorf("Subtract() modified the receiver: %s, want %s", acl, before)
+			}
+
+			// The difference has a consistent size and count, and can be serialized
+			want := mustFromString(t, tt.want).DACL()
+			if !bytes.Equal(got.Binary(), want.Binary()) {
+				t.Errorf("Subtract().Binary() = % x, want % x", got.Binary(), want.Binary())
+			}
+			if got.BinarySize() != len(got.Binary()) {
+				t.Errorf("Subtract().BinarySize() = %d, want %d", got.BinarySize(), len(got.Binary()))
+			}
+		})
+	}
+}
+
+func TestACL_Conflicts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  [][2]int
+	}{
+		{
+			name:  "Allow and deny for the same trustee",
+			input: "D:(D;;FW;;;BU)(A;;FA;;;SY)(A;;FA;;;BU)",
+			want:  [][2]int{{0, 2}},
+		},
+		{
+			name:  "Disjoint masks",
+			input: "D:(D;;0x2;;;BU)(A;;0x1;;;BU)",
+			want:  nil,
+		},
+		{
+			name:  "Different trustees",
+			input: "D:(D;;FA;;;BG)(A;;FA;;;BU)",
+			want:  nil,
+		},
+		{
+			name:  "Object ACEs",
+			input: "D:(OD;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)(A;;FR;;;BU)(OA;;CR;;;WD)",
+			want:  [][2]int{{0, 2}},
+		},
+		{
+			name:  "Allow only",
+			input: "D:(A;;FA;;;BU)(A;;FR;;;BU)",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+			if got := sd.DACL().Conflicts(); !slices.Equal(got, tt.want) {
+				t.Errorf("Conflicts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSecurityDescriptor_Binary(t *testing.T) {
 	t.Parallel()
 
 	// Helper function to create a basic SID
-	createSID := func(authority uint64, subAuth ...uint32) *sid {
-		return &sid{
+	createSID := func(authority uint64, subAuth ...uint32) *SID {
+		return &SID{
 			revision:            1,
 			identifierAuthority: authority,
 			subAuthority:        subAuth,
@@ -354,12 +872,12 @@ func TestSecurityDescriptor_Binary(t *testing.T) {
 	}
 
 	// Helper function to create a basic ACE
-	createACE := func(aceType byte, aceFlags byte, accessMask uint32, sid *sid) *ace {
+	createACE := func(aceType byte, aceFlags byte, accessMask uint32, sid *SID) *ACE {
 		size := uint16(8 + 12) // 8 bytes for header+mask + minimum 12 bytes for SID
 		if sid != nil {
 			size = uint16(8 + 8 + 4*len(sid.subAuthority))
 		}
-		return &ace{
+		return &ACE{
 			header: &aceHeader{
 				aceType:  aceType,
 				aceFlags: aceFlags,
@@ -370,20 +888,20 @@ func TestSecurityDescriptor_Binary(t *testing.T) {
 		}
 	}
 
-	// Helper function to create a basic ACL
-	createACL := func(aclType string, control uint16, aces ...ace) *acl {
+	// Helper function to create a basic ACL, keeping only the flags of the descriptor control describing it
+	createACL := func(aclType string, control uint16, aces ...ACE) *ACL {
 		size := uint16(8) // ACL header size
 		for _, ace := range aces {
 			size += ace.header.aceSize
 		}
-		return &acl{
+		return &ACL{
 			aclRevision: 2,
 			sbzl:        0,
 			aclSize:     size,
 			aceCount:    uint16(len(aces)),
 			sbz2:        0,
 			aclType:     aclType,
-			control:     control,
+			control:     aclControl(aclType, control),
 			aces:        aces,
 		}
 	}
@@ -595,6 +1113,9 @@ func TestSecurityDescriptor_Binary(t *testing.T) {
 			t.Parallel()
 
 			got := tt.sd.Binary()
+			if size := tt.sd.BinarySize(); size != len(got) {
+				t.Errorf("BinarySize() = %d, want len(Binary()) = %d", size, len(got))
+			}
 
 			if len(got) != len(tt.want) {
 				t.Errorf("Binary() length mismatch\ngot  = %d bytes\nwant = %d bytes", len(got), len(tt.want))
@@ -645,32 +1166,347 @@ func TestSecurityDescriptor_Binary(t *testing.T) {
 	}
 }
 
-func TestSID_Binary(t *testing.T) {
+// completeBinaryFixture returns a security descriptor with all the components and kinds of ACEs: object
+// ACEs, a callback ACE with application data and an ACE of unknown type, both of unaligned sizes.
+func completeBinaryFixture(tb testing.TB) *SecurityDescriptor {
+	tb.Helper()
+
+	sd, err := FromString("O:BAG:SYD:PAI(A;OICI;FA;;;BA)(A;OICIIO;GA;;;CO)(OA;;RP;bf967a86-0de6-11d0-a285-00aa003049e2;;AU)" +
+		"(A;;FR;;;BU)(D;;FW;;;AN)S:AI(AU;SAFA;FA;;;WD)(ML;;NW;;;HI)")
+	if err != nil {
+		tb.Fatalf("FromString() unexpected error = %v", err)
+	}
+
+	authenticatedUsers := &SID{revision: 1, identifierAuthority: 5, subAuthority: []uint32{11}}
+	callback := newACE(accessAllowedCallbackACEType, 0, FileGenericRead, authenticatedUsers)
+	callback.applicationData = []byte{'a', 'r', 't', 'x', 0x01}
+	callback.updateSize()
+	unknown := &ACE{header: &aceHeader{aceType: 0x20, aceFlags: inheritedACE}, accessMask: 0x1, rawData: []byte{1, 2, 3}}
+	unknown.updateSize()
+	sd.dacl.aces = append(sd.dacl.aces, *callback, *unknown)
+	sd.dacl.updateSize()
+	return sd
+}
+
+func TestSecurityDescriptor_Binary_CompleteFixture(t *testing.T) {
 	t.Parallel()
 
-	tests := []struct {
-		name    string
-		sid     *sid
-		want    []byte
-		wantErr error
-	}{
-		{
-			name: "NULL SID (S-1-0-0)",
-			sid: &sid{
-				revision:            1,
-				identifierAuthority: 0,
-				subAuthority:        []uint32{0},
-			},
-			want: []byte{
-				0x01,                               // Revision
-				0x01,                               // SubAuthorityCount
+	// Output of Binary when it serialized each component into its own slice before copying them
+	want := []byte{
+		0x01, 0x00, 0x14, 0x9C, 0x14, 0x00, 0x00, 0x00, 0x24, 0x00, 0x00, 0x00, 0x30, 0x00, 0x00, 0x00,
+		0x60, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00,
+		0x20, 0x02, 0x00, 0x00, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00,
+		0x02, 0x00, 0x30, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 0xC0, 0x14, 0x00, 0xFF, 0x01, 0x1F, 0x00,
+		0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x11, 0x00, 0x14, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x30, 0x00, 0x00,
+		0x04, 0x00, 0xB0, 0x00, 0x07, 0x00, 0x00, 0x00, 0x00, 0x03, 0x18, 0x00, 0xFF, 0x01, 0x1F, 0x00,
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00, 0x20, 0x02, 0x00, 0x00,
+		0x00, 0x0B, 0x14, 0x00, 0x00, 0x00, 0x00, 0x10, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0x28, 0x00, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		0x86, 0x7A, 0x96, 0xBF, 0xE6, 0x0D, 0xD0, 0x11, 0xA2, 0x85, 0x00, 0xAA, 0x00, 0x30, 0x49, 0xE2,
+		0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x0B, 0x00, 0x00, 0x00, 0x00, 0x00, 0x18, 0x00,
+		0x89, 0x00, 0x12, 0x00, 0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00,
+		0x21, 0x02, 0x00, 0x00, 0x01, 0x00, 0x14, 0x00, 0x16, 0x01, 0x12, 0x00, 0x01, 0x01, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x05, 0x07, 0x00, 0x00, 0x00, 0x09, 0x00, 0x1C, 0x00, 0x89, 0x00, 0x12, 0x00,
+		0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x0B, 0x00, 0x00, 0x00, 0x61, 0x72, 0x74, 0x78,
+		0x01, 0x00, 0x00, 0x00, 0x20, 0x10, 0x0C, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x00,
+	}
+
+	sd := completeBinaryFixture(t)
+	if got := sd.Binary(); !bytes.Equal(got, want) {
+		t.Errorf("Binary() = %x, want %x", got, want)
+	}
+	if got := sd.BinarySize(); got != len(want) {
+		t.Errorf("BinarySize() = %d, want %d", got, len(want))
+	}
+}
+
+// TestSecurityDescriptor_Binary_Allocs isn't parallel, as AllocsPerRun cannot run in parallel tests.
+func TestSecurityDescriptor_Binary_Allocs(t *testing.T) {
+	sd := completeBinaryFixture(t)
+
+	// The components are appended to the result buffer, which is the only allocation
+	if allocs := testing.AllocsPerRun(100, func() { sd.Binary() }); allocs != 1 {
+		t.Errorf("Binary() allocations = %v, want 1", allocs)
+	}
+}
+
+func BenchmarkSecurityDescriptor_Binary(b *testing.B) {
+	sd := completeBinaryFixture(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sd.Binary()
+	}
+}
+
+func TestSecurityDescriptor_ResourceManagerControl(t *testing.T) {
+	t.Parallel()
+
+	data := []byte{
+		0x01,       // Revision
+		0xA5,       // Sbz1, the resource manager control bits
+		0x04, 0xC0, // Control (SE_SELF_RELATIVE | SE_RESOURCE_MANAGER_CONTROL_VALID | SE_DACL_PRESENT)
+		0x00, 0x00, 0x00, 0x00, // Owner offset
+		0x00, 0x00, 0x00, 0x00, // Group offset
+		0x00, 0x00, 0x00, 0x00, // SACL offset
+		0x14, 0x00, 0x00, 0x00, // DACL offset
+		0x02, 0x00, 0x1C, 0x00, 0x01, 0x00, 0x00, 0x00, // DACL header, 1 ACE
+		0x00, 0x00, 0x14, 0x00, 0xFF, 0x01, 0x1F, 0x00, // A, no flags, FA
+		0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00, // S-1-5-18
+	}
+
+	sd, err := FromBinary(data)
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	if rmControl, ok := sd.ResourceManagerControl(); rmControl != 0xA5 || !ok {
+		t.Errorf("ResourceManagerControl() = (%#02x, %v), want (0xa5, true)", rmControl, ok)
+	}
+	if got := sd.Binary(); !bytes.Equal(got, data) {
+		t.Errorf("Binary() = % x, want % x", got, data)
+	}
+	if got := sd.StringIndent(2); !strings.HasPrefix(got, "  RM: 0xA5\n  D:") {
+		t.Errorf("StringIndent() = %q, want the resource manager control before the DACL", got)
+	}
+
+	// Without SE_RESOURCE_MANAGER_CONTROL_VALID, there is no resource manager control
+	sd = mustFromString(t, "D:(A;;FA;;;SY)")
+	if rmControl, ok := sd.ResourceManagerControl(); rmControl != 0 || ok {
+		t.Errorf("ResourceManagerControl() = (%#02x, %v), want (0, false)", rmControl, ok)
+	}
+	if got := sd.StringIndent(0); strings.Contains(got, "RM:") {
+		t.Errorf("StringIndent() = %q, want no resource manager control", got)
+	}
+
+	sd.SetResourceManagerControl(0x01)
+	back, err := FromBinary(sd.Binary())
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	if rmControl, ok := back.ResourceManagerControl(); rmControl != 0x01 || !ok {
+		t.Errorf("SetResourceManagerControl() -> Binary() -> FromBinary() = (%#02x, %v), want (0x01, true)", rmControl, ok)
+	}
+}
+
+func TestSecurityDescriptor_Defaulted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		sddl            string
+		wantDACL        bool
+		wantSACL        bool
+		wantDACLPresent bool
+	}{
+		{
+			name:     "Empty descriptor",
+			sddl:     "",
+			wantDACL: true,
+			wantSACL: true,
+		},
+		{
+			name:            "Explicit DACL",
+			sddl:            "O:BAD:(A;;FA;;;SY)",
+			wantSACL:        true,
+			wantDACLPresent: true,
+		},
+		{
+			name:            "Defaulted DACL",
+			sddl:            "D:R(A;;FA;;;SY)S:",
+			wantDACL:        true,
+			wantDACLPresent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			if got := sd.IsDACLDefaulted(); got != tt.wantDACL {
+				t.Errorf("IsDACLDefaulted() = %v, want %v", got, tt.wantDACL)
+			}
+			if got := sd.IsSACLDefaulted(); got != tt.wantSACL {
+				t.Errorf("IsSACLDefaulted() = %v, want %v", got, tt.wantSACL)
+			}
+
+			// The setters only change the defaulted flags, in the descriptor and its ACLs
+			sd.SetDACLDefaulted(!tt.wantDACL)
+			sd.SetSACLDefaulted(!tt.wantSACL)
+			if sd.IsDACLDefaulted() == tt.wantDACL || sd.IsSACLDefaulted() == tt.wantSACL {
+				t.Errorf("Set*Defaulted() didn't toggle the flags, control = %#04x", sd.control)
+			}
+			if got := sd.control&seDACLPresent != 0; got != tt.wantDACLPresent {
+				t.Errorf("SE_DACL_PRESENT = %v, want %v", got, tt.wantDACLPresent)
+			}
+			if sd.dacl != nil && sd.dacl.control != aclControl("D", sd.control) {
+				t.Errorf("DACL control = %#04x, want %#04x", sd.dacl.control, aclControl("D", sd.control))
+			}
+		})
+	}
+
+	// The "R" flag of a DACL follows the defaulted flag
+	sd := mustFromString(t, "D:(A;;FA;;;SY)")
+	sd.SetDACLDefaulted(true)
+	if got, want := sd.String(), "D:R(A;;FA;;;SY)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestSecurityDescriptor_Equal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		a, b         string
+		wantEqual    bool
+		wantExplicit bool
+		// sddlFlags is set if a has ACL flags without binary form, which are lost by Binary
+		sddlFlags bool
+	}{
+		{
+			name:         "Identical",
+			a:            "O:SYG:BAD:PAI(A;;FA;;;SY)(A;;FR;;;BU)S:(AU;SA;FA;;;WD)",
+			b:            "O:SYG:BAD:PAI(A;;FA;;;SY)(A;;FR;;;BU)S:(AU;SA;FA;;;WD)",
+			wantEqual:    true,
+			wantExplicit: true,
+		},
+		{
+			name:         "Differ only in inherited ACEs",
+			a:            "O:SYG:BAD:(A;;FA;;;SY)(A;;FR;;;BU)",
+			b:            "O:SYG:BAD:AI(A;;FA;;;SY)(A;ID;FA;;;BA)(A;;FR;;;BU)(A;OICIID;FR;;;AU)",
+			wantEqual:    false,
+			wantExplicit: true,
+		},
+		{
+			name:         "Different explicit ACE",
+			a:            "O:SYD:(A;;FA;;;SY)(A;;FR;;;BU)",
+			b:            "O:SYD:(A;;FA;;;SY)(A;;FW;;;BU)(A;ID;FR;;;BU)",
+			wantEqual:    false,
+			wantExplicit: false,
+		},
+		{
+			name:         "Different order",
+			a:            "D:(A;;FA;;;SY)(A;;FR;;;BU)",
+			b:            "D:(A;;FR;;;BU)(A;;FA;;;SY)",
+			wantEqual:    false,
+			wantExplicit: false,
+		},
+		{
+			name:         "Different SDDL ACL flags",
+			a:            "D:NO(A;;FA;;;SY)",
+			b:            "D:(A;;FA;;;SY)",
+			wantEqual:    false,
+			wantExplicit: true,
+			sddlFlags:    true,
+		},
+		{
+			name:         "Same SDDL ACL flags",
+			a:            "D:NOIO(A;;FA;;;SY)",
+			b:            "D:IONO(A;;FA;;;SY)",
+			wantEqual:    true,
+			wantExplicit: true,
+			sddlFlags:    true,
+		},
+		{
+			name:         "Different owner",
+			a:            "O:SYD:(A;;FA;;;SY)",
+			b:            "O:BAD:(A;;FA;;;SY)",
+			wantEqual:    false,
+			wantExplicit: false,
+		},
+		{
+			name:         "Different group",
+			a:            "O:SYG:BAD:(A;;FA;;;SY)",
+			b:            "O:SYD:(A;;FA;;;SY)",
+			wantEqual:    false,
+			wantExplicit: false,
+		},
+		{
+			name:         "Protected DACL",
+			a:            "D:(A;;FA;;;SY)",
+			b:            "D:P(A;;FA;;;SY)",
+			wantEqual:    false,
+			wantExplicit: false,
+		},
+		{
+			name:         "Missing SACL",
+			a:            "D:(A;;FA;;;SY)S:",
+			b:            "D:(A;;FA;;;SY)",
+			wantEqual:    false,
+			wantExplicit: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := FromString(tt.a)
+			if err != nil {
+				t.Fatalf("FromString(%q) unexpected error = %v", tt.a, err)
+			}
+			b, err := FromString(tt.b)
+			if err != nil {
+				t.Fatalf("FromString(%q) unexpected error = %v", tt.b, err)
+			}
+
+			if got := a.Equal(b); got != tt.wantEqual {
+				t.Errorf("Equal() = %v, want %v", got, tt.wantEqual)
+			}
+			if got := b.Equal(a); got != tt.wantEqual {
+				t.Errorf("Equal() reversed = %v, want %v", got, tt.wantEqual)
+			}
+			if got := a.EqualExplicit(b); got != tt.wantExplicit {
+				t.Errorf("EqualExplicit() = %v, want %v", got, tt.wantExplicit)
+			}
+			if got := b.EqualExplicit(a); got != tt.wantExplicit {
+				t.Errorf("EqualExplicit() reversed = %v, want %v", got, tt.wantExplicit)
+			}
+
+			// The binary form describes the same descriptor, but for the ACL flags it can't hold
+			back, err := FromBinary(a.Binary())
+			if err != nil {
+				t.Fatalf("Binary() -> FromBinary() unexpected error = %v", err)
+			}
+			if got := back.Equal(a); got == tt.sddlFlags {
+				t.Errorf("Binary() -> FromBinary() Equal to the original descriptor = %v, want %v", got, !tt.sddlFlags)
+			}
+			if !back.EqualExplicit(a) {
+				t.Errorf("Binary() -> FromBinary() is not EqualExplicit to the original descriptor")
+			}
+		})
+	}
+}
+
+func TestSID_Binary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		sid     *SID
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "NULL SID (S-1-0-0)",
+			sid: &SID{
+				revision:            1,
+				identifierAuthority: 0,
+				subAuthority:        []uint32{0},
+			},
+			want: []byte{
+				0x01,                               // Revision
+				0x01,                               // SubAuthorityCount
 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Authority (0 in big-endian)
 				0x00, 0x00, 0x00, 0x00, // SubAuthority[0] = 0 in little-endian
 			},
 		},
 		{
 			name: "Well-known SID - Local System (S-1-5-18)",
-			sid: &sid{
+			sid: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{18},
@@ -684,7 +1520,7 @@ func TestSID_Binary(t *testing.T) {
 		},
 		{
 			name: "Well-known SID - BUILTIN\\Administrators (S-1-5-32-544)",
-			sid: &sid{
+			sid: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{32, 544},
@@ -699,7 +1535,7 @@ func TestSID_Binary(t *testing.T) {
 		},
 		{
 			name: "Maximum valid authority value (2^48-1)",
-			sid: &sid{
+			sid: &SID{
 				revision:            1,
 				identifierAuthority: (1 << 48) - 1,
 				subAuthority:        []uint32{1},
@@ -713,7 +1549,7 @@ func TestSID_Binary(t *testing.T) {
 		},
 		{
 			name: "Maximum number of sub-authorities (15)",
-			sid: &sid{
+			sid: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority: []uint32{
@@ -744,7 +1580,7 @@ func TestSID_Binary(t *testing.T) {
 		},
 		{
 			name: "Well known RID (LA)",
-			sid: &sid{
+			sid: &SID{
 				revision:            1,
 				identifierAuthority: 5,
 				subAuthority:        []uint32{21, 2781442215, 2946190836, 3058968086, 500},
@@ -768,6 +1604,9 @@ func TestSID_Binary(t *testing.T) {
 			t.Parallel()
 
 			got := tt.sid.Binary()
+			if size := tt.sid.BinarySize(); size != len(got) {
+				t.Errorf("BinarySize() = %d, want len(Binary()) = %d", size, len(got))
+			}
 
 			// Check successful cases
 			if !bytes.Equal(got, tt.want) {
@@ -811,61 +1650,1549 @@ func TestSID_Binary(t *testing.T) {
 	}
 }
 
-func TestSID_Domain(t *testing.T) {
+// TestSID_BinaryByteOrder guards the byte order of the binary SID: the identifier authority is a
+// 6-byte big-endian value, while the sub-authorities are little-endian. Every byte of each value is
+// distinct, so that a flipped or shifted byte order can't go unnoticed.
+func TestSID_BinaryByteOrder(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
-		name string
-		sid  *sid
-		want []uint32
+		name      string
+		authority uint64
+		subs      []uint32
+		want      []byte
 	}{
 		{
-			name: "valid domain SID",
-			sid: &sid{
-				revision:            1,
-				identifierAuthority: 5,
-				subAuthority:        []uint32{21, 2781442215, 2946190836, 3058968086, 500},
+			name:      "All six authority bytes",
+			authority: 0x010203040506,
+			subs:      []uint32{0x0A0B0C0D},
+			want: []byte{
+				0x01, 0x01, // Revision, SubAuthorityCount
+				0x01, 0x02, 0x03, 0x04, 0x05, 0x06, // Authority, big-endian
+				0x0D, 0x0C, 0x0B, 0x0A, // SubAuthority[0], little-endian
 			},
-			want: []uint32{2781442215, 2946190836, 3058968086},
 		},
 		{
-			name: "too few sub-authorities",
-			sid: &sid{
-				revision:            1,
-				identifierAuthority: 5,
-				subAuthority:        []uint32{18, 500},
+			name:      "High authority bytes only",
+			authority: 0xA1B2_0000_0000,
+			subs:      []uint32{0x11223344, 0x55667788},
+			want: []byte{
+				0x01, 0x02, // Revision, SubAuthorityCount
+				0xA1, 0xB2, 0x00, 0x00, 0x00, 0x00, // Authority, big-endian
+				0x44, 0x33, 0x22, 0x11, // SubAuthority[0], little-endian
+				0x88, 0x77, 0x66, 0x55, // SubAuthority[1], little-endian
 			},
-			want: []uint32{},
 		},
 		{
-			name: "exactly three sub-authorities",
-			sid: &sid{
-				revision:            1,
-				identifierAuthority: 5,
-				subAuthority:        []uint32{21, 123, 500},
+			name:      "Low authority bytes only",
+			authority: 0x0000_00C3_D4E5,
+			subs:      []uint32{0xF0000001},
+			want: []byte{
+				0x01, 0x01, // Revision, SubAuthorityCount
+				0x00, 0x00, 0x00, 0xC3, 0xD4, 0xE5, // Authority, big-endian
+				0x01, 0x00, 0x00, 0xF0, // SubAuthority[0], little-endian
 			},
-			want: []uint32{123},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sid := NewSID(tt.authority, tt.subs...)
+			if got := sid.Binary(); !bytes.Equal(got, tt.want) {
+				t.Errorf("Binary() = % x, want % x", got, tt.want)
+			}
+
+			back, err := parseSIDBinary(tt.want)
+			if err != nil {
+				t.Fatalf("parseSIDBinary() unexpected error = %v", err)
+			}
+			if back.identifierAuthority != tt.authority {
+				t.Errorf("parseSIDBinary() authority = %#012x, want %#012x", back.identifierAuthority, tt.authority)
+			}
+			if !slices.Equal(back.subAuthority, tt.subs) {
+				t.Errorf("parseSIDBinary() sub-authorities = %#x, want %#x", back.subAuthority, tt.subs)
+			}
+		})
+	}
+}
+
+func TestSID_AuthorityFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		authority uint64
+		want      string
+		wantErr   bool
+	}{
+		{name: "Largest decimal authority", input: "S-1-4294967295-1", authority: 1<<32 - 1, want: "S-1-4294967295-1"},
+		{name: "Smallest hexadecimal authority", input: "S-1-0x000100000000-1", authority: 1 << 32, want: "S-1-0x000100000000-1"},
+		{name: "Largest authority", input: "S-1-0xFFFFFFFFFFFF-1", authority: 1<<48 - 1, want: "S-1-0xFFFFFFFFFFFF-1"},
+		{name: "Short lowercase hexadecimal", input: "S-1-0x100000000-1", authority: 1 << 32, want: "S-1-0x000100000000-1"},
+		{name: "Decimal from 2^32", input: "S-1-4294967296-1", authority: 1 << 32, want: "S-1-0x000100000000-1"},
+		{name: "Hexadecimal below 2^32", input: "S-1-0xFFFFFFFF-1", authority: 1<<32 - 1, want: "S-1-4294967295-1"},
+		{name: "Decimal 2^48", input: "S-1-281474976710656-1", wantErr: true},
+		{name: "Hexadecimal 2^48", input: "S-1-0x1000000000000-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := parseSIDString(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSIDString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			sid, err := r.toSID(nil)
+			if err != nil {
+				t.Fatalf("toSID() unexpected error = %v", err)
+			}
+
+			if sid.identifierAuthority != tt.authority {
+				t.Errorf("authority = %#x, want %#x", sid.identifierAuthority, tt.authority)
+			}
+			if got := sid.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+			if got := NewSID(tt.authority, 1).String(); got != tt.want {
+				t.Errorf("NewSID().String() = %s, want %s", got, tt.want)
+			}
+
+			// The string and binary forms round-trip
+			back, err := parseSIDBinary(sid.Binary())
+			if err != nil {
+				t.Fatalf("parseSIDBinary() unexpected error = %v", err)
+			}
+			if !back.Equal(sid) || back.String() != tt.want {
+				t.Errorf("Binary() -> parseSIDBinary() = %s, want %s", back, tt.want)
+			}
+			sd := mustFromString(t, "O:"+tt.want)
+			if got := sd.Owner().String(); got != tt.want {
+				t.Errorf("FromString().Owner() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSID_NoSubAuthorities(t *testing.T) {
+	t.Parallel()
+
+	// S-1-5 has no sub-authority: it is accepted, as by IsValidSid, and handled like any other SID
+	wantBinary := []byte{
+		0x01, 0x00, // Revision, SubAuthorityCount
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05, // Authority
+	}
+
+	r, err := parseSIDString("S-1-5")
+	if err != nil {
+		t.Fatalf("parseSIDString() unexpected error = %v", err)
+	}
+	sid, err := r.toSID(nil)
+	if err != nil {
+		t.Fatalf("toSID() unexpected error = %v", err)
+	}
+	if !sid.Equal(NewSID(5)) {
+		t.Errorf("parseSIDString() = %s, want S-1-5", sid.rawString())
+	}
+	if got := sid.String(); got != "S-1-5" {
+		t.Errorf("String() = %s, want S-1-5", got)
+	}
+	if got := sid.Binary(); !bytes.Equal(got, wantBinary) {
+		t.Errorf("Binary() = % x, want % x", got, wantBinary)
+	}
+	if sid.IsCreatorPlaceholder() || len(sid.Domain()) != 0 {
+		t.Errorf("IsCreatorPlaceholder() or Domain() unexpectedly matched S-1-5")
+	}
+
+	back, err := parseSIDBinary(wantBinary)
+	if err != nil {
+		t.Fatalf("parseSIDBinary() unexpected error = %v", err)
+	}
+	if !back.Equal(sid) {
+		t.Errorf("parseSIDBinary() = %s, want S-1-5", back.rawString())
+	}
+
+	// As the owner, the group and the trustee of an ACE
+	const sddl = "O:S-1-5G:S-1-5D:(A;;FA;;;S-1-5)"
+	sd := mustFromString(t, sddl)
+	if got := sd.String(); got != sddl {
+		t.Errorf("String() = %s, want %s", got, sddl)
+	}
+	sd, err = FromBinary(sd.Binary())
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	if got := sd.String(); got != sddl {
+		t.Errorf("FromBinary().String() = %s, want %s", got, sddl)
+	}
+}
+
+func TestNewSID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		authority      uint64
+		subAuthorities []uint32
+		want           string
+		wantErr        error
+	}{
+		{name: "Everyone", authority: 1, subAuthorities: []uint32{0}, want: "WD"},
+		{name: "Builtin administrators", authority: 5, subAuthorities: []uint32{32, 544}, want: "BA"},
+		{name: "No sub-authority", authority: 5, want: "S-1-5"},
+		{name: "Largest authority", authority: 1<<48 - 1, subAuthorities: []uint32{1}, want: "S-1-0xFFFFFFFFFFFF-1"},
+		{
+			name:           "15 sub-authorities",
+			authority:      5,
+			subAuthorities: []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+			want:           "S-1-5-1-2-3-4-5-6-7-8-9-10-11-12-13-14-15",
 		},
 		{
-			name: "empty sub-authorities",
-			sid: &sid{
-				revision:            1,
-				identifierAuthority: 5,
-				subAuthority:        []uint32{},
-			},
-			want: []uint32{},
+			name:           "16 sub-authorities",
+			authority:      5,
+			subAuthorities: []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			wantErr:        ErrTooManySubAuthorities,
 		},
+		{name: "Authority over 48 bits", authority: 1 << 48, subAuthorities: []uint32{1}, wantErr: ErrInvalidAuthority},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := tt.sid.Domain()
-			if len(got) != len(tt.want) {
-				t.Errorf("Domain() got len = %v, want len = %v", len(got), len(tt.want))
-				return
+			t.Parallel()
+
+			sid, err := NewSIDChecked(tt.authority, tt.subAuthorities...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewSIDChecked() error = %v, want %v", err, tt.wantErr)
 			}
-			for i := range got {
-				if got[i] != tt.want[i] {
-					t.Errorf("Domain()[%d] = %v, want %v", i, got[i], tt.want[i])
+
+			defer func() {
+				if r := recover(); (r != nil) != (tt.wantErr != nil) {
+					t.Errorf("NewSID() panic = %v, want panic %v", r, tt.wantErr != nil)
 				}
+			}()
+			panicking := NewSID(tt.authority, tt.subAuthorities...)
+
+			if tt.wantErr != nil {
+				return
+			}
+			if got := sid.String(); got != tt.want {
+				t.Errorf("NewSIDChecked() = %s, want %s", got, tt.want)
+			}
+			if !panicking.Equal(sid) {
+				t.Errorf("NewSID() = %s, want %s", panicking, sid)
+			}
+		})
+	}
+
+	t.Run("Sub-authorities are copied", func(t *testing.T) {
+		t.Parallel()
+
+		subAuthorities := []uint32{32, 544}
+		sid := NewNTSID(subAuthorities...)
+		subAuthorities[1] = 545
+		if got := sid.String(); got != "BA" {
+			t.Errorf("NewNTSID() = %s after modifying the arguments, want BA", got)
+		}
+	})
+}
+
+func TestSID_AccountDomainSID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		sid    string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "Domain user",
+			sid:    "S-1-5-21-2781442215-2946190836-3058968086-1104",
+			want:   "S-1-5-21-2781442215-2946190836-3058968086",
+			wantOK: true,
+		},
+		{
+			name:   "Local account of a machine",
+			sid:    "S-1-5-21-1004336348-1177238915-682003330-1001",
+			want:   "S-1-5-21-1004336348-1177238915-682003330",
+			wantOK: true,
+		},
+		{
+			name:   "Domain SID itself",
+			sid:    "S-1-5-21-1004336348-1177238915-682003330",
+			wantOK: false,
+		},
+		{
+			name:   "Well-known SID",
+			sid:    "S-1-5-18",
+			wantOK: false,
+		},
+		{
+			name:   "Built-in group",
+			sid:    "S-1-5-32-544",
+			wantOK: false,
+		},
+		{
+			name:   "Non NT authority",
+			sid:    "S-1-16-21-1-2-3-4",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sidr, err := parseSIDString(tt.sid)
+			if err != nil {
+				t.Fatalf("parseSIDString() unexpected error = %v", err)
+			}
+			sid, err := sidr.toSID(nil)
+			if err != nil {
+				t.Fatalf("toSID() unexpected error = %v", err)
+			}
+
+			got, ok := sid.AccountDomainSID()
+			if ok != tt.wantOK {
+				t.Fatalf("AccountDomainSID() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if got != nil {
+					t.Errorf("AccountDomainSID() = %v, want nil", got)
+				}
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("AccountDomainSID() = %s, want %s", got.String(), tt.want)
+			}
+			if sid.String() != tt.sid {
+				t.Errorf("AccountDomainSID() modified the receiver: %s", sid.String())
+			}
+		})
+	}
+}
+
+func TestSID_Domain(t *testing.T) {
+	tests := []struct {
+		name string
+		sid  *SID
+		want []uint32
+	}{
+		{
+			name: "valid domain SID",
+			sid: &SID{
+				revision:            1,
+				identifierAuthority: 5,
+				subAuthority:        []uint32{21, 2781442215, 2946190836, 3058968086, 500},
+			},
+			want: []uint32{2781442215, 2946190836, 3058968086},
+		},
+		{
+			name: "too few sub-authorities",
+			sid: &SID{
+				revision:            1,
+				identifierAuthority: 5,
+				subAuthority:        []uint32{18, 500},
+			},
+			want: []uint32{},
+		},
+		{
+			name: "exactly three sub-authorities",
+			sid: &SID{
+				revision:            1,
+				identifierAuthority: 5,
+				subAuthority:        []uint32{21, 123, 500},
+			},
+			want: []uint32{123},
+		},
+		{
+			name: "empty sub-authorities",
+			sid: &SID{
+				revision:            1,
+				identifierAuthority: 5,
+				subAuthority:        []uint32{},
+			},
+			want: []uint32{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.sid.Domain()
+			if len(got) != len(tt.want) {
+				t.Errorf("Domain() got len = %v, want len = %v", len(got), len(tt.want))
+				return
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Domain()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+
+			// The result is a copy: modifying it leaves the SID unchanged
+			before := tt.sid.String()
+			for i := range got {
+				got[i]++
+			}
+			if after := tt.sid.String(); after != before {
+				t.Errorf("modifying the result of Domain() changed the SID from %s to %s", before, after)
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_Validate(t *testing.T) {
+	t.Parallel()
+
+	systemSID := &SID{revision: 1, identifierAuthority: 5, subAuthority: []uint32{18}}
+
+	tests := []struct {
+		name    string
+		sd      *SecurityDescriptor
+		wantErr bool
+	}{
+		{
+			name: "Empty security descriptor",
+			sd: &SecurityDescriptor{
+				revision: 1,
+				control:  seSelfRelative,
+			},
+		},
+		{
+			name: "Valid DACL",
+			sd: &SecurityDescriptor{
+				revision: 1,
+				control:  seSelfRelative | seDACLPresent,
+				ownerSID: systemSID,
+				dacl: &ACL{
+					aclRevision: 2,
+					aclSize:     28,
+					aceCount:    1,
+					aclType:     "D",
+					aces: []ACE{
+						{
+							header:     &aceHeader{aceType: accessAllowedACEType, aceSize: 20},
+							accessMask: 0x1F01FF,
+							sid:        systemSID,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid revision",
+			sd: &SecurityDescriptor{
+				revision: 2,
+				control:  seSelfRelative,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid owner SID revision",
+			sd: &SecurityDescriptor{
+				revision: 1,
+				control:  seSelfRelative,
+				ownerSID: &SID{revision: 2, identifierAuthority: 5, subAuthority: []uint32{18}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "NULL DACL",
+			sd: &SecurityDescriptor{
+				revision: 1,
+				control:  seSelfRelative | seDACLPresent,
+			},
+			wantErr: false,
+		},
+		{
+			name: "SACL without SACL present flag",
+			sd: &SecurityDescriptor{
+				revision: 1,
+				control:  seSelfRelative,
+				sacl:     &ACL{aclRevision: 2, aclSize: 8, aclType: "S"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ACE count mismatch",
+			sd: &SecurityDescriptor{
+				revision: 1,
+				control:  seSelfRelative | seDACLPresent,
+				dacl:     &ACL{aclRevision: 2, aclSize: 8, aceCount: 1, aclType: "D"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ACE with nil SID",
+			sd: &SecurityDescriptor{
+				revision: 1,
+				control:  seSelfRelative | seDACLPresent,
+				dacl: &ACL{
+					aclRevision: 2,
+					aclSize:     28,
+					aceCount:    1,
+					aclType:     "D",
+					aces: []ACE{
+						{
+							header:     &aceHeader{aceType: accessAllowedACEType, aceSize: 20},
+							accessMask: 0x1F01FF,
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.sd.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Parsed descriptors must always be valid
+	for _, s := range []string{"", "O:SYG:BA", "D:", "O:SYG:BAD:PAI(A;;FA;;;SY)(D;;FR;;;WD)S:AI(AU;SA;FA;;;BA)"} {
+		sd, err := FromString(s)
+		if err != nil {
+			t.Fatalf("FromString(%q) unexpected error = %v", s, err)
+		}
+		if err := sd.Validate(); err != nil {
+			t.Errorf("FromString(%q).Validate() unexpected error = %v", s, err)
+		}
+	}
+}
+
+func TestSecurityDescriptor_ValidateStrict(t *testing.T) {
+	t.Parallel()
+
+	// withDACLRevision returns the descriptor of s with the revision of its DACL set to revision
+	withDACLRevision := func(t *testing.T, s string, revision byte) *SecurityDescriptor {
+		t.Helper()
+		sd := mustFromString(t, s)
+		sd.dacl.aclRevision = revision
+		return sd
+	}
+
+	tests := []struct {
+		name string
+		sd   *SecurityDescriptor
+		// wantValid checks that Validate accepts a descriptor that ValidateStrict rejects
+		wantValid bool
+		wantErr   error
+	}{
+		{
+			name: "Revision 2 without object ACE",
+			sd:   mustFromString(t, "O:BAD:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)"),
+		},
+		{
+			name: "Revision 4 with object ACE",
+			sd:   mustFromString(t, "D:(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)(A;;FA;;;SY)"),
+		},
+		{
+			name: "Revision 3 without object ACE",
+			sd:   withDACLRevision(t, "D:(A;;FA;;;SY)", 3),
+		},
+		{
+			name: "Revision 4 without object ACE",
+			sd:   withDACLRevision(t, "D:(A;;FA;;;SY)", 4),
+		},
+		{
+			name:      "Revision 2 with object ACE",
+			sd:        withDACLRevision(t, "D:(A;;FA;;;SY)(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)", 2),
+			wantValid: true,
+			wantErr:   ErrInvalidACLRevision,
+		},
+		{
+			name:      "Revision 3 with object ACE",
+			sd:        withDACLRevision(t, "D:(OD;;WP;;bf967aba-0de6-11d0-a285-00aa003049e2;BG)", 3),
+			wantValid: true,
+			wantErr:   ErrInvalidACLRevision,
+		},
+		{
+			name:      "Revision 1",
+			sd:        withDACLRevision(t, "D:(A;;FA;;;SY)", 1),
+			wantValid: true,
+			wantErr:   ErrInvalidACLRevision,
+		},
+		{
+			name: "Masks without reserved bits",
+			sd:   mustFromString(t, "D:(A;;GAMAAS;;;SY)(A;;0x001F01FF;;;BA)S:(AU;SA;FA;;;WD)"),
+		},
+		{
+			name:      "Reserved standard right bit",
+			sd:        mustFromString(t, "D:(A;;FA;;;SY)(A;;0x00800000;;;BA)"),
+			wantValid: true,
+			wantErr:   ErrReservedAccessBits,
+		},
+		{
+			name:      "Reserved bit below the generic rights in a SACL",
+			sd:        mustFromString(t, "S:(AU;SA;0x041F01FF;;;WD)"),
+			wantValid: true,
+			wantErr:   ErrReservedAccessBits,
+		},
+		{
+			name: "SACL revision 5",
+			sd: func() *SecurityDescriptor {
+				sd := mustFromString(t, "S:(AU;SA;FA;;;WD)")
+				sd.sacl.aclRevision = 5
+				return sd
+			}(),
+			wantValid: true,
+			wantErr:   ErrInvalidACLRevision,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.wantValid {
+				if err := tt.sd.Validate(); err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+			}
+
+			err := tt.sd.ValidateStrict()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("ValidateStrict() unexpected error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The errors of Validate are reported as well
+	if err := (&SecurityDescriptor{revision: 2}).ValidateStrict(); err == nil {
+		t.Errorf("ValidateStrict() with revision 2 error = nil, want error")
+	}
+}
+
+func TestSID_IsSelf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		wantSID  string
+		wantStr  string
+		wantSelf bool
+	}{
+		{
+			name:     "SELF alias",
+			input:    "D:(A;;FA;;;PS)",
+			wantSID:  "S-1-5-10",
+			wantStr:  "D:(A;;FA;;;PS)",
+			wantSelf: true,
+		},
+		{
+			name:     "SELF full SID",
+			input:    "D:(A;;FA;;;S-1-5-10)",
+			wantSID:  "S-1-5-10",
+			wantStr:  "D:(A;;FA;;;PS)",
+			wantSelf: true,
+		},
+		{
+			name:     "Service asserted identity alias",
+			input:    "D:(A;;FA;;;SS)",
+			wantSID:  "S-1-18-2",
+			wantStr:  "D:(A;;FA;;;SS)",
+			wantSelf: false,
+		},
+		{
+			name:     "PROXY full SID",
+			input:    "D:(A;;FA;;;S-1-5-8)",
+			wantSID:  "S-1-5-8",
+			wantStr:  "D:(A;;FA;;;S-1-5-8)",
+			wantSelf: false,
+		},
+		{
+			name:     "Domain SID ending in 10",
+			input:    "D:(A;;FA;;;S-1-5-21-1-2-3-10)",
+			wantSID:  "S-1-5-21-1-2-3-10",
+			wantStr:  "D:(A;;FA;;;S-1-5-21-1-2-3-10)",
+			wantSelf: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+
+			aces := sd.DACL().ACEs()
+			if len(aces) != 1 {
+				t.Fatalf("FromString() got %d ACEs, want 1", len(aces))
+			}
+
+			trustee := aces[0].SID()
+			if got := trustee.rawString(); got != tt.wantSID {
+				t.Errorf("ACE.SID() = %s, want %s", got, tt.wantSID)
+			}
+			if got := trustee.IsSelf(); got != tt.wantSelf {
+				t.Errorf("IsSelf() = %v, want %v", got, tt.wantSelf)
+			}
+
+			// Well-known SIDs are always rendered using their alias
+			if got := sd.String(); got != tt.wantStr {
+				t.Errorf("String() = %s, want %s", got, tt.wantStr)
+			}
+		})
+	}
+
+	t.Run("Nil SID", func(t *testing.T) {
+		t.Parallel()
+
+		if (*SID)(nil).IsSelf() {
+			t.Error("IsSelf() = true, want false")
+		}
+	})
+}
+
+func TestSID_IsCreatorPlaceholder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sid  *SID
+		want bool
+	}{
+		{name: "CREATOR OWNER", sid: NewSID(3, 0), want: true},
+		{name: "CREATOR GROUP", sid: NewSID(3, 1), want: true},
+		{name: "OWNER RIGHTS", sid: NewSID(3, 4), want: false},
+		{name: "Creator authority with more sub-authorities", sid: NewSID(3, 0, 1), want: false},
+		{name: "Local System", sid: NewNTSID(18), want: false},
+		{name: "Nil SID", sid: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.sid.IsCreatorPlaceholder(); got != tt.want {
+				t.Errorf("IsCreatorPlaceholder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecomposeAccessMask(t *testing.T) {
+	t.Parallel()
+
+	// Every access right code is written, exactly once
+	if len(accessMaskComponentOrder) != len(accessMaskComponents) {
+		t.Fatalf("accessMaskComponentOrder has %d codes, want %d", len(accessMaskComponentOrder), len(accessMaskComponents))
+	}
+	for _, name := range accessMaskComponentOrder {
+		if _, ok := accessMaskComponents[name]; !ok {
+			t.Errorf("accessMaskComponentOrder has unknown code %s", name)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		mask          uint32
+		want          string
+		wantRemaining uint32
+	}{
+		{
+			// As written by Windows for the default security descriptor of a service, granted to BA
+			name: "Directory service rights and standard rights",
+			mask: 0x000F01FF,
+			want: "CCDCLCSWRPWPDTLOCRSDRCWDWO",
+		},
+		{
+			// As written by Windows for the default security descriptor of a service, granted to SY
+			name: "Service query rights",
+			mask: 0x000201FD,
+			want: "CCLCSWRPWPDTLOCRRC",
+		},
+		{
+			name: "Generic rights",
+			mask: 0xA0000000,
+			want: "GXGR",
+		},
+		{
+			name: "All codes",
+			mask: 0xF31F01FF,
+			want: "CCDCLCSWRPWPDTLOCRSDRCWDWOSYASMAGAGXGWGR",
+		},
+		{
+			name:          "Unknown bits",
+			mask:          0x00000205,
+			want:          "CCLC",
+			wantRemaining: 0x00000200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			components, remaining := decomposeAccessMask(tt.mask)
+			if got := strings.Join(components, ""); got != tt.want || remaining != tt.wantRemaining {
+				t.Errorf("decomposeAccessMask(%#08x) = (%s, %#x), want (%s, %#x)", tt.mask, got, remaining, tt.want, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestACE_SpecialRights(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sddl     string
+		wantMask uint32
+		wantSID  string
+	}{
+		{
+			name:     "Access system security",
+			sddl:     "D:(A;;AS;;;BA)",
+			wantMask: 0x01000000,
+			wantSID:  "S-1-5-32-544",
+		},
+		{
+			name:     "Maximum allowed",
+			sddl:     "D:(A;;MA;;;BA)",
+			wantMask: 0x02000000,
+			wantSID:  "S-1-5-32-544",
+		},
+		{
+			name:     "Both, with standard rights",
+			sddl:     "D:(A;;RCSYASMA;;;BA)",
+			wantMask: 0x03120000,
+			wantSID:  "S-1-5-32-544",
+		},
+		{
+			name:     "AS right for the AS trustee",
+			sddl:     "D:(A;;AS;;;AS)",
+			wantMask: 0x01000000,
+			wantSID:  "S-1-18-1",
+		},
+		{
+			name:     "WD and SY rights for the WD and SY trustees",
+			sddl:     "D:(A;;WD;;;WD)(A;;SY;;;SY)",
+			wantMask: 0x00040000,
+			wantSID:  "S-1-1-0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			ace := sd.DACL().aces[0]
+			if ace.accessMask != tt.wantMask {
+				t.Errorf("access mask = %#08x, want %#08x", ace.accessMask, tt.wantMask)
+			}
+			if got := ace.sid.rawString(); got != tt.wantSID {
+				t.Errorf("SID = %s, want %s", got, tt.wantSID)
+			}
+			if got := sd.String(); got != tt.sddl {
+				t.Errorf("String() = %s, want %s", got, tt.sddl)
+			}
+
+			back, err := FromBinary(sd.Binary())
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			if got := back.String(); got != tt.sddl {
+				t.Errorf("FromBinary().String() = %s, want %s", got, tt.sddl)
+			}
+		})
+	}
+
+	// The rights aren't trustees and the trustees aren't rights
+	for _, s := range []string{"D:(A;;MA;;;MA)", "D:(A;;BA;;;BA)"} {
+		if _, err := FromString(s); err == nil {
+			t.Errorf("FromString(%q) expected error, got nil", s)
+		}
+	}
+}
+
+func TestWellKnownTables(t *testing.T) {
+	t.Parallel()
+
+	sids := WellKnownSIDs()
+	if len(sids) == 0 || sids["SY"] != "S-1-5-18" || sids["WD"] != "S-1-1-0" {
+		t.Errorf("WellKnownSIDs() = %v, want SY and WD among the aliases", sids)
+	}
+	masks := WellKnownAccessMasks()
+	if len(masks) == 0 || masks["FA"] != FileAllAccess {
+		t.Errorf("WellKnownAccessMasks() = %v, want FA among the masks", masks)
+	}
+	components := AccessMaskComponents()
+	if len(components) == 0 || components["RC"] != ReadControl {
+		t.Errorf("AccessMaskComponents() = %v, want RC among the codes", components)
+	}
+
+	// The maps are copies: modifying them doesn't change the tables of the package
+	clear(sids)
+	clear(masks)
+	clear(components)
+	if len(WellKnownSIDs()) == 0 || len(WellKnownAccessMasks()) == 0 || len(AccessMaskComponents()) == 0 {
+		t.Fatal("modifying the returned maps changed the tables of the package")
+	}
+	if got := NewSID(5, 18).String(); got != "SY" {
+		t.Errorf("String() = %s, want SY", got)
+	}
+	if got, err := parseAccessMask("FARC"); err != nil || got != FileAllAccess {
+		t.Errorf("parseAccessMask() = 0x%08X, %v, want 0x%08X", got, err, FileAllAccess)
+	}
+}
+
+func TestSID_Text(t *testing.T) {
+	t.Parallel()
+
+	// SIDs are written in their full form as JSON map keys
+	system, user := NewSID(5, 18), NewSID(5, 21, 1, 2, 3, 1001)
+	data, err := json.Marshal(map[*SID]string{system: "system", user: "user"})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error = %v", err)
+	}
+	if got, want := string(data), `{"S-1-5-18":"system","S-1-5-21-1-2-3-1001":"user"}`; got != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+
+	// And parsed back from them
+	var keys map[string]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error = %v", err)
+	}
+	for key, name := range keys {
+		var sid SID
+		if err := sid.UnmarshalText([]byte(key)); err != nil {
+			t.Fatalf("UnmarshalText(%s) unexpected error = %v", key, err)
+		}
+		want := map[string]*SID{"system": system, "user": user}[name]
+		if !sid.Equal(want) {
+			t.Errorf("UnmarshalText(%s) = %s, want %s", key, &sid, want)
+		}
+	}
+
+	// As values, they round-trip through encoding/json directly, aliases being accepted
+	type config struct {
+		Trustees []*SID `json:"trustees"`
+	}
+	var c config
+	if err := json.Unmarshal([]byte(`{"trustees":["BA","S-1-5-21-1-2-3-1001"]}`), &c); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error = %v", err)
+	}
+	if len(c.Trustees) != 2 || !c.Trustees[0].Equal(NewSID(5, 32, 544)) || !c.Trustees[1].Equal(user) {
+		t.Errorf("json.Unmarshal() = %v, want [BA %s]", c.Trustees, user)
+	}
+	data, err = json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error = %v", err)
+	}
+	if got, want := string(data), `{"trustees":["S-1-5-32-544","S-1-5-21-1-2-3-1001"]}`; got != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+
+	// Domain relative aliases need a domain
+	var sid SID
+	if err := sid.UnmarshalText([]byte("DA")); !errors.Is(err, ErrMissingDomainInformation) {
+		t.Errorf("UnmarshalText(DA) error = %v, want %v", err, ErrMissingDomainInformation)
+	}
+}
+
+func TestAliasedSID_Text(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Trustees []AliasedSID `json:"trustees"`
+	}
+	c := config{Trustees: []AliasedSID{
+		{NewSID(5, 18)},
+		{NewSID(5, 32, 544)},
+		{NewSID(5, 21, 1, 2, 3, 500)}, // LA, relative to the domain
+		{NewSID(5, 21, 1, 2, 3, 1001)},
+	}}
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error = %v", err)
+	}
+	if got, want := string(data), `{"trustees":["SY","BA","S-1-5-21-1-2-3-500","S-1-5-21-1-2-3-1001"]}`; got != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+
+	var back config
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error = %v", err)
+	}
+	if len(back.Trustees) != len(c.Trustees) {
+		t.Fatalf("json.Unmarshal() = %v, want %v", back.Trustees, c.Trustees)
+	}
+	for i := range c.Trustees {
+		if !back.Trustees[i].Equal(c.Trustees[i].SID) {
+			t.Errorf("json.Unmarshal() trustee %d = %s, want %s", i, back.Trustees[i], c.Trustees[i])
+		}
+	}
+
+	// Every alias written is parsed back
+	for s := range wellKnownSids {
+		r, err := parseSIDString(s)
+		if err != nil {
+			t.Fatalf("parseSIDString(%s) unexpected error = %v", s, err)
+		}
+		sid, err := r.toSID(nil)
+		if err != nil {
+			t.Fatalf("toSID(%s) unexpected error = %v", s, err)
+		}
+		text, err := AliasedSID{sid}.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%s) unexpected error = %v", s, err)
+		}
+		var a AliasedSID
+		if err := a.UnmarshalText(text); err != nil {
+			t.Errorf("UnmarshalText(%s) unexpected error = %v", text, err)
+		} else if !a.Equal(sid) {
+			t.Errorf("UnmarshalText(%s) = %s, want %s", text, a, sid)
+		}
+	}
+
+	if _, err := (AliasedSID{}).MarshalText(); !errors.Is(err, ErrInvalidSIDFormat) {
+		t.Errorf("MarshalText() without SID error = %v, want %v", err, ErrInvalidSIDFormat)
+	}
+}
+
+func TestSecurityDescriptor_AddDACLAllowDeny(t *testing.T) {
+	t.Parallel()
+
+	system, everyone, users := NewSID(5, 18), NewSID(1, 0), NewSID(5, 32, 545)
+
+	tests := []struct {
+		name string
+		sddl string
+		add  func(sd *SecurityDescriptor)
+		want string
+	}{
+		{
+			name: "From scratch",
+			sddl: "O:BA",
+			add: func(sd *SecurityDescriptor) {
+				sd.AddDACLAllow(system, FileAllAccess, 0)
+				sd.AddDACLAllow(users, FileGenericRead, ACEFlagObjectInherit|ACEFlagContainerInherit)
+				sd.AddDACLDeny(everyone, Delete, 0)
+			},
+			want: "O:BAD:(D;;SD;;;WD)(A;;FA;;;SY)(A;OICI;FR;;;BU)",
+		},
+		{
+			name: "Defaulted descriptor",
+			sddl: "",
+			add: func(sd *SecurityDescriptor) {
+				sd.AddDACLDeny(everyone, FileWriteData, 0)
+			},
+			want: "D:(D;;DC;;;WD)",
+		},
+		{
+			name: "Existing DACL with inherited ACEs",
+			sddl: "D:AI(A;;FA;;;SY)(A;ID;FR;;;BU)",
+			add: func(sd *SecurityDescriptor) {
+				sd.AddDACLDeny(everyone, Delete, 0)
+				sd.AddDACLAllow(users, FileGenericExecute, 0)
+			},
+			want: "D:AI(D;;SD;;;WD)(A;;FA;;;SY)(A;;FX;;;BU)(A;ID;FR;;;BU)",
+		},
+		{
+			name: "NULL DACL replaced",
+			sddl: "D:NO_ACCESS_CONTROL",
+			add: func(sd *SecurityDescriptor) {
+				sd.AddDACLAllow(system, FileAllAccess, 0)
+			},
+			want: "D:(A;;FA;;;SY)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			tt.add(sd)
+
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+			if sd.IsDACLDefaulted() {
+				t.Errorf("IsDACLDefaulted() = true, want false")
+			}
+			if err := sd.Validate(); err != nil {
+				t.Fatalf("Validate() unexpected error = %v", err)
+			}
+
+			// The sizes and control flags are those of the parsed descriptor
+			compareSecurityDescriptors(t, sd, mustFromString(t, tt.want))
+		})
+	}
+
+	// The trustee is copied
+	sid := NewSID(5, 21, 1, 2, 3, 1001)
+	sd := mustFromString(t, "O:BA")
+	sd.AddDACLAllow(sid, FileAllAccess, 0)
+	sid.subAuthority[4] = 1002
+	if got, want := sd.String(), "O:BAD:(A;;FA;;;S-1-5-21-1-2-3-1001)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestSecurityDescriptor_ComponentStrings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                     string
+		sddl                     string
+		owner, group, dacl, sacl string
+	}{
+		{
+			name:  "Complete descriptor",
+			sddl:  "O:BAG:SYD:PAI(D;;FW;;;WD)(A;OICI;FA;;;SY)(A;ID;FR;;;S-1-5-21-1-2-3-1001)S:AI(AU;SAFA;FA;;;WD)(ML;;NW;;;LW)",
+			owner: "O:BA",
+			group: "G:SY",
+			dacl:  "D:PAI(D;;FW;;;WD)(A;OICI;FA;;;SY)(A;ID;FR;;;S-1-5-21-1-2-3-1001)",
+			sacl:  "S:AI(AU;SAFA;FA;;;WD)(ML;;NW;;;LW)",
+		},
+		{
+			name: "DACL only",
+			sddl: "D:(A;;FA;;;SY)",
+			dacl: "D:(A;;FA;;;SY)",
+		},
+		{
+			name:  "NULL ACLs",
+			sddl:  "O:SYD:PNO_ACCESS_CONTROLS:NO_ACCESS_CONTROL",
+			owner: "O:SY",
+			dacl:  "D:PNO_ACCESS_CONTROL",
+			sacl:  "S:NO_ACCESS_CONTROL",
+		},
+		{
+			name: "Empty descriptor",
+			sddl: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			for _, c := range []struct {
+				name   string
+				render func() (string, bool, error)
+				want   string
+			}{
+				{"OwnerString", sd.OwnerString, tt.owner},
+				{"GroupString", sd.GroupString, tt.group},
+				{"DACLString", sd.DACLString, tt.dacl},
+				{"SACLString", sd.SACLString, tt.sacl},
+			} {
+				got, ok, err := c.render()
+				if err != nil {
+					t.Fatalf("%s() unexpected error = %v", c.name, err)
+				}
+				if got != c.want || ok != (c.want != "") {
+					t.Errorf("%s() = (%s, %v), want (%s, %v)", c.name, got, ok, c.want, c.want != "")
+				}
+
+				// Each component is parsed back on its own
+				if ok {
+					if back := mustFromString(t, got).String(); back != got {
+						t.Errorf("%s() -> FromString() -> String() = %s, want %s", c.name, back, got)
+					}
+				}
+			}
+
+			// The components make up the full string
+			if got, want := tt.owner+tt.group+tt.dacl+tt.sacl, sd.String(); got != want {
+				t.Errorf("components = %s, want String() = %s", got, want)
+			}
+		})
+	}
+
+	// Invalid SIDs are reported instead of panicking
+	sd := mustFromString(t, "O:BAD:(A;;FA;;;SY)")
+	sd.ownerSID.subAuthority = make([]uint32, 16)
+	sd.dacl.aces[0].sid.subAuthority = make([]uint32, 16)
+	if _, ok, err := sd.OwnerString(); !ok || !errors.Is(err, ErrTooManySubAuthorities) {
+		t.Errorf("OwnerString() = (%v, %v), want (true, %v)", ok, err, ErrTooManySubAuthorities)
+	}
+	if _, ok, err := sd.DACLString(); !ok || !errors.Is(err, ErrTooManySubAuthorities) {
+		t.Errorf("DACLString() = (%v, %v), want (true, %v)", ok, err, ErrTooManySubAuthorities)
+	}
+}
+
+func TestSecurityDescriptor_SACLStates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		sddl        string
+		wantPresent bool
+		wantEmpty   bool
+		wantNull    bool
+		wantBinary  []byte // The SACL in the binary form, if any
+	}{
+		{
+			name: "No SACL",
+			sddl: "",
+		},
+		{
+			name:        "Empty SACL",
+			sddl:        "S:",
+			wantPresent: true,
+			wantEmpty:   true,
+			wantBinary: []byte{
+				0x02,       // Revision
+				0x00,       // Sbz1
+				0x08, 0x00, // Size (8 bytes - just header)
+				0x00, 0x00, // AceCount
+				0x00, 0x00, // Sbz2
+			},
+		},
+		{
+			name:        "NULL SACL",
+			sddl:        "S:NO_ACCESS_CONTROL",
+			wantPresent: true,
+			wantNull:    true,
+		},
+		{
+			name:        "Populated SACL",
+			sddl:        "S:(AU;SA;FA;;;WD)",
+			wantPresent: true,
+			wantBinary: []byte{
+				0x02,       // Revision
+				0x00,       // Sbz1
+				0x1C, 0x00, // Size (28 bytes)
+				0x01, 0x00, // AceCount
+				0x00, 0x00, // Sbz2
+				0x02, 0x40, 0x14, 0x00, // ACE header (SYSTEM_AUDIT_ACE_TYPE, SUCCESSFUL_ACCESS_ACE, 20 bytes)
+				0xFF, 0x01, 0x1F, 0x00, // Access mask (Full Access)
+				0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // Everyone
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parsed := mustFromString(t, tt.sddl)
+			data := parsed.Binary()
+			if tt.wantBinary != nil && !bytes.HasSuffix(data, tt.wantBinary) {
+				t.Errorf("Binary() = % x, want the SACL % x", data, tt.wantBinary)
+			}
+			decoded, err := FromBinary(data)
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+
+			// The state survives both the string and the binary forms
+			for form, sd := range map[string]*SecurityDescriptor{"FromString": parsed, "FromBinary": decoded} {
+				if got := sd.String(); got != tt.sddl {
+					t.Errorf("%s().String() = %s, want %s", form, got, tt.sddl)
+				}
+				if got := sd.control&seSACLPresent != 0; got != tt.wantPresent {
+					t.Errorf("%s() SE_SACL_PRESENT = %v, want %v", form, got, tt.wantPresent)
+				}
+				if got := sd.HasEmptySACL(); got != tt.wantEmpty {
+					t.Errorf("%s().HasEmptySACL() = %v, want %v", form, got, tt.wantEmpty)
+				}
+				if got := sd.HasNullSACL(); got != tt.wantNull {
+					t.Errorf("%s().HasNullSACL() = %v, want %v", form, got, tt.wantNull)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityDescriptor_BinaryLayout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sddl string
+		want []LayoutEntry
+	}{
+		{
+			name: "Complete security descriptor",
+			sddl: "O:SYG:WDD:(A;;FA;;;SY)S:(AU;SA;FA;;;SY)",
+			want: []LayoutEntry{
+				{Name: "Header", Offset: 0, Length: 20},
+				{Name: "Owner", Offset: 0x14, Length: 12},
+				{Name: "Group", Offset: 0x20, Length: 12},
+				{Name: "SACL", Offset: 0x2C, Length: 28},
+				{Name: "DACL", Offset: 0x48, Length: 28},
+			},
+		},
+		{
+			name: "Empty",
+			sddl: "",
+			want: []LayoutEntry{
+				{Name: "Header", Offset: 0, Length: 20},
+			},
+		},
+		{
+			name: "NULL DACL and empty SACL",
+			sddl: "G:BAD:NO_ACCESS_CONTROLS:",
+			want: []LayoutEntry{
+				{Name: "Header", Offset: 0, Length: 20},
+				{Name: "Group", Offset: 0x14, Length: 16},
+				{Name: "SACL", Offset: 0x24, Length: 8},
+			},
+		},
+	}
+
+	// headerOffsets are the positions of the offsets of the components in the header
+	headerOffsets := map[string]int{"Owner": 4, "Group": 8, "SACL": 12, "DACL": 16}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			got := sd.BinaryLayout()
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("BinaryLayout() = %+v, want %+v", got, tt.want)
+			}
+
+			// The layout matches the offsets written by Binary, and covers all of its bytes
+			data := sd.Binary()
+			end := 0
+			for _, entry := range got {
+				if pos, ok := headerOffsets[entry.Name]; ok {
+					if offset := int(binary.LittleEndian.Uint32(data[pos:])); offset != entry.Offset {
+						t.Errorf("Binary() %s offset = %d, want %d", entry.Name, offset, entry.Offset)
+					}
+				}
+				end = entry.Offset + entry.Length
+			}
+			if end != len(data) {
+				t.Errorf("BinaryLayout() ends at %d, want len(Binary()) = %d", end, len(data))
+			}
+		})
+	}
+}
+
+func TestSID_IsLogonSession(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "Logon session", input: "S-1-5-5-0-123456", want: true},
+		{name: "Logon session with a high part", input: "S-1-5-5-4294967295-4294967295", want: true},
+		{name: "Missing logon identifier part", input: "S-1-5-5-0", want: false},
+		{name: "Extra sub-authority", input: "S-1-5-5-0-123456-1", want: false},
+		{name: "Other authority", input: "S-1-16-5-0-123456", want: false},
+		{name: "SYSTEM", input: "S-1-5-18", want: false},
+		{name: "Domain SID", input: "S-1-5-21-5-2-3", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sid, err := parseSIDString(tt.input)
+			if err != nil {
+				t.Fatalf("parseSIDString() unexpected error = %v", err)
+			}
+			s, err := sid.toSID(nil)
+			if err != nil {
+				t.Fatalf("toSID() unexpected error = %v", err)
+			}
+			if got := s.IsLogonSession(); got != tt.want {
+				t.Errorf("IsLogonSession() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var nilSID *SID
+	if nilSID.IsLogonSession() {
+		t.Error("IsLogonSession() = true for a nil SID, want false")
+	}
+
+	// Logon session SIDs have no alias, and round-trip through both forms
+	const input = "D:(A;;GA;;;S-1-5-5-0-123456)"
+	sd := mustFromString(t, input)
+	decoded, err := FromBinary(sd.Binary())
+	if err != nil {
+		t.Fatalf("FromBinary() unexpected error = %v", err)
+	}
+	for form, got := range map[string]*SecurityDescriptor{"FromString": sd, "FromBinary": decoded} {
+		if str := got.String(); str != input {
+			t.Errorf("%s().String() = %s, want %s", form, str, input)
+		}
+		if sid := got.DACL().ACEs()[0].SID(); !sid.IsLogonSession() {
+			t.Errorf("%s() SID %s is not a logon session SID", form, sid)
+		}
+	}
+}
+
+func TestSecurityDescriptor_String_ComponentOrder(t *testing.T) {
+	t.Parallel()
+
+	const want = "O:SYG:BAD:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)"
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Canonical order", input: want},
+		{name: "Reversed order", input: "S:(AU;SA;FA;;;WD)D:(A;;FA;;;SY)G:BAO:SY"},
+		{name: "ACLs first", input: "D:(A;;FA;;;SY)S:(AU;SA;FA;;;WD)O:SYG:BA"},
+		{name: "Group first", input: "G:BAS:(AU;SA;FA;;;WD)O:SYD:(A;;FA;;;SY)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.input)
+			if got := sd.String(); got != want {
+				t.Errorf("String() = %s, want %s", got, want)
+			}
+			decoded, err := FromBinary(sd.Binary())
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			if got := decoded.String(); got != want {
+				t.Errorf("FromBinary().String() = %s, want %s", got, want)
+			}
+		})
+	}
+
+	// The order doesn't depend on which components are present, including NULL ACLs
+	sd := mustFromString(t, "S:NO_ACCESS_CONTROLD:NO_ACCESS_CONTROLG:BA")
+	if got, want := sd.String(), "G:BAD:NO_ACCESS_CONTROLS:NO_ACCESS_CONTROL"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestACE_With(t *testing.T) {
+	t.Parallel()
+
+	domainUser := NewSID(5, 21, 1000000000, 2000000000, 3000000000, 1001)
+
+	tests := []struct {
+		name     string
+		ace      string
+		update   func(*ACE) *ACE
+		want     string
+		wantSize int
+	}{
+		{
+			name:     "Longer SID",
+			ace:      "D:(A;OICI;FA;;;SY)",
+			update:   func(e *ACE) *ACE { return e.WithSID(domainUser) },
+			want:     "(A;OICI;FA;;;S-1-5-21-1000000000-2000000000-3000000000-1001)",
+			wantSize: 36,
+		},
+		{
+			name:     "Shorter SID",
+			ace:      "D:(A;;FA;;;S-1-5-21-1000000000-2000000000-3000000000-1001)",
+			update:   func(e *ACE) *ACE { return e.WithSID(NewSID(1, 0)) },
+			want:     "(A;;FA;;;WD)",
+			wantSize: 20,
+		},
+		{
+			name:     "Object ACE SID",
+			ace:      "D:(OA;;CR;ab721a54-1e2f-11d0-9819-00aa0040529b;;WD)",
+			update:   func(e *ACE) *ACE { return e.WithSID(NewSID(5, 18)) },
+			want:     "(OA;;CR;ab721a54-1e2f-11d0-9819-00aa0040529b;;SY)",
+			wantSize: 40,
+		},
+		{
+			name:     "Mask",
+			ace:      "D:(D;CI;FA;;;BG)",
+			update:   func(e *ACE) *ACE { return e.WithMask(FileGenericWrite) },
+			want:     "(D;CI;FW;;;BG)",
+			wantSize: 24,
+		},
+		{
+			name:     "Flags",
+			ace:      "D:(A;OICIID;FR;;;BU)",
+			update:   func(e *ACE) *ACE { return e.WithFlags(ACEFlagObjectInherit | ACEFlagContainerInherit) },
+			want:     "(A;OICI;FR;;;BU)",
+			wantSize: 24,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ace := mustFromString(t, tt.ace).DACL().ACEs()[0]
+			before := ace.String()
+
+			got := tt.update(&ace)
+			if s := got.String(); s != tt.want {
+				t.Errorf("String() = %s, want %s", s, tt.want)
+			}
+			if ace.String() != before {
+				t.Errorf("the original ACE was modified: %s, want %s", ace.String(), before)
+			}
+
+			// The copy serializes with a header size matching its content
+			data := got.Binary()
+			if len(data) != tt.wantSize {
+				t.Errorf("len(Binary()) = %d, want %d", len(data), tt.wantSize)
+			}
+			if size := int(binary.LittleEndian.Uint16(data[2:4])); size != tt.wantSize {
+				t.Errorf("Binary() AceSize = %d, want %d", size, tt.wantSize)
+			}
+		})
+	}
+
+	// The copy doesn't share the SID it was given
+	sid := NewSID(5, 18)
+	ace := newACE(accessAllowedACEType, 0, FileAllAccess, NewSID(1, 0)).WithSID(sid)
+	sid.subAuthority[0] = 19
+	if got := ace.String(); got != "(A;;FA;;;SY)" {
+		t.Errorf("String() = %s after modifying the SID given to WithSID, want (A;;FA;;;SY)", got)
+	}
+}
+
+func TestSID_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		sid     *SID
+		wantErr error
+	}{
+		{name: "Valid", sid: NewNTSID(18)},
+		{name: "Revision 0", sid: &SID{revision: 0, identifierAuthority: 5, subAuthority: []uint32{18}}, wantErr: ErrInvalidSIDFormat},
+		{name: "Authority over 48 bits", sid: &SID{revision: 1, identifierAuthority: 1 << 48}, wantErr: ErrInvalidAuthority},
+		{name: "16 sub-authorities", sid: &SID{revision: 1, identifierAuthority: 5, subAuthority: make([]uint32, 16)}, wantErr: ErrTooManySubAuthorities},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.sid.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -0,0 +1,143 @@
+package sddl
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SimplifyOptions selects the components that SecurityDescriptor.Simplify drops.
+type SimplifyOptions struct {
+	// DropSACL removes the SACL, along with the control flags describing it.
+	DropSACL bool
+	// DropObjectTypes downgrades object ACEs to the matching non-object ACEs (e.g. OA to A),
+	// dropping their object type and inherited object type GUIDs.
+	DropObjectTypes bool
+	// DropConditions downgrades callback ACEs to the matching non-callback ACEs (e.g. XA,
+	// ACCESS_ALLOWED_CALLBACK_ACE_TYPE, to A), dropping the conditional expressions they carry.
+	DropConditions bool
+}
+
+// objectACETypeBases maps each object ACE type to the matching non-object ACE type
+var objectACETypeBases = map[byte]byte{
+	accessAllowedObjectACEType:         accessAllowedACEType,
+	accessDeniedObjectACEType:          accessDeniedACEType,
+	systemAuditObjectACEType:           systemAuditACEType,
+	systemAlarmObjectACEType:           systemAlarmACEType,
+	accessAllowedCallbackObjectACEType: accessAllowedCallbackACEType,
+	accessDeniedCallbackObjectACEType:  accessDeniedCallbackACEType,
+	systemAuditCallbackObjectACEType:   systemAuditCallbackACEType,
+	systemAlarmCallbackObjectACEType:   systemAlarmCallbackACEType,
+}
+
+// callbackACETypeBases maps each callback ACE type to the matching non-callback ACE type
+var callbackACETypeBases = map[byte]byte{
+	accessAllowedCallbackACEType:       accessAllowedACEType,
+	accessDeniedCallbackACEType:        accessDeniedACEType,
+	accessAllowedCallbackObjectACEType: accessAllowedObjectACEType,
+	accessDeniedCallbackObjectACEType:  accessDeniedObjectACEType,
+	systemAuditCallbackACEType:         systemAuditACEType,
+	systemAlarmCallbackACEType:         systemAlarmACEType,
+	systemAuditCallbackObjectACEType:   systemAuditObjectACEType,
+	systemAlarmCallbackObjectACEType:   systemAlarmObjectACEType,
+}
+
+// Simplify returns a copy of the security descriptor without the components selected by opts, for
+// systems that only support basic descriptors. It also returns a description of each dropped component,
+// e.g. "SACL" or "DACL ACE 2: object types".
+//
+// Whatever the options, Simplify drops the components that this package can decode but not represent
+// in SDDL: ACEs of unknown type, kept as raw data, and the bytes found after the SID of decoded ACEs.
+// The result therefore converts losslessly to binary and SDDL and back, except for the application data
+// that this package can't decode, such as a malformed conditional expression, if DropConditions is not set.
+//
+// The receiver is not modified.
+func (sd *SecurityDescriptor) Simplify(opts SimplifyOptions) (*SecurityDescriptor, []string) {
+	var dropped []string
+
+	simplified := &SecurityDescriptor{
+		revision: sd.revision,
+		sbzl:     sd.sbzl,
+		control:  sd.control,
+	}
+	if sd.ownerSID != nil {
+		simplified.ownerSID = sd.ownerSID.clone()
+	}
+	if sd.groupSID != nil {
+		simplified.groupSID = sd.groupSID.clone()
+	}
+	if sd.dacl != nil {
+		simplified.dacl = sd.dacl.simplify(opts, &dropped)
+	}
+
+	switch {
+	case opts.DropSACL:
+		if sd.sacl != nil || sd.control&seSACLPresent != 0 {
+			dropped = append(dropped, "SACL")
+		}
+		simplified.control &^= saclControlMask
+	case sd.sacl != nil:
+		simplified.sacl = sd.sacl.simplify(opts, &dropped)
+	}
+
+	return simplified, dropped
+}
+
+// simplify returns a copy of the ACL without the components selected by opts, see SecurityDescriptor.Simplify.
+// A description of each dropped component is appended to dropped.
+func (a *ACL) simplify(opts SimplifyOptions, dropped *[]string) *ACL {
+	simplified := &ACL{
+		aclRevision: a.aclRevision,
+		aclType:     a.aclType,
+		control:     a.control,
+		sddlFlags:   a.sddlFlags,
+	}
+
+	for i := range a.aces {
+		prefix := fmt.Sprintf("%sACL ACE %d", a.aclType, i)
+		ace := a.aces[i].clone()
+		if ace.header == nil {
+			simplified.aces = append(simplified.aces, *ace)
+			continue
+		}
+
+		if ace.isRaw() {
+			*dropped = append(*dropped, fmt.Sprintf("%s: unknown ACE type 0x%02X", prefix, ace.header.aceType))
+			continue
+		}
+
+		// Callback ACEs carry their conditional expression in their application data
+		if base, ok := callbackACETypeBases[ace.header.aceType]; ok && opts.DropConditions {
+			ace.header.aceType = base
+			if len(ace.applicationData) > 0 {
+				*dropped = append(*dropped, prefix+": conditional expression")
+			}
+			ace.applicationData = nil
+		}
+		if len(ace.padding) > 0 {
+			*dropped = append(*dropped, fmt.Sprintf("%s: %d padding bytes", prefix, len(ace.padding)))
+			ace.padding = nil
+		}
+
+		if base, ok := objectACETypeBases[ace.header.aceType]; ok && opts.DropObjectTypes {
+			ace.header.aceType = base
+			if ace.objectType != nil || ace.inheritedObjectType != nil {
+				*dropped = append(*dropped, prefix+": object types")
+			}
+			ace.objectType = nil
+			ace.inheritedObjectType = nil
+		}
+
+		ace.header.aceSize = uint16(ace.BinarySize())
+		simplified.aces = append(simplified.aces, *ace)
+	}
+	simplified.updateSize()
+
+	// Without object ACEs, the directory service revision is no longer needed
+	if simplified.aclRevision == aclRevisionDS && !slices.ContainsFunc(simplified.aces, func(ace ACE) bool {
+		return ace.header != nil && isObjectACEType(ace.header.aceType)
+	}) {
+		simplified.aclRevision = aclRevision
+	}
+
+	return simplified
+}
@@ -0,0 +1,134 @@
+package sddl
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestSecurityDescriptor_Simplify(t *testing.T) {
+	t.Parallel()
+
+	// richSD returns a descriptor with an object ACE, padding, a callback ACE carrying a conditional
+	// expression, an ACE of unknown type, a SACL and resource manager control bits
+	richSD := func(t *testing.T) *SecurityDescriptor {
+		t.Helper()
+
+		sd, err := FromString("O:BAG:SYD:PAI(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;WD)(A;;FA;;;SY)S:(AU;SA;FA;;;WD)")
+		if err != nil {
+			t.Fatalf("FromString() unexpected error = %v", err)
+		}
+
+		sd.dacl.aces[1].padding = []byte{0, 0, 0, 0}
+		sd.dacl.aces[1].header.aceSize += 4
+
+		callback := newACE(accessAllowedCallbackACEType, 0, FileAllAccess, NewSID(1, 0))
+		callback.applicationData = []byte{'a', 'r', 't', 'x', 0x01, 0x00, 0x00, 0x00}
+		callback.header.aceSize = uint16(callback.BinarySize())
+
+		raw := &ACE{
+			header:     &aceHeader{aceType: 0x14, aceSize: 12},
+			accessMask: 1,
+			rawData:    []byte{1, 2, 3, 4},
+		}
+
+		sd.dacl.aces = append(sd.dacl.aces, *callback, *raw)
+		sd.dacl.updateSize()
+		sd.SetResourceManagerControl(0x5A)
+		if err := sd.Validate(); err != nil {
+			t.Fatalf("Validate() unexpected error = %v", err)
+		}
+		return sd
+	}
+
+	tests := []struct {
+		name        string
+		opts        SimplifyOptions
+		want        string
+		wantDropped []string
+	}{
+		{
+			name: "Everything",
+			opts: SimplifyOptions{DropSACL: true, DropObjectTypes: true, DropConditions: true},
+			want: "O:BAG:SYD:PAI(A;;CR;;;WD)(A;;FA;;;SY)(A;;FA;;;WD)",
+			wantDropped: []string{
+				"DACL ACE 0: object types",
+				"DACL ACE 1: 4 padding bytes",
+				"DACL ACE 2: conditional expression",
+				"DACL ACE 3: unknown ACE type 0x14",
+				"SACL",
+			},
+		},
+		{
+			name: "Only what can't be represented",
+			opts: SimplifyOptions{},
+			want: "O:BAG:SYD:PAI(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;WD)(A;;FA;;;SY)(XA;;FA;;;WD)S:(AU;SA;FA;;;WD)",
+			wantDropped: []string{
+				"DACL ACE 1: 4 padding bytes",
+				"DACL ACE 3: unknown ACE type 0x14",
+			},
+		},
+		{
+			name: "Conditions only",
+			opts: SimplifyOptions{DropConditions: true},
+			want: "O:BAG:SYD:PAI(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;WD)(A;;FA;;;SY)(A;;FA;;;WD)S:(AU;SA;FA;;;WD)",
+			wantDropped: []string{
+				"DACL ACE 1: 4 padding bytes",
+				"DACL ACE 2: conditional expression",
+				"DACL ACE 3: unknown ACE type 0x14",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := richSD(t)
+			before := sd.Binary()
+
+			got, dropped := sd.Simplify(tt.opts)
+			if s := got.String(); s != tt.want {
+				t.Errorf("Simplify().String() = %s, want %s", s, tt.want)
+			}
+			if !slices.Equal(dropped, tt.wantDropped) {
+				t.Errorf("Simplify() dropped = %q, want %q", dropped, tt.wantDropped)
+			}
+			if err := got.Validate(); err != nil {
+				t.Fatalf("Simplify() returned an invalid descriptor: %v", err)
+			}
+			if rmControl, ok := got.ResourceManagerControl(); !ok || rmControl != 0x5A {
+				t.Errorf("Simplify().ResourceManagerControl() = %#x, %v, want 0x5a, true", rmControl, ok)
+			}
+
+			// The simplified descriptor round-trips losslessly through binary
+			data := got.Binary()
+			decoded, err := FromBinary(data)
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			if !bytes.Equal(decoded.Binary(), data) {
+				t.Errorf("FromBinary().Binary() = % x, want % x", decoded.Binary(), data)
+			}
+
+			// and, without conditional expressions, through SDDL
+			if tt.opts.DropConditions {
+				parsed, err := FromString(got.String())
+				if err != nil {
+					t.Fatalf("FromString() unexpected error = %v", err)
+				}
+				if s := parsed.String(); s != tt.want {
+					t.Errorf("FromString().String() = %s, want %s", s, tt.want)
+				}
+				if dacl := parsed.DACL().Binary(); !bytes.Equal(dacl, got.DACL().Binary()) {
+					t.Errorf("FromString().DACL().Binary() = % x, want % x", dacl, got.DACL().Binary())
+				}
+			}
+
+			// The receiver must not be modified
+			if after := sd.Binary(); !bytes.Equal(after, before) {
+				t.Errorf("receiver modified: got % x, want % x", after, before)
+			}
+		})
+	}
+}
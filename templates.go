@@ -0,0 +1,118 @@
+package sddl
+
+// NewRestrictiveFileSD returns a security descriptor for a file that only its owner and the system can
+// modify. The DACL is protected, so no permission is inherited from the parent directory, and everybody
+// not listed is implicitly denied. The resulting SDDL string is:
+//
+//	O:<owner>G:<group>D:P(A;;FA;;;<owner>)(A;;FA;;;SY)(A;;FR;;;BA)
+//
+// Granting:
+//   - Full access (FA) to the owner
+//   - Full access (FA) to Local System (SY)
+//   - Read access (FR) to Built-in Administrators (BA)
+//
+// Parameters:
+//   - owner: the owner of the file, it must not be nil
+//   - group: the primary group of the file, or nil to omit it (the G: component is then left out)
+//
+// The SIDs are copied, so the caller can keep modifying them.
+func NewRestrictiveFileSD(owner, group *SID) *SecurityDescriptor {
+	return newRestrictiveSD(owner, group, 0)
+}
+
+// NewRestrictiveDirectorySD returns a security descriptor for a directory granting the same access as
+// NewRestrictiveFileSD, to the directory and everything created inside it. The resulting SDDL string is:
+//
+//	O:<owner>G:<group>D:P(A;OICI;FA;;;<owner>)(A;OICI;FA;;;SY)(A;OICI;FR;;;BA)
+//
+// Parameters:
+//   - owner: the owner of the directory, it must not be nil
+//   - group: the primary group of the directory, or nil to omit it (the G: component is then left out)
+//
+// The SIDs are copied, so the caller can keep modifying them.
+func NewRestrictiveDirectorySD(owner, group *SID) *SecurityDescriptor {
+	return newRestrictiveSD(owner, group, objectInheritACE|containerInheritACE)
+}
+
+// newRestrictiveSD builds the descriptors of NewRestrictiveFileSD and NewRestrictiveDirectorySD,
+// setting aceFlags on every ACE of the DACL.
+func newRestrictiveSD(owner, group *SID, aceFlags byte) *SecurityDescriptor {
+	if owner == nil {
+		panic("restrictive security descriptor requires an owner")
+	}
+
+	const control = seSelfRelative | seDACLPresent | seDACLProtected
+
+	dacl := &ACL{
+		aclRevision: aclRevision,
+		aclType:     "D",
+		control:     aclControl("D", control),
+		aces: []ACE{
+			*newACE(accessAllowedACEType, aceFlags, 0x001f01ff, owner.clone()),
+			*newACE(accessAllowedACEType, aceFlags, 0x001f01ff, NewNTSID(18)),
+			*newACE(accessAllowedACEType, aceFlags, 0x00120089, NewNTSID(32, 544)),
+		},
+	}
+	dacl.updateSize()
+
+	sd := &SecurityDescriptor{
+		revision: 1,
+		control:  control,
+		ownerSID: owner.clone(),
+		dacl:     dacl,
+	}
+	if group != nil {
+		sd.groupSID = group.clone()
+	}
+
+	return sd
+}
+
+// NewAuditSACL returns a SACL auditing the access to mask by each of the trustees, with one SYSTEM_AUDIT
+// ACE per trustee, in order. success audits the successful accesses (SA flag) and failure the failed
+// ones (FA flag): if neither is set, the ACEs audit nothing. For example, auditing all access by
+// Everyone, NewAuditSACL([]*SID{NewSID(1, 0)}, 0x001f01ff, true, true), gives the SDDL string:
+//
+//	S:(AU;SAFA;FA;;;WD)
+//
+// The ACL has the SE_SACL_PRESENT control flag. The SIDs are copied, so the caller can keep modifying them.
+// It panics if a trustee is nil.
+func NewAuditSACL(trustees []*SID, mask uint32, success, failure bool) *ACL {
+	var aceFlags byte
+	if success {
+		aceFlags |= successfulAccessACE
+	}
+	if failure {
+		aceFlags |= failedAccessACE
+	}
+
+	sacl := &ACL{
+		aclRevision: aclRevision,
+		aclType:     "S",
+		control:     aclControl("S", seSACLPresent),
+	}
+	for _, trustee := range trustees {
+		if trustee == nil {
+			panic("audit SACL requires non-nil trustees")
+		}
+		sacl.aces = append(sacl.aces, *newACE(systemAuditACEType, aceFlags, mask, trustee.clone()))
+	}
+	sacl.updateSize()
+
+	return sacl
+}
+
+// newACE returns an ACE of the given type, flags and access mask for the trustee sid, with its size
+// computed from its content.
+func newACE(aceType, aceFlags byte, accessMask uint32, sid *SID) *ACE {
+	ace := &ACE{
+		header: &aceHeader{
+			aceType:  aceType,
+			aceFlags: aceFlags,
+		},
+		accessMask: accessMask,
+		sid:        sid,
+	}
+	ace.header.aceSize = uint16(ace.BinarySize())
+	return ace
+}
@@ -0,0 +1,112 @@
+package sddl
+
+import (
+	"testing"
+)
+
+func TestNewRestrictiveSD(t *testing.T) {
+	t.Parallel()
+
+	owner := &SID{revision: 1, identifierAuthority: 5, subAuthority: []uint32{21, 1, 2, 3, 1001}}
+	group := &SID{revision: 1, identifierAuthority: 5, subAuthority: []uint32{21, 1, 2, 3, 513}}
+
+	tests := []struct {
+		name string
+		sd   *SecurityDescriptor
+		want string
+	}{
+		{
+			name: "File with group",
+			sd:   NewRestrictiveFileSD(owner, group),
+			want: "O:S-1-5-21-1-2-3-1001G:S-1-5-21-1-2-3-513D:P(A;;FA;;;S-1-5-21-1-2-3-1001)(A;;FA;;;SY)(A;;FR;;;BA)",
+		},
+		{
+			name: "File without group",
+			sd:   NewRestrictiveFileSD(owner, nil),
+			want: "O:S-1-5-21-1-2-3-1001D:P(A;;FA;;;S-1-5-21-1-2-3-1001)(A;;FA;;;SY)(A;;FR;;;BA)",
+		},
+		{
+			name: "Directory",
+			sd:   NewRestrictiveDirectorySD(owner, group),
+			want: "O:S-1-5-21-1-2-3-1001G:S-1-5-21-1-2-3-513D:P(A;OICI;FA;;;S-1-5-21-1-2-3-1001)(A;OICI;FA;;;SY)(A;OICI;FR;;;BA)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+			if err := tt.sd.Validate(); err != nil {
+				t.Fatalf("Validate() unexpected error = %v", err)
+			}
+
+			back, err := FromBinary(tt.sd.Binary())
+			if err != nil {
+				t.Fatalf("Binary() -> FromBinary() unexpected error = %v", err)
+			}
+			compareSecurityDescriptors(t, back, tt.sd)
+		})
+	}
+}
+
+func TestNewAuditSACL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		trustees []*SID
+		mask     uint32
+		success  bool
+		failure  bool
+		want     string
+	}{
+		{
+			name:     "All access by Everyone",
+			trustees: []*SID{NewSID(1, 0)},
+			mask:     0x001f01ff,
+			success:  true,
+			failure:  true,
+			want:     "S:(AU;SAFA;FA;;;WD)",
+		},
+		{
+			name:     "Failed writes by several trustees",
+			trustees: []*SID{NewNTSID(11), NewNTSID(32, 545)},
+			mask:     0x00120116,
+			failure:  true,
+			want:     "S:(AU;FA;FW;;;AU)(AU;FA;FW;;;BU)",
+		},
+		{
+			name:     "Successful reads",
+			trustees: []*SID{NewNTSID(18)},
+			mask:     0x00120089,
+			success:  true,
+			want:     "S:(AU;SA;FR;;;SY)",
+		},
+		{
+			name: "No trustee",
+			mask: 0x001f01ff,
+			want: "S:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sacl := NewAuditSACL(tt.trustees, tt.mask, tt.success, tt.failure)
+			sd := &SecurityDescriptor{revision: 1, control: seSelfRelative | seSACLPresent, sacl: sacl}
+			if got := sd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+			if err := sd.Validate(); err != nil {
+				t.Fatalf("Validate() unexpected error = %v", err)
+			}
+
+			// The SACL is the same as the one parsed from its SDDL string, sizes included
+			compareACLs(t, "NewAuditSACL()", sacl, mustFromString(t, tt.want).SACL())
+		})
+	}
+}
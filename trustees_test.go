@@ -0,0 +1,103 @@
+package sddl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSecurityDescriptor_DenyOnlyTrustees(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "Allowed and denied trustee excluded, deny-only included",
+			input: "D:(D;;FW;;;BU)(D;;FA;;;BG)(A;;FR;;;BU)(A;;FA;;;SY)",
+			want:  []string{"BG"},
+		},
+		{
+			name:  "Trustee denied several times",
+			input: "D:(D;;FW;;;WD)(D;;FX;;;WD)(A;;FA;;;SY)",
+			want:  []string{"WD"},
+		},
+		{
+			name:  "Inherit-only allow doesn't count",
+			input: "D:(D;;FW;;;BU)(A;OICIIO;FR;;;BU)",
+			want:  []string{"BU"},
+		},
+		{
+			name:  "Inherit-only deny doesn't count",
+			input: "D:(D;OICIIO;FW;;;BG)(A;;FA;;;SY)",
+			want:  nil,
+		},
+		{
+			name:  "Object ACEs",
+			input: "D:(OD;;CR;00299570-246d-11d0-a768-00aa006e0529;;AU)(OA;;RP;;;WD)",
+			want:  []string{"AU"},
+		},
+		{
+			name:  "Allow only",
+			input: "D:(A;;FA;;;SY)(A;;FR;;;BU)",
+			want:  nil,
+		},
+		{
+			name:  "No DACL",
+			input: "O:SY",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd, err := FromString(tt.input)
+			if err != nil {
+				t.Fatalf("FromString() unexpected error = %v", err)
+			}
+
+			var got []string
+			for _, sid := range sd.DenyOnlyTrustees() {
+				got = append(got, sid.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DenyOnlyTrustees() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSID_Equal(t *testing.T) {
+	t.Parallel()
+
+	system := &SID{revision: 1, identifierAuthority: 5, subAuthority: []uint32{18}}
+
+	tests := []struct {
+		name  string
+		a, b  *SID
+		equal bool
+	}{
+		{name: "Same SID", a: system, b: &SID{revision: 1, identifierAuthority: 5, subAuthority: []uint32{18}}, equal: true},
+		{name: "Different sub-authority", a: system, b: &SID{revision: 1, identifierAuthority: 5, subAuthority: []uint32{19}}, equal: false},
+		{name: "Different authority", a: system, b: &SID{revision: 1, identifierAuthority: 1, subAuthority: []uint32{18}}, equal: false},
+		{name: "Prefix", a: system, b: &SID{revision: 1, identifierAuthority: 5, subAuthority: []uint32{18, 1}}, equal: false},
+		{name: "Nil and non-nil", a: nil, b: system, equal: false},
+		{name: "Both nil", a: nil, b: nil, equal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.a.Equal(tt.b); got != tt.equal {
+				t.Errorf("Equal() = %v, want %v", got, tt.equal)
+			}
+			if got := tt.b.Equal(tt.a); got != tt.equal {
+				t.Errorf("Equal() reversed = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+}
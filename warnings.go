@@ -0,0 +1,63 @@
+package sddl
+
+import "fmt"
+
+// SecurityWarnings returns a description of each common misconfiguration found in the security descriptor,
+// such as "NULL DACL: everyone is granted full access", or nil if there is none. The conditions are:
+//   - A NULL DACL, or no DACL at all: everyone is granted full access
+//   - An allow ACE granting full access to Everyone (WD), be it GA or all the FA rights
+//   - An explicit deny ACE following an explicit allow ACE: the DACL is not in canonical order, so the
+//     deny ACE is ignored for the rights the allow ACE already granted
+//   - A SACL that is present but empty or NULL, so that nothing is audited. SACLs that only hold other ACEs,
+//     such as a mandatory label (ML), are not reported, as they are used for these ACEs rather than auditing
+//
+// It is advisory only: the security descriptor may be intended, and it is left unchanged.
+func (sd *SecurityDescriptor) SecurityWarnings() []string {
+	var warnings []string
+
+	switch {
+	case sd.HasNullDACL():
+		warnings = append(warnings, "NULL DACL: everyone is granted full access")
+	case sd.dacl == nil:
+		warnings = append(warnings, "no DACL: everyone is granted full access")
+	default:
+		warnings = append(warnings, sd.dacl.securityWarnings()...)
+	}
+
+	if sd.control&seSACLPresent != 0 && (sd.sacl == nil || len(sd.sacl.aces) == 0) {
+		warnings = append(warnings, "empty SACL: nothing is audited")
+	}
+
+	return warnings
+}
+
+// securityWarnings returns the warnings of SecurityWarnings about the ACEs of a DACL.
+func (a *ACL) securityWarnings() []string {
+	var warnings []string
+
+	everyone := NewSID(1, 0)
+	firstAllow := -1
+	for i := range a.aces {
+		ace := &a.aces[i]
+		if ace.header == nil {
+			continue
+		}
+		explicit := ace.header.aceFlags&inheritedACE == 0
+
+		switch {
+		case isAllowACEType(ace.header.aceType):
+			if ace.sid.Equal(everyone) && (ace.accessMask&GenericAll != 0 || ace.accessMask&FileAllAccess == FileAllAccess) {
+				warnings = append(warnings, fmt.Sprintf("DACL ACE %d: Everyone is granted full access", i))
+			}
+			if explicit && firstAllow < 0 {
+				firstAllow = i
+			}
+		case isDenyACEType(ace.header.aceType):
+			if explicit && firstAllow >= 0 {
+				warnings = append(warnings, fmt.Sprintf("DACL ACE %d: deny ACE after allow ACE %d, not in canonical order", i, firstAllow))
+			}
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,97 @@
+package sddl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSecurityDescriptor_SecurityWarnings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sddl string
+		want []string
+	}{
+		{
+			name: "Sound descriptor",
+			sddl: "O:BAG:SYD:PAI(D;;FW;;;AN)(A;;FA;;;SY)(A;;FR;;;WD)(D;ID;FA;;;BG)(A;ID;FA;;;BA)S:(AU;FA;FA;;;WD)",
+		},
+		{
+			name: "NULL DACL",
+			sddl: "O:BAD:NO_ACCESS_CONTROL",
+			want: []string{"NULL DACL: everyone is granted full access"},
+		},
+		{
+			name: "No DACL",
+			sddl: "O:BAG:SY",
+			want: []string{"no DACL: everyone is granted full access"},
+		},
+		{
+			name: "Everyone full control",
+			sddl: "D:(A;;FA;;;SY)(A;OICI;FA;;;WD)(A;;GA;;;WD)(A;;FA;;;BU)",
+			want: []string{
+				"DACL ACE 1: Everyone is granted full access",
+				"DACL ACE 2: Everyone is granted full access",
+			},
+		},
+		{
+			name: "Everyone partial control",
+			sddl: "D:(A;;FRFW;;;WD)(A;;0x001f01fe;;;WD)",
+		},
+		{
+			name: "Deny after allow",
+			sddl: "D:(D;;FW;;;AN)(A;;FA;;;SY)(A;;FR;;;BU)(D;;FW;;;BU)(OD;;CR;00299570-246d-11d0-a768-00aa006e0529;;BG)",
+			want: []string{
+				"DACL ACE 3: deny ACE after allow ACE 1, not in canonical order",
+				"DACL ACE 4: deny ACE after allow ACE 1, not in canonical order",
+			},
+		},
+		{
+			name: "Inherited deny after explicit allow",
+			sddl: "D:(A;;FA;;;SY)(D;ID;FW;;;BU)",
+		},
+		{
+			name: "Empty SACL",
+			sddl: "D:(A;;FA;;;SY)S:",
+			want: []string{"empty SACL: nothing is audited"},
+		},
+		{
+			name: "SACL with only a mandatory label",
+			sddl: "D:(A;;FA;;;SY)S:(ML;;NW;;;HI)",
+		},
+		{
+			name: "SACL with only resource attributes",
+			sddl: `D:(A;;FA;;;SY)S:(RA;;;;;WD;("Project",TS,0x0,"SQL"))`,
+		},
+		{
+			name: "NULL SACL",
+			sddl: "D:(A;;FA;;;SY)S:NO_ACCESS_CONTROL",
+			want: []string{"empty SACL: nothing is audited"},
+		},
+		{
+			name: "Several warnings",
+			sddl: "D:NO_ACCESS_CONTROLS:",
+			want: []string{
+				"NULL DACL: everyone is granted full access",
+				"empty SACL: nothing is audited",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := mustFromString(t, tt.sddl)
+			before := sd.String()
+
+			if got := sd.SecurityWarnings(); !slices.Equal(got, tt.want) {
+				t.Errorf("SecurityWarnings() = %q, want %q", got, tt.want)
+			}
+			if after := sd.String(); after != before {
+				t.Errorf("SecurityWarnings() modified the descriptor: %s, want %s", after, before)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package sddl
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// xmlSecurityDescriptor is the XML representation of a security descriptor, see ToXML.
+type xmlSecurityDescriptor struct {
+	XMLName   xml.Name `xml:"SecurityDescriptor"`
+	Revision  byte     `xml:"revision,attr"`
+	Control   string   `xml:"control,attr"`
+	RMControl string   `xml:"rmControl,attr,omitempty"`
+	Owner     *xmlSID  `xml:"Owner"`
+	Group     *xmlSID  `xml:"Group"`
+	DACL      *xmlACL  `xml:"DACL"`
+	SACL      *xmlACL  `xml:"SACL"`
+}
+
+// xmlSID is the XML representation of the owner or the group of a security descriptor.
+type xmlSID struct {
+	SID string `xml:"sid,attr"`
+}
+
+// xmlACL is the XML representation of an ACL.
+type xmlACL struct {
+	Revision byte     `xml:"revision,attr"`
+	ACEs     []xmlACE `xml:"ACE"`
+}
+
+// xmlACE is the XML representation of an ACE.
+type xmlACE struct {
+	Type                string `xml:"type,attr"`
+	Flags               string `xml:"flags,attr"`
+	Mask                string `xml:"mask,attr"`
+	ObjectType          string `xml:"objectType,attr,omitempty"`
+	InheritedObjectType string `xml:"inheritedObjectType,attr,omitempty"`
+	SID                 string `xml:"sid,attr,omitempty"`
+	Data                string `xml:"data,attr,omitempty"`
+	Padding             string `xml:"padding,attr,omitempty"`
+}
+
+// ToXML returns the XML representation of the security descriptor, for tools such as Group Policy
+// editors that exchange security descriptors as XML. FromXML converts it back. For example:
+//
+//	<SecurityDescriptor revision="1" control="0x9424">
+//	  <Owner sid="S-1-5-32-544"></Owner>
+//	  <Group sid="S-1-5-18"></Group>
+//	  <DACL revision="2">
+//	    <ACE type="A" flags="0x03" mask="0x001F01FF" sid="S-1-5-18"></ACE>
+//	  </DACL>
+//	</SecurityDescriptor>
+//
+// The schema is:
+//   - SecurityDescriptor: the root element. Its revision attribute is the revision of the security
+//     descriptor and its control attribute the control flags, in hexadecimal. The rmControl attribute
+//     holds the resource manager control bits, the Sbz1 byte, in hexadecimal, if it is not zero. It
+//     contains optional Owner, Group, DACL and SACL elements, in this order.
+//   - Owner and Group: the sid attribute is the SID, in its "S-1-..." form.
+//   - DACL and SACL: the revision attribute is the revision of the ACL. They contain one ACE element
+//     per ACE, in order. A NULL ACL has no element, only its SE_DACL_PRESENT or SE_SACL_PRESENT flag.
+//   - ACE: the type attribute is the type of the ACE, as written by ACEType.String. The flags and mask
+//     attributes are the ACE flags and the access mask, in hexadecimal. The sid attribute is the trustee.
+//     Object ACEs have optional objectType and inheritedObjectType attributes holding the GUIDs.
+//     The padding attribute holds the bytes following the SID within the ACE, if any, in hexadecimal.
+//     Callback ACEs have a data attribute holding their application data, if any, in hexadecimal.
+//     ACEs of unknown type have no sid attribute but a data attribute holding the bytes following the
+//     access mask, in hexadecimal.
+//
+// Unlike SDDL, the XML representation keeps everything FromBinary decodes, so that it converts back to
+// the same binary security descriptor. It returns an error if the security descriptor is not valid.
+func (sd *SecurityDescriptor) ToXML() ([]byte, error) {
+	if err := sd.Validate(); err != nil {
+		return nil, err
+	}
+
+	x := xmlSecurityDescriptor{
+		Revision: sd.revision,
+		Control:  fmt.Sprintf("0x%04X", sd.control|seSelfRelative),
+	}
+	if sd.sbzl != 0 {
+		x.RMControl = fmt.Sprintf("0x%02X", sd.sbzl)
+	}
+	if sd.ownerSID != nil {
+		x.Owner = &xmlSID{SID: sd.ownerSID.rawString()}
+	}
+	if sd.groupSID != nil {
+		x.Group = &xmlSID{SID: sd.groupSID.rawString()}
+	}
+	if sd.dacl != nil {
+		x.DACL = sd.dacl.toXML()
+	}
+	if sd.sacl != nil {
+		x.SACL = sd.sacl.toXML()
+	}
+
+	return xml.MarshalIndent(x, "", "  ")
+}
+
+// toXML returns the XML representation of the ACL.
+func (a *ACL) toXML() *xmlACL {
+	x := &xmlACL{Revision: a.aclRevision}
+	for i := range a.aces {
+		ace := &a.aces[i]
+		xa := xmlACE{
+			Type:    ace.typeString(),
+			Flags:   fmt.Sprintf("0x%02X", ace.header.aceFlags),
+			Mask:    fmt.Sprintf("0x%08X", ace.accessMask),
+			Padding: hex.EncodeToString(ace.padding),
+		}
+		xa.ObjectType, xa.InheritedObjectType = ace.objectTypeStrings()
+		if ace.isRaw() {
+			xa.Data = hex.EncodeToString(ace.rawData)
+		} else {
+			xa.SID = ace.sid.rawString()
+			xa.Data = hex.EncodeToString(ace.applicationData)
+		}
+		x.ACEs = append(x.ACEs, xa)
+	}
+	return x
+}
+
+// FromXML parses the XML representation of a security descriptor, as returned by ToXML.
+// It returns an error if the document doesn't follow the schema described in ToXML, or if the
+// resulting security descriptor is not valid.
+func FromXML(data []byte) (*SecurityDescriptor, error) {
+	var x xmlSecurityDescriptor
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return nil, fmt.Errorf("invalid XML security descriptor: %w", err)
+	}
+
+	control, err := strconv.ParseUint(x.Control, 0, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XML security descriptor: invalid control %q", x.Control)
+	}
+
+	var rmControl uint64
+	if x.RMControl != "" {
+		if rmControl, err = strconv.ParseUint(x.RMControl, 0, 8); err != nil {
+			return nil, fmt.Errorf("invalid XML security descriptor: invalid rmControl %q", x.RMControl)
+		}
+	}
+
+	sd := &SecurityDescriptor{
+		revision: x.Revision,
+		sbzl:     byte(rmControl),
+		control:  uint16(control),
+	}
+	if x.Owner != nil {
+		if sd.ownerSID, err = parseXMLSID(x.Owner.SID); err != nil {
+			return nil, fmt.Errorf("invalid XML owner: %w", err)
+		}
+	}
+	if x.Group != nil {
+		if sd.groupSID, err = parseXMLSID(x.Group.SID); err != nil {
+			return nil, fmt.Errorf("invalid XML group: %w", err)
+		}
+	}
+	if x.DACL != nil {
+		if sd.dacl, err = x.DACL.toACL("D", sd.control); err != nil {
+			return nil, fmt.Errorf("invalid XML DACL: %w", err)
+		}
+	}
+	if x.SACL != nil {
+		if sd.sacl, err = x.SACL.toACL("S", sd.control); err != nil {
+			return nil, fmt.Errorf("invalid XML SACL: %w", err)
+		}
+	}
+
+	if err := sd.Validate(); err != nil {
+		return nil, err
+	}
+	return sd, nil
+}
+
+// toACL converts the XML representation of an ACL of the given type back to an ACL.
+func (x *xmlACL) toACL(aclType string, control uint16) (*ACL, error) {
+	acl := &ACL{
+		aclRevision: x.Revision,
+		aclType:     aclType,
+		control:     aclControl(aclType, control),
+	}
+
+	for i, xa := range x.ACEs {
+		ace, err := xa.toACE()
+		if err != nil {
+			return nil, fmt.Errorf("ACE %d: %w", i, err)
+		}
+		acl.aces = append(acl.aces, *ace)
+	}
+	acl.updateSize()
+
+	return acl, nil
+}
+
+// toACE converts the XML representation of an ACE back to an ACE.
+func (xa *xmlACE) toACE() (*ACE, error) {
+	aceType, err := parseACEType(xa.Type)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACE type: %w", err)
+	}
+	flags, err := strconv.ParseUint(xa.Flags, 0, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flags %q", xa.Flags)
+	}
+	mask, err := strconv.ParseUint(xa.Mask, 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mask %q", xa.Mask)
+	}
+
+	ace := &ACE{
+		header:     &aceHeader{aceType: aceType, aceFlags: byte(flags)},
+		accessMask: uint32(mask),
+	}
+
+	if xa.SID == "" {
+		// ACEs of unknown type only hold raw data, possibly empty
+		if isKnownACEType(aceType) {
+			return nil, fmt.Errorf("missing SID")
+		}
+		if ace.rawData, err = hex.DecodeString(xa.Data); err != nil {
+			return nil, fmt.Errorf("invalid data %q", xa.Data)
+		}
+		if ace.rawData == nil {
+			ace.rawData = []byte{}
+		}
+		ace.header.aceSize = uint16(ace.BinarySize())
+		return ace, nil
+	}
+	if !isKnownACEType(aceType) {
+		return nil, fmt.Errorf("unknown ACE type %s has no SID", xa.Type)
+	}
+
+	if ace.sid, err = parseXMLSID(xa.SID); err != nil {
+		return nil, err
+	}
+	if ace.objectType, err = parseObjectTypeString(xa.ObjectType, aceType); err != nil {
+		return nil, fmt.Errorf("invalid object type: %w", err)
+	}
+	if ace.inheritedObjectType, err = parseObjectTypeString(xa.InheritedObjectType, aceType); err != nil {
+		return nil, fmt.Errorf("invalid inherited object type: %w", err)
+	}
+	if xa.Data != "" {
+		if !hasApplicationData(aceType) {
+			return nil, fmt.Errorf("ACE type %s has no application data", xa.Type)
+		}
+		if ace.applicationData, err = hex.DecodeString(xa.Data); err != nil {
+			return nil, fmt.Errorf("invalid data %q", xa.Data)
+		}
+	}
+	if xa.Padding != "" {
+		if ace.padding, err = hex.DecodeString(xa.Padding); err != nil {
+			return nil, fmt.Errorf("invalid padding %q", xa.Padding)
+		}
+	}
+	ace.header.aceSize = uint16(ace.BinarySize())
+
+	return ace, nil
+}
+
+// parseXMLSID parses a SID of the XML representation, which must not be a domain relative alias.
+func parseXMLSID(s string) (*SID, error) {
+	r, err := parseSIDString(s)
+	if err != nil {
+		return nil, err
+	}
+	return r.toSID(nil)
+}
@@ -0,0 +1,227 @@
+package sddl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecurityDescriptor_ToXML(t *testing.T) {
+	t.Parallel()
+
+	sd, err := FromString("O:BAG:SYD:PAI(A;OICI;FA;;;SY)(OA;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)S:(AU;SA;FA;;;WD)")
+	if err != nil {
+		t.Fatalf("FromString() unexpected error = %v", err)
+	}
+
+	want := `<SecurityDescriptor revision="1" control="0x9414">
+  <Owner sid="S-1-5-32-544"></Owner>
+  <Group sid="S-1-5-18"></Group>
+  <DACL revision="4">
+    <ACE type="A" flags="0x03" mask="0x001F01FF" sid="S-1-5-18"></ACE>
+    <ACE type="OA" flags="0x00" mask="0x00000100" objectType="00299570-246d-11d0-a768-00aa006e0529" sid="S-1-1-0"></ACE>
+  </DACL>
+  <SACL revision="2">
+    <ACE type="AU" flags="0x40" mask="0x001F01FF" sid="S-1-1-0"></ACE>
+  </SACL>
+</SecurityDescriptor>`
+
+	got, err := sd.ToXML()
+	if err != nil {
+		t.Fatalf("ToXML() unexpected error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("ToXML() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFromXML_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// withExtras returns the descriptor of s with padding after the SID of its first ACE and an ACE of
+	// unknown type appended to its DACL, which SDDL can't represent
+	withExtras := func(t *testing.T, s string) *SecurityDescriptor {
+		t.Helper()
+		sd := mustFromString(t, s)
+		sd.dacl.aces[0].padding = []byte{0xAB, 0xCD, 0, 0}
+		sd.dacl.aces[0].header.aceSize += 4
+		sd.dacl.aces = append(sd.dacl.aces, ACE{
+			header:     &aceHeader{aceType: 0x16, aceFlags: 0x20, aceSize: 12},
+			accessMask: 1,
+			rawData:    []byte{1, 2, 3, 4},
+		})
+		sd.dacl.updateSize()
+		return sd
+	}
+
+	tests := []struct {
+		name string
+		sd   func(t *testing.T) *SecurityDescriptor
+	}{
+		{
+			name: "Owner, group, DACL and SACL",
+			sd: func(t *testing.T) *SecurityDescriptor {
+				return mustFromString(t, "O:BAG:SYD:PAI(A;OICI;FA;;;SY)(D;;FW;;;AN)S:AI(AU;SAFA;FA;;;WD)")
+			},
+		},
+		{
+			name: "Object ACEs",
+			sd: func(t *testing.T) *SecurityDescriptor {
+				return mustFromString(t, "D:(OA;CIIO;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;PS)(OD;;RP;;bf967aba-0de6-11d0-a285-00aa003049e2;WD)")
+			},
+		},
+		{
+			name: "NULL DACL and empty SACL",
+			sd: func(t *testing.T) *SecurityDescriptor {
+				return mustFromString(t, "O:SYD:NO_ACCESS_CONTROLS:")
+			},
+		},
+		{
+			name: "Domain SIDs and large authority",
+			sd: func(t *testing.T) *SecurityDescriptor {
+				return mustFromString(t, "O:S-1-5-21-1-2-3-500D:(A;;0x1200a9;;;S-1-0xFFFF00000000-1)")
+			},
+		},
+		{
+			name: "Defaulted owner",
+			sd: func(t *testing.T) *SecurityDescriptor {
+				sd := mustFromString(t, "O:SYD:(A;;FA;;;SY)")
+				sd.control |= seOwnerDefaulted
+				return sd
+			},
+		},
+		{
+			name: "Padding and unknown ACE type",
+			sd: func(t *testing.T) *SecurityDescriptor {
+				return withExtras(t, "O:SYD:(A;;FA;;;SY)")
+			},
+		},
+		{
+			name: "Resource manager control",
+			sd: func(t *testing.T) *SecurityDescriptor {
+				sd := mustFromString(t, "O:SYD:(A;;FA;;;SY)")
+				sd.SetResourceManagerControl(0x5A)
+				return sd
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sd := tt.sd(t)
+			want := sd.Binary()
+
+			data, err := sd.ToXML()
+			if err != nil {
+				t.Fatalf("ToXML() unexpected error = %v", err)
+			}
+			got, err := FromXML(data)
+			if err != nil {
+				t.Fatalf("FromXML() unexpected error = %v\n%s", err, data)
+			}
+			if !bytes.Equal(got.Binary(), want) {
+				t.Errorf("FromXML().Binary() = % x, want % x", got.Binary(), want)
+			}
+
+			// Going through the binary form doesn't change the XML representation
+			decoded, err := FromBinary(want)
+			if err != nil {
+				t.Fatalf("FromBinary() unexpected error = %v", err)
+			}
+			again, err := decoded.ToXML()
+			if err != nil {
+				t.Fatalf("ToXML() unexpected error = %v", err)
+			}
+			if !bytes.Equal(again, data) {
+				t.Errorf("FromBinary().ToXML() =\n%s\nwant\n%s", again, data)
+			}
+		})
+	}
+}
+
+func TestFromXML_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Not XML",
+			input: "O:SYG:SY",
+		},
+		{
+			name:  "Wrong root element",
+			input: `<Descriptor revision="1" control="0x8000"></Descriptor>`,
+		},
+		{
+			name:  "Missing control",
+			input: `<SecurityDescriptor revision="1"></SecurityDescriptor>`,
+		},
+		{
+			name:  "Invalid revision",
+			input: `<SecurityDescriptor revision="2" control="0x8000"></SecurityDescriptor>`,
+		},
+		{
+			name:  "Invalid resource manager control",
+			input: `<SecurityDescriptor revision="1" control="0xC000" rmControl="0x100"></SecurityDescriptor>`,
+		},
+		{
+			name:  "Invalid owner SID",
+			input: `<SecurityDescriptor revision="1" control="0x8000"><Owner sid="X-1-5-18"></Owner></SecurityDescriptor>`,
+		},
+		{
+			name:  "Domain relative alias",
+			input: `<SecurityDescriptor revision="1" control="0x8000"><Owner sid="LA"></Owner></SecurityDescriptor>`,
+		},
+		{
+			name:  "DACL without SE_DACL_PRESENT",
+			input: `<SecurityDescriptor revision="1" control="0x8000"><DACL revision="2"></DACL></SecurityDescriptor>`,
+		},
+		{
+			name:  "Invalid ACE type",
+			input: `<SecurityDescriptor revision="1" control="0x8004"><DACL revision="2"><ACE type="ZZ" flags="0x00" mask="0x1" sid="S-1-5-18"></ACE></DACL></SecurityDescriptor>`,
+		},
+		{
+			name:  "Invalid ACE mask",
+			input: `<SecurityDescriptor revision="1" control="0x8004"><DACL revision="2"><ACE type="A" flags="0x00" mask="FA" sid="S-1-5-18"></ACE></DACL></SecurityDescriptor>`,
+		},
+		{
+			name:  "Known ACE type without SID",
+			input: `<SecurityDescriptor revision="1" control="0x8004"><DACL revision="2"><ACE type="A" flags="0x00" mask="0x1"></ACE></DACL></SecurityDescriptor>`,
+		},
+		{
+			name:  "Unknown ACE type with SID",
+			input: `<SecurityDescriptor revision="1" control="0x8004"><DACL revision="2"><ACE type="0x16" flags="0x00" mask="0x1" sid="S-1-5-18"></ACE></DACL></SecurityDescriptor>`,
+		},
+		{
+			name:  "Object type on non-object ACE",
+			input: `<SecurityDescriptor revision="1" control="0x8004"><DACL revision="2"><ACE type="A" flags="0x00" mask="0x1" objectType="00299570-246d-11d0-a768-00aa006e0529" sid="S-1-5-18"></ACE></DACL></SecurityDescriptor>`,
+		},
+		{
+			name:  "Invalid padding",
+			input: `<SecurityDescriptor revision="1" control="0x8004"><DACL revision="2"><ACE type="A" flags="0x00" mask="0x1" sid="S-1-5-18" padding="xyz"></ACE></DACL></SecurityDescriptor>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := FromXML([]byte(tt.input)); err == nil {
+				t.Errorf("FromXML(%s) expected error, got nil", tt.input)
+			}
+		})
+	}
+}
+
+// mustFromString returns the security descriptor of the SDDL string s, failing the test if it is invalid.
+func mustFromString(t *testing.T, s string) *SecurityDescriptor {
+	t.Helper()
+	sd, err := FromString(s)
+	if err != nil {
+		t.Fatalf("FromString(%q) unexpected error = %v", s, err)
+	}
+	return sd
+}